@@ -0,0 +1,337 @@
+package main
+
+import (
+	"flag"
+	"fmt"
+	"math/rand"
+	"os"
+	"path/filepath"
+	"time"
+
+	"github.com/furkankorkmaz309/todo-api/internal/backup"
+	"github.com/furkankorkmaz309/todo-api/internal/db"
+	"github.com/furkankorkmaz309/todo-api/internal/handlers"
+	"github.com/furkankorkmaz309/todo-api/internal/models"
+	"github.com/furkankorkmaz309/todo-api/internal/store"
+)
+
+// newFlagSet returns the flag set of a subcommand with the shared config
+// registered on it.
+func newFlagSet(cfg *config, name, synopsis string) *flag.FlagSet {
+	fs := flag.NewFlagSet(name, flag.ExitOnError)
+	cfg.register(fs)
+	fs.Usage = func() {
+		fmt.Fprintf(fs.Output(), "usage: todo-api %s %s\n", name, synopsis)
+		fs.PrintDefaults()
+	}
+	return fs
+}
+
+// parseArgs parses args and exits with the usage when the number of
+// positional arguments is not n.
+func parseArgs(fs *flag.FlagSet, args []string, n int) {
+	fs.Parse(args)
+	if fs.NArg() != n {
+		fs.Usage()
+		os.Exit(2)
+	}
+}
+
+// runMigrate implements `todo-api migrate [-status]`. The server migrates
+// on start as well; this lets it be done, or checked, ahead of a deploy.
+func runMigrate(args []string) error {
+	var cfg config
+	fs := newFlagSet(&cfg, "migrate", "[-status]")
+	status := fs.Bool("status", false, "report the schema version without migrating")
+	parseArgs(fs, args, 0)
+
+	app, err := cfg.openApp(false)
+	if err != nil {
+		return err
+	}
+	defer app.DB.Close()
+
+	if !*status {
+		applied, err := db.Migrate(app.DB)
+		if err != nil {
+			return err
+		}
+		app.InfoLog.Printf("Applied %d migrations", applied)
+	}
+
+	version, err := db.SchemaVersion(app.DB)
+	if err != nil {
+		return err
+	}
+	fmt.Printf("schema version %d of %d\n", version, db.LatestVersion())
+	return nil
+}
+
+// runBackup implements `todo-api backup [-list] [file]`. Without a file the
+// backup goes to the backup directory, which is then rotated. It is safe
+// to run while the server is up.
+func runBackup(args []string) error {
+	var cfg config
+	fs := newFlagSet(&cfg, "backup", "[-list] [file]")
+	list := fs.Bool("list", false, "list the backups in the backup directory")
+	fs.Parse(args)
+	if fs.NArg() > 1 || (*list && fs.NArg() > 0) {
+		fs.Usage()
+		os.Exit(2)
+	}
+
+	app, err := cfg.openApp(false)
+	if err != nil {
+		return err
+	}
+	defer app.DB.Close()
+	backups := cfg.backups(app)
+
+	if *list {
+		all, err := backups.List()
+		if err != nil {
+			return err
+		}
+		for _, b := range all {
+			fmt.Printf("%s\t%d\t%s\n", b.Name, b.Size, b.CreatedAt.Local().Format("2006-01-02 15:04:05"))
+		}
+		return nil
+	}
+
+	if fs.NArg() == 1 {
+		err = db.Backup(app.DB, fs.Arg(0))
+		if err != nil {
+			return err
+		}
+		app.InfoLog.Printf("Backed up %s to %s", cfg.DBPath, fs.Arg(0))
+		return nil
+	}
+
+	b, err := backups.Create()
+	if err != nil {
+		return err
+	}
+	app.InfoLog.Printf("Backed up %s to %s", cfg.DBPath, filepath.Join(cfg.backupDir(), b.Name))
+	return nil
+}
+
+// runRestore implements `todo-api restore <file>`, where file may also be
+// the name of a backup in the backup directory. The backup must be
+// undamaged and no newer than this build's schema. The current database is
+// backed up first, so a restore can be undone. Stop the server before
+// restoring: it keeps the old file open.
+func runRestore(args []string) error {
+	var cfg config
+	fs := newFlagSet(&cfg, "restore", "<file>")
+	parseArgs(fs, args, 1)
+
+	src := fs.Arg(0)
+	if _, err := os.Stat(src); err != nil && filepath.Base(src) == src {
+		src = filepath.Join(cfg.backupDir(), src)
+	}
+
+	// Refuse a bad backup before touching the current database.
+	_, err := backup.Validate(src)
+	if err != nil {
+		return err
+	}
+
+	if _, err := os.Stat(cfg.DBPath); err == nil {
+		app, err := cfg.openApp(false)
+		if err != nil {
+			return err
+		}
+		// Rotating now could remove src, when it is the oldest backup.
+		b, err := cfg.backups(app).Save()
+		app.DB.Close()
+		if err != nil {
+			return err
+		}
+		fmt.Printf("Saved the current database as %s\n", filepath.Join(cfg.backupDir(), b.Name))
+	}
+
+	version, err := backup.Restore(src, cfg.DBPath)
+	if err != nil {
+		return err
+	}
+
+	fmt.Printf("Restored %s from %s (schema version %d)\n", cfg.DBPath, src, version)
+	if version < db.LatestVersion() {
+		fmt.Printf("It is migrated to version %d when the server starts, or by todo-api migrate\n", db.LatestVersion())
+	}
+	return nil
+}
+
+var seedCategories = []models.Category{
+	{Name: "Work", Description: "Things to get done at work"},
+	{Name: "Home", Description: "Chores and repairs"},
+	{Name: "Errands", Description: "Things to pick up in town"},
+}
+
+var seedTitles = []string{
+	"Write the quarterly report",
+	"Review open pull requests",
+	"Prepare the team meeting",
+	"Fix the leaking tap",
+	"Clean the gutters",
+	"Water the plants",
+	"Buy groceries",
+	"Pick up the dry cleaning",
+	"Renew the passport",
+	"Return library books",
+}
+
+var seedTags = []string{"urgent", "quick", "weekend", "waiting"}
+
+// runSeed implements `todo-api seed [-todos n]`, filling a development
+// database with demo categories and todos due over the next month.
+func runSeed(args []string) error {
+	var cfg config
+	fs := newFlagSet(&cfg, "seed", "[-todos n]")
+	count := fs.Int("todos", 20, "how many todos to add")
+	parseArgs(fs, args, 0)
+
+	app, err := cfg.openApp(true)
+	if err != nil {
+		return err
+	}
+	defer app.DB.Close()
+
+	tx, err := app.DB.Begin()
+	if err != nil {
+		return err
+	}
+	defer tx.Rollback()
+
+	categories := append([]models.Category(nil), seedCategories...)
+	for i := range categories {
+		err = store.InsertCategory(tx, &categories[i])
+		if err != nil {
+			return err
+		}
+	}
+
+	for i := 0; i < *count; i++ {
+		todo := models.Todo{
+			Title:      seedTitles[i%len(seedTitles)],
+			Content:    "Added by todo-api seed",
+			Priority:   rand.Intn(5) + 1,
+			DueDate:    time.Now().Add(time.Duration(rand.Intn(30*24)+1) * time.Hour),
+			CategoryID: categories[i%len(categories)].ID,
+		}
+		if rand.Intn(2) == 0 {
+			todo.Tags = []string{seedTags[rand.Intn(len(seedTags))]}
+		}
+
+		err = store.InsertTodo(tx, &todo)
+		if err != nil {
+			return err
+		}
+	}
+
+	err = tx.Commit()
+	if err != nil {
+		return err
+	}
+	app.InfoLog.Printf("Seeded %d categories and %d todos", len(categories), *count)
+	return nil
+}
+
+// runVacuum implements `todo-api vacuum`, rebuilding the database file to
+// give the space of deleted rows back to the file system.
+func runVacuum(args []string) error {
+	var cfg config
+	fs := newFlagSet(&cfg, "vacuum", "")
+	parseArgs(fs, args, 0)
+
+	app, err := cfg.openApp(false)
+	if err != nil {
+		return err
+	}
+	defer app.DB.Close()
+
+	before, err := os.Stat(cfg.DBPath)
+	if err != nil {
+		return err
+	}
+	_, err = app.DB.Exec(`VACUUM`)
+	if err != nil {
+		return fmt.Errorf("vacuum failed: %v", err)
+	}
+	_, err = app.DB.Exec(`PRAGMA optimize`)
+	if err != nil {
+		return fmt.Errorf("optimize failed: %v", err)
+	}
+	after, err := os.Stat(cfg.DBPath)
+	if err != nil {
+		return err
+	}
+
+	app.InfoLog.Printf("Vacuumed %s: %d bytes, was %d", cfg.DBPath, after.Size(), before.Size())
+	return nil
+}
+
+// runCheck implements `todo-api check`, exiting non-zero when the database
+// is damaged or its schema is behind this build.
+func runCheck(args []string) error {
+	var cfg config
+	fs := newFlagSet(&cfg, "check", "")
+	parseArgs(fs, args, 0)
+
+	app, err := cfg.openApp(false)
+	if err != nil {
+		return err
+	}
+	defer app.DB.Close()
+
+	problems, err := db.Check(app.DB)
+	if err != nil {
+		return err
+	}
+
+	version, err := db.SchemaVersion(app.DB)
+	if err != nil {
+		return err
+	}
+	if version != db.LatestVersion() {
+		problems = append(problems, fmt.Sprintf("schema version is %d, this build expects %d (run todo-api migrate)", version, db.LatestVersion()))
+	}
+
+	for _, p := range problems {
+		fmt.Println(p)
+	}
+	if len(problems) > 0 {
+		return fmt.Errorf("%s: %d problems found", cfg.DBPath, len(problems))
+	}
+	fmt.Println("ok")
+	return nil
+}
+
+// runArchive implements `todo-api archive [-days n]`, archiving done todos
+// the way the auto-archive policy of the server does.
+func runArchive(args []string) error {
+	var cfg config
+	fs := newFlagSet(&cfg, "archive", "[-days n]")
+	days := fs.Int("days", 0, "only archive todos done for at least this many days")
+	parseArgs(fs, args, 0)
+
+	app, err := cfg.openApp(true)
+	if err != nil {
+		return err
+	}
+	defer app.DB.Close()
+
+	var where []string
+	var whereArgs []interface{}
+	if *days > 0 {
+		where = append(where, store.DoneBeforeCondition)
+		whereArgs = append(whereArgs, time.Now().UTC().AddDate(0, 0, -*days))
+	}
+
+	ids, err := handlers.ArchiveDone(app, where, whereArgs)
+	if err != nil {
+		return err
+	}
+	app.InfoLog.Printf("Archived %d todos", len(ids))
+	return nil
+}
@@ -0,0 +1,90 @@
+package main
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/furkankorkmaz309/todo-api/internal/db"
+	"github.com/furkankorkmaz309/todo-api/internal/models"
+	"github.com/furkankorkmaz309/todo-api/internal/store"
+)
+
+func addCategory(t *testing.T, path, name string) {
+	t.Helper()
+	conn, err := db.InitDB(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer conn.Close()
+	err = store.InsertCategory(conn, &models.Category{Name: name})
+	if err != nil {
+		t.Fatal(err)
+	}
+}
+
+func categoryNames(t *testing.T, path string) []string {
+	t.Helper()
+	conn, err := db.Open(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer conn.Close()
+	rows, err := conn.Query(`SELECT name FROM category ORDER BY id`)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer rows.Close()
+	var names []string
+	for rows.Next() {
+		var name string
+		err = rows.Scan(&name)
+		if err != nil {
+			t.Fatal(err)
+		}
+		names = append(names, name)
+	}
+	return names
+}
+
+// TestRestoreOldestBackup restores the oldest backup while the directory
+// holds as many as it keeps, so the backup of the current database taken
+// first must not rotate it away.
+func TestRestoreOldestBackup(t *testing.T) {
+	dir := t.TempDir()
+	path := filepath.Join(dir, "todo.db")
+	backups := filepath.Join(dir, "backups")
+	flags := []string{"-db", path, "-backup-dir", backups, "-backup-keep", "3"}
+
+	for _, name := range []string{"first", "second", "third"} {
+		addCategory(t, path, name)
+		err := runBackup(flags)
+		if err != nil {
+			t.Fatal(err)
+		}
+		// Backups are named by the millisecond.
+		time.Sleep(5 * time.Millisecond)
+	}
+	entries, err := os.ReadDir(backups)
+	if err != nil || len(entries) != 3 {
+		t.Fatalf("backup directory has %d entries (%v), want 3", len(entries), err)
+	}
+	oldest := entries[0].Name()
+
+	err = runRestore(append(flags, oldest))
+	if err != nil {
+		t.Fatalf("restoring the oldest backup: %v", err)
+	}
+	if got := categoryNames(t, path); len(got) != 1 || got[0] != "first" {
+		t.Errorf("restored database has categories %v, want [first]", got)
+	}
+
+	entries, err = os.ReadDir(backups)
+	if err != nil || len(entries) != 4 {
+		t.Fatalf("backup directory has %d entries (%v), want the 3 backups and the one of the replaced database", len(entries), err)
+	}
+	if got := categoryNames(t, filepath.Join(backups, entries[3].Name())); len(got) != 3 {
+		t.Errorf("backup of the replaced database has categories %v, want all 3", got)
+	}
+}
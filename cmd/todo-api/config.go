@@ -0,0 +1,69 @@
+package main
+
+import (
+	"flag"
+	"log"
+	"os"
+	"path/filepath"
+
+	"github.com/furkankorkmaz309/todo-api/internal/app"
+	"github.com/furkankorkmaz309/todo-api/internal/backup"
+	"github.com/furkankorkmaz309/todo-api/internal/db"
+	"github.com/furkankorkmaz309/todo-api/internal/events"
+	"github.com/furkankorkmaz309/todo-api/internal/webhooks"
+)
+
+// config holds the settings every subcommand shares. Each subcommand
+// registers them on its own flag set, so they can be given after its name.
+type config struct {
+	DBPath     string
+	BackupDir  string
+	BackupKeep int
+}
+
+func (c *config) register(fs *flag.FlagSet) {
+	path := os.Getenv("TODO_API_DB")
+	if path == "" {
+		path = db.DefaultPath
+	}
+	fs.StringVar(&c.DBPath, "db", path, "SQLite database file (env TODO_API_DB)")
+	fs.StringVar(&c.BackupDir, "backup-dir", os.Getenv("TODO_API_BACKUP_DIR"), "where backups are kept (env TODO_API_BACKUP_DIR, default: backups next to the database)")
+	fs.IntVar(&c.BackupKeep, "backup-keep", 7, "how many backups to keep (0 keeps all)")
+}
+
+func (c *config) backupDir() string {
+	if c.BackupDir == "" {
+		return filepath.Join(filepath.Dir(c.DBPath), "backups")
+	}
+	return c.BackupDir
+}
+
+// backups returns the backup manager of app's database.
+func (c *config) backups(app *app.App) *backup.Manager {
+	return backup.NewManager(app.DB, c.backupDir(), c.BackupKeep, app.InfoLog, app.ErrorLog)
+}
+
+// openApp opens the database, migrating it unless migrate is false, and
+// wraps it in an App with the usual loggers. Webhook deliveries for the
+// events it publishes are queued in the database, so a server sends those
+// of commands run alongside it too.
+func (c *config) openApp(migrate bool) (*app.App, error) {
+	open := db.InitDB
+	if !migrate {
+		open = db.Open
+	}
+	conn, err := open(c.DBPath)
+	if err != nil {
+		return nil, err
+	}
+
+	app := &app.App{
+		InfoLog:  log.New(os.Stdout, "INFO\t", log.Ltime|log.Ldate),
+		ErrorLog: log.New(os.Stderr, "ERROR\t", log.Ltime|log.Ldate|log.Lshortfile),
+		DB:       conn,
+		Events:   events.NewBus(1000),
+	}
+	app.Webhooks = webhooks.NewDispatcher(conn, app.InfoLog, app.ErrorLog)
+	app.Events.Subscribe(app.Webhooks.Enqueue)
+	return app, nil
+}
@@ -0,0 +1,74 @@
+package main
+
+import (
+	"encoding/json"
+	"flag"
+	"fmt"
+	"io"
+	"os"
+	"strings"
+	"time"
+
+	"github.com/furkankorkmaz309/todo-api/internal/handlers"
+	"github.com/furkankorkmaz309/todo-api/internal/importer"
+)
+
+// runImport implements `todo-api import [flags] <format> <file>`, importing
+// straight into the database without a running server. A file of "-"
+// reads standard input.
+func runImport(args []string) error {
+	var cfg config
+	fs := flag.NewFlagSet("import", flag.ExitOnError)
+	cfg.register(fs)
+	dryRun := fs.Bool("dry-run", false, "validate and report without saving")
+	createCategories := fs.Bool("create-categories", false, "create categories that do not exist yet")
+	category := fs.String("category", "", "category for todos that have none")
+	defaultDueDays := fs.Int("default-due-days", 7, "due date, in days from now, for todos that have none (0 for none)")
+	fs.Usage = func() {
+		formats := append([]string{"csv", "json", "ndjson"}, importer.Formats()...)
+		fmt.Fprintf(fs.Output(), "usage: todo-api import [flags] <%s> <file>\n", strings.Join(formats, "|"))
+		fs.PrintDefaults()
+	}
+	fs.Parse(args)
+
+	if fs.NArg() != 2 {
+		fs.Usage()
+		os.Exit(2)
+	}
+	format, path := fs.Arg(0), fs.Arg(1)
+
+	app, err := cfg.openApp(true)
+	if err != nil {
+		return err
+	}
+	defer app.DB.Close()
+
+	var in io.Reader = os.Stdin
+	if path != "-" {
+		f, err := os.Open(path)
+		if err != nil {
+			return err
+		}
+		defer f.Close()
+		in = f
+	}
+
+	opts := handlers.ImportOptions{
+		DryRun:           *dryRun,
+		CreateCategories: *createCategories,
+		DefaultCategory:  *category,
+	}
+	if *defaultDueDays > 0 {
+		opts.DefaultDue = time.Now().AddDate(0, 0, *defaultDueDays)
+	}
+
+	report, err := handlers.ImportFile(app.DB, format, in, opts)
+	if err != nil {
+		return err
+	}
+
+	app.InfoLog.Println(report.String())
+	enc := json.NewEncoder(os.Stdout)
+	enc.SetIndent("", "  ")
+	return enc.Encode(report.Errors)
+}
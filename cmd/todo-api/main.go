@@ -1,49 +1,69 @@
 package main
 
 import (
-	"flag"
+	"fmt"
 	"log"
-	"net/http"
 	"os"
+	"sort"
 
-	"github.com/furkankorkmaz309/todo-api/internal/app"
 	"github.com/furkankorkmaz309/todo-api/internal/db"
-	"github.com/furkankorkmaz309/todo-api/internal/routes"
 )
 
+type command struct {
+	usage string
+	run   func(args []string) error
+}
+
+var commands = map[string]command{
+	"serve":    {"serve [flags]                  run the API server (the default)", runServe},
+	"migrate":  {"migrate [-status]              apply pending schema migrations", runMigrate},
+	"backup":   {"backup [-list] [file]          back the database up, to the backup directory by default", runBackup},
+	"restore":  {"restore <file>                 replace the database with a backup", runRestore},
+	"seed":     {"seed [-todos n]                add demo categories and todos", runSeed},
+	"vacuum":   {"vacuum                         rebuild the database file to reclaim space", runVacuum},
+	"check":    {"check                          check the database for corruption and schema drift", runCheck},
+	"archive":  {"archive [-days n]              archive todos done for at least n days", runArchive},
+	"import":   {"import [flags] <format> <file> import todos from a file", runImport},
+	"mailsink": {"mailsink [-addr host:port]     print mails sent to it, to try email reminders", runMailsink},
+}
+
+// main runs the subcommand named by the first argument. Without one, or
+// when the first argument is a flag, it serves, as it always has.
 func main() {
-	errorLog := log.New(os.Stderr, "ERROR\t", log.Ltime|log.Ldate|log.Lshortfile)
-	infoLog := log.New(os.Stdout, "INFO\t", log.Ltime|log.Ldate)
+	args := os.Args[1:]
+	name := "serve"
+	if len(args) > 0 {
+		switch args[0] {
+		case "help", "-h", "-help", "--help":
+			usage()
+			return
+		}
+		if _, ok := commands[args[0]]; ok {
+			name, args = args[0], args[1:]
+		} else if args[0][0] != '-' {
+			fmt.Fprintf(os.Stderr, "todo-api: unknown command %q\n\n", args[0])
+			usage()
+			os.Exit(2)
+		}
+	}
 
-	db, err := db.InitDB()
+	err := commands[name].run(args)
 	if err != nil {
-		errorLog.Fatal(err)
+		log.New(os.Stderr, "ERROR\t", log.Ltime|log.Ldate).Fatal(err)
 	}
-	defer db.Close()
+}
 
-	app := &app.App{
-		InfoLog:  infoLog,
-		ErrorLog: errorLog,
-		DB:       db,
+func usage() {
+	names := make([]string, 0, len(commands))
+	for name := range commands {
+		names = append(names, name)
 	}
+	sort.Strings(names)
 
-	addr := flag.String("addr", ":8080", "new http port")
-	flag.Parse()
-
-	router := routes.Routes(app)
-
-	srv := &http.Server{
-		Addr:    *addr,
-		Handler: router,
+	fmt.Fprintln(os.Stderr, "usage: todo-api <command> [arguments]")
+	fmt.Fprintln(os.Stderr, "\ncommands:")
+	for _, name := range names {
+		fmt.Fprintln(os.Stderr, "  "+commands[name].usage)
 	}
-
-	app.InfoLog.Println("Server running on port", *addr)
-	go func() {
-		err = srv.ListenAndServe()
-		if err != nil && err != http.ErrServerClosed {
-			app.ErrorLog.Fatalf("ListenAndServe(): %s", err)
-		}
-	}()
-
-	select {} // ?
+	fmt.Fprintln(os.Stderr, "\nEvery command takes -db, which defaults to $TODO_API_DB or", db.DefaultPath)
 }
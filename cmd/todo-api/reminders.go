@@ -0,0 +1,91 @@
+package main
+
+import (
+	"flag"
+	"fmt"
+	"net"
+	"net/smtp"
+	"os"
+	"strings"
+	"time"
+
+	"github.com/furkankorkmaz309/todo-api/internal/app"
+	"github.com/furkankorkmaz309/todo-api/internal/mailsink"
+	"github.com/furkankorkmaz309/todo-api/internal/reminders"
+)
+
+// notifyConfig selects the channels reminders can be sent through besides
+// the log, which is always there.
+type notifyConfig struct {
+	WebhookURL    string
+	WebhookSecret string
+
+	SMTPAddr     string
+	SMTPFrom     string
+	SMTPTo       string
+	SMTPUser     string
+	SMTPPassword string
+}
+
+func (c *notifyConfig) register(fs *flag.FlagSet) {
+	fs.StringVar(&c.WebhookURL, "notify-webhook-url", os.Getenv("TODO_API_NOTIFY_WEBHOOK_URL"), "URL reminders are posted to (env TODO_API_NOTIFY_WEBHOOK_URL, empty disables the webhook channel)")
+	fs.StringVar(&c.WebhookSecret, "notify-webhook-secret", os.Getenv("TODO_API_NOTIFY_WEBHOOK_SECRET"), "key reminder posts are signed with (env TODO_API_NOTIFY_WEBHOOK_SECRET)")
+	fs.StringVar(&c.SMTPAddr, "smtp-addr", os.Getenv("TODO_API_SMTP_ADDR"), "host:port of the SMTP server reminders are mailed through (env TODO_API_SMTP_ADDR, empty disables the email channel)")
+	fs.StringVar(&c.SMTPFrom, "smtp-from", "todo-api@localhost", "sender of reminder mails")
+	fs.StringVar(&c.SMTPTo, "smtp-to", os.Getenv("TODO_API_SMTP_TO"), "comma-separated recipients of reminder mails (env TODO_API_SMTP_TO)")
+	fs.StringVar(&c.SMTPUser, "smtp-user", os.Getenv("TODO_API_SMTP_USER"), "SMTP user name, empty for servers without authentication (env TODO_API_SMTP_USER)")
+	fs.StringVar(&c.SMTPPassword, "smtp-password", os.Getenv("TODO_API_SMTP_PASSWORD"), "SMTP password (env TODO_API_SMTP_PASSWORD)")
+}
+
+// scheduler returns the reminder scheduler of app with the configured
+// channels registered.
+func (c *notifyConfig) scheduler(app *app.App) (*reminders.Scheduler, error) {
+	s := reminders.NewScheduler(app.DB, app.InfoLog, app.ErrorLog)
+
+	if c.WebhookURL != "" {
+		s.Register("webhook", reminders.WebhookNotifier{URL: c.WebhookURL, Secret: c.WebhookSecret})
+	}
+
+	if c.SMTPAddr != "" {
+		host, _, err := net.SplitHostPort(c.SMTPAddr)
+		if err != nil {
+			return nil, fmt.Errorf("-smtp-addr: %v", err)
+		}
+		var to []string
+		for _, addr := range strings.Split(c.SMTPTo, ",") {
+			if addr = strings.TrimSpace(addr); addr != "" {
+				to = append(to, addr)
+			}
+		}
+		if len(to) == 0 {
+			return nil, fmt.Errorf("-smtp-addr needs -smtp-to")
+		}
+
+		var auth smtp.Auth
+		if c.SMTPUser != "" {
+			auth = smtp.PlainAuth("", c.SMTPUser, c.SMTPPassword, host)
+		}
+		s.Register("email", reminders.SMTPNotifier{Addr: c.SMTPAddr, Auth: auth, From: c.SMTPFrom, To: to})
+	}
+	return s, nil
+}
+
+// runMailsink implements `todo-api mailsink [-addr host:port]`, an SMTP
+// server that prints the mails it receives instead of delivering them. Run
+// the server with -smtp-addr pointing at it to try email reminders.
+func runMailsink(args []string) error {
+	var cfg config
+	fs := newFlagSet(&cfg, "mailsink", "[-addr host:port]")
+	addr := fs.String("addr", "localhost:1025", "address to listen on")
+	parseArgs(fs, args, 0)
+
+	l, err := net.Listen("tcp", *addr)
+	if err != nil {
+		return err
+	}
+	fmt.Fprintf(os.Stderr, "Accepting mail on %s\n", l.Addr())
+
+	return mailsink.Serve(l, func(m mailsink.Message) {
+		fmt.Printf("--- %s from %s to %s\n%s\n", time.Now().Format(time.RFC3339), m.From, strings.Join(m.To, ", "), m.Data)
+	})
+}
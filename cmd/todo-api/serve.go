@@ -0,0 +1,115 @@
+package main
+
+import (
+	"flag"
+	"fmt"
+	"net"
+	"net/http"
+	"os"
+	"time"
+
+	"github.com/furkankorkmaz309/todo-api/internal/idempotency"
+	"github.com/furkankorkmaz309/todo-api/internal/jobs"
+	"github.com/furkankorkmaz309/todo-api/internal/live"
+	"github.com/furkankorkmaz309/todo-api/internal/routes"
+	"github.com/furkankorkmaz309/todo-api/internal/rpc"
+)
+
+// runServe implements `todo-api [serve] [flags]`, running the HTTP and gRPC
+// servers until the process is stopped.
+func runServe(args []string) error {
+	var cfg config
+	var notify notifyConfig
+	fs := flag.NewFlagSet("serve", flag.ExitOnError)
+	cfg.register(fs)
+	notify.register(fs)
+	addr := fs.String("addr", ":8080", "new http port")
+	grpcAddr := fs.String("grpc-addr", ":9090", "gRPC port (empty disables)")
+	autoArchiveDays := fs.Int("auto-archive-days", 0, "archive todos done for this many days (0 disables)")
+	autoArchiveInterval := fs.Duration("auto-archive-interval", time.Hour, "how often the auto-archive policy runs")
+	idempotencyStore := fs.String("idempotency-store", "sqlite", "where idempotency keys are kept: sqlite or memory")
+	idempotencyTTL := fs.Duration("idempotency-ttl", 24*time.Hour, "how long idempotency keys are remembered")
+	webhookInterval := fs.Duration("webhook-interval", 5*time.Second, "how often pending webhook deliveries are retried")
+	reminderInterval := fs.Duration("reminder-interval", 30*time.Second, "how often due reminders are looked for")
+	escalationInterval := fs.Duration("escalation-interval", time.Hour, "how often escalation rules run on overdue todos (0 disables)")
+	dev := fs.Bool("dev", false, "development mode: serves the GraphiQL page at /graphiql")
+	backupInterval := fs.Duration("backup-interval", 24*time.Hour, "how often the database is backed up (0 disables)")
+	adminToken := fs.String("admin-token", os.Getenv("TODO_API_ADMIN_TOKEN"), "bearer token for the /admin endpoints (env TODO_API_ADMIN_TOKEN, empty disables them)")
+	validateResponses := fs.Bool("validate-responses", false, "log responses that do not match the OpenAPI document (for tests)")
+	fs.Parse(args)
+
+	app, err := cfg.openApp(true)
+	if err != nil {
+		return err
+	}
+	defer app.DB.Close()
+
+	app.Dev = *dev
+	app.ValidateResponses = *validateResponses
+	app.AdminToken = *adminToken
+	app.Backups = cfg.backups(app)
+
+	switch *idempotencyStore {
+	case "sqlite":
+		app.Idempotency = idempotency.NewSQLStore(app.DB, *idempotencyTTL)
+	case "memory":
+		app.Idempotency = idempotency.NewMemoryStore(*idempotencyTTL)
+	default:
+		return fmt.Errorf("unknown idempotency store %q", *idempotencyStore)
+	}
+
+	go app.Webhooks.Run(*webhookInterval)
+
+	app.Reminders, err = notify.scheduler(app)
+	if err != nil {
+		return err
+	}
+	go app.Reminders.Run(*reminderInterval)
+
+	app.Live = live.NewHub()
+	app.Events.Subscribe(app.Live.Publish)
+
+	if *backupInterval > 0 {
+		go app.Backups.Run(*backupInterval)
+	}
+
+	if *escalationInterval > 0 {
+		go jobs.Escalate(app, *escalationInterval)
+	}
+
+	if *autoArchiveDays > 0 {
+		go jobs.AutoArchive(app, *autoArchiveDays, *autoArchiveInterval)
+	}
+
+	router := routes.Routes(app)
+
+	srv := &http.Server{
+		Addr:    *addr,
+		Handler: router,
+	}
+
+	app.InfoLog.Println("Server running on port", *addr)
+	go func() {
+		err = srv.ListenAndServe()
+		if err != nil && err != http.ErrServerClosed {
+			app.ErrorLog.Fatalf("ListenAndServe(): %s", err)
+		}
+	}()
+
+	if *grpcAddr != "" {
+		lis, err := net.Listen("tcp", *grpcAddr)
+		if err != nil {
+			return fmt.Errorf("gRPC listen: %s", err)
+		}
+
+		app.InfoLog.Println("gRPC server running on port", *grpcAddr)
+		go func() {
+			err := rpc.NewServer(app).Serve(lis)
+			if err != nil {
+				app.ErrorLog.Fatalf("gRPC Serve(): %s", err)
+			}
+		}()
+	}
+
+	select {} // ?
+}
@@ -0,0 +1,134 @@
+package main
+
+import (
+	"flag"
+	"fmt"
+	"os"
+	"strconv"
+	"strings"
+	"text/tabwriter"
+
+	"github.com/furkankorkmaz309/todo-api/pkg/client"
+)
+
+func runCategory(e *env, args []string) error {
+	usage := fmt.Errorf("usage: todo %s", commands["cat"].usage)
+	if len(args) == 0 {
+		return usage
+	}
+
+	switch args[0] {
+	case "ls":
+		fs := flag.NewFlagSet("cat ls", flag.ExitOnError)
+		quiet := fs.Bool("q", false, "print only the names")
+		parseArgs(fs, args[1:])
+		return listCategories(e, *quiet)
+
+	case "add":
+		fs := flag.NewFlagSet("cat add", flag.ExitOnError)
+		description := fs.String("d", "", "description")
+		name := strings.Join(parseArgs(fs, args[1:]), " ")
+		if name == "" {
+			return usage
+		}
+
+		category, err := e.client.CreateCategory(e.ctx, client.CategoryInput{Name: name, Description: *description})
+		if err != nil {
+			return err
+		}
+		if e.json {
+			return printJSON(category)
+		}
+		fmt.Printf("added category %d: %s\n", category.ID, category.Name)
+		return nil
+
+	case "rm":
+		fs := flag.NewFlagSet("cat rm", flag.ExitOnError)
+		policy := fs.String("policy", client.PolicyRefuse, "what to do with its todos: refuse, reassign, archive or delete")
+		target := fs.String("target", "", "category the todos move to with --policy reassign")
+		rest := parseArgs(fs, args[1:])
+		if len(rest) != 1 {
+			return usage
+		}
+
+		id, err := resolveCategory(e, rest[0])
+		if err != nil {
+			return err
+		}
+		targetID, err := resolveCategory(e, *target)
+		if err != nil {
+			return err
+		}
+
+		err = e.client.DeleteCategory(e.ctx, id, *policy, targetID)
+		if err != nil {
+			return err
+		}
+		if !e.json {
+			fmt.Printf("deleted category %d\n", id)
+		}
+		return nil
+	}
+	return usage
+}
+
+func listCategories(e *env, quiet bool) error {
+	categories, err := e.client.ListCategories(e.ctx)
+	if err != nil {
+		return err
+	}
+
+	if e.json {
+		if categories == nil {
+			categories = []client.Category{}
+		}
+		return printJSON(categories)
+	}
+	if quiet {
+		for _, c := range categories {
+			fmt.Println(c.Name)
+		}
+		return nil
+	}
+
+	tw := tabwriter.NewWriter(os.Stdout, 0, 0, 2, ' ', 0)
+	fmt.Fprintln(tw, "ID\tNAME\tDESCRIPTION")
+	for _, c := range categories {
+		fmt.Fprintf(tw, "%d\t%s\t%s\n", c.ID, c.Name, c.Description)
+	}
+	return tw.Flush()
+}
+
+// resolveCategory turns a category name, matched case-insensitively, or ID
+// into an ID. An empty name gives 0.
+func resolveCategory(e *env, nameOrID string) (int, error) {
+	if nameOrID == "" {
+		return 0, nil
+	}
+	if id, err := strconv.Atoi(nameOrID); err == nil {
+		return id, nil
+	}
+
+	categories, err := e.client.ListCategories(e.ctx)
+	if err != nil {
+		return 0, err
+	}
+	for _, c := range categories {
+		if strings.EqualFold(c.Name, nameOrID) {
+			return c.ID, nil
+		}
+	}
+	return 0, fmt.Errorf("no category named %q", nameOrID)
+}
+
+func categoryNames(e *env) (map[int]string, error) {
+	categories, err := e.client.ListCategories(e.ctx)
+	if err != nil {
+		return nil, err
+	}
+	names := make(map[int]string, len(categories))
+	for _, c := range categories {
+		names[c.ID] = c.Name
+	}
+	return names, nil
+}
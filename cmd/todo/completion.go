@@ -0,0 +1,84 @@
+package main
+
+import (
+	"fmt"
+	"sort"
+	"strings"
+)
+
+// Completion scripts complete commands and flags, and category names after
+// --cat and --target by asking `todo cat ls -q`.
+const bashCompletion = `_todo() {
+    local cur prev cmd
+    cur="${COMP_WORDS[COMP_CWORD]}"
+    prev="${COMP_WORDS[COMP_CWORD-1]}"
+    cmd="${COMP_WORDS[1]}"
+
+    case "$prev" in
+        --cat|-cat|--target|-target)
+            COMPREPLY=($(compgen -W "$(todo cat ls -q 2>/dev/null)" -- "$cur"))
+            return ;;
+        --due|-due)
+            COMPREPLY=($(compgen -W "today tomorrow monday tuesday wednesday thursday friday saturday sunday +1d +1w" -- "$cur"))
+            return ;;
+        --policy|-policy)
+            COMPREPLY=($(compgen -W "refuse reassign archive delete" -- "$cur"))
+            return ;;
+    esac
+
+    if [ "$COMP_CWORD" -eq 1 ]; then
+        COMPREPLY=($(compgen -W "%[1]s" -- "$cur"))
+        return
+    fi
+    case "$cmd" in
+        add) COMPREPLY=($(compgen -W "-m -p --due --cat -t" -- "$cur")) ;;
+        ls) COMPREPLY=($(compgen -W "--overdue --done --open --cat -p --archived" -- "$cur")) ;;
+        edit) COMPREPLY=($(compgen -W "--title -m -p --due --cat -t --no-tags" -- "$cur")) ;;
+        cat) COMPREPLY=($(compgen -W "ls add rm -q -d --policy --target" -- "$cur")) ;;
+        completion) COMPREPLY=($(compgen -W "bash zsh fish" -- "$cur")) ;;
+        config) COMPREPLY=($(compgen -W "set server api_key" -- "$cur")) ;;
+    esac
+}
+complete -F _todo todo
+`
+
+const zshCompletion = `#compdef todo
+autoload -U +X bashcompinit && bashcompinit
+` + bashCompletion
+
+const fishCompletion = `complete -c todo -f
+complete -c todo -n __fish_use_subcommand -a "%[1]s"
+complete -c todo -n "__fish_seen_subcommand_from add ls edit" -l cat -r -a "(todo cat ls -q 2>/dev/null)"
+complete -c todo -n "__fish_seen_subcommand_from add edit" -l due -r -a "today tomorrow monday friday +1d +1w"
+complete -c todo -n "__fish_seen_subcommand_from add ls edit" -s p -r -a "1 2 3 4 5"
+complete -c todo -n "__fish_seen_subcommand_from ls" -l overdue
+complete -c todo -n "__fish_seen_subcommand_from ls" -l done
+complete -c todo -n "__fish_seen_subcommand_from ls" -l open
+complete -c todo -n "__fish_seen_subcommand_from ls" -l archived
+complete -c todo -n "__fish_seen_subcommand_from cat" -a "ls add rm"
+complete -c todo -n "__fish_seen_subcommand_from completion" -a "bash zsh fish"
+`
+
+func runCompletion(e *env, args []string) error {
+	if len(args) != 1 {
+		return fmt.Errorf("usage: todo %s", commands["completion"].usage)
+	}
+
+	names := make([]string, 0, len(commands))
+	for name := range commands {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+
+	switch args[0] {
+	case "bash":
+		fmt.Printf(bashCompletion, strings.Join(names, " "))
+	case "zsh":
+		fmt.Printf(zshCompletion, strings.Join(names, " "))
+	case "fish":
+		fmt.Printf(fishCompletion, strings.Join(names, " "))
+	default:
+		return fmt.Errorf("unknown shell %q, use bash, zsh or fish", args[0])
+	}
+	return nil
+}
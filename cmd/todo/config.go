@@ -0,0 +1,119 @@
+package main
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io/fs"
+	"os"
+	"path/filepath"
+)
+
+// config is read from config.json in the user's config directory, such as
+// ~/.config/todo/config.json, and overridden by the environment and then
+// by the command-line flags.
+type config struct {
+	Server string `json:"server"`
+	APIKey string `json:"api_key,omitempty"`
+}
+
+func configPath() (string, error) {
+	dir, err := os.UserConfigDir()
+	if err != nil {
+		return "", err
+	}
+	return filepath.Join(dir, "todo", "config.json"), nil
+}
+
+func loadConfig() (config, error) {
+	cfg := config{Server: "http://localhost:8080"}
+
+	path, err := configPath()
+	if err == nil {
+		data, err := os.ReadFile(path)
+		if err != nil && !errors.Is(err, fs.ErrNotExist) {
+			return cfg, err
+		}
+		if err == nil {
+			err = json.Unmarshal(data, &cfg)
+			if err != nil {
+				return cfg, fmt.Errorf("%s: %v", path, err)
+			}
+		}
+	}
+
+	if v := os.Getenv("TODO_SERVER"); v != "" {
+		cfg.Server = v
+	}
+	if v := os.Getenv("TODO_API_KEY"); v != "" {
+		cfg.APIKey = v
+	}
+	return cfg, nil
+}
+
+func saveConfig(cfg config) (string, error) {
+	path, err := configPath()
+	if err != nil {
+		return "", err
+	}
+	err = os.MkdirAll(filepath.Dir(path), 0o700)
+	if err != nil {
+		return "", err
+	}
+
+	data, err := json.MarshalIndent(cfg, "", "  ")
+	if err != nil {
+		return "", err
+	}
+	// The file may hold an API key, so only the user can read it.
+	return path, os.WriteFile(path, append(data, '\n'), 0o600)
+}
+
+func runConfig(e *env, args []string) error {
+	path, err := configPath()
+	if err != nil {
+		return err
+	}
+
+	if len(args) == 0 {
+		cfg, err := loadConfig()
+		if err != nil {
+			return err
+		}
+		if cfg.APIKey != "" {
+			cfg.APIKey = "(set)"
+		}
+		fmt.Printf("file:    %s\nserver:  %s\napi_key: %s\n", path, cfg.Server, cfg.APIKey)
+		return nil
+	}
+
+	if len(args) != 3 || args[0] != "set" {
+		return fmt.Errorf("usage: todo %s", commands["config"].usage)
+	}
+
+	// Only the file is updated, not the environment overrides.
+	var cfg config
+	data, err := os.ReadFile(path)
+	if err == nil {
+		err = json.Unmarshal(data, &cfg)
+	}
+	if err != nil && !errors.Is(err, fs.ErrNotExist) {
+		return err
+	}
+
+	switch args[1] {
+	case "server":
+		cfg.Server = args[2]
+	case "api_key":
+		cfg.APIKey = args[2]
+	default:
+		return fmt.Errorf("unknown setting %q, use server or api_key", args[1])
+	}
+
+	path, err = saveConfig(cfg)
+	if err != nil {
+		return err
+	}
+	fmt.Println("saved", path)
+	return nil
+}
@@ -0,0 +1,135 @@
+// Command todo manages todos from the terminal through the todo-api REST
+// API.
+//
+//	todo add "Write report" -p 4 --due tomorrow --cat work
+//	todo ls --overdue
+//	todo done 12
+package main
+
+import (
+	"context"
+	"errors"
+	"flag"
+	"fmt"
+	"os"
+	"sort"
+	"strings"
+
+	"github.com/furkankorkmaz309/todo-api/pkg/client"
+)
+
+// env is what every command gets: the API client and the output settings
+// from the global flags.
+type env struct {
+	ctx    context.Context
+	client *client.Client
+	json   bool
+}
+
+type command struct {
+	usage string
+	run   func(e *env, args []string) error
+}
+
+var commands map[string]command
+
+func init() {
+	commands = map[string]command{
+		"add":        {`add "title" [-m content] [-p 1-5] [--due when] [--cat name] [-t tag]...`, runAdd},
+		"ls":         {"ls [--overdue] [--done|--open] [--cat name] [-p 1-5] [--archived]", runList},
+		"show":       {"show <id>", runShow},
+		"edit":       {"edit <id> [--title t] [-m content] [-p 1-5] [--due when] [--cat name] [-t tag]...", runEdit},
+		"done":       {"done <id>...", runDone},
+		"undo":       {"undo <id>...", runUndo},
+		"rm":         {"rm <id>...", runRemove},
+		"archive":    {"archive [<id>...]   (no id: archive all finished todos)", runArchive},
+		"unarchive":  {"unarchive <id>...", runUnarchive},
+		"cat":        {"cat ls [-q] | cat add <name> [-d description] | cat rm <name> [--policy p] [--target name]", runCategory},
+		"config":     {"config [set <server|api_key> <value>]", runConfig},
+		"completion": {"completion <bash|zsh|fish>", runCompletion},
+	}
+}
+
+func main() {
+	cfg, err := loadConfig()
+	if err != nil {
+		fail(err)
+	}
+
+	fs := flag.NewFlagSet("todo", flag.ExitOnError)
+	server := fs.String("server", cfg.Server, "API base URL (config: server, env: TODO_SERVER)")
+	apiKey := fs.String("api-key", cfg.APIKey, "API key (config: api_key, env: TODO_API_KEY)")
+	jsonOut := fs.Bool("json", false, "print JSON instead of tables")
+	fs.Usage = usage
+	fs.Parse(os.Args[1:])
+
+	if fs.NArg() == 0 {
+		usage()
+		os.Exit(2)
+	}
+	cmd, ok := commands[fs.Arg(0)]
+	if !ok {
+		fmt.Fprintf(os.Stderr, "todo: unknown command %q\n", fs.Arg(0))
+		usage()
+		os.Exit(2)
+	}
+
+	e := &env{
+		ctx:    context.Background(),
+		client: client.New(*server, client.WithAPIKey(*apiKey), client.WithUserAgent("todo-cli")),
+		json:   *jsonOut,
+	}
+	err = cmd.run(e, fs.Args()[1:])
+	if err != nil {
+		fail(err)
+	}
+}
+
+func usage() {
+	fmt.Fprintln(os.Stderr, "usage: todo [--server url] [--api-key key] [--json] <command> [args]")
+	fmt.Fprintln(os.Stderr, "\ncommands:")
+
+	names := make([]string, 0, len(commands))
+	for name := range commands {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	for _, name := range names {
+		fmt.Fprintln(os.Stderr, "  todo "+commands[name].usage)
+	}
+}
+
+func fail(err error) {
+	var apiErr *client.Error
+	if errors.As(err, &apiErr) {
+		err = errors.New(apiErr.Message)
+	}
+	fmt.Fprintln(os.Stderr, "todo:", err)
+	os.Exit(1)
+}
+
+// parseArgs parses flags that may come before, between or after the
+// positional arguments, as in `todo add "title" -p 3`, and returns the
+// positional ones.
+func parseArgs(fs *flag.FlagSet, args []string) []string {
+	var positional []string
+	for {
+		fs.Parse(args)
+		args = fs.Args()
+		if len(args) == 0 {
+			return positional
+		}
+		positional = append(positional, args[0])
+		args = args[1:]
+	}
+}
+
+// stringList is a repeatable flag.
+type stringList []string
+
+func (s *stringList) String() string { return strings.Join(*s, ",") }
+
+func (s *stringList) Set(v string) error {
+	*s = append(*s, v)
+	return nil
+}
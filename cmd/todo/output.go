@@ -0,0 +1,73 @@
+package main
+
+import (
+	"encoding/json"
+	"fmt"
+	"io"
+	"os"
+	"strings"
+	"text/tabwriter"
+	"time"
+
+	"github.com/furkankorkmaz309/todo-api/pkg/client"
+)
+
+func printJSON(v interface{}) error {
+	enc := json.NewEncoder(os.Stdout)
+	enc.SetIndent("", "  ")
+	return enc.Encode(v)
+}
+
+// statusMarks show the status of a todo in the DONE column.
+var statusMarks = map[string]string{
+	"in_progress": "[~]",
+	"blocked":     "[!]",
+	"done":        "[x]",
+	"cancelled":   "[-]",
+}
+
+// printTodos writes a table of todos. Open todos past their due date are
+// marked with "!".
+func printTodos(w io.Writer, todos []client.Todo, categories map[int]string) {
+	if len(todos) == 0 {
+		fmt.Fprintln(w, "no todos")
+		return
+	}
+
+	tw := tabwriter.NewWriter(w, 0, 0, 2, ' ', 0)
+	fmt.Fprintln(tw, "ID\tDONE\tPRI\tDUE\tCATEGORY\tTITLE\tTAGS")
+	for _, t := range todos {
+		done := statusMarks[t.Status]
+		if done == "" {
+			done = "[ ]"
+		}
+		due := formatTime(t.DueDate)
+		if t.Overdue {
+			due += " !"
+		}
+		fmt.Fprintf(tw, "%d\t%s\t%d\t%s\t%s\t%s\t%s\n", t.ID, done, t.Priority, due, categories[t.CategoryID], t.Title, strings.Join(t.Tags, ","))
+	}
+	tw.Flush()
+}
+
+func printTodo(w io.Writer, t client.Todo, categories map[int]string) {
+	tw := tabwriter.NewWriter(w, 0, 0, 2, ' ', 0)
+	fmt.Fprintf(tw, "ID:\t%d\n", t.ID)
+	fmt.Fprintf(tw, "Title:\t%s\n", t.Title)
+	fmt.Fprintf(tw, "Content:\t%s\n", t.Content)
+	fmt.Fprintf(tw, "Priority:\t%d\n", t.Priority)
+	fmt.Fprintf(tw, "Due:\t%s\n", formatTime(t.DueDate))
+	fmt.Fprintf(tw, "Category:\t%s\n", categories[t.CategoryID])
+	fmt.Fprintf(tw, "Tags:\t%s\n", strings.Join(t.Tags, ", "))
+	fmt.Fprintf(tw, "Done:\t%v\n", t.IsDone)
+	if t.CompletedAt != nil {
+		fmt.Fprintf(tw, "Completed:\t%s\n", formatTime(*t.CompletedAt))
+	}
+	fmt.Fprintf(tw, "Archived:\t%v\n", t.Archived)
+	fmt.Fprintf(tw, "Created:\t%s\n", formatTime(t.CreatedAt))
+	tw.Flush()
+}
+
+func formatTime(t time.Time) string {
+	return t.Local().Format("2006-01-02 15:04")
+}
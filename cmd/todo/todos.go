@@ -0,0 +1,306 @@
+package main
+
+import (
+	"flag"
+	"fmt"
+	"os"
+	"sort"
+	"strconv"
+	"strings"
+
+	"github.com/furkankorkmaz309/todo-api/pkg/client"
+)
+
+func runAdd(e *env, args []string) error {
+	fs := flag.NewFlagSet("add", flag.ExitOnError)
+	content := fs.String("m", "", "content (defaults to the title)")
+	priority := fs.Int("p", 3, "priority, 1 (lowest) to 5 (highest)")
+	due := fs.String("due", "+7d", "due date, read by the server: today, tomorrow 9am, friday, in 3 days, 2025-06-01, ...")
+	category := fs.String("cat", "", "category name or ID")
+	var tags stringList
+	fs.Var(&tags, "t", "tag (repeatable)")
+	title := strings.Join(parseArgs(fs, args), " ")
+
+	if strings.TrimSpace(title) == "" {
+		return fmt.Errorf("usage: todo %s", commands["add"].usage)
+	}
+	if *content == "" {
+		*content = title
+	}
+
+	categoryID, err := resolveCategory(e, *category)
+	if err != nil {
+		return err
+	}
+
+	todo, err := e.client.CreateTodo(e.ctx, client.NewTodo{
+		Title:      title,
+		Content:    *content,
+		Priority:   *priority,
+		Due:        *due,
+		CategoryID: categoryID,
+		Tags:       tags,
+	})
+	if err != nil {
+		return err
+	}
+
+	if e.json {
+		return printJSON(todo)
+	}
+	fmt.Printf("added %d: %s (due %s)\n", todo.ID, todo.Title, formatTime(todo.DueDate))
+	return nil
+}
+
+func runList(e *env, args []string) error {
+	fs := flag.NewFlagSet("ls", flag.ExitOnError)
+	overdue := fs.Bool("overdue", false, "only todos past their due date and neither done nor cancelled")
+	done := fs.Bool("done", false, "only finished todos")
+	open := fs.Bool("open", false, "only open todos")
+	category := fs.String("cat", "", "category name or ID")
+	priority := fs.Int("p", 0, "only this priority")
+	archived := fs.Bool("archived", false, "list archived todos instead")
+	parseArgs(fs, args)
+
+	opts := &client.ListOptions{Archived: *archived, Priority: *priority}
+	switch {
+	case *done && (*open || *overdue):
+		return fmt.Errorf("--done cannot be combined with --open or --overdue")
+	case *done:
+		opts.IsDone = boolPtr(true)
+	case *open:
+		opts.IsDone = boolPtr(false)
+	}
+	opts.Overdue = *overdue
+
+	var err error
+	opts.CategoryID, err = resolveCategory(e, *category)
+	if err != nil {
+		return err
+	}
+
+	todos, err := e.client.ListTodos(e.ctx, opts)
+	if err != nil {
+		return err
+	}
+	sort.SliceStable(todos, func(i, j int) bool { return todos[i].DueDate.Before(todos[j].DueDate) })
+
+	if e.json {
+		if todos == nil {
+			todos = []client.Todo{}
+		}
+		return printJSON(todos)
+	}
+	names, err := categoryNames(e)
+	if err != nil {
+		return err
+	}
+	printTodos(os.Stdout, todos, names)
+	return nil
+}
+
+func runShow(e *env, args []string) error {
+	ids, err := parseIDs("show", args)
+	if err != nil {
+		return err
+	}
+
+	names, err := categoryNames(e)
+	if err != nil {
+		return err
+	}
+
+	for i, id := range ids {
+		todo, err := e.client.GetTodo(e.ctx, id)
+		if err != nil {
+			return err
+		}
+		if e.json {
+			err = printJSON(todo)
+			if err != nil {
+				return err
+			}
+			continue
+		}
+		if i > 0 {
+			fmt.Println()
+		}
+		printTodo(os.Stdout, todo, names)
+	}
+	return nil
+}
+
+func runEdit(e *env, args []string) error {
+	fs := flag.NewFlagSet("edit", flag.ExitOnError)
+	title := fs.String("title", "", "new title")
+	content := fs.String("m", "", "new content")
+	priority := fs.Int("p", 0, "new priority, 1 to 5")
+	due := fs.String("due", "", "new due date, read as by add")
+	category := fs.String("cat", "", "new category name or ID")
+	var tags stringList
+	fs.Var(&tags, "t", "tag (repeatable); replaces all tags")
+	noTags := fs.Bool("no-tags", false, "remove all tags")
+
+	ids, err := parseIDs("edit", parseArgs(fs, args))
+	if err != nil {
+		return err
+	}
+	if len(ids) != 1 {
+		return fmt.Errorf("usage: todo %s", commands["edit"].usage)
+	}
+
+	current, err := e.client.GetTodo(e.ctx, ids[0])
+	if err != nil {
+		return err
+	}
+
+	// The version makes the edit fail if someone changed the todo since.
+	patch := client.TodoPatch{
+		Title:    *title,
+		Content:  *content,
+		Priority: *priority,
+		Due:      *due,
+		Tags:     tags,
+		Version:  current.Version,
+	}
+	if *noTags {
+		patch.Tags = []string{}
+	}
+	patch.CategoryID, err = resolveCategory(e, *category)
+	if err != nil {
+		return err
+	}
+
+	todo, err := e.client.PatchTodo(e.ctx, current.ID, patch)
+	if err != nil {
+		return err
+	}
+	if e.json {
+		return printJSON(todo)
+	}
+	fmt.Printf("updated %d: %s\n", todo.ID, todo.Title)
+	return nil
+}
+
+func runDone(e *env, args []string) error {
+	return setDone(e, "done", args, true)
+}
+
+func runUndo(e *env, args []string) error {
+	return setDone(e, "undo", args, false)
+}
+
+func setDone(e *env, name string, args []string, done bool) error {
+	ids, err := parseIDs(name, args)
+	if err != nil {
+		return err
+	}
+
+	verb := "reopened"
+	if done {
+		verb = "finished"
+	}
+
+	for _, id := range ids {
+		current, err := e.client.GetTodo(e.ctx, id)
+		if err != nil {
+			return fmt.Errorf("todo %d: %w", id, err)
+		}
+		if current.IsDone == done {
+			continue
+		}
+		_, err = e.client.PatchTodo(e.ctx, id, client.TodoPatch{IsDone: boolPtr(done), Version: current.Version})
+		if err != nil {
+			return fmt.Errorf("todo %d: %w", id, err)
+		}
+		if !e.json {
+			fmt.Printf("%s %d: %s\n", verb, id, current.Title)
+		}
+	}
+	return nil
+}
+
+func runRemove(e *env, args []string) error {
+	ids, err := parseIDs("rm", args)
+	if err != nil {
+		return err
+	}
+	for _, id := range ids {
+		err = e.client.DeleteTodo(e.ctx, id)
+		if err != nil {
+			return fmt.Errorf("todo %d: %w", id, err)
+		}
+		if !e.json {
+			fmt.Printf("deleted %d\n", id)
+		}
+	}
+	return nil
+}
+
+func runArchive(e *env, args []string) error {
+	if len(args) == 0 {
+		ids, err := e.client.ArchiveFinished(e.ctx, nil)
+		if err != nil {
+			return err
+		}
+		if e.json {
+			if ids == nil {
+				ids = []int{}
+			}
+			return printJSON(ids)
+		}
+		fmt.Printf("archived %d finished todos\n", len(ids))
+		return nil
+	}
+
+	ids, err := parseIDs("archive", args)
+	if err != nil {
+		return err
+	}
+	for _, id := range ids {
+		err = e.client.ArchiveTodo(e.ctx, id)
+		if err != nil {
+			return fmt.Errorf("todo %d: %w", id, err)
+		}
+		if !e.json {
+			fmt.Printf("archived %d\n", id)
+		}
+	}
+	return nil
+}
+
+func runUnarchive(e *env, args []string) error {
+	ids, err := parseIDs("unarchive", args)
+	if err != nil {
+		return err
+	}
+	for _, id := range ids {
+		err = e.client.UnarchiveTodo(e.ctx, id)
+		if err != nil {
+			return fmt.Errorf("todo %d: %w", id, err)
+		}
+		if !e.json {
+			fmt.Printf("unarchived %d\n", id)
+		}
+	}
+	return nil
+}
+
+func parseIDs(name string, args []string) ([]int, error) {
+	if len(args) == 0 {
+		return nil, fmt.Errorf("usage: todo %s", commands[name].usage)
+	}
+	ids := make([]int, 0, len(args))
+	for _, arg := range args {
+		id, err := strconv.Atoi(arg)
+		if err != nil || id <= 0 {
+			return nil, fmt.Errorf("invalid todo ID %q", arg)
+		}
+		ids = append(ids, id)
+	}
+	return ids, nil
+}
+
+func boolPtr(b bool) *bool {
+	return &b
+}
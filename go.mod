@@ -3,6 +3,17 @@ module github.com/furkankorkmaz309/todo-api
 go 1.24.2
 
 require (
-	github.com/go-chi/chi v1.5.5 // indirect
-	github.com/mattn/go-sqlite3 v1.14.28 // indirect
+	github.com/go-chi/chi v1.5.5
+	github.com/gorilla/websocket v1.5.3
+	github.com/graph-gophers/graphql-go v1.6.0
+	github.com/mattn/go-sqlite3 v1.14.28
+	google.golang.org/grpc v1.80.0
+	google.golang.org/protobuf v1.36.11
+)
+
+require (
+	golang.org/x/net v0.49.0 // indirect
+	golang.org/x/sys v0.40.0 // indirect
+	golang.org/x/text v0.33.0 // indirect
+	google.golang.org/genproto/googleapis/rpc v0.0.0-20260120221211-b8f7ae30c516 // indirect
 )
@@ -3,10 +3,33 @@ package app
 import (
 	"database/sql"
 	"log"
+
+	"github.com/furkankorkmaz309/todo-api/internal/backup"
+	"github.com/furkankorkmaz309/todo-api/internal/events"
+	"github.com/furkankorkmaz309/todo-api/internal/idempotency"
+	"github.com/furkankorkmaz309/todo-api/internal/live"
+	"github.com/furkankorkmaz309/todo-api/internal/reminders"
+	"github.com/furkankorkmaz309/todo-api/internal/webhooks"
 )
 
 type App struct {
 	InfoLog  *log.Logger
 	ErrorLog *log.Logger
 	DB       *sql.DB
+	Dev      bool
+
+	// ValidateResponses logs responses that do not match the OpenAPI
+	// document; it is meant for tests and development.
+	ValidateResponses bool
+
+	// AdminToken is the bearer token of the /admin endpoints, which are
+	// disabled when it is empty.
+	AdminToken string
+
+	Idempotency idempotency.Store
+	Events      *events.Bus
+	Webhooks    *webhooks.Dispatcher
+	Live        *live.Hub
+	Backups     *backup.Manager
+	Reminders   *reminders.Scheduler
 }
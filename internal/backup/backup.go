@@ -0,0 +1,231 @@
+// Package backup takes consistent copies of the database while the server
+// is using it, keeps the latest few, and puts a copy back in place.
+package backup
+
+import (
+	"database/sql"
+	"fmt"
+	"io"
+	"log"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/furkankorkmaz309/todo-api/internal/db"
+)
+
+const (
+	prefix = "todo-api-"
+	suffix = ".db"
+)
+
+type Backup struct {
+	Name      string    `json:"name"`
+	Size      int64     `json:"size"`
+	CreatedAt time.Time `json:"created_at"`
+}
+
+// Manager writes backups to Dir, keeping only the Keep newest of them.
+type Manager struct {
+	DB       *sql.DB
+	Dir      string
+	Keep     int // 0 keeps every backup
+	InfoLog  *log.Logger
+	ErrorLog *log.Logger
+
+	mu sync.Mutex
+}
+
+func NewManager(db *sql.DB, dir string, keep int, infoLog, errorLog *log.Logger) *Manager {
+	return &Manager{DB: db, Dir: dir, Keep: keep, InfoLog: infoLog, ErrorLog: errorLog}
+}
+
+// Create backs the database up into Dir, named after the current time so
+// the names sort by age, then removes the backups beyond Keep.
+func (m *Manager) Create() (Backup, error) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	backup, err := m.save()
+	if err != nil {
+		return backup, err
+	}
+	err = m.rotate()
+	if err != nil {
+		return backup, err
+	}
+	return backup, nil
+}
+
+// Save is Create without removing any backup, for when one of them is
+// still to be read, as by a restore.
+func (m *Manager) Save() (Backup, error) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	return m.save()
+}
+
+func (m *Manager) save() (Backup, error) {
+	err := os.MkdirAll(m.Dir, 0o755)
+	if err != nil {
+		return Backup{}, fmt.Errorf("an error occurred while creating backup directory : %v", err)
+	}
+
+	now := time.Now().UTC()
+	name := prefix + now.Format("20060102-150405.000") + suffix
+	path := filepath.Join(m.Dir, name)
+	err = db.Backup(m.DB, path)
+	if err != nil {
+		return Backup{}, err
+	}
+
+	info, err := os.Stat(path)
+	if err != nil {
+		return Backup{}, err
+	}
+	return Backup{Name: name, Size: info.Size(), CreatedAt: now}, nil
+}
+
+// List returns the backups in Dir, newest first.
+func (m *Manager) List() ([]Backup, error) {
+	entries, err := os.ReadDir(m.Dir)
+	if os.IsNotExist(err) {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, fmt.Errorf("an error occurred while listing backups : %v", err)
+	}
+
+	var backups []Backup
+	for _, e := range entries {
+		name := e.Name()
+		if e.IsDir() || !strings.HasPrefix(name, prefix) || !strings.HasSuffix(name, suffix) {
+			continue
+		}
+		info, err := e.Info()
+		if err != nil {
+			continue
+		}
+		backups = append(backups, Backup{Name: name, Size: info.Size(), CreatedAt: info.ModTime().UTC()})
+	}
+
+	sort.Slice(backups, func(i, j int) bool { return backups[i].Name > backups[j].Name })
+	return backups, nil
+}
+
+func (m *Manager) rotate() error {
+	if m.Keep <= 0 {
+		return nil
+	}
+
+	backups, err := m.List()
+	if err != nil {
+		return err
+	}
+	for i := m.Keep; i < len(backups); i++ {
+		err = os.Remove(filepath.Join(m.Dir, backups[i].Name))
+		if err != nil {
+			return fmt.Errorf("an error occurred while removing old backup : %v", err)
+		}
+	}
+	return nil
+}
+
+// Run creates a backup once per interval. It blocks, so run it in its own
+// goroutine.
+func (m *Manager) Run(interval time.Duration) {
+	ticker := time.NewTicker(interval)
+	defer ticker.Stop()
+
+	for range ticker.C {
+		backup, err := m.Create()
+		if err != nil {
+			m.ErrorLog.Printf("scheduled backup failed: %v", err)
+			continue
+		}
+		m.InfoLog.Printf("Backed up the database to %s", backup.Name)
+	}
+}
+
+// Validate checks that the database at path is undamaged and has a schema
+// this build can run, and returns its schema version.
+func Validate(path string) (int, error) {
+	_, err := os.Stat(path)
+	if err != nil {
+		return 0, err
+	}
+
+	conn, err := db.Open(path)
+	if err != nil {
+		return 0, fmt.Errorf("%s is not a usable database: %v", path, err)
+	}
+	defer conn.Close()
+
+	problems, err := db.Check(conn)
+	if err != nil {
+		return 0, fmt.Errorf("%s is not a usable database: %v", path, err)
+	}
+	if len(problems) > 0 {
+		return 0, fmt.Errorf("%s is damaged: %s", path, problems[0])
+	}
+
+	version, err := db.SchemaVersion(conn)
+	if err != nil {
+		return 0, err
+	}
+	if version == 0 {
+		return 0, fmt.Errorf("%s is not a todo-api database", path)
+	}
+	if version > db.LatestVersion() {
+		return version, fmt.Errorf("%s has schema version %d, newer than this build knows (%d)", path, version, db.LatestVersion())
+	}
+	return version, nil
+}
+
+// Restore validates the backup at src and swaps it in for the database at
+// dst. The copy is made next to dst and renamed over it, so a failure
+// leaves dst as it was. Nothing may have dst open meanwhile.
+func Restore(src, dst string) (int, error) {
+	version, err := Validate(src)
+	if err != nil {
+		return 0, err
+	}
+
+	tmp := dst + ".restore"
+	err = copyFile(src, tmp)
+	if err == nil {
+		err = os.Rename(tmp, dst)
+	}
+	if err != nil {
+		os.Remove(tmp)
+		return 0, fmt.Errorf("an error occurred while restoring database : %v", err)
+	}
+
+	// A journal left by the old file would be applied to the new one.
+	os.Remove(dst + "-journal")
+	return version, nil
+}
+
+func copyFile(src, dst string) error {
+	in, err := os.Open(src)
+	if err != nil {
+		return err
+	}
+	defer in.Close()
+
+	out, err := os.OpenFile(dst, os.O_WRONLY|os.O_CREATE|os.O_TRUNC, 0o644)
+	if err != nil {
+		return err
+	}
+	_, err = io.Copy(out, in)
+	if err == nil {
+		err = out.Sync()
+	}
+	if closeErr := out.Close(); err == nil {
+		err = closeErr
+	}
+	return err
+}
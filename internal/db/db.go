@@ -3,30 +3,57 @@ package db
 import (
 	"database/sql"
 	"fmt"
+	"strings"
 
 	_ "github.com/mattn/go-sqlite3"
 )
 
-func InitDB() (*sql.DB, error) {
-	db, err := sql.Open("sqlite3", "../../internal/data/todo-api.db")
+// DefaultPath is where the database lives when no other path is given,
+// relative to cmd/todo-api, where the server is started from.
+const DefaultPath = "../../internal/data/todo-api.db"
+
+// InitDB opens the database at path and brings its schema up to date.
+func InitDB(path string) (*sql.DB, error) {
+	db, err := Open(path)
+	if err != nil {
+		return nil, err
+	}
+
+	_, err = Migrate(db)
+	if err != nil {
+		db.Close()
+		return nil, err
+	}
+	return db, nil
+}
+
+// Open opens the database at path without touching its schema.
+func Open(path string) (*sql.DB, error) {
+	db, err := sql.Open("sqlite3", path+"?_foreign_keys=on&_loc=UTC")
 	if err != nil {
 		return nil, fmt.Errorf("an error occured while opening database : %v", err)
 	}
 
 	err = db.Ping()
 	if err != nil {
+		db.Close()
 		return nil, fmt.Errorf("an error occured while connecting database : %v", err)
 	}
+	return db, nil
+}
 
+// Migrate creates the base tables if needed and applies the migrations
+// not applied yet, returning how many it applied.
+func Migrate(db *sql.DB) (int, error) {
 	queryCategory := `CREATE TABLE IF NOT EXISTS category (
 	id INTEGER PRIMARY KEY AUTOINCREMENT,
 	name TEXT,
 	description TEXT
 	)`
 
-	_, err = db.Exec(queryCategory)
+	_, err := db.Exec(queryCategory)
 	if err != nil {
-		return nil, fmt.Errorf("an error occurred while creating category table : %v", err)
+		return 0, fmt.Errorf("an error occurred while creating category table : %v", err)
 	}
 
 	queryTodo := `CREATE TABLE IF NOT EXISTS todo (
@@ -44,7 +71,207 @@ func InitDB() (*sql.DB, error) {
 
 	_, err = db.Exec(queryTodo)
 	if err != nil {
-		return nil, fmt.Errorf("an error occurred while creating todo table : %v", err)
+		return 0, fmt.Errorf("an error occurred while creating todo table : %v", err)
 	}
-	return db, nil
+
+	return migrate(db)
+}
+
+// migrations are applied in order on top of the base tables; the index of
+// the last applied migration is kept in PRAGMA user_version.
+var migrations = []string{
+	// Nothing records when the todos done so far were completed, so their
+	// completed_at stays NULL rather than being made up.
+	`ALTER TABLE todo ADD COLUMN completed_at TIMESTAMP`,
+	`CREATE TABLE idempotency_key (
+	key TEXT PRIMARY KEY,
+	request_hash TEXT NOT NULL,
+	status INTEGER NOT NULL DEFAULT 0,
+	body BLOB,
+	created_at TIMESTAMP NOT NULL
+	)`,
+	`CREATE TABLE calendar_token (
+	id INTEGER PRIMARY KEY AUTOINCREMENT,
+	token_hash TEXT NOT NULL UNIQUE,
+	name TEXT NOT NULL,
+	category_id INT REFERENCES category(id) ON DELETE CASCADE,
+	created_at TIMESTAMP NOT NULL
+	)`,
+	`CREATE TABLE todo_tag (
+	todo_id INTEGER NOT NULL REFERENCES todo(id) ON DELETE CASCADE,
+	name TEXT NOT NULL,
+	PRIMARY KEY (todo_id, name)
+	)`,
+	`CREATE TABLE webhook (
+	id INTEGER PRIMARY KEY AUTOINCREMENT,
+	url TEXT NOT NULL,
+	secret TEXT NOT NULL,
+	events TEXT NOT NULL,
+	active BOOLEAN NOT NULL DEFAULT 1,
+	created_at TIMESTAMP NOT NULL
+	);
+	CREATE TABLE webhook_delivery (
+	id INTEGER PRIMARY KEY AUTOINCREMENT,
+	webhook_id INTEGER NOT NULL REFERENCES webhook(id) ON DELETE CASCADE,
+	event_type TEXT NOT NULL,
+	payload BLOB NOT NULL,
+	status TEXT NOT NULL DEFAULT 'pending',
+	attempts INTEGER NOT NULL DEFAULT 0,
+	next_attempt_at TIMESTAMP NOT NULL,
+	response_code INTEGER,
+	last_error TEXT,
+	created_at TIMESTAMP NOT NULL,
+	delivered_at TIMESTAMP
+	);
+	CREATE INDEX webhook_delivery_pending ON webhook_delivery (status, next_attempt_at)`,
+	// Every change to a todo row bumps its version, whichever code path
+	// made it, so clients can detect edits they have not seen.
+	`ALTER TABLE todo ADD COLUMN version INTEGER NOT NULL DEFAULT 1;
+	CREATE TRIGGER todo_version AFTER UPDATE ON todo FOR EACH ROW WHEN NEW.version = OLD.version
+	BEGIN
+		UPDATE todo SET version = OLD.version + 1 WHERE id = NEW.id;
+	END`,
+	// Timestamps used to keep the offset of whoever wrote them, which broke
+	// comparing them as text; they are UTC from here on. The version
+	// trigger is dropped meanwhile, as the todos themselves do not change.
+	`DROP TRIGGER todo_version;
+	` + toUTC("todo", "created_at", "due_date", "completed_at") + `;
+	CREATE TRIGGER todo_version AFTER UPDATE ON todo FOR EACH ROW WHEN NEW.version = OLD.version
+	BEGIN
+		UPDATE todo SET version = OLD.version + 1 WHERE id = NEW.id;
+	END;
+	` + toUTC("idempotency_key", "created_at") + `;
+	` + toUTC("calendar_token", "created_at") + `;
+	` + toUTC("webhook", "created_at") + `;
+	` + toUTC("webhook_delivery", "next_attempt_at", "created_at", "delivered_at") + `;
+	ALTER TABLE todo ADD COLUMN all_day BOOLEAN NOT NULL DEFAULT 0;
+	CREATE TABLE setting (
+	key TEXT PRIMARY KEY,
+	value TEXT NOT NULL
+	)`,
+	// A reminder fires at remind_at, or offset_minutes before the due date
+	// of its todo, which may still move.
+	`CREATE TABLE reminder (
+	id INTEGER PRIMARY KEY AUTOINCREMENT,
+	todo_id INTEGER NOT NULL REFERENCES todo(id) ON DELETE CASCADE,
+	remind_at TIMESTAMP,
+	offset_minutes INTEGER,
+	channel TEXT NOT NULL,
+	status TEXT NOT NULL DEFAULT 'pending',
+	attempts INTEGER NOT NULL DEFAULT 0,
+	next_attempt_at TIMESTAMP,
+	last_error TEXT,
+	created_at TIMESTAMP NOT NULL,
+	sent_at TIMESTAMP,
+	CHECK ((remind_at IS NULL) != (offset_minutes IS NULL))
+	);
+	CREATE INDEX reminder_todo ON reminder (todo_id);
+	CREATE INDEX reminder_pending ON reminder (status)`,
+	// An escalation records that a rule acted on a todo, once per due date
+	// the todo was overdue from.
+	`CREATE TABLE escalation_rule (
+	id INTEGER PRIMARY KEY AUTOINCREMENT,
+	name TEXT NOT NULL,
+	after_days INTEGER NOT NULL,
+	action TEXT NOT NULL,
+	category_id INT REFERENCES category(id) ON DELETE CASCADE,
+	channel TEXT,
+	active BOOLEAN NOT NULL DEFAULT 1,
+	created_at TIMESTAMP NOT NULL
+	);
+	CREATE TABLE escalation (
+	rule_id INTEGER NOT NULL REFERENCES escalation_rule(id) ON DELETE CASCADE,
+	todo_id INTEGER NOT NULL REFERENCES todo(id) ON DELETE CASCADE,
+	due_date TIMESTAMP NOT NULL,
+	applied_at TIMESTAMP NOT NULL,
+	PRIMARY KEY (rule_id, todo_id, due_date)
+	)`,
+	// status replaces done, which is kept in step with it for the queries
+	// and clients that only know done.
+	`ALTER TABLE todo ADD COLUMN status TEXT NOT NULL DEFAULT 'todo';
+	ALTER TABLE todo ADD COLUMN started_at TIMESTAMP;
+	UPDATE todo SET status = 'done' WHERE done = 1`,
+	// Todos are ordered on a board by rank, text that a moved todo gets a
+	// new value of between its neighbours', so no other row changes.
+	`CREATE TABLE board (
+	id INTEGER PRIMARY KEY AUTOINCREMENT,
+	name TEXT NOT NULL,
+	column_by TEXT NOT NULL,
+	created_at TIMESTAMP NOT NULL
+	);
+	CREATE TABLE board_column (
+	id INTEGER PRIMARY KEY AUTOINCREMENT,
+	board_id INTEGER NOT NULL REFERENCES board(id) ON DELETE CASCADE,
+	name TEXT NOT NULL,
+	status TEXT,
+	category_id INT REFERENCES category(id) ON DELETE CASCADE,
+	position INTEGER NOT NULL
+	);
+	CREATE TABLE board_position (
+	board_id INTEGER NOT NULL REFERENCES board(id) ON DELETE CASCADE,
+	todo_id INTEGER NOT NULL REFERENCES todo(id) ON DELETE CASCADE,
+	rank TEXT NOT NULL,
+	PRIMARY KEY (board_id, todo_id),
+	UNIQUE (board_id, rank)
+	)`,
+	// Foreign keys were not enforced before, so todos may still point at
+	// categories deleted since; such rows could not be updated any more.
+	`UPDATE todo SET category_id = NULL WHERE category_id IS NOT NULL AND category_id NOT IN (SELECT id FROM category)`,
+}
+
+// toUTC rewrites the timestamp columns of table in UTC, in the layout the
+// driver writes. Values SQLite cannot read are left as they are.
+func toUTC(table string, columns ...string) string {
+	sets := make([]string, len(columns))
+	for i, c := range columns {
+		sets[i] = fmt.Sprintf("%s = COALESCE(strftime('%%Y-%%m-%%d %%H:%%M:%%f+00:00', %s), %s)", c, c, c)
+	}
+	return "UPDATE " + table + " SET " + strings.Join(sets, ", ")
+}
+
+// SchemaVersion returns the number of migrations applied to db.
+func SchemaVersion(db *sql.DB) (int, error) {
+	var version int
+	err := db.QueryRow(`PRAGMA user_version`).Scan(&version)
+	if err != nil {
+		return 0, fmt.Errorf("an error occurred while reading schema version : %v", err)
+	}
+	return version, nil
+}
+
+// LatestVersion is the schema version of a fully migrated database.
+func LatestVersion() int {
+	return len(migrations)
+}
+
+func migrate(db *sql.DB) (int, error) {
+	version, err := SchemaVersion(db)
+	if err != nil {
+		return 0, err
+	}
+	if version > len(migrations) {
+		return 0, fmt.Errorf("database schema version %d is newer than this build knows (%d)", version, len(migrations))
+	}
+
+	for i := version; i < len(migrations); i++ {
+		tx, err := db.Begin()
+		if err != nil {
+			return i - version, fmt.Errorf("an error occurred while starting migration %d : %v", i+1, err)
+		}
+
+		_, err = tx.Exec(migrations[i])
+		if err == nil {
+			_, err = tx.Exec(fmt.Sprintf(`PRAGMA user_version = %d`, i+1))
+		}
+		if err != nil {
+			tx.Rollback()
+			return i - version, fmt.Errorf("an error occurred while applying migration %d : %v", i+1, err)
+		}
+
+		err = tx.Commit()
+		if err != nil {
+			return i - version, fmt.Errorf("an error occurred while committing migration %d : %v", i+1, err)
+		}
+	}
+	return len(migrations) - version, nil
 }
@@ -0,0 +1,76 @@
+package db
+
+import (
+	"database/sql"
+	"path/filepath"
+	"testing"
+)
+
+// TestMigrateDanglingCategories migrates a database from before foreign
+// keys were enforced, holding a todo whose category was deleted.
+func TestMigrateDanglingCategories(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "old.db")
+	old, err := sql.Open("sqlite3", path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	for _, query := range []string{
+		`CREATE TABLE category (id INTEGER PRIMARY KEY AUTOINCREMENT, name TEXT, description TEXT)`,
+		`CREATE TABLE todo (id INTEGER PRIMARY KEY AUTOINCREMENT, title TEXT, content TEXT, priority INTEGER,
+		created_at TIMESTAMP, due_date TIMESTAMP, done BOOLEAN DEFAULT 0, archived BOOLEAN DEFAULT 0,
+		category_id INT, FOREIGN KEY (category_id) REFERENCES category(id))`,
+		`INSERT INTO category (name, description) VALUES ('work', ''), ('gone', '')`,
+		`INSERT INTO todo (title, content, priority, created_at, due_date, done, category_id) VALUES
+		('kept', 'c', 3, '2024-01-01 10:00:00+00:00', '2024-02-01 10:00:00+00:00', 0, 1),
+		('orphaned', 'c', 3, '2024-01-01 10:00:00+00:00', '2024-02-01 10:00:00+00:00', 1, 2),
+		('none', 'c', 3, '2024-01-01 10:00:00+00:00', '2024-02-01 10:00:00+00:00', 0, NULL)`,
+		`DELETE FROM category WHERE id = 2`,
+	} {
+		_, err = old.Exec(query)
+		if err != nil {
+			t.Fatal(err)
+		}
+	}
+	old.Close()
+
+	conn, err := InitDB(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer conn.Close()
+
+	problems, err := Check(conn)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(problems) > 0 {
+		t.Errorf("Check after migrating = %q, want no problems", problems)
+	}
+
+	rows, err := conn.Query(`SELECT title, category_id FROM todo ORDER BY id`)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer rows.Close()
+	want := map[string]sql.NullInt64{
+		"kept":     {Int64: 1, Valid: true},
+		"orphaned": {},
+		"none":     {},
+	}
+	for rows.Next() {
+		var title string
+		var categoryID sql.NullInt64
+		err = rows.Scan(&title, &categoryID)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if categoryID != want[title] {
+			t.Errorf("todo %q has category %v, want %v", title, categoryID, want[title])
+		}
+	}
+
+	_, err = conn.Exec(`UPDATE todo SET title = 'renamed' WHERE title = 'orphaned'`)
+	if err != nil {
+		t.Errorf("updating the formerly orphaned todo: %v", err)
+	}
+}
@@ -0,0 +1,59 @@
+package db
+
+import (
+	"database/sql"
+	"fmt"
+)
+
+// Backup writes a consistent copy of db to path, which must not exist
+// yet. It is safe while the database is in use.
+func Backup(db *sql.DB, path string) error {
+	_, err := db.Exec(`VACUUM INTO ?`, path)
+	if err != nil {
+		return fmt.Errorf("an error occurred while backing up database : %v", err)
+	}
+	return nil
+}
+
+// Check runs SQLite's integrity and foreign key checks and returns the
+// problems they find.
+func Check(db *sql.DB) ([]string, error) {
+	var problems []string
+
+	rows, err := db.Query(`PRAGMA integrity_check`)
+	if err != nil {
+		return nil, fmt.Errorf("an error occurred while checking integrity : %v", err)
+	}
+	for rows.Next() {
+		var msg string
+		err = rows.Scan(&msg)
+		if err != nil {
+			rows.Close()
+			return nil, fmt.Errorf("an error occurred while checking integrity : %v", err)
+		}
+		if msg != "ok" {
+			problems = append(problems, msg)
+		}
+	}
+	rows.Close()
+	if err = rows.Err(); err != nil {
+		return nil, fmt.Errorf("an error occurred while checking integrity : %v", err)
+	}
+
+	rows, err = db.Query(`PRAGMA foreign_key_check`)
+	if err != nil {
+		return nil, fmt.Errorf("an error occurred while checking foreign keys : %v", err)
+	}
+	defer rows.Close()
+	for rows.Next() {
+		var table, parent string
+		var rowID sql.NullInt64
+		var fk int
+		err = rows.Scan(&table, &rowID, &parent, &fk)
+		if err != nil {
+			return nil, fmt.Errorf("an error occurred while checking foreign keys : %v", err)
+		}
+		problems = append(problems, fmt.Sprintf("%s row %d references a missing %s", table, rowID.Int64, parent))
+	}
+	return problems, rows.Err()
+}
@@ -0,0 +1,321 @@
+// Package duedate reads due dates written the way people say them, such as
+// "tomorrow 9am", "next friday", "in 3 days" or "end of month".
+package duedate
+
+import (
+	"fmt"
+	"regexp"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// A day without a time of day is due at the end of it.
+const (
+	endHour   = 23
+	endMinute = 59
+)
+
+// Due is a resolved due date. DateOnly means text named a day but no time
+// of day, so Time is the end of that day.
+type Due struct {
+	Time     time.Time
+	DateOnly bool
+}
+
+// Parse resolves text relative to now, in now's location. RFC 3339 is taken
+// as it is. Otherwise text is a day, a time of day, or a day followed by a
+// time of day:
+//
+//	days:  today, tomorrow, friday, next friday, this friday, next week,
+//	       next month, next year, end of week/month/year, in 3 days,
+//	       in 2 weeks, +3d, 2026-11-05, nov 5, 5 november 2027
+//	times: 9am, 9:30pm, 21:00, noon, morning, afternoon, evening, with an
+//	       optional "at" before them
+//
+// "in 30 minutes" and "in 2 hours" are exact. A day without a time of day
+// means the end of that day, 23:59, and a time of day alone means the next
+// time the clock shows it. Weekdays mean the first such day after today;
+// "this friday" is today when today is a Friday. Day and month names may be
+// cut to three letters.
+func Parse(text string, now time.Time) (Due, error) {
+	text = strings.TrimSpace(text)
+	if text == "" {
+		return Due{}, fmt.Errorf("due date is empty")
+	}
+	if t, err := time.Parse(time.RFC3339, text); err == nil {
+		return Due{Time: t}, nil
+	}
+
+	words := strings.Fields(strings.ToLower(strings.ReplaceAll(text, ",", " ")))
+	clock, words, err := splitClock(words)
+	if err != nil {
+		return Due{}, err
+	}
+
+	var day time.Time
+	switch {
+	case len(words) == 0:
+		// Only a time of day, which splitClock guarantees is there.
+		t := at(now, clock)
+		if !t.After(now) {
+			t = at(now.AddDate(0, 0, 1), clock)
+		}
+		return Due{Time: t}, nil
+
+	case words[0] == "in" || strings.HasPrefix(words[0], "+"):
+		n, unit, err := parseAmount(words)
+		if err != nil {
+			return Due{}, fmt.Errorf("cannot understand due date %q: %v", text, err)
+		}
+		switch unit {
+		case "minute", "hour":
+			if clock != nil {
+				return Due{}, fmt.Errorf("cannot understand due date %q: a time of day does not go with %ss", text, unit)
+			}
+			if unit == "minute" {
+				return Due{Time: now.Add(time.Duration(n) * time.Minute)}, nil
+			}
+			return Due{Time: now.Add(time.Duration(n) * time.Hour)}, nil
+		case "day":
+			day = now.AddDate(0, 0, n)
+		case "week":
+			day = now.AddDate(0, 0, 7*n)
+		case "month":
+			day = now.AddDate(0, n, 0)
+		case "year":
+			day = now.AddDate(n, 0, 0)
+		}
+
+	default:
+		var ok bool
+		day, ok = parseDay(words, now)
+		if !ok {
+			return Due{}, fmt.Errorf("cannot understand due date %q", text)
+		}
+	}
+
+	return Due{Time: at(day, clock), DateOnly: clock == nil}, nil
+}
+
+// clock is a time of day.
+type clock struct {
+	hour, minute int
+}
+
+// at returns day at the time of day c, or at the end of day when c is nil.
+func at(day time.Time, c *clock) time.Time {
+	if c == nil {
+		c = &clock{endHour, endMinute}
+	}
+	return time.Date(day.Year(), day.Month(), day.Day(), c.hour, c.minute, 0, 0, day.Location())
+}
+
+var namedClocks = map[string]clock{
+	"noon":      {12, 0},
+	"morning":   {9, 0},
+	"afternoon": {15, 0},
+	"evening":   {18, 0},
+}
+
+var clockPattern = regexp.MustCompile(`^(\d{1,2})(?::(\d{2}))?(am|pm)?$`)
+
+// splitClock takes a time of day off the end of words. A bare number is
+// not a time of day, since it may be the day of a month.
+func splitClock(words []string) (*clock, []string, error) {
+	n := len(words)
+	if n == 0 {
+		return nil, words, nil
+	}
+
+	last := words[n-1]
+	if (last == "am" || last == "pm") && n > 1 {
+		last = words[n-2] + last
+		n--
+	}
+
+	var c *clock
+	if named, ok := namedClocks[last]; ok {
+		c = &named
+	} else if m := clockPattern.FindStringSubmatch(last); m != nil && (m[2] != "" || m[3] != "") {
+		hour, _ := strconv.Atoi(m[1])
+		minute, _ := strconv.Atoi(m[2])
+		switch {
+		case m[3] != "" && (hour < 1 || hour > 12):
+			return nil, nil, fmt.Errorf("%s is not a time of day", last)
+		case m[3] == "am" && hour == 12:
+			hour = 0
+		case m[3] == "pm" && hour != 12:
+			hour += 12
+		}
+		if hour > 23 || minute > 59 {
+			return nil, nil, fmt.Errorf("%s is not a time of day", last)
+		}
+		c = &clock{hour, minute}
+	}
+	if c == nil {
+		return nil, words, nil
+	}
+
+	rest := words[:n-1]
+	if len(rest) > 0 && rest[len(rest)-1] == "at" {
+		rest = rest[:len(rest)-1]
+	}
+	return c, rest, nil
+}
+
+var units = map[string]string{
+	"m": "minute", "min": "minute", "mins": "minute", "minute": "minute", "minutes": "minute",
+	"h": "hour", "hr": "hour", "hrs": "hour", "hour": "hour", "hours": "hour",
+	"d": "day", "day": "day", "days": "day",
+	"w": "week", "week": "week", "weeks": "week",
+	"month": "month", "months": "month",
+	"y": "year", "year": "year", "years": "year",
+}
+
+// parseAmount reads "in 3 days", "in an hour" or "+3d".
+func parseAmount(words []string) (int, string, error) {
+	var count, unit string
+	switch {
+	case words[0] == "in" && len(words) == 3:
+		count, unit = words[1], words[2]
+	case strings.HasPrefix(words[0], "+") && len(words) == 1:
+		i := strings.IndexFunc(words[0][1:], func(r rune) bool { return r < '0' || r > '9' }) + 1
+		if i == 0 {
+			return 0, "", fmt.Errorf("a unit is missing")
+		}
+		count, unit = words[0][1:i], words[0][i:]
+	default:
+		return 0, "", fmt.Errorf(`expected "in <number> <unit>"`)
+	}
+
+	n, err := strconv.Atoi(count)
+	if count == "a" || count == "an" {
+		n, err = 1, nil
+	}
+	if err != nil || n < 0 {
+		return 0, "", fmt.Errorf("%q is not a count", count)
+	}
+	if units[unit] == "" {
+		return 0, "", fmt.Errorf("unknown unit %q", unit)
+	}
+	return n, units[unit], nil
+}
+
+// parseDay reads the day words name, at midnight in now's location.
+func parseDay(words []string, now time.Time) (time.Time, bool) {
+	today := time.Date(now.Year(), now.Month(), now.Day(), 0, 0, 0, 0, now.Location())
+	phrase := strings.Join(words, " ")
+
+	switch phrase {
+	case "today", "tonight":
+		return today, true
+	case "tomorrow":
+		return today.AddDate(0, 0, 1), true
+	case "next week":
+		return today.AddDate(0, 0, 7), true
+	case "next month":
+		return today.AddDate(0, 1, 0), true
+	case "next year":
+		return today.AddDate(1, 0, 0), true
+	case "end of day", "end of the day", "eod":
+		return today, true
+	case "end of week", "end of the week", "eow":
+		// Weeks end on Sunday.
+		return today.AddDate(0, 0, (7-int(today.Weekday()))%7), true
+	case "end of month", "end of the month", "eom":
+		return time.Date(today.Year(), today.Month()+1, 0, 0, 0, 0, 0, today.Location()), true
+	case "end of year", "end of the year", "eoy":
+		return time.Date(today.Year(), time.December, 31, 0, 0, 0, 0, today.Location()), true
+	}
+
+	if t, err := time.ParseInLocation("2006-01-02", phrase, now.Location()); err == nil {
+		return t, true
+	}
+
+	switch {
+	case len(words) == 1:
+		if day, ok := weekday(words[0]); ok {
+			return nextWeekday(today, day, false), true
+		}
+	case len(words) == 2 && (words[0] == "next" || words[0] == "this"):
+		if day, ok := weekday(words[1]); ok {
+			return nextWeekday(today, day, words[0] == "this"), true
+		}
+	}
+
+	return monthDay(words, today)
+}
+
+func nextWeekday(today time.Time, day time.Weekday, todayCounts bool) time.Time {
+	days := (int(day) - int(today.Weekday()) + 7) % 7
+	if days == 0 && !todayCounts {
+		days = 7
+	}
+	return today.AddDate(0, 0, days)
+}
+
+// monthDay reads "nov 5", "5 november" or either with a year after it.
+// Without a year it is the next such day, today included.
+func monthDay(words []string, today time.Time) (time.Time, bool) {
+	if len(words) != 2 && len(words) != 3 {
+		return time.Time{}, false
+	}
+
+	month, ok := monthName(words[0])
+	dayWord := words[1]
+	if !ok {
+		month, ok = monthName(words[1])
+		dayWord = words[0]
+	}
+	if !ok {
+		return time.Time{}, false
+	}
+
+	day, err := strconv.Atoi(strings.TrimRight(dayWord, "stndrh"))
+	if err != nil || day < 1 || day > 31 {
+		return time.Time{}, false
+	}
+
+	year := today.Year()
+	if len(words) == 3 {
+		year, err = strconv.Atoi(words[2])
+		if err != nil {
+			return time.Time{}, false
+		}
+	}
+
+	t := time.Date(year, month, day, 0, 0, 0, 0, today.Location())
+	if t.Day() != day {
+		// time.Date normalizes nov 31 to dec 1.
+		return time.Time{}, false
+	}
+	if len(words) == 2 && t.Before(today) {
+		t = t.AddDate(1, 0, 0)
+	}
+	return t, true
+}
+
+func weekday(word string) (time.Weekday, bool) {
+	if len(word) < 3 {
+		return 0, false
+	}
+	for day := time.Sunday; day <= time.Saturday; day++ {
+		if strings.HasPrefix(strings.ToLower(day.String()), word) {
+			return day, true
+		}
+	}
+	return 0, false
+}
+
+func monthName(word string) (time.Month, bool) {
+	if len(word) < 3 {
+		return 0, false
+	}
+	for month := time.January; month <= time.December; month++ {
+		if strings.HasPrefix(strings.ToLower(month.String()), word) {
+			return month, true
+		}
+	}
+	return 0, false
+}
@@ -0,0 +1,114 @@
+package duedate
+
+import (
+	"testing"
+	"time"
+)
+
+func TestParse(t *testing.T) {
+	// A Wednesday afternoon, away from UTC so that days are read in loc.
+	loc := time.FixedZone("UTC+3", 3*60*60)
+	now := time.Date(2026, time.March, 11, 14, 30, 0, 0, loc)
+	day := func(month time.Month, d, hour, minute int) time.Time {
+		return time.Date(2026, month, d, hour, minute, 0, 0, loc)
+	}
+
+	for _, tc := range []struct {
+		text     string
+		want     time.Time
+		dateOnly bool
+	}{
+		{"today", day(time.March, 11, 23, 59), true},
+		{"tonight", day(time.March, 11, 23, 59), true},
+		{"eod", day(time.March, 11, 23, 59), true},
+		{"tomorrow", day(time.March, 12, 23, 59), true},
+		{"tomorrow 9am", day(time.March, 12, 9, 0), false},
+		{"Tomorrow, 9 AM", day(time.March, 12, 9, 0), false},
+		{"tomorrow at 9:30pm", day(time.March, 12, 21, 30), false},
+		{"tomorrow 21:00", day(time.March, 12, 21, 0), false},
+		{"tomorrow morning", day(time.March, 12, 9, 0), false},
+
+		// A time of day alone is the next time the clock shows it.
+		{"5pm", day(time.March, 11, 17, 0), false},
+		{"9am", day(time.March, 12, 9, 0), false},
+		{"14:30", day(time.March, 12, 14, 30), false},
+		{"12am", day(time.March, 12, 0, 0), false},
+		{"noon", day(time.March, 12, 12, 0), false},
+		{"evening", day(time.March, 11, 18, 0), false},
+
+		{"friday", day(time.March, 13, 23, 59), true},
+		{"fri", day(time.March, 13, 23, 59), true},
+		{"next friday", day(time.March, 13, 23, 59), true},
+		{"wednesday", day(time.March, 18, 23, 59), true},
+		{"this wednesday", day(time.March, 11, 23, 59), true},
+		{"monday 8:15am", day(time.March, 16, 8, 15), false},
+
+		{"in 3 days", day(time.March, 14, 23, 59), true},
+		{"+3d", day(time.March, 14, 23, 59), true},
+		{"in 2 weeks", day(time.March, 25, 23, 59), true},
+		{"+1w noon", day(time.March, 18, 12, 0), false},
+		{"in 30 minutes", day(time.March, 11, 15, 0), false},
+		{"in an hour", day(time.March, 11, 15, 30), false},
+		{"+2h", day(time.March, 11, 16, 30), false},
+		{"in 0 days", day(time.March, 11, 23, 59), true},
+		{"next week", day(time.March, 18, 23, 59), true},
+		{"next month", day(time.April, 11, 23, 59), true},
+		{"in 1 year", time.Date(2027, time.March, 11, 23, 59, 0, 0, loc), true},
+		{"end of week", day(time.March, 15, 23, 59), true},
+		{"end of month", day(time.March, 31, 23, 59), true},
+		{"end of year", day(time.December, 31, 23, 59), true},
+
+		{"2026-11-05", day(time.November, 5, 23, 59), true},
+		{"2026-11-05 21:00", day(time.November, 5, 21, 0), false},
+		{"nov 5", day(time.November, 5, 23, 59), true},
+		{"5th november", day(time.November, 5, 23, 59), true},
+		{"5 november 2027", time.Date(2027, time.November, 5, 23, 59, 0, 0, loc), true},
+		{"march 11", day(time.March, 11, 23, 59), true},
+		{"march 1", time.Date(2027, time.March, 1, 23, 59, 0, 0, loc), true},
+		{"feb 28 2028 6pm", time.Date(2028, time.February, 28, 18, 0, 0, 0, loc), false},
+
+		// RFC 3339 keeps its own offset.
+		{"2026-05-01T10:00:00Z", time.Date(2026, time.May, 1, 10, 0, 0, 0, time.UTC), false},
+	} {
+		got, err := Parse(tc.text, now)
+		if err != nil {
+			t.Errorf("Parse(%q): %v", tc.text, err)
+			continue
+		}
+		if !got.Time.Equal(tc.want) || got.DateOnly != tc.dateOnly {
+			t.Errorf("Parse(%q) = %v, date only %v; want %v, date only %v", tc.text, got.Time, got.DateOnly, tc.want, tc.dateOnly)
+		}
+		if tc.dateOnly && got.Time.Location() != loc {
+			t.Errorf("Parse(%q) is in %v, want the location of now", tc.text, got.Time.Location())
+		}
+	}
+}
+
+func TestParseInvalid(t *testing.T) {
+	now := time.Date(2026, time.March, 11, 14, 30, 0, 0, time.UTC)
+	for _, text := range []string{
+		"",
+		"   ",
+		"someday",
+		"13pm",
+		"0am",
+		"25:00",
+		"9:75",
+		"tomorrow 13pm",
+		"in 3 fortnights",
+		"in -3 days",
+		"in three days",
+		"in 2 hours at 5pm",
+		"+d",
+		"+3",
+		"nov 31",
+		"2026-02-30",
+		"next",
+		"fr",
+	} {
+		got, err := Parse(text, now)
+		if err == nil {
+			t.Errorf("Parse(%q) = %v, want an error", text, got.Time)
+		}
+	}
+}
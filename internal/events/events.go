@@ -0,0 +1,194 @@
+// Package events lets handlers announce changes to todos and categories to
+// whoever is interested, such as webhook delivery and the /events stream.
+package events
+
+import (
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/furkankorkmaz309/todo-api/internal/models"
+)
+
+const (
+	TodoCreated    = "todo.created"
+	TodoUpdated    = "todo.updated"
+	TodoCompleted  = "todo.completed"
+	TodoArchived   = "todo.archived"
+	TodoUnarchived = "todo.unarchived"
+	TodoDeleted    = "todo.deleted"
+
+	CategoryCreated = "category.created"
+	CategoryUpdated = "category.updated"
+	CategoryDeleted = "category.deleted"
+)
+
+// Types lists every event type, in the order above.
+var Types = []string{
+	TodoCreated, TodoUpdated, TodoCompleted, TodoArchived, TodoUnarchived, TodoDeleted,
+	CategoryCreated, CategoryUpdated, CategoryDeleted,
+}
+
+type Event struct {
+	ID   int64       `json:"id"`
+	Type string      `json:"type"`
+	Time time.Time   `json:"time"`
+	Data interface{} `json:"data"`
+}
+
+// Deleted is the data of *.deleted events.
+type Deleted struct {
+	ID int `json:"id"`
+}
+
+// Bus hands every published event to all subscribers, synchronously, and
+// keeps the most recent ones so listeners can resume after a reconnect.
+// Listeners get events in publish order; handlers are called outside the
+// bus's lock, so events published at the same time may reach them in either
+// order. A nil *Bus drops events, so callers need not check.
+type Bus struct {
+	mu        sync.Mutex
+	nextID    int64
+	handlers  []func(Event)
+	log       []Event
+	logSize   int
+	listeners map[chan Event]struct{}
+}
+
+// NewBus returns a bus that remembers the last logSize events.
+func NewBus(logSize int) *Bus {
+	return &Bus{
+		logSize:   logSize,
+		listeners: make(map[chan Event]struct{}),
+	}
+}
+
+func (b *Bus) Subscribe(handler func(Event)) {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	b.handlers = append(b.handlers, handler)
+}
+
+// Publish must be called after the change is committed, never inside a
+// transaction, since subscribers may write to the database themselves.
+func (b *Bus) Publish(eventType string, data interface{}) {
+	if b == nil {
+		return
+	}
+
+	b.mu.Lock()
+	b.nextID++
+	e := Event{ID: b.nextID, Type: eventType, Time: time.Now(), Data: data}
+
+	if b.logSize > 0 {
+		if len(b.log) == b.logSize {
+			b.log = append(b.log[:0], b.log[1:]...)
+		}
+		b.log = append(b.log, e)
+	}
+
+	// Listeners that fall behind are dropped rather than slowing down
+	// the request that published; they can resume from the log.
+	for ch := range b.listeners {
+		select {
+		case ch <- e:
+		default:
+			delete(b.listeners, ch)
+			close(ch)
+		}
+	}
+
+	// Handlers may be slow (webhooks write to the database), so they run
+	// after the lock is released and hold up no other publisher.
+	handlers := b.handlers
+	b.mu.Unlock()
+
+	for _, handler := range handlers {
+		handler(e)
+	}
+}
+
+// Listen returns the logged events after lastID and a channel carrying the
+// events published from now on. complete is false when events after lastID
+// have already left the log, or lastID is unknown, e.g. from before a
+// restart. The channel is closed when the listener falls behind or cancel
+// is called.
+func (b *Bus) Listen(lastID int64, buffer int) (backlog []Event, complete bool, ch <-chan Event, cancel func()) {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+
+	complete = true
+	if lastID > b.nextID {
+		complete = false
+	} else if lastID < b.nextID {
+		oldest := b.nextID - int64(len(b.log)) + 1
+		if lastID+1 < oldest {
+			complete = false
+		}
+		for _, e := range b.log {
+			if e.ID > lastID {
+				backlog = append(backlog, e)
+			}
+		}
+	}
+
+	c := make(chan Event, buffer)
+	b.listeners[c] = struct{}{}
+
+	cancel = func() {
+		b.mu.Lock()
+		defer b.mu.Unlock()
+		if _, ok := b.listeners[c]; ok {
+			delete(b.listeners, c)
+			close(c)
+		}
+	}
+	return backlog, complete, c, cancel
+}
+
+// LastID returns the ID of the latest published event.
+func (b *Bus) LastID() int64 {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	return b.nextID
+}
+
+// Match reports whether filter selects eventType. The filter holds event
+// types, "todo.*"-style prefixes or "*" for all.
+func Match(filter []string, eventType string) bool {
+	for _, f := range filter {
+		if f == "*" || f == eventType {
+			return true
+		}
+		if strings.HasSuffix(f, ".*") && strings.HasPrefix(eventType, strings.TrimSuffix(f, "*")) {
+			return true
+		}
+	}
+	return false
+}
+
+// ValidFilter reports whether filter is an event type, a "todo.*" or
+// "category.*" prefix, or "*".
+func ValidFilter(filter string) bool {
+	if filter == "*" || filter == "todo.*" || filter == "category.*" {
+		return true
+	}
+	for _, t := range Types {
+		if t == filter {
+			return true
+		}
+	}
+	return false
+}
+
+// CategoryOf returns the category a todo or category event belongs to.
+// Deletions only carry an ID, so ok is false for them.
+func CategoryOf(e Event) (id int, ok bool) {
+	switch data := e.Data.(type) {
+	case models.Todo:
+		return data.CategoryID, true
+	case models.Category:
+		return data.ID, true
+	}
+	return 0, false
+}
@@ -0,0 +1,78 @@
+package events
+
+import (
+	"testing"
+	"time"
+)
+
+// A handler that takes long, or uses the bus itself, must not block other
+// publishers and listeners.
+func TestHandlersRunOutsideLock(t *testing.T) {
+	b := NewBus(10)
+	entered := make(chan struct{})
+	release := make(chan struct{})
+	b.Subscribe(func(e Event) {
+		if e.Type == TodoCreated {
+			close(entered)
+			<-release
+		}
+	})
+	_, _, ch, cancel := b.Listen(0, 10)
+	defer cancel()
+
+	done := make(chan struct{})
+	go func() {
+		b.Publish(TodoCreated, nil)
+		close(done)
+	}()
+	<-entered
+
+	published := make(chan struct{})
+	go func() {
+		b.Publish(TodoUpdated, nil)
+		close(published)
+	}()
+	select {
+	case <-published:
+	case <-time.After(time.Second):
+		t.Fatal("Publish blocked while another event's handler was running")
+	}
+	for _, want := range []string{TodoCreated, TodoUpdated} {
+		select {
+		case e := <-ch:
+			if e.Type != want {
+				t.Errorf("listener got %s, want %s", e.Type, want)
+			}
+		case <-time.After(time.Second):
+			t.Fatalf("listener did not get %s", want)
+		}
+	}
+
+	close(release)
+	<-done
+}
+
+func TestHandlerCanPublish(t *testing.T) {
+	b := NewBus(10)
+	var got []string
+	b.Subscribe(func(e Event) {
+		got = append(got, e.Type)
+		if e.Type == TodoCompleted {
+			b.Publish(TodoArchived, nil)
+		}
+	})
+
+	finished := make(chan struct{})
+	go func() {
+		b.Publish(TodoCompleted, nil)
+		close(finished)
+	}()
+	select {
+	case <-finished:
+	case <-time.After(time.Second):
+		t.Fatal("a handler publishing deadlocked the bus")
+	}
+	if len(got) != 2 || got[1] != TodoArchived {
+		t.Errorf("handler got %v, want [%s %s]", got, TodoCompleted, TodoArchived)
+	}
+}
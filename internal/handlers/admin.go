@@ -0,0 +1,57 @@
+package handlers
+
+import (
+	"crypto/subtle"
+	"net/http"
+	"strings"
+
+	"github.com/furkankorkmaz309/todo-api/internal/app"
+)
+
+// RequireAdmin lets through requests that carry app.AdminToken as a bearer
+// token. Without a token configured the admin endpoints are disabled.
+func RequireAdmin(app *app.App) func(next http.Handler) http.Handler {
+	return func(next http.Handler) http.Handler {
+		return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			if app.AdminToken == "" {
+				respondError(w, app.ErrorLog, http.StatusForbidden, "Admin endpoints are disabled", nil)
+				return
+			}
+
+			token, ok := strings.CutPrefix(r.Header.Get("Authorization"), "Bearer ")
+			if !ok || subtle.ConstantTimeCompare([]byte(token), []byte(app.AdminToken)) != 1 {
+				w.Header().Set("WWW-Authenticate", "Bearer")
+				respondError(w, app.ErrorLog, http.StatusUnauthorized, "Invalid admin token", nil)
+				return
+			}
+			next.ServeHTTP(w, r)
+		})
+	}
+}
+
+func GetBackups(app *app.App) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		backups, err := app.Backups.List()
+		if err != nil {
+			respondError(w, app.ErrorLog, http.StatusInternalServerError, "Failed to list backups", err)
+			return
+		}
+
+		respondJSON(w, http.StatusOK, backups, "Backups listed successfully.")
+	}
+}
+
+// CreateBackup backs the database up on demand, the same way the scheduled
+// backups are taken, so it counts towards their retention.
+func CreateBackup(app *app.App) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		backup, err := app.Backups.Create()
+		if err != nil {
+			respondError(w, app.ErrorLog, http.StatusInternalServerError, "Backup failed", err)
+			return
+		}
+
+		app.InfoLog.Printf("Backed up the database to %s", backup.Name)
+		respondJSON(w, http.StatusCreated, backup, "Backup created successfully.")
+	}
+}
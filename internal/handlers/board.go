@@ -0,0 +1,208 @@
+package handlers
+
+import (
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"strconv"
+
+	"github.com/furkankorkmaz309/todo-api/internal/app"
+	"github.com/furkankorkmaz309/todo-api/internal/models"
+	"github.com/furkankorkmaz309/todo-api/internal/store"
+	"github.com/go-chi/chi"
+)
+
+func GetBoards(app *app.App) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		boards, err := store.ListBoards(app.DB)
+		if err != nil {
+			respondAPIError(w, app.ErrorLog, err)
+			return
+		}
+		respondJSON(w, http.StatusOK, boards, "Boards listed successfully.")
+	}
+}
+
+// GetBoard returns a board with the todos of each column in order.
+func GetBoard(app *app.App) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		id, err := strconv.Atoi(chi.URLParam(r, "id"))
+		if err != nil {
+			err = fmt.Errorf("an error occurred while converting string to integer: %v", err)
+			respondError(w, app.ErrorLog, http.StatusBadRequest, "Invalid board ID", err)
+			return
+		}
+
+		board, err := store.SelectBoard(app.DB, id)
+		if err == nil {
+			err = store.BoardTodos(app.DB, &board)
+		}
+		if err != nil {
+			respondAPIError(w, app.ErrorLog, err)
+			return
+		}
+		respondJSON(w, http.StatusOK, board, "Board fetched successfully.")
+	}
+}
+
+func AddBoard(app *app.App) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		var board models.Board
+		err := json.NewDecoder(r.Body).Decode(&board)
+		if err != nil {
+			respondError(w, app.ErrorLog, http.StatusBadRequest, "Invalid JSON body", err)
+			return
+		}
+
+		tx, err := app.DB.Begin()
+		if err != nil {
+			respondError(w, app.ErrorLog, http.StatusInternalServerError, "Database error", err)
+			return
+		}
+		defer tx.Rollback()
+
+		err = store.InsertBoard(tx, &board)
+		if err != nil {
+			respondAPIError(w, app.ErrorLog, err)
+			return
+		}
+		err = tx.Commit()
+		if err != nil {
+			respondError(w, app.ErrorLog, http.StatusInternalServerError, "Database error", err)
+			return
+		}
+		respondJSON(w, http.StatusCreated, board, "Board created successfully.")
+	}
+}
+
+// PatchBoard renames a board or replaces its columns. What the columns
+// stand for cannot change.
+func PatchBoard(app *app.App) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		id, err := strconv.Atoi(chi.URLParam(r, "id"))
+		if err != nil {
+			err = fmt.Errorf("an error occurred while converting string to integer: %v", err)
+			respondError(w, app.ErrorLog, http.StatusBadRequest, "Invalid board ID", err)
+			return
+		}
+
+		var input struct {
+			Name    *string               `json:"name"`
+			Columns *[]models.BoardColumn `json:"columns"`
+		}
+		err = json.NewDecoder(r.Body).Decode(&input)
+		if err != nil {
+			respondError(w, app.ErrorLog, http.StatusBadRequest, "Invalid JSON body", err)
+			return
+		}
+
+		tx, err := app.DB.Begin()
+		if err != nil {
+			respondError(w, app.ErrorLog, http.StatusInternalServerError, "Database error", err)
+			return
+		}
+		defer tx.Rollback()
+
+		board, err := store.SelectBoard(tx, id)
+		if err != nil {
+			respondAPIError(w, app.ErrorLog, err)
+			return
+		}
+		if input.Name != nil {
+			board.Name = *input.Name
+		}
+		if input.Columns != nil {
+			board.Columns = *input.Columns
+		}
+
+		err = store.UpdateBoard(tx, &board)
+		if err != nil {
+			respondAPIError(w, app.ErrorLog, err)
+			return
+		}
+		err = tx.Commit()
+		if err != nil {
+			respondError(w, app.ErrorLog, http.StatusInternalServerError, "Database error", err)
+			return
+		}
+		respondJSON(w, http.StatusOK, board, "Board updated successfully.")
+	}
+}
+
+func DeleteBoard(app *app.App) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		id, err := strconv.Atoi(chi.URLParam(r, "id"))
+		if err != nil {
+			err = fmt.Errorf("an error occurred while converting string to integer: %v", err)
+			respondError(w, app.ErrorLog, http.StatusBadRequest, "Invalid board ID", err)
+			return
+		}
+
+		err = store.DeleteBoard(app.DB, id)
+		if err != nil {
+			respondAPIError(w, app.ErrorLog, err)
+			return
+		}
+		respondSuccess(w, http.StatusOK, fmt.Sprintf("Board with ID %d deleted.", id))
+	}
+}
+
+// MoveBoardTodo moves a todo to a column of the board and a place in it,
+// in one transaction, and returns the board as it is afterwards.
+func MoveBoardTodo(app *app.App) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		id, err := strconv.Atoi(chi.URLParam(r, "id"))
+		if err != nil {
+			err = fmt.Errorf("an error occurred while converting string to integer: %v", err)
+			respondError(w, app.ErrorLog, http.StatusBadRequest, "Invalid board ID", err)
+			return
+		}
+
+		var input struct {
+			TodoID   int `json:"todo_id"`
+			ColumnID int `json:"column_id"`
+			AfterID  int `json:"after_id"`
+		}
+		err = json.NewDecoder(r.Body).Decode(&input)
+		if err != nil {
+			respondError(w, app.ErrorLog, http.StatusBadRequest, "Invalid JSON body", err)
+			return
+		}
+
+		tx, err := app.DB.Begin()
+		if err != nil {
+			respondError(w, app.ErrorLog, http.StatusInternalServerError, "Database error", err)
+			return
+		}
+		defer tx.Rollback()
+
+		board, err := store.SelectBoard(tx, id)
+		if err != nil {
+			respondAPIError(w, app.ErrorLog, err)
+			return
+		}
+		changed, err := store.MoveTodo(tx, board, input.TodoID, input.ColumnID, input.AfterID)
+		if err == nil {
+			err = store.BoardTodos(tx, &board)
+		}
+		if err != nil {
+			respondAPIError(w, app.ErrorLog, err)
+			return
+		}
+		err = tx.Commit()
+		if err != nil {
+			respondError(w, app.ErrorLog, http.StatusInternalServerError, "Database error", err)
+			return
+		}
+		if changed != "" {
+			todo, err := store.SelectTodo(app.DB, input.TodoID)
+			if err != nil {
+				app.ErrorLog.Printf("publishing move of todo %d: %v", input.TodoID, err)
+			} else {
+				PublishTodoUpdate(app, todo, changed)
+			}
+		}
+
+		respondJSON(w, http.StatusOK, board, fmt.Sprintf("Todo with ID %d moved.", input.TodoID))
+	}
+}
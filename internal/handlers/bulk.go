@@ -0,0 +1,201 @@
+package handlers
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"log"
+	"net/http"
+	"time"
+
+	"github.com/furkankorkmaz309/todo-api/internal/app"
+	"github.com/furkankorkmaz309/todo-api/internal/events"
+	"github.com/furkankorkmaz309/todo-api/internal/models"
+	"github.com/furkankorkmaz309/todo-api/internal/store"
+)
+
+const maxBulkOperations = 1000
+
+type bulkRequest struct {
+	// Mode is "atomic" (default), where any failure rolls back the whole
+	// batch, or "best_effort", where failed operations are skipped.
+	Mode       string          `json:"mode"`
+	Operations []bulkOperation `json:"operations"`
+}
+
+type bulkOperation struct {
+	Op         string   `json:"op"`
+	ID         int      `json:"id"`
+	CategoryID int      `json:"category_id"`
+	Todo       todoBody `json:"todo"`
+}
+
+type bulkResult struct {
+	Index   int          `json:"index"`
+	Op      string       `json:"op"`
+	ID      int          `json:"id,omitempty"`
+	Success bool         `json:"success"`
+	Error   string       `json:"error,omitempty"`
+	Todo    *models.Todo `json:"todo,omitempty"`
+
+	changed string
+}
+
+func BulkTodos(app *app.App) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		loc, err := requestLocation(app, r)
+		if err != nil {
+			respondAPIError(w, app.ErrorLog, err)
+			return
+		}
+
+		var input bulkRequest
+		err = json.NewDecoder(r.Body).Decode(&input)
+		if err != nil {
+			respondError(w, app.ErrorLog, http.StatusBadRequest, "Invalid JSON body", err)
+			return
+		}
+
+		if input.Mode == "" {
+			input.Mode = "atomic"
+		}
+		if input.Mode != "atomic" && input.Mode != "best_effort" {
+			respondError(w, app.ErrorLog, http.StatusBadRequest, "Mode must be atomic or best_effort", nil)
+			return
+		}
+		if len(input.Operations) == 0 {
+			respondError(w, app.ErrorLog, http.StatusBadRequest, "No operations provided", nil)
+			return
+		}
+		if len(input.Operations) > maxBulkOperations {
+			respondError(w, app.ErrorLog, http.StatusBadRequest, fmt.Sprintf("At most %d operations are allowed", maxBulkOperations), nil)
+			return
+		}
+
+		tx, err := app.DB.Begin()
+		if err != nil {
+			respondError(w, app.ErrorLog, http.StatusInternalServerError, "Database error", err)
+			return
+		}
+		defer tx.Rollback()
+
+		results := make([]bulkResult, 0, len(input.Operations))
+		failed := 0
+
+		for i, op := range input.Operations {
+			// Each operation gets a savepoint so a failure in best_effort
+			// mode only undoes that operation's own writes.
+			_, err = tx.Exec(`SAVEPOINT bulk_op`)
+			if err != nil {
+				respondError(w, app.ErrorLog, http.StatusInternalServerError, "Database error", err)
+				return
+			}
+
+			result := runBulkOperation(tx, app.ErrorLog, loc, op)
+			result.Index = i
+			results = append(results, result)
+
+			if result.Success {
+				_, err = tx.Exec(`RELEASE bulk_op`)
+			} else {
+				failed++
+				if input.Mode == "atomic" {
+					respondErrorWithData(w, app.ErrorLog, http.StatusBadRequest, fmt.Sprintf("Operation %d failed, no changes applied", i), results, nil)
+					return
+				}
+				_, err = tx.Exec(`ROLLBACK TO bulk_op; RELEASE bulk_op`)
+			}
+			if err != nil {
+				respondError(w, app.ErrorLog, http.StatusInternalServerError, "Database error", err)
+				return
+			}
+		}
+
+		err = tx.Commit()
+		if err != nil {
+			respondError(w, app.ErrorLog, http.StatusInternalServerError, "Database error", err)
+			return
+		}
+		for _, result := range results {
+			if result.Success {
+				publishBulkResult(app, result)
+			}
+		}
+
+		respondJSON(w, http.StatusOK, results, fmt.Sprintf("%d operations succeeded, %d failed.", len(results)-failed, failed))
+	}
+}
+
+func runBulkOperation(q store.Querier, logger *log.Logger, loc *time.Location, op bulkOperation) bulkResult {
+	result := bulkResult{Op: op.Op, ID: op.ID}
+
+	var err error
+	switch op.Op {
+	case "create":
+		var todo models.Todo
+		todo, err = op.Todo.resolve(loc)
+		if err == nil {
+			err = store.InsertTodo(q, &todo)
+		}
+		if err == nil {
+			result.ID = todo.ID
+			result.Todo = &todo
+		}
+	case "update":
+		var todo models.Todo
+		todo, err = op.Todo.resolveUpdate(q, op.ID, loc)
+		if err == nil {
+			todo, result.changed, err = store.UpdateTodo(q, op.ID, todo)
+		}
+		if err == nil {
+			result.Todo = &todo
+		}
+	case "delete":
+		err = store.DeleteTodo(q, op.ID)
+	case "complete":
+		var todo models.Todo
+		todo, err = store.SetTodoStatus(q, op.ID, store.StatusDone)
+		if err == nil {
+			result.Todo = &todo
+		}
+	case "move_category":
+		err = store.CheckCategory(q, op.CategoryID)
+		if err == nil {
+			err = store.ExecTodo(q, op.ID, `UPDATE todo SET category_id = ? WHERE id = ?`, op.CategoryID)
+		}
+		if err == nil {
+			var todo models.Todo
+			todo, err = store.SelectTodo(q, op.ID)
+			result.Todo = &todo
+		}
+	default:
+		err = fmt.Errorf("Unknown operation %q", op.Op)
+	}
+
+	if err != nil {
+		var apiErr *store.Error
+		if errors.As(err, &apiErr) && apiErr.Err != nil {
+			logger.Printf("[ERROR %d] bulk %s: %s: %v", apiErr.Status, op.Op, apiErr.Msg, apiErr.Err)
+		}
+		result.Error = err.Error()
+		return result
+	}
+	result.Success = true
+	return result
+}
+
+func publishBulkResult(app *app.App, result bulkResult) {
+	switch result.Op {
+	case "create":
+		app.Events.Publish(events.TodoCreated, *result.Todo)
+	case "update":
+		PublishTodoUpdate(app, *result.Todo, result.changed)
+	case "delete":
+		app.Events.Publish(events.TodoDeleted, events.Deleted{ID: result.ID})
+	case "complete":
+		app.Events.Publish(events.TodoUpdated, *result.Todo)
+		app.Events.Publish(events.TodoCompleted, *result.Todo)
+	case "move_category":
+		app.Events.Publish(events.TodoUpdated, *result.Todo)
+	}
+}
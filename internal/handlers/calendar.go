@@ -0,0 +1,280 @@
+package handlers
+
+import (
+	"crypto/rand"
+	"crypto/sha256"
+	"database/sql"
+	"encoding/hex"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/furkankorkmaz309/todo-api/internal/app"
+	"github.com/furkankorkmaz309/todo-api/internal/ical"
+	"github.com/furkankorkmaz309/todo-api/internal/models"
+	"github.com/furkankorkmaz309/todo-api/internal/store"
+	"github.com/go-chi/chi"
+)
+
+func GetCalendarTokens(app *app.App) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		query := `SELECT id, name, COALESCE(category_id, 0), created_at FROM calendar_token`
+		rows, err := app.DB.Query(query)
+		if err != nil {
+			respondError(w, app.ErrorLog, http.StatusInternalServerError, "Database error", err)
+			return
+		}
+		defer rows.Close()
+
+		var tokens []models.CalendarToken
+		for rows.Next() {
+			var token models.CalendarToken
+			err = rows.Scan(&token.ID, &token.Name, &token.CategoryID, &token.CreatedAt)
+			if err != nil {
+				respondError(w, app.ErrorLog, http.StatusInternalServerError, "Row scan error", err)
+				return
+			}
+			tokens = append(tokens, token)
+		}
+
+		respondJSON(w, http.StatusOK, tokens, "Calendar tokens listed successfully.")
+	}
+}
+
+// CreateCalendarToken issues a secret feed URL. The token itself is only
+// returned here; the database keeps its hash.
+func CreateCalendarToken(app *app.App) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		var input models.CalendarToken
+		err := json.NewDecoder(r.Body).Decode(&input)
+		if err != nil {
+			respondError(w, app.ErrorLog, http.StatusBadRequest, "Invalid JSON body", err)
+			return
+		}
+
+		if strings.TrimSpace(input.Name) == "" {
+			respondError(w, app.ErrorLog, http.StatusBadRequest, "Name field is blank", nil)
+			return
+		}
+		if len(input.Name) > 30 {
+			respondError(w, app.ErrorLog, http.StatusBadRequest, "Name field is too long", nil)
+			return
+		}
+		if input.CategoryID != 0 {
+			err = store.CheckCategory(app.DB, input.CategoryID)
+			if err != nil {
+				respondAPIError(w, app.ErrorLog, err)
+				return
+			}
+		}
+
+		secret := make([]byte, 32)
+		_, err = rand.Read(secret)
+		if err != nil {
+			respondError(w, app.ErrorLog, http.StatusInternalServerError, "Could not generate token", err)
+			return
+		}
+		input.Token = hex.EncodeToString(secret)
+		input.CreatedAt = time.Now().UTC()
+
+		query := `INSERT INTO calendar_token (token_hash, name, category_id, created_at) VALUES (?, ?, NULLIF(?, 0), ?)`
+		result, err := app.DB.Exec(query, hashToken(input.Token), input.Name, input.CategoryID, input.CreatedAt)
+		if err != nil {
+			respondError(w, app.ErrorLog, http.StatusInternalServerError, "Database error", err)
+			return
+		}
+
+		id, err := result.LastInsertId()
+		if err != nil {
+			respondError(w, app.ErrorLog, http.StatusInternalServerError, "Failed to retrieve inserted ID", err)
+			return
+		}
+		input.ID = int(id)
+
+		scheme := "http"
+		if r.TLS != nil || r.Header.Get("X-Forwarded-Proto") == "https" {
+			scheme = "https"
+		}
+		input.URL = fmt.Sprintf("%s://%s/calendar.ics?token=%s", scheme, r.Host, input.Token)
+
+		respondJSON(w, http.StatusCreated, input, "Calendar token created successfully.")
+	}
+}
+
+func DeleteCalendarToken(app *app.App) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		idStr := chi.URLParam(r, "id")
+		id, err := strconv.Atoi(idStr)
+		if err != nil {
+			err = fmt.Errorf("an error occurred while converting string to integer: %v", err)
+			respondError(w, app.ErrorLog, http.StatusBadRequest, "Invalid calendar token ID", err)
+			return
+		}
+
+		result, err := app.DB.Exec(`DELETE FROM calendar_token WHERE id = ?`, id)
+		if err != nil {
+			respondError(w, app.ErrorLog, http.StatusInternalServerError, "Database error", err)
+			return
+		}
+
+		rowsAffected, err := result.RowsAffected()
+		if err != nil {
+			respondError(w, app.ErrorLog, http.StatusInternalServerError, "Could not retrieve delete result", err)
+			return
+		}
+		if rowsAffected == 0 {
+			respondError(w, app.ErrorLog, http.StatusNotFound, fmt.Sprintf("No calendar token with ID %d", id), nil)
+			return
+		}
+
+		respondSuccess(w, http.StatusOK, fmt.Sprintf("Calendar token with ID %d revoked.", id))
+	}
+}
+
+// CalendarFeed serves unarchived todos as an iCalendar file. Calendar
+// clients cannot send headers, so the token comes in the query string.
+// type=vevent emits events instead of VTODOs for clients without task
+// support; the usual list filters apply.
+func CalendarFeed(app *app.App) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		token := r.URL.Query().Get("token")
+		if token == "" {
+			respondError(w, app.ErrorLog, http.StatusUnauthorized, "Missing calendar token", nil)
+			return
+		}
+
+		var name string
+		var categoryID int
+		query := `SELECT name, COALESCE(category_id, 0) FROM calendar_token WHERE token_hash = ?`
+		err := app.DB.QueryRow(query, hashToken(token)).Scan(&name, &categoryID)
+		if err == sql.ErrNoRows {
+			respondError(w, app.ErrorLog, http.StatusUnauthorized, "Invalid calendar token", nil)
+			return
+		}
+		if err != nil {
+			respondError(w, app.ErrorLog, http.StatusInternalServerError, "Database error", err)
+			return
+		}
+
+		component := "VTODO"
+		switch r.URL.Query().Get("type") {
+		case "", "vtodo":
+		case "vevent":
+			component = "VEVENT"
+		default:
+			respondError(w, app.ErrorLog, http.StatusBadRequest, "Type must be vtodo or vevent", nil)
+			return
+		}
+
+		loc, err := requestLocation(app, r)
+		if err != nil {
+			respondAPIError(w, app.ErrorLog, err)
+			return
+		}
+		filter, err := parseTodoFilter(app, r)
+		if err != nil {
+			respondError(w, app.ErrorLog, http.StatusBadRequest, "Invalid filter: "+err.Error(), nil)
+			return
+		}
+		if categoryID != 0 {
+			filter.Add("category_id = ?", categoryID)
+		}
+
+		query = `SELECT ` + store.TodoColumns + `, COALESCE((SELECT name FROM category WHERE category.id = todo.category_id), '') FROM todo WHERE archived = 0` + filter.And() + ` ORDER BY due_date`
+		rows, err := app.DB.Query(query, filter.Args...)
+		if err != nil {
+			respondError(w, app.ErrorLog, http.StatusInternalServerError, "Database error", err)
+			return
+		}
+		defer rows.Close()
+
+		var todos []exportTodo
+		for rows.Next() {
+			var todo exportTodo
+			err = store.ScanTodo(rows, &todo.Todo, &todo.Category)
+			if err != nil {
+				respondError(w, app.ErrorLog, http.StatusInternalServerError, "Row could not read", err)
+				return
+			}
+			todos = append(todos, todo)
+		}
+
+		w.Header().Set("Content-Type", "text/calendar; charset=utf-8")
+		w.Header().Set("Content-Disposition", `inline; filename="todos.ics"`)
+
+		now := time.Now()
+		cal := ical.NewWriter(w)
+		cal.Begin("VCALENDAR")
+		cal.Raw("VERSION", "2.0")
+		cal.Raw("PRODID", "-//todo-api//todo-api//EN")
+		cal.Raw("CALSCALE", "GREGORIAN")
+		cal.Text("X-WR-CALNAME", "Todos ("+name+")")
+
+		for _, todo := range todos {
+			if todo.DueDate.IsZero() {
+				continue
+			}
+
+			cal.Begin(component)
+			cal.Raw("UID", fmt.Sprintf("todo-%d@todo-api", todo.ID))
+			cal.Time("DTSTAMP", now)
+			cal.Time("CREATED", todo.CreatedAt)
+			cal.Text("SUMMARY", todo.Title)
+			cal.Text("DESCRIPTION", todo.Content)
+			if todo.Category != "" {
+				cal.Text("CATEGORIES", todo.Category)
+			}
+			cal.Raw("PRIORITY", strconv.Itoa(icalPriority(todo.Priority)))
+
+			// All-day todos are due on a day, wherever the calendar is.
+			due := cal.Time
+			if todo.AllDay {
+				due = func(name string, t time.Time) { cal.Date(name, t.In(loc)) }
+			}
+
+			if component == "VTODO" {
+				due("DUE", todo.DueDate)
+				switch todo.Status {
+				case store.StatusDone:
+					cal.Raw("STATUS", "COMPLETED")
+					if todo.CompletedAt != nil {
+						cal.Time("COMPLETED", *todo.CompletedAt)
+					}
+				case store.StatusCancelled:
+					cal.Raw("STATUS", "CANCELLED")
+				case store.StatusInProgress:
+					cal.Raw("STATUS", "IN-PROCESS")
+				default:
+					cal.Raw("STATUS", "NEEDS-ACTION")
+				}
+			} else {
+				due("DTSTART", todo.DueDate)
+				cal.Raw("TRANSP", "TRANSPARENT")
+			}
+			cal.End(component)
+		}
+
+		cal.End("VCALENDAR")
+		err = cal.Flush()
+		if err != nil {
+			app.ErrorLog.Printf("calendar feed for %q stopped: %v", name, err)
+		}
+	}
+}
+
+// icalPriority maps 5 (highest) .. 1 (lowest) onto iCalendar's 1 (highest)
+// .. 9 (lowest) scale.
+func icalPriority(priority int) int {
+	if priority < 1 || priority > 5 {
+		return 0
+	}
+	return 11 - 2*priority
+}
+
+func hashToken(token string) string {
+	sum := sha256.Sum256([]byte(token))
+	return hex.EncodeToString(sum[:])
+}
@@ -5,10 +5,11 @@ import (
 	"fmt"
 	"net/http"
 	"strconv"
-	"strings"
 
 	"github.com/furkankorkmaz309/todo-api/internal/app"
+	"github.com/furkankorkmaz309/todo-api/internal/events"
 	"github.com/furkankorkmaz309/todo-api/internal/models"
+	"github.com/furkankorkmaz309/todo-api/internal/store"
 	"github.com/go-chi/chi"
 )
 
@@ -49,34 +50,12 @@ func AddCategory(app *app.App) http.HandlerFunc {
 			return
 		}
 
-		if strings.TrimSpace(input.Name) == "" {
-			respondError(w, app.ErrorLog, http.StatusBadRequest, "Name field is blank", fmt.Errorf("blank name"))
-			return
-		}
-
-		if len(input.Name) > 30 {
-			respondError(w, app.ErrorLog, http.StatusBadRequest, "Name field is too long", nil)
-			return
-		}
-
-		if len(input.Description) > 100 {
-			respondError(w, app.ErrorLog, http.StatusBadRequest, "Description field is too long", nil)
-			return
-		}
-
-		query := `INSERT INTO category (name, description) VALUES (?,?)`
-		result, err := app.DB.Exec(query, input.Name, input.Description)
-		if err != nil {
-			respondError(w, app.ErrorLog, http.StatusInternalServerError, "Database error", err)
-			return
-		}
-
-		id, err := result.LastInsertId()
+		err = store.InsertCategory(app.DB, &input)
 		if err != nil {
-			respondError(w, app.ErrorLog, http.StatusInternalServerError, "Failed to retrieve inserted ID", err)
+			respondAPIError(w, app.ErrorLog, err)
 			return
 		}
-		input.ID = int(id)
+		app.Events.Publish(events.CategoryCreated, input)
 
 		respondJSON(w, http.StatusCreated, input, "Category created successfully!")
 	}
@@ -92,16 +71,6 @@ func PatchCategory(app *app.App) http.HandlerFunc {
 			return
 		}
 
-		query := `SELECT * FROM category WHERE id = ?`
-		row := app.DB.QueryRow(query, id)
-
-		var oldCategory models.Category
-		err = row.Scan(&oldCategory.ID, &oldCategory.Name, &oldCategory.Description)
-		if err != nil {
-			respondError(w, app.ErrorLog, http.StatusNotFound, "Category not found", err)
-			return
-		}
-
 		var newCategory models.Category
 		err = json.NewDecoder(r.Body).Decode(&newCategory)
 		if err != nil {
@@ -109,54 +78,19 @@ func PatchCategory(app *app.App) http.HandlerFunc {
 			return
 		}
 
-		responseString := "name, description updated!"
-
-		if len(newCategory.Name) > 30 {
-			respondError(w, app.ErrorLog, http.StatusBadRequest, "Name is too long", nil)
-			return
-		}
-		if len(newCategory.Description) > 100 {
-			respondError(w, app.ErrorLog, http.StatusBadRequest, "Description is too long", nil)
-			return
-		}
-		if strings.TrimSpace(newCategory.Name) == "" {
-			newCategory.Name = oldCategory.Name
-			responseString = strings.ReplaceAll(responseString, "name, ", "")
-		}
-		if strings.TrimSpace(newCategory.Description) == "" {
-			newCategory.Description = oldCategory.Description
-			responseString = strings.ReplaceAll(responseString, "description ", "")
-		}
-
-		if responseString == "updated!" {
-			respondError(w, app.ErrorLog, http.StatusBadRequest, "No fields provided for update", nil)
-			return
-		}
-
-		queryUpdate := `UPDATE category SET name = ?, description = ? WHERE id = ?`
-		result, err := app.DB.Exec(queryUpdate, newCategory.Name, newCategory.Description, id)
+		newCategory, responseString, err := store.UpdateCategory(app.DB, id, newCategory)
 		if err != nil {
-			respondError(w, app.ErrorLog, http.StatusInternalServerError, "Failed to update category", err)
-			return
-		}
-
-		rowsAffected, err := result.RowsAffected()
-		if err != nil {
-			respondError(w, app.ErrorLog, http.StatusInternalServerError, "Could not retrieve update result", err)
-			return
-		}
-
-		if rowsAffected == 0 {
-			respondError(w, app.ErrorLog, http.StatusNotFound, fmt.Sprintf("No category with ID %d", id), nil)
+			respondAPIError(w, app.ErrorLog, err)
 			return
 		}
-
-		newCategory.ID = id
+		app.Events.Publish(events.CategoryUpdated, newCategory)
 
 		respondJSON(w, http.StatusOK, newCategory, responseString)
 	}
 }
 
+// DeleteCategory honors the policy query parameter for todos still in the
+// category: refuse (default), reassign (to ?target=), archive or delete.
 func DeleteCategory(app *app.App) http.HandlerFunc {
 	return func(w http.ResponseWriter, r *http.Request) {
 		idStr := chi.URLParam(r, "id")
@@ -167,24 +101,38 @@ func DeleteCategory(app *app.App) http.HandlerFunc {
 			return
 		}
 
-		query := `DELETE FROM category WHERE id = ?`
-		result, err := app.DB.Exec(query, id)
-		if err != nil {
-			respondError(w, app.ErrorLog, http.StatusInternalServerError, "Database error", err)
-			return
+		policy := r.URL.Query().Get("policy")
+		if policy == "" {
+			policy = "refuse"
 		}
 
-		rowsAffected, err := result.RowsAffected()
-		if err != nil {
-			respondError(w, app.ErrorLog, http.StatusInternalServerError, "Could not retrieve delete result", err)
+		var targetID int
+		switch policy {
+		case "refuse", "archive", "delete":
+		case "reassign":
+			targetID, err = strconv.Atoi(r.URL.Query().Get("target"))
+			if err != nil {
+				err = fmt.Errorf("an error occurred while converting string to integer: %v", err)
+				respondError(w, app.ErrorLog, http.StatusBadRequest, "Invalid target category ID", err)
+				return
+			}
+			if targetID == id {
+				respondError(w, app.ErrorLog, http.StatusBadRequest, "Target category must differ from the deleted one", nil)
+				return
+			}
+		default:
+			respondError(w, app.ErrorLog, http.StatusBadRequest, "Policy must be one of refuse, reassign, archive, delete", nil)
 			return
 		}
 
-		if rowsAffected == 0 {
-			respondError(w, app.ErrorLog, http.StatusNotFound, fmt.Sprintf("No category with ID %d", id), nil)
+		todoIDs, todoEvent, responseString, err := store.DeleteCategory(app.DB, id, policy, targetID)
+		if err != nil {
+			respondAPIError(w, app.ErrorLog, err)
 			return
 		}
+		PublishTodos(app, todoEvent, todoIDs)
+		app.Events.Publish(events.CategoryDeleted, events.Deleted{ID: id})
 
-		respondSuccess(w, http.StatusOK, fmt.Sprintf("Category with ID %d deleted.", id))
+		respondSuccess(w, http.StatusOK, responseString)
 	}
 }
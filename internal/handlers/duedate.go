@@ -0,0 +1,105 @@
+package handlers
+
+import (
+	"net/http"
+	"time"
+
+	"github.com/furkankorkmaz309/todo-api/internal/app"
+	"github.com/furkankorkmaz309/todo-api/internal/duedate"
+	"github.com/furkankorkmaz309/todo-api/internal/models"
+	"github.com/furkankorkmaz309/todo-api/internal/store"
+)
+
+// todoBody is a todo as REST clients write it: due_date is RFC 3339 or a
+// phrase such as "tomorrow 9am" that duedate.Parse understands, and
+// is_done may be missing.
+type todoBody struct {
+	models.Todo
+	DueDate string `json:"due_date"`
+	IsDone  *bool  `json:"is_done"`
+}
+
+// resolve returns the todo with its due date read in loc. A due date
+// without a time of day, or with all_day set, is all day: due at the end
+// of its day in loc. An empty due date is left zero, which UpdateTodo takes
+// as unchanged.
+func (in todoBody) resolve(loc *time.Location) (models.Todo, error) {
+	todo := in.Todo
+	todo.IsDone = in.IsDone != nil && *in.IsDone
+	if in.DueDate == "" {
+		return todo, nil
+	}
+
+	due, err := duedate.Parse(in.DueDate, time.Now().In(loc))
+	if err != nil {
+		return todo, store.NewError(http.StatusBadRequest, "Invalid due date: "+err.Error(), nil)
+	}
+	todo.DueDate = due.Time
+	todo.AllDay = due.DateOnly || in.AllDay
+	if todo.AllDay && !due.DateOnly {
+		day := due.Time.In(loc)
+		todo.DueDate = time.Date(day.Year(), day.Month(), day.Day(), 23, 59, 0, 0, loc)
+	}
+	return todo, nil
+}
+
+// resolveUpdate is resolve for an update of the todo with the given ID.
+// Without status or is_done the todo keeps its is_done, as it does over
+// GraphQL and gRPC.
+func (in todoBody) resolveUpdate(q store.Querier, id int, loc *time.Location) (models.Todo, error) {
+	todo, err := in.resolve(loc)
+	if err != nil || in.IsDone != nil || in.Status != "" {
+		return todo, err
+	}
+
+	current, err := store.SelectTodo(q, id)
+	if err != nil {
+		return todo, err
+	}
+	todo.IsDone = current.IsDone
+	return todo, nil
+}
+
+// requestLocation is the time zone dates in r are read in: the IANA name
+// in the X-Timezone header, else the time zone preference.
+func requestLocation(app *app.App, r *http.Request) (*time.Location, error) {
+	name := r.Header.Get("X-Timezone")
+	if name == "" {
+		return store.Location(app.DB)
+	}
+
+	loc, err := time.LoadLocation(name)
+	if err != nil {
+		return nil, store.NewError(http.StatusBadRequest, "Unknown time zone "+name, err)
+	}
+	return loc, nil
+}
+
+type dueDatePreview struct {
+	Text     string    `json:"text"`
+	DueDate  time.Time `json:"due_date"`
+	AllDay   bool      `json:"all_day"`
+	Timezone string    `json:"timezone"`
+}
+
+// PreviewDueDate shows what a due date phrase resolves to, without
+// creating anything, so clients can confirm it while the user types.
+func PreviewDueDate(app *app.App) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		loc, err := requestLocation(app, r)
+		if err != nil {
+			respondAPIError(w, app.ErrorLog, err)
+			return
+		}
+
+		text := r.URL.Query().Get("text")
+		due, err := duedate.Parse(text, time.Now().In(loc))
+		if err != nil {
+			respondError(w, app.ErrorLog, http.StatusBadRequest, "Invalid due date: "+err.Error(), nil)
+			return
+		}
+
+		preview := dueDatePreview{Text: text, DueDate: due.Time.In(loc), AllDay: due.DateOnly, Timezone: loc.String()}
+		respondJSON(w, http.StatusOK, preview, "Due date parsed successfully.")
+	}
+}
@@ -0,0 +1,257 @@
+package handlers
+
+import (
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"strconv"
+	"time"
+
+	"github.com/furkankorkmaz309/todo-api/internal/app"
+	"github.com/furkankorkmaz309/todo-api/internal/events"
+	"github.com/furkankorkmaz309/todo-api/internal/models"
+	"github.com/furkankorkmaz309/todo-api/internal/store"
+	"github.com/go-chi/chi"
+)
+
+func GetEscalationRules(app *app.App) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		rules, err := store.ListEscalationRules(app.DB, false)
+		if err != nil {
+			respondAPIError(w, app.ErrorLog, err)
+			return
+		}
+		respondJSON(w, http.StatusOK, rules, "Escalation rules listed successfully.")
+	}
+}
+
+func AddEscalationRule(app *app.App) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		var rule models.EscalationRule
+		err := json.NewDecoder(r.Body).Decode(&rule)
+		if err != nil {
+			respondError(w, app.ErrorLog, http.StatusBadRequest, "Invalid JSON body", err)
+			return
+		}
+
+		err = checkChannel(app, &rule)
+		if err == nil {
+			err = store.InsertEscalationRule(app.DB, &rule)
+		}
+		if err != nil {
+			respondAPIError(w, app.ErrorLog, err)
+			return
+		}
+		respondJSON(w, http.StatusCreated, rule, "Escalation rule created successfully.")
+	}
+}
+
+// PatchEscalationRule changes the given fields of a rule, including active
+// to pause or resume it. The action cannot change.
+func PatchEscalationRule(app *app.App) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		id, err := strconv.Atoi(chi.URLParam(r, "id"))
+		if err != nil {
+			err = fmt.Errorf("an error occurred while converting string to integer: %v", err)
+			respondError(w, app.ErrorLog, http.StatusBadRequest, "Invalid escalation rule ID", err)
+			return
+		}
+
+		var input struct {
+			Name       *string `json:"name"`
+			AfterDays  *int    `json:"after_days"`
+			CategoryID *int    `json:"category_id"`
+			Channel    *string `json:"channel"`
+			Active     *bool   `json:"active"`
+		}
+		err = json.NewDecoder(r.Body).Decode(&input)
+		if err != nil {
+			respondError(w, app.ErrorLog, http.StatusBadRequest, "Invalid JSON body", err)
+			return
+		}
+
+		rule, err := store.SelectEscalationRule(app.DB, id)
+		if err != nil {
+			respondAPIError(w, app.ErrorLog, err)
+			return
+		}
+		if input.Name != nil {
+			rule.Name = *input.Name
+		}
+		if input.AfterDays != nil {
+			rule.AfterDays = *input.AfterDays
+		}
+		if input.CategoryID != nil {
+			rule.CategoryID = *input.CategoryID
+		}
+		if input.Channel != nil {
+			rule.Channel = *input.Channel
+		}
+		if input.Active != nil {
+			rule.Active = *input.Active
+		}
+
+		err = checkChannel(app, &rule)
+		if err == nil {
+			err = store.UpdateEscalationRule(app.DB, &rule)
+		}
+		if err != nil {
+			respondAPIError(w, app.ErrorLog, err)
+			return
+		}
+		respondJSON(w, http.StatusOK, rule, "Escalation rule updated successfully.")
+	}
+}
+
+func DeleteEscalationRule(app *app.App) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		id, err := strconv.Atoi(chi.URLParam(r, "id"))
+		if err != nil {
+			err = fmt.Errorf("an error occurred while converting string to integer: %v", err)
+			respondError(w, app.ErrorLog, http.StatusBadRequest, "Invalid escalation rule ID", err)
+			return
+		}
+
+		err = store.DeleteEscalationRule(app.DB, id)
+		if err != nil {
+			respondAPIError(w, app.ErrorLog, err)
+			return
+		}
+		respondSuccess(w, http.StatusOK, fmt.Sprintf("Escalation rule with ID %d deleted.", id))
+	}
+}
+
+// checkChannel defaults the channel of a notify rule to log and checks
+// that the server can send through it.
+func checkChannel(app *app.App, rule *models.EscalationRule) error {
+	if rule.Action != store.ActionNotify {
+		return nil
+	}
+	if rule.Channel == "" {
+		rule.Channel = "log"
+	}
+	if app.Reminders != nil && !app.Reminders.HasChannel(rule.Channel) {
+		return store.NewError(http.StatusBadRequest, fmt.Sprintf("Channel must be one of %v", app.Reminders.Channels()), nil)
+	}
+	return nil
+}
+
+// RunEscalations applies the escalation rules now. With ?dry_run=true it
+// only reports what they would do.
+func RunEscalations(app *app.App) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		dryRun, err := parseBoolParam(r.URL.Query().Get("dry_run"))
+		if err != nil {
+			respondError(w, app.ErrorLog, http.StatusBadRequest, "dry_run must be true or false", err)
+			return
+		}
+
+		report, err := Escalate(app, dryRun)
+		if err != nil {
+			respondAPIError(w, app.ErrorLog, err)
+			return
+		}
+
+		msg := fmt.Sprintf("%d escalations applied.", len(report))
+		if dryRun {
+			msg = fmt.Sprintf("%d escalations would be applied.", len(report))
+		}
+		respondJSON(w, http.StatusOK, report, msg)
+	}
+}
+
+// Escalate runs every active escalation rule, the ones acting soonest
+// first, over the todos overdue long enough for it. Each rule acts on a
+// todo once per due date. With dryRun nothing changes and the report says
+// what would have happened.
+func Escalate(app *app.App, dryRun bool) ([]models.Escalation, error) {
+	rules, err := store.ListEscalationRules(app.DB, true)
+	if err != nil {
+		return nil, err
+	}
+
+	report := []models.Escalation{}
+	now := time.Now()
+	// A dry run changes nothing, so it tracks the priorities it would have
+	// raised for the rules after it.
+	priorities := make(map[int]int)
+	var updated []int
+	notified := false
+
+	for _, rule := range rules {
+		todos, err := store.EscalationCandidates(app.DB, rule, now)
+		if err != nil {
+			return report, err
+		}
+
+		for _, todo := range todos {
+			e := models.Escalation{RuleID: rule.ID, Rule: rule.Name, TodoID: todo.ID, Action: rule.Action}
+
+			switch rule.Action {
+			case store.ActionBumpPriority:
+				priority, ok := priorities[todo.ID]
+				if !ok {
+					priority = todo.Priority
+				}
+				if priority >= 5 {
+					e.Detail = "priority is already 5"
+				} else {
+					e.Detail = fmt.Sprintf("priority %d to %d", priority, priority+1)
+					priorities[todo.ID] = priority + 1
+				}
+			case store.ActionNotify:
+				e.Detail = "remind through " + rule.Channel
+			case store.ActionMoveCategory:
+				e.Detail = fmt.Sprintf("category %d to %d", todo.CategoryID, rule.CategoryID)
+			}
+
+			if !dryRun {
+				err = applyEscalation(app, rule, todo.ID)
+				if err != nil {
+					e.Error = err.Error()
+				} else if rule.Action == store.ActionNotify {
+					notified = true
+				} else {
+					updated = append(updated, todo.ID)
+				}
+			}
+			report = append(report, e)
+		}
+	}
+
+	PublishTodos(app, events.TodoUpdated, updated)
+	if notified && app.Reminders != nil {
+		app.Reminders.Notify()
+	}
+	return report, nil
+}
+
+// applyEscalation makes rule act on the todo and records that it did.
+func applyEscalation(app *app.App, rule models.EscalationRule, todoID int) error {
+	tx, err := app.DB.Begin()
+	if err != nil {
+		return store.NewError(http.StatusInternalServerError, "Database error", err)
+	}
+	defer tx.Rollback()
+
+	switch rule.Action {
+	case store.ActionBumpPriority:
+		err = store.ExecTodo(tx, todoID, `UPDATE todo SET priority = MIN(priority + 1, 5) WHERE id = ?`)
+	case store.ActionNotify:
+		_, err = store.QueueReminder(tx, todoID, rule.Channel)
+	case store.ActionMoveCategory:
+		err = store.ExecTodo(tx, todoID, `UPDATE todo SET category_id = ? WHERE id = ?`, rule.CategoryID)
+	}
+	if err == nil {
+		err = store.RecordEscalation(tx, rule.ID, todoID)
+	}
+	if err != nil {
+		return err
+	}
+
+	err = tx.Commit()
+	if err != nil {
+		return store.NewError(http.StatusInternalServerError, "Database error", err)
+	}
+	return nil
+}
@@ -0,0 +1,246 @@
+package handlers
+
+import (
+	"encoding/csv"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/furkankorkmaz309/todo-api/internal/app"
+	"github.com/furkankorkmaz309/todo-api/internal/models"
+	"github.com/furkankorkmaz309/todo-api/internal/store"
+)
+
+// exportTodo is a todo as it appears in exports and imports: categories are
+// referred to by name so the data can move between databases.
+type exportTodo struct {
+	models.Todo
+	Category string `json:"category"`
+}
+
+type exportRecord struct {
+	Type string      `json:"type"`
+	Data interface{} `json:"data"`
+}
+
+// csvHeader is shared by category and todo rows; the type column tells
+// which of the remaining columns apply.
+var csvHeader = []string{"type", "id", "name", "description", "title", "content", "priority", "created_at", "due_date", "all_day", "status", "is_done", "archived", "started_at", "completed_at", "category", "tags"}
+
+func Export(app *app.App) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		format := r.URL.Query().Get("format")
+		if format == "" {
+			format = "json"
+		}
+
+		var enc exportEncoder
+		switch format {
+		case "json":
+			w.Header().Set("Content-Type", "application/json")
+			enc = &jsonExporter{w: w}
+		case "ndjson":
+			w.Header().Set("Content-Type", "application/x-ndjson")
+			enc = &ndjsonExporter{enc: json.NewEncoder(w)}
+		case "csv":
+			w.Header().Set("Content-Type", "text/csv")
+			enc = &csvExporter{w: csv.NewWriter(w)}
+		default:
+			respondError(w, app.ErrorLog, http.StatusBadRequest, "Format must be one of csv, json, ndjson", nil)
+			return
+		}
+
+		rows, err := app.DB.Query(`SELECT id, name, description FROM category ORDER BY id`)
+		if err != nil {
+			respondError(w, app.ErrorLog, http.StatusInternalServerError, "Database error", err)
+			return
+		}
+		defer rows.Close()
+
+		filename := fmt.Sprintf("todo-api-%s.%s", time.Now().Format("20060102-150405"), format)
+		w.Header().Set("Content-Disposition", fmt.Sprintf(`attachment; filename="%s"`, filename))
+		w.WriteHeader(http.StatusOK)
+
+		// From here on the status is sent, so failures can only be logged
+		// and the stream cut short.
+		err = enc.begin()
+		for err == nil && rows.Next() {
+			var category models.Category
+			err = rows.Scan(&category.ID, &category.Name, &category.Description)
+			if err == nil {
+				err = enc.category(category)
+			}
+		}
+		if err == nil {
+			err = rows.Err()
+		}
+		rows.Close()
+		if err != nil {
+			app.ErrorLog.Printf("export of categories stopped: %v", err)
+			return
+		}
+
+		query := `SELECT ` + store.TodoColumns + `, COALESCE((SELECT name FROM category WHERE category.id = todo.category_id), '') FROM todo ORDER BY id`
+		todoRows, err := app.DB.Query(query)
+		if err != nil {
+			app.ErrorLog.Printf("export of todos stopped: %v", err)
+			return
+		}
+		defer todoRows.Close()
+
+		for err == nil && todoRows.Next() {
+			var todo exportTodo
+			err = store.ScanTodo(todoRows, &todo.Todo, &todo.Category)
+			if err == nil {
+				err = enc.todo(todo)
+			}
+		}
+		if err == nil {
+			err = todoRows.Err()
+		}
+		if err == nil {
+			err = enc.end()
+		}
+		if err != nil {
+			app.ErrorLog.Printf("export of todos stopped: %v", err)
+		}
+	}
+}
+
+type exportEncoder interface {
+	begin() error
+	category(models.Category) error
+	todo(exportTodo) error
+	end() error
+}
+
+// jsonExporter writes {"categories":[...],"todos":[...]} one element at a
+// time instead of building the whole document in memory.
+type jsonExporter struct {
+	w       io.Writer
+	inTodos bool
+	count   int
+}
+
+func (e *jsonExporter) begin() error {
+	_, err := io.WriteString(e.w, `{"categories":[`)
+	return err
+}
+
+func (e *jsonExporter) element(v interface{}) error {
+	b, err := json.Marshal(v)
+	if err != nil {
+		return err
+	}
+	if e.count > 0 {
+		_, err = io.WriteString(e.w, ",")
+		if err != nil {
+			return err
+		}
+	}
+	e.count++
+	_, err = e.w.Write(b)
+	return err
+}
+
+func (e *jsonExporter) category(c models.Category) error {
+	return e.element(c)
+}
+
+func (e *jsonExporter) todo(t exportTodo) error {
+	if !e.inTodos {
+		err := e.startTodos()
+		if err != nil {
+			return err
+		}
+	}
+	return e.element(t)
+}
+
+func (e *jsonExporter) startTodos() error {
+	e.inTodos = true
+	e.count = 0
+	_, err := io.WriteString(e.w, `],"todos":[`)
+	return err
+}
+
+func (e *jsonExporter) end() error {
+	if !e.inTodos {
+		err := e.startTodos()
+		if err != nil {
+			return err
+		}
+	}
+	_, err := io.WriteString(e.w, "]}\n")
+	return err
+}
+
+type ndjsonExporter struct {
+	enc *json.Encoder
+}
+
+func (e *ndjsonExporter) begin() error {
+	return nil
+}
+
+func (e *ndjsonExporter) category(c models.Category) error {
+	return e.enc.Encode(exportRecord{Type: "category", Data: c})
+}
+
+func (e *ndjsonExporter) todo(t exportTodo) error {
+	return e.enc.Encode(exportRecord{Type: "todo", Data: t})
+}
+
+func (e *ndjsonExporter) end() error {
+	return nil
+}
+
+type csvExporter struct {
+	w *csv.Writer
+}
+
+func (e *csvExporter) begin() error {
+	return e.w.Write(csvHeader)
+}
+
+func (e *csvExporter) category(c models.Category) error {
+	return e.w.Write([]string{"category", strconv.Itoa(c.ID), c.Name, c.Description, "", "", "", "", "", "", "", "", "", "", ""})
+}
+
+func (e *csvExporter) todo(t exportTodo) error {
+	startedAt, completedAt := "", ""
+	if t.StartedAt != nil {
+		startedAt = t.StartedAt.Format(time.RFC3339Nano)
+	}
+	if t.CompletedAt != nil {
+		completedAt = t.CompletedAt.Format(time.RFC3339Nano)
+	}
+	return e.w.Write([]string{
+		"todo",
+		strconv.Itoa(t.ID),
+		"",
+		"",
+		t.Title,
+		t.Content,
+		strconv.Itoa(t.Priority),
+		t.CreatedAt.Format(time.RFC3339Nano),
+		t.DueDate.Format(time.RFC3339Nano),
+		strconv.FormatBool(t.AllDay),
+		t.Status,
+		strconv.FormatBool(t.IsDone),
+		strconv.FormatBool(t.Archived),
+		startedAt,
+		completedAt,
+		t.Category,
+		strings.Join(t.Tags, ","),
+	})
+}
+
+func (e *csvExporter) end() error {
+	e.w.Flush()
+	return e.w.Error()
+}
@@ -0,0 +1,260 @@
+package handlers
+
+import (
+	"context"
+	_ "embed"
+	"encoding/json"
+	"net/http"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/furkankorkmaz309/todo-api/internal/app"
+	"github.com/furkankorkmaz309/todo-api/internal/models"
+	"github.com/furkankorkmaz309/todo-api/internal/store"
+	graphql "github.com/graph-gophers/graphql-go"
+)
+
+//go:embed schema.graphql
+var graphqlSchema string
+
+// GraphQL serves the schema in schema.graphql. Unlike the REST handlers it
+// answers in the usual {"data":...,"errors":[...]} shape that GraphQL
+// clients expect.
+func GraphQL(app *app.App) http.HandlerFunc {
+	schema := graphql.MustParseSchema(graphqlSchema, &gqlResolver{app: app},
+		graphql.MaxDepth(8),
+		graphql.MaxParallelism(10),
+		graphql.MaxQueryLength(20000))
+
+	return func(w http.ResponseWriter, r *http.Request) {
+		var params struct {
+			Query         string                 `json:"query"`
+			OperationName string                 `json:"operationName"`
+			Variables     map[string]interface{} `json:"variables"`
+		}
+		err := json.NewDecoder(r.Body).Decode(&params)
+		if err != nil {
+			respondError(w, app.ErrorLog, http.StatusBadRequest, "Invalid JSON body", err)
+			return
+		}
+
+		ctx := context.WithValue(r.Context(), loaderKey{}, newGQLLoader(app))
+		response := schema.Exec(ctx, params.Query, params.OperationName, params.Variables)
+
+		w.Header().Set("Content-Type", "application/json")
+		json.NewEncoder(w).Encode(response)
+	}
+}
+
+// GraphiQL serves an in-browser IDE for /graphql. It is only routed in
+// development mode.
+func GraphiQL(w http.ResponseWriter, r *http.Request) {
+	w.Header().Set("Content-Type", "text/html; charset=utf-8")
+	w.Write([]byte(graphiqlPage))
+}
+
+const graphiqlPage = `<!DOCTYPE html>
+<html>
+<head>
+<title>todo-api GraphiQL</title>
+<link rel="stylesheet" href="https://unpkg.com/graphiql@3/graphiql.min.css">
+</head>
+<body style="margin: 0">
+<div id="graphiql" style="height: 100vh"></div>
+<script src="https://unpkg.com/react@18/umd/react.production.min.js"></script>
+<script src="https://unpkg.com/react-dom@18/umd/react-dom.production.min.js"></script>
+<script src="https://unpkg.com/graphiql@3/graphiql.min.js"></script>
+<script>
+const fetcher = GraphiQL.createFetcher({ url: '/graphql' });
+ReactDOM.createRoot(document.getElementById('graphiql')).render(React.createElement(GraphiQL, { fetcher }));
+</script>
+</body>
+</html>
+`
+
+type loaderKey struct{}
+
+// gqlLoader batches the relationship lookups of one GraphQL request. List
+// resolvers register the IDs they returned, and the first lookup of a
+// category or a category's todos loads all registered ones in one query,
+// so a list of N todos costs one category query instead of N.
+type gqlLoader struct {
+	app *app.App
+
+	mu          sync.Mutex
+	categoryIDs map[int]bool
+	categories  map[int]*models.Category
+	todoCatIDs  map[int]bool
+	todosByCat  map[bool]map[int][]models.Todo
+	statsByCat  map[int]gqlStats
+	statsLoaded bool
+}
+
+func newGQLLoader(app *app.App) *gqlLoader {
+	return &gqlLoader{
+		app:         app,
+		categoryIDs: make(map[int]bool),
+		categories:  make(map[int]*models.Category),
+		todoCatIDs:  make(map[int]bool),
+		todosByCat:  make(map[bool]map[int][]models.Todo),
+	}
+}
+
+func loaderFrom(ctx context.Context) *gqlLoader {
+	return ctx.Value(loaderKey{}).(*gqlLoader)
+}
+
+// wantCategories registers the categories of todos about to be resolved.
+func (l *gqlLoader) wantCategories(todos []models.Todo) {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+	for _, t := range todos {
+		if t.CategoryID != 0 {
+			l.categoryIDs[t.CategoryID] = true
+		}
+	}
+}
+
+// wantTodos registers categories whose todos may be resolved.
+func (l *gqlLoader) wantTodos(categories []models.Category) {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+	for _, c := range categories {
+		l.todoCatIDs[c.ID] = true
+	}
+}
+
+func (l *gqlLoader) category(id int) (*models.Category, error) {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+
+	if c, ok := l.categories[id]; ok {
+		return c, nil
+	}
+
+	l.categoryIDs[id] = true
+	var ids []interface{}
+	for cid := range l.categoryIDs {
+		if _, ok := l.categories[cid]; !ok {
+			ids = append(ids, cid)
+			l.categories[cid] = nil
+		}
+	}
+
+	query := `SELECT id, name, description FROM category WHERE id IN (` + placeholders(len(ids)) + `)`
+	rows, err := l.app.DB.Query(query, ids...)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	for rows.Next() {
+		var c models.Category
+		err = rows.Scan(&c.ID, &c.Name, &c.Description)
+		if err != nil {
+			return nil, err
+		}
+		l.categories[c.ID] = &c
+	}
+	return l.categories[id], rows.Err()
+}
+
+func (l *gqlLoader) todosOf(categoryID int, archived bool) ([]models.Todo, error) {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+
+	byCat, ok := l.todosByCat[archived]
+	if ok {
+		if todos, ok := byCat[categoryID]; ok {
+			return todos, nil
+		}
+	} else {
+		byCat = make(map[int][]models.Todo)
+		l.todosByCat[archived] = byCat
+	}
+
+	l.todoCatIDs[categoryID] = true
+	args := []interface{}{archived}
+	for cid := range l.todoCatIDs {
+		if _, ok := byCat[cid]; !ok {
+			args = append(args, cid)
+			byCat[cid] = []models.Todo{}
+		}
+	}
+
+	query := `SELECT ` + store.TodoColumns + ` FROM todo WHERE archived = ? AND category_id IN (` + placeholders(len(args)-1) + `) ORDER BY id`
+	rows, err := l.app.DB.Query(query, args...)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	var todos []models.Todo
+	for rows.Next() {
+		var todo models.Todo
+		err = store.ScanTodo(rows, &todo)
+		if err != nil {
+			return nil, err
+		}
+		todos = append(todos, todo)
+		byCat[todo.CategoryID] = append(byCat[todo.CategoryID], todo)
+	}
+	if err = rows.Err(); err != nil {
+		return nil, err
+	}
+
+	l.categoryIDs[categoryID] = true
+	for _, t := range todos {
+		if t.CategoryID != 0 {
+			l.categoryIDs[t.CategoryID] = true
+		}
+	}
+	return byCat[categoryID], nil
+}
+
+// statsOf loads the stats of every category in one grouped query.
+func (l *gqlLoader) statsOf(categoryID int) (gqlStats, error) {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+
+	if !l.statsLoaded {
+		query := `SELECT COALESCE(category_id, 0), ` + statsColumns + ` FROM todo GROUP BY category_id`
+		rows, err := l.app.DB.Query(query, time.Now().UTC())
+		if err != nil {
+			return gqlStats{}, err
+		}
+		defer rows.Close()
+
+		l.statsByCat = make(map[int]gqlStats)
+		for rows.Next() {
+			var id int
+			var s gqlStats
+			err = rows.Scan(&id, &s.total, &s.open, &s.done, &s.archived, &s.overdue)
+			if err != nil {
+				return gqlStats{}, err
+			}
+			l.statsByCat[id] = s
+		}
+		if err = rows.Err(); err != nil {
+			return gqlStats{}, err
+		}
+		l.statsLoaded = true
+	}
+	return l.statsByCat[categoryID], nil
+}
+
+// statsColumns aggregates todo rows into the Stats fields; it takes the
+// current time as its only argument.
+const statsColumns = `COUNT(*),
+	COALESCE(SUM(NOT ` + store.ClosedCondition + ` AND archived = 0), 0),
+	COALESCE(SUM(done = 1), 0),
+	COALESCE(SUM(archived = 1), 0),
+	COALESCE(SUM(archived = 0 AND ` + store.OverdueCondition + `), 0)`
+
+func placeholders(n int) string {
+	if n == 0 {
+		return "NULL"
+	}
+	return strings.TrimSuffix(strings.Repeat("?, ", n), ", ")
+}
@@ -0,0 +1,648 @@
+package handlers
+
+import (
+	"context"
+	"encoding/base64"
+	"errors"
+	"fmt"
+	"net/http"
+	"net/url"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/furkankorkmaz309/todo-api/internal/app"
+	"github.com/furkankorkmaz309/todo-api/internal/events"
+	"github.com/furkankorkmaz309/todo-api/internal/models"
+	"github.com/furkankorkmaz309/todo-api/internal/store"
+	graphql "github.com/graph-gophers/graphql-go"
+)
+
+type gqlResolver struct {
+	app *app.App
+}
+
+// gqlError turns a store error into one safe to show to clients; causes
+// of server errors are only logged, as respondAPIError does.
+func (r *gqlResolver) gqlError(err error) error {
+	storeErr := store.AsError(err)
+	if storeErr.Status >= 500 && storeErr.Err != nil {
+		r.app.ErrorLog.Println(storeErr.Err)
+	}
+	return errors.New(storeErr.Msg)
+}
+
+func parseGQLID(id graphql.ID) (int, error) {
+	n, err := strconv.Atoi(string(id))
+	if err != nil {
+		return 0, fmt.Errorf("Invalid ID %q", string(id))
+	}
+	return n, nil
+}
+
+func gqlID(id int) graphql.ID {
+	return graphql.ID(strconv.Itoa(id))
+}
+
+type gqlTodoFilter struct {
+	CategoryID      *graphql.ID
+	Status          *string
+	IsDone          *bool
+	Priority        *int32
+	Overdue         *bool
+	Due             *string
+	DueBefore       *string
+	DueAfter        *string
+	CompletedBefore *string
+	CompletedAfter  *string
+}
+
+// filter maps the arguments onto the REST query parameters so both share
+// one set of rules. Dates are read in the time zone preference.
+func (f *gqlTodoFilter) filter(app *app.App) (store.Filter, error) {
+	q := url.Values{}
+	if f != nil {
+		if f.CategoryID != nil {
+			q.Set("category_id", string(*f.CategoryID))
+		}
+		if f.Status != nil {
+			q.Set("status", *f.Status)
+		}
+		if f.IsDone != nil {
+			q.Set("is_done", strconv.FormatBool(*f.IsDone))
+		}
+		if f.Overdue != nil {
+			q.Set("overdue", strconv.FormatBool(*f.Overdue))
+		}
+		if f.Priority != nil {
+			q.Set("priority", strconv.Itoa(int(*f.Priority)))
+		}
+		for param, v := range map[string]*string{
+			"due":              f.Due,
+			"due_before":       f.DueBefore,
+			"due_after":        f.DueAfter,
+			"completed_before": f.CompletedBefore,
+			"completed_after":  f.CompletedAfter,
+		} {
+			if v != nil {
+				q.Set(param, *v)
+			}
+		}
+	}
+	loc, err := store.Location(app.DB)
+	if err != nil {
+		return store.Filter{}, err
+	}
+	filter, err := store.ParseFilter(q, loc)
+	if err != nil {
+		return filter, fmt.Errorf("Invalid filter: %v", err)
+	}
+	return filter, nil
+}
+
+func (r *gqlResolver) Todos(ctx context.Context, args struct {
+	Filter   *gqlTodoFilter
+	Archived bool
+	First    int32
+	After    *string
+}) (*todoConnectionResolver, error) {
+	filter, err := args.Filter.filter(r.app)
+	if err != nil {
+		return nil, err
+	}
+	archived := args.Archived
+
+	first := int(args.First)
+	if first < 1 || first > 200 {
+		return nil, errors.New("first must be between 1 and 200")
+	}
+
+	conn := &todoConnectionResolver{}
+	query := `SELECT COUNT(*) FROM todo WHERE archived = ?` + filter.And()
+	err = r.app.DB.QueryRow(query, append([]interface{}{archived}, filter.Args...)...).Scan(&conn.totalCount)
+	if err != nil {
+		return nil, r.gqlError(err)
+	}
+
+	if args.After != nil {
+		afterID, err := decodeCursor(*args.After)
+		if err != nil {
+			return nil, err
+		}
+		filter.Add("id > ?", afterID)
+	}
+
+	query = `SELECT ` + store.TodoColumns + ` FROM todo WHERE archived = ?` + filter.And() + ` ORDER BY id LIMIT ?`
+	rows, err := r.app.DB.Query(query, append(append([]interface{}{archived}, filter.Args...), first+1)...)
+	if err != nil {
+		return nil, r.gqlError(err)
+	}
+	defer rows.Close()
+
+	var todos []models.Todo
+	for rows.Next() {
+		var todo models.Todo
+		err = store.ScanTodo(rows, &todo)
+		if err != nil {
+			return nil, r.gqlError(err)
+		}
+		todos = append(todos, todo)
+	}
+	if err = rows.Err(); err != nil {
+		return nil, r.gqlError(err)
+	}
+
+	if len(todos) > first {
+		todos = todos[:first]
+		conn.hasNextPage = true
+	}
+	if len(todos) > 0 {
+		cursor := encodeCursor(todos[len(todos)-1].ID)
+		conn.endCursor = &cursor
+	}
+
+	loaderFrom(ctx).wantCategories(todos)
+	conn.nodes = r.todoResolvers(todos)
+	return conn, nil
+}
+
+func encodeCursor(id int) string {
+	return base64.RawURLEncoding.EncodeToString([]byte("todo:" + strconv.Itoa(id)))
+}
+
+func decodeCursor(cursor string) (int, error) {
+	b, err := base64.RawURLEncoding.DecodeString(cursor)
+	if err == nil && strings.HasPrefix(string(b), "todo:") {
+		id, err := strconv.Atoi(strings.TrimPrefix(string(b), "todo:"))
+		if err == nil {
+			return id, nil
+		}
+	}
+	return 0, errors.New("Invalid cursor")
+}
+
+func (r *gqlResolver) Todo(ctx context.Context, args struct{ ID graphql.ID }) (*todoResolver, error) {
+	id, err := parseGQLID(args.ID)
+	if err != nil {
+		return nil, err
+	}
+
+	todo, err := store.SelectTodo(r.app.DB, id)
+	var apiErr *store.Error
+	if errors.As(err, &apiErr) && apiErr.Status == http.StatusNotFound {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, r.gqlError(err)
+	}
+	return &todoResolver{r, todo}, nil
+}
+
+func (r *gqlResolver) Categories(ctx context.Context) ([]*categoryResolver, error) {
+	rows, err := r.app.DB.Query(`SELECT id, name, description FROM category ORDER BY id`)
+	if err != nil {
+		return nil, r.gqlError(err)
+	}
+	defer rows.Close()
+
+	var categories []models.Category
+	for rows.Next() {
+		var category models.Category
+		err = rows.Scan(&category.ID, &category.Name, &category.Description)
+		if err != nil {
+			return nil, r.gqlError(err)
+		}
+		categories = append(categories, category)
+	}
+	if err = rows.Err(); err != nil {
+		return nil, r.gqlError(err)
+	}
+
+	loaderFrom(ctx).wantTodos(categories)
+	resolvers := []*categoryResolver{}
+	for _, c := range categories {
+		resolvers = append(resolvers, &categoryResolver{r, c})
+	}
+	return resolvers, nil
+}
+
+func (r *gqlResolver) Category(ctx context.Context, args struct{ ID graphql.ID }) (*categoryResolver, error) {
+	id, err := parseGQLID(args.ID)
+	if err != nil {
+		return nil, err
+	}
+
+	category, err := loaderFrom(ctx).category(id)
+	if err != nil {
+		return nil, r.gqlError(err)
+	}
+	if category == nil {
+		return nil, nil
+	}
+	return &categoryResolver{r, *category}, nil
+}
+
+func (r *gqlResolver) Stats(ctx context.Context, args struct{ CategoryID *graphql.ID }) (*statsResolver, error) {
+	query := `SELECT ` + statsColumns + ` FROM todo`
+	queryArgs := []interface{}{time.Now().UTC()}
+	if args.CategoryID != nil {
+		id, err := parseGQLID(*args.CategoryID)
+		if err != nil {
+			return nil, err
+		}
+		query += ` WHERE category_id = ?`
+		queryArgs = append(queryArgs, id)
+	}
+
+	var s gqlStats
+	err := r.app.DB.QueryRow(query, queryArgs...).Scan(&s.total, &s.open, &s.done, &s.archived, &s.overdue)
+	if err != nil {
+		return nil, r.gqlError(err)
+	}
+	return &statsResolver{s}, nil
+}
+
+func (r *gqlResolver) todoResolvers(todos []models.Todo) []*todoResolver {
+	resolvers := []*todoResolver{}
+	for _, t := range todos {
+		resolvers = append(resolvers, &todoResolver{r, t})
+	}
+	return resolvers
+}
+
+type todoInput struct {
+	Title      string
+	Content    string
+	Priority   int32
+	DueDate    graphql.Time
+	CategoryID graphql.ID
+	Status     *string
+	Tags       *[]string
+}
+
+func (r *gqlResolver) CreateTodo(args struct{ Input todoInput }) (*todoResolver, error) {
+	categoryID, err := parseGQLID(args.Input.CategoryID)
+	if err != nil {
+		return nil, err
+	}
+
+	todo := models.Todo{
+		Title:      args.Input.Title,
+		Content:    args.Input.Content,
+		Priority:   int(args.Input.Priority),
+		DueDate:    args.Input.DueDate.Time,
+		CategoryID: categoryID,
+	}
+	if args.Input.Status != nil {
+		todo.Status = *args.Input.Status
+	}
+	if args.Input.Tags != nil {
+		todo.Tags = *args.Input.Tags
+	}
+
+	err = store.InsertTodo(r.app.DB, &todo)
+	if err != nil {
+		return nil, r.gqlError(err)
+	}
+	r.app.Events.Publish(events.TodoCreated, todo)
+
+	return &todoResolver{r, todo}, nil
+}
+
+type todoPatch struct {
+	Title      *string
+	Content    *string
+	Priority   *int32
+	DueDate    *graphql.Time
+	Status     *string
+	IsDone     *bool
+	CategoryID *graphql.ID
+	Tags       *[]string
+	Version    *int32
+}
+
+func (r *gqlResolver) UpdateTodo(args struct {
+	ID    graphql.ID
+	Input todoPatch
+}) (*todoResolver, error) {
+	id, err := parseGQLID(args.ID)
+	if err != nil {
+		return nil, err
+	}
+
+	in := args.Input
+	var newTodo models.Todo
+	if in.Title != nil {
+		newTodo.Title = *in.Title
+	}
+	if in.Content != nil {
+		newTodo.Content = *in.Content
+	}
+	if in.Priority != nil {
+		newTodo.Priority = int(*in.Priority)
+	}
+	if in.DueDate != nil {
+		newTodo.DueDate = in.DueDate.Time
+	}
+	if in.CategoryID != nil {
+		newTodo.CategoryID, err = parseGQLID(*in.CategoryID)
+		if err != nil {
+			return nil, err
+		}
+	}
+	if in.Tags != nil {
+		newTodo.Tags = *in.Tags
+	}
+	if in.Version != nil {
+		newTodo.Version = int(*in.Version)
+	}
+
+	if in.Status != nil {
+		newTodo.Status = *in.Status
+	}
+
+	// A missing is_done leaves it unchanged, as over REST.
+	if in.IsDone != nil {
+		newTodo.IsDone = *in.IsDone
+	} else if in.Status == nil {
+		current, err := store.SelectTodo(r.app.DB, id)
+		if err != nil {
+			return nil, r.gqlError(err)
+		}
+		newTodo.IsDone = current.IsDone
+	}
+
+	todo, responseString, err := store.UpdateTodo(r.app.DB, id, newTodo)
+	if err != nil {
+		return nil, r.gqlError(err)
+	}
+	PublishTodoUpdate(r.app, todo, responseString)
+
+	return &todoResolver{r, todo}, nil
+}
+
+func (r *gqlResolver) DeleteTodo(args struct{ ID graphql.ID }) (graphql.ID, error) {
+	id, err := parseGQLID(args.ID)
+	if err != nil {
+		return "", err
+	}
+
+	err = store.DeleteTodo(r.app.DB, id)
+	if err != nil {
+		return "", r.gqlError(err)
+	}
+	r.app.Events.Publish(events.TodoDeleted, events.Deleted{ID: id})
+
+	return args.ID, nil
+}
+
+func (r *gqlResolver) ArchiveTodo(args struct{ ID graphql.ID }) (*todoResolver, error) {
+	return r.setArchived(args.ID, true)
+}
+
+func (r *gqlResolver) UnarchiveTodo(args struct{ ID graphql.ID }) (*todoResolver, error) {
+	return r.setArchived(args.ID, false)
+}
+
+func (r *gqlResolver) setArchived(gid graphql.ID, archived bool) (*todoResolver, error) {
+	id, err := parseGQLID(gid)
+	if err != nil {
+		return nil, err
+	}
+
+	err = store.ExecTodo(r.app.DB, id, `UPDATE todo SET archived = ? WHERE id = ?`, archived)
+	if err != nil {
+		return nil, r.gqlError(err)
+	}
+	todo, err := store.SelectTodo(r.app.DB, id)
+	if err != nil {
+		return nil, r.gqlError(err)
+	}
+
+	if archived {
+		r.app.Events.Publish(events.TodoArchived, todo)
+	} else {
+		r.app.Events.Publish(events.TodoUnarchived, todo)
+	}
+	return &todoResolver{r, todo}, nil
+}
+
+func (r *gqlResolver) ArchiveFinished(args struct{ Filter *gqlTodoFilter }) ([]graphql.ID, error) {
+	filter, err := args.Filter.filter(r.app)
+	if err != nil {
+		return nil, err
+	}
+
+	ids, err := ArchiveDone(r.app, filter.Where, filter.Args)
+	if err != nil {
+		return nil, r.gqlError(err)
+	}
+
+	gids := []graphql.ID{}
+	for _, id := range ids {
+		gids = append(gids, gqlID(id))
+	}
+	return gids, nil
+}
+
+func (r *gqlResolver) CreateCategory(args struct {
+	Input struct {
+		Name        string
+		Description *string
+	}
+}) (*categoryResolver, error) {
+	category := models.Category{Name: args.Input.Name}
+	if args.Input.Description != nil {
+		category.Description = *args.Input.Description
+	}
+
+	err := store.InsertCategory(r.app.DB, &category)
+	if err != nil {
+		return nil, r.gqlError(err)
+	}
+	r.app.Events.Publish(events.CategoryCreated, category)
+
+	return &categoryResolver{r, category}, nil
+}
+
+func (r *gqlResolver) UpdateCategory(args struct {
+	ID    graphql.ID
+	Input struct {
+		Name        *string
+		Description *string
+	}
+}) (*categoryResolver, error) {
+	id, err := parseGQLID(args.ID)
+	if err != nil {
+		return nil, err
+	}
+
+	var newCategory models.Category
+	if args.Input.Name != nil {
+		newCategory.Name = *args.Input.Name
+	}
+	if args.Input.Description != nil {
+		newCategory.Description = *args.Input.Description
+	}
+
+	category, _, err := store.UpdateCategory(r.app.DB, id, newCategory)
+	if err != nil {
+		return nil, r.gqlError(err)
+	}
+	r.app.Events.Publish(events.CategoryUpdated, category)
+
+	return &categoryResolver{r, category}, nil
+}
+
+func (r *gqlResolver) DeleteCategory(args struct {
+	ID     graphql.ID
+	Policy string
+	Target *graphql.ID
+}) (graphql.ID, error) {
+	id, err := parseGQLID(args.ID)
+	if err != nil {
+		return "", err
+	}
+
+	policy := args.Policy
+
+	var targetID int
+	switch policy {
+	case "refuse", "archive", "delete":
+	case "reassign":
+		if args.Target == nil {
+			return "", errors.New("Invalid target category ID")
+		}
+		targetID, err = parseGQLID(*args.Target)
+		if err != nil {
+			return "", err
+		}
+		if targetID == id {
+			return "", errors.New("Target category must differ from the deleted one")
+		}
+	default:
+		return "", errors.New("Policy must be one of refuse, reassign, archive, delete")
+	}
+
+	todoIDs, todoEvent, _, err := store.DeleteCategory(r.app.DB, id, policy, targetID)
+	if err != nil {
+		return "", r.gqlError(err)
+	}
+	PublishTodos(r.app, todoEvent, todoIDs)
+	r.app.Events.Publish(events.CategoryDeleted, events.Deleted{ID: id})
+
+	return args.ID, nil
+}
+
+type todoResolver struct {
+	root *gqlResolver
+	t    models.Todo
+}
+
+func (r *todoResolver) ID() graphql.ID          { return gqlID(r.t.ID) }
+func (r *todoResolver) Title() string           { return r.t.Title }
+func (r *todoResolver) Content() string         { return r.t.Content }
+func (r *todoResolver) Priority() int32         { return int32(r.t.Priority) }
+func (r *todoResolver) CreatedAt() graphql.Time { return graphql.Time{Time: r.t.CreatedAt} }
+func (r *todoResolver) DueDate() graphql.Time   { return graphql.Time{Time: r.t.DueDate} }
+func (r *todoResolver) AllDay() bool            { return r.t.AllDay }
+func (r *todoResolver) Status() string          { return r.t.Status }
+func (r *todoResolver) IsDone() bool            { return r.t.IsDone }
+func (r *todoResolver) Overdue() bool           { return r.t.Overdue }
+func (r *todoResolver) Archived() bool          { return r.t.Archived }
+func (r *todoResolver) Version() int32          { return int32(r.t.Version) }
+
+func (r *todoResolver) StartedAt() *graphql.Time {
+	if r.t.StartedAt == nil {
+		return nil
+	}
+	return &graphql.Time{Time: *r.t.StartedAt}
+}
+
+func (r *todoResolver) CompletedAt() *graphql.Time {
+	if r.t.CompletedAt == nil {
+		return nil
+	}
+	return &graphql.Time{Time: *r.t.CompletedAt}
+}
+
+func (r *todoResolver) Tags() []string {
+	if r.t.Tags == nil {
+		return []string{}
+	}
+	return r.t.Tags
+}
+
+func (r *todoResolver) Category(ctx context.Context) (*categoryResolver, error) {
+	if r.t.CategoryID == 0 {
+		return nil, nil
+	}
+	category, err := loaderFrom(ctx).category(r.t.CategoryID)
+	if err != nil {
+		return nil, r.root.gqlError(err)
+	}
+	if category == nil {
+		return nil, nil
+	}
+	return &categoryResolver{r.root, *category}, nil
+}
+
+type categoryResolver struct {
+	root *gqlResolver
+	c    models.Category
+}
+
+func (r *categoryResolver) ID() graphql.ID      { return gqlID(r.c.ID) }
+func (r *categoryResolver) Name() string        { return r.c.Name }
+func (r *categoryResolver) Description() string { return r.c.Description }
+
+func (r *categoryResolver) Todos(ctx context.Context, args struct{ Archived bool }) ([]*todoResolver, error) {
+	todos, err := loaderFrom(ctx).todosOf(r.c.ID, args.Archived)
+	if err != nil {
+		return nil, r.root.gqlError(err)
+	}
+	return r.root.todoResolvers(todos), nil
+}
+
+func (r *categoryResolver) Stats(ctx context.Context) (*statsResolver, error) {
+	s, err := loaderFrom(ctx).statsOf(r.c.ID)
+	if err != nil {
+		return nil, r.root.gqlError(err)
+	}
+	return &statsResolver{s}, nil
+}
+
+type todoConnectionResolver struct {
+	nodes       []*todoResolver
+	totalCount  int32
+	endCursor   *string
+	hasNextPage bool
+}
+
+func (r *todoConnectionResolver) Nodes() []*todoResolver { return r.nodes }
+func (r *todoConnectionResolver) TotalCount() int32      { return r.totalCount }
+func (r *todoConnectionResolver) PageInfo() *pageInfoResolver {
+	return &pageInfoResolver{r.endCursor, r.hasNextPage}
+}
+
+type pageInfoResolver struct {
+	endCursor   *string
+	hasNextPage bool
+}
+
+func (r *pageInfoResolver) EndCursor() *string { return r.endCursor }
+func (r *pageInfoResolver) HasNextPage() bool  { return r.hasNextPage }
+
+type gqlStats struct {
+	total, open, done, archived, overdue int32
+}
+
+type statsResolver struct {
+	s gqlStats
+}
+
+func (r *statsResolver) Total() int32    { return r.s.total }
+func (r *statsResolver) Open() int32     { return r.s.open }
+func (r *statsResolver) Done() int32     { return r.s.done }
+func (r *statsResolver) Archived() int32 { return r.s.archived }
+func (r *statsResolver) Overdue() int32  { return r.s.overdue }
@@ -0,0 +1,547 @@
+package handlers
+
+import (
+	"bufio"
+	"database/sql"
+	"encoding/csv"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/furkankorkmaz309/todo-api/internal/app"
+	"github.com/furkankorkmaz309/todo-api/internal/importer"
+	"github.com/furkankorkmaz309/todo-api/internal/models"
+	"github.com/furkankorkmaz309/todo-api/internal/store"
+	"github.com/go-chi/chi"
+)
+
+const maxImportSize = 10 << 20
+
+// importRow is one category or todo read from an import file. Rows that
+// could not be parsed keep the parse error and are reported, not imported.
+type importRow struct {
+	row      int
+	category *models.Category
+	todo     *exportTodo
+	err      error
+}
+
+type importRowError struct {
+	Row   int    `json:"row"`
+	Error string `json:"error"`
+}
+
+type ImportReport struct {
+	DryRun            bool             `json:"dry_run"`
+	CategoriesCreated int              `json:"categories_created"`
+	TodosImported     int              `json:"todos_imported"`
+	Errors            []importRowError `json:"errors"`
+}
+
+func (report ImportReport) String() string {
+	if report.DryRun {
+		return fmt.Sprintf("Dry run: would import %d todos and %d categories, %d rows failed.", report.TodosImported, report.CategoriesCreated, len(report.Errors))
+	}
+	return fmt.Sprintf("Imported %d todos and %d categories, %d rows failed.", report.TodosImported, report.CategoriesCreated, len(report.Errors))
+}
+
+type ImportOptions struct {
+	DryRun           bool
+	CreateCategories bool
+	// DefaultCategory names the category of todos that have none.
+	DefaultCategory string
+	// DefaultDue is used for todos without a due date when not zero.
+	DefaultDue time.Time
+}
+
+// Import reads categories and todos in any of the export formats. Todos are
+// validated like CreateTodo and matched to categories by name; with
+// create_categories=true unknown names are created. dry_run=true reports
+// what would happen without keeping any changes.
+func Import(app *app.App) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		format := r.URL.Query().Get("format")
+		if format == "" {
+			format = formatFromContentType(r.Header.Get("Content-Type"))
+		}
+		importFormat(app, w, r, format)
+	}
+}
+
+// ImportFrom is Import for the formats of the importer package as well as
+// the export formats, named by the format URL parameter. Items without a due
+// date get one default_due_days (7 unless given) from now.
+func ImportFrom(app *app.App) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		importFormat(app, w, r, chi.URLParam(r, "format"))
+	}
+}
+
+func importFormat(app *app.App, w http.ResponseWriter, r *http.Request, format string) {
+	q := r.URL.Query()
+
+	var opts ImportOptions
+	var err error
+	opts.DryRun, err = parseBoolParam(q.Get("dry_run"))
+	if err != nil {
+		respondError(w, app.ErrorLog, http.StatusBadRequest, "Invalid dry_run", err)
+		return
+	}
+	opts.CreateCategories, err = parseBoolParam(q.Get("create_categories"))
+	if err != nil {
+		respondError(w, app.ErrorLog, http.StatusBadRequest, "Invalid create_categories", err)
+		return
+	}
+	opts.DefaultCategory = q.Get("category")
+
+	if v := q.Get("default_due_days"); v != "" {
+		days, err := strconv.Atoi(v)
+		if err != nil || days < 0 {
+			respondError(w, app.ErrorLog, http.StatusBadRequest, "Invalid default_due_days", err)
+			return
+		}
+		if days > 0 {
+			opts.DefaultDue = time.Now().AddDate(0, 0, days)
+		}
+	} else if _, ok := importer.Get(format); ok {
+		opts.DefaultDue = time.Now().AddDate(0, 0, 7)
+	}
+
+	body := http.MaxBytesReader(w, r.Body, maxImportSize)
+	report, err := ImportFile(app.DB, format, body, opts)
+	if err != nil {
+		respondAPIError(w, app.ErrorLog, err)
+		return
+	}
+
+	respondJSON(w, http.StatusOK, report, report.String())
+}
+
+// ImportFile imports r, written in one of the export formats (csv, json,
+// ndjson) or a format registered with the importer package. Rows that fail
+// validation are skipped and listed in the report; the returned error is
+// only set when nothing could be imported at all.
+func ImportFile(db *sql.DB, format string, r io.Reader, opts ImportOptions) (ImportReport, error) {
+	report := ImportReport{DryRun: opts.DryRun, Errors: []importRowError{}}
+
+	// Dates without a time zone are read in the time zone preference.
+	loc, err := store.Location(db)
+	if err != nil {
+		return report, err
+	}
+	rows, err := parseImport(format, r, loc)
+	if err != nil {
+		return report, err
+	}
+
+	tx, err := db.Begin()
+	if err != nil {
+		return report, store.NewError(http.StatusInternalServerError, "Database error", err)
+	}
+	defer tx.Rollback()
+
+	categories, err := categoryIDsByName(tx)
+	if err != nil {
+		return report, store.NewError(http.StatusInternalServerError, "Database error", err)
+	}
+
+	for _, row := range rows {
+		if row.err != nil {
+			report.Errors = append(report.Errors, importRowError{Row: row.row, Error: row.err.Error()})
+			continue
+		}
+
+		_, err = tx.Exec(`SAVEPOINT import_row`)
+		if err != nil {
+			return report, store.NewError(http.StatusInternalServerError, "Database error", err)
+		}
+
+		var created string
+		if row.category != nil {
+			created, err = importCategory(tx, categories, row.category)
+		} else {
+			created, err = importTodo(tx, categories, opts, row.todo)
+		}
+
+		if err != nil {
+			report.Errors = append(report.Errors, importRowError{Row: row.row, Error: err.Error()})
+			if created != "" {
+				delete(categories, created)
+			}
+			_, err = tx.Exec(`ROLLBACK TO import_row; RELEASE import_row`)
+		} else {
+			if created != "" {
+				report.CategoriesCreated++
+			}
+			if row.todo != nil {
+				report.TodosImported++
+			}
+			_, err = tx.Exec(`RELEASE import_row`)
+		}
+		if err != nil {
+			return report, store.NewError(http.StatusInternalServerError, "Database error", err)
+		}
+	}
+
+	if !opts.DryRun {
+		err = tx.Commit()
+		if err != nil {
+			return report, store.NewError(http.StatusInternalServerError, "Database error", err)
+		}
+	}
+	return report, nil
+}
+
+func parseImport(format string, r io.Reader, loc *time.Location) ([]importRow, error) {
+	var rows []importRow
+	var err error
+
+	switch format {
+	case "json":
+		rows, err = parseJSONImport(r)
+	case "ndjson":
+		rows, err = parseNDJSONImport(r)
+	case "csv":
+		rows, err = parseCSVImport(r, loc)
+	default:
+		parser, ok := importer.Get(format)
+		if !ok {
+			formats := append([]string{"csv", "json", "ndjson"}, importer.Formats()...)
+			return nil, store.NewError(http.StatusBadRequest, "Format must be one of "+strings.Join(formats, ", "), nil)
+		}
+
+		var items []importer.Item
+		items, err = parser.Parse(r)
+		for _, item := range items {
+			rows = append(rows, importRow{row: item.Line, todo: todoFromItem(item)})
+		}
+	}
+	if err != nil {
+		return nil, store.NewError(http.StatusBadRequest, "Invalid import file: "+err.Error(), err)
+	}
+	return rows, nil
+}
+
+// todoFromItem fills in what foreign formats leave out: the title doubles
+// as content and unknown priorities become 3.
+func todoFromItem(item importer.Item) *exportTodo {
+	todo := &exportTodo{Category: item.Category}
+	todo.Title = item.Title
+	todo.Content = item.Content
+	if strings.TrimSpace(todo.Content) == "" {
+		todo.Content = item.Title
+	}
+	todo.Priority = item.Priority
+	if todo.Priority == 0 {
+		todo.Priority = 3
+	}
+	todo.DueDate = item.DueDate
+	todo.CreatedAt = item.CreatedAt
+	todo.IsDone = item.Done
+	todo.CompletedAt = item.CompletedAt
+	todo.Archived = item.Archived
+	todo.Tags = item.Tags
+	return todo
+}
+
+func categoryIDsByName(tx *sql.Tx) (map[string]int, error) {
+	rows, err := tx.Query(`SELECT id, name FROM category`)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	categories := make(map[string]int)
+	for rows.Next() {
+		var id int
+		var name string
+		err = rows.Scan(&id, &name)
+		if err != nil {
+			return nil, err
+		}
+		categories[name] = id
+	}
+	return categories, rows.Err()
+}
+
+// importCategory creates the category unless one with the same name exists
+// and returns the name when it was created.
+func importCategory(q store.Querier, categories map[string]int, category *models.Category) (string, error) {
+	_, ok := categories[category.Name]
+	if ok {
+		return "", nil
+	}
+
+	err := store.InsertCategory(q, category)
+	if err != nil {
+		return "", err
+	}
+	categories[category.Name] = category.ID
+	return category.Name, nil
+}
+
+// importTodo inserts the todo, keeping its done, archived and timestamp
+// fields, and returns the name of the category it created, if any.
+func importTodo(q store.Querier, categories map[string]int, opts ImportOptions, todo *exportTodo) (string, error) {
+	if strings.TrimSpace(todo.Category) == "" {
+		todo.Category = opts.DefaultCategory
+	}
+	if strings.TrimSpace(todo.Category) == "" {
+		return "", fmt.Errorf("Category is blank")
+	}
+	if todo.DueDate.IsZero() {
+		todo.DueDate = opts.DefaultDue
+	}
+
+	var created string
+	id, ok := categories[todo.Category]
+	if !ok {
+		if !opts.CreateCategories {
+			return "", fmt.Errorf("No category named %q", todo.Category)
+		}
+		category := models.Category{Name: todo.Category}
+		err := store.InsertCategory(q, &category)
+		if err != nil {
+			return "", err
+		}
+		id = category.ID
+		categories[category.Name] = id
+		created = category.Name
+	}
+	todo.CategoryID = id
+
+	err := store.ValidateImportedTodo(q, &todo.Todo)
+	if err != nil {
+		return created, err
+	}
+
+	if todo.CreatedAt.IsZero() {
+		todo.CreatedAt = time.Now()
+	}
+	// Exports from before statuses only have is_done.
+	if todo.Status == "" {
+		todo.Status = store.StatusTodo
+		if todo.IsDone {
+			todo.Status = store.StatusDone
+		}
+	} else if !store.IsStatus(todo.Status) {
+		return created, store.NewError(http.StatusBadRequest, "Status must be one of "+strings.Join(store.TodoStatuses, ", "), nil)
+	}
+	todo.IsDone = todo.Status == store.StatusDone
+	if todo.Status == store.StatusTodo {
+		todo.StartedAt = nil
+	}
+	if !todo.IsDone {
+		todo.CompletedAt = nil
+	}
+	var startedAt, completedAt *time.Time
+	if todo.StartedAt != nil {
+		utc := todo.StartedAt.UTC()
+		startedAt = &utc
+	}
+	if todo.CompletedAt != nil {
+		utc := todo.CompletedAt.UTC()
+		completedAt = &utc
+	}
+
+	query := `INSERT INTO todo(title, content, priority, created_at, due_date, all_day, status, done, archived, category_id, started_at, completed_at) VALUES(?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?)`
+	result, err := q.Exec(query, todo.Title, todo.Content, todo.Priority, todo.CreatedAt.UTC(), todo.DueDate.UTC(), todo.AllDay, todo.Status, todo.IsDone, todo.Archived, todo.CategoryID, startedAt, completedAt)
+	if err != nil {
+		return created, store.NewError(http.StatusInternalServerError, "Insert failed", err)
+	}
+	todoID, err := result.LastInsertId()
+	if err != nil {
+		return created, store.NewError(http.StatusInternalServerError, "Failed to retrieve inserted ID", err)
+	}
+	return created, store.SetTags(q, int(todoID), 0, todo.Tags)
+}
+
+func parseBoolParam(v string) (bool, error) {
+	if v == "" {
+		return false, nil
+	}
+	return strconv.ParseBool(v)
+}
+
+func formatFromContentType(contentType string) string {
+	switch {
+	case strings.HasPrefix(contentType, "text/csv"):
+		return "csv"
+	case strings.HasPrefix(contentType, "application/x-ndjson"):
+		return "ndjson"
+	default:
+		return "json"
+	}
+}
+
+func parseJSONImport(r io.Reader) ([]importRow, error) {
+	var input struct {
+		Categories []models.Category `json:"categories"`
+		Todos      []exportTodo      `json:"todos"`
+	}
+	err := json.NewDecoder(r).Decode(&input)
+	if err != nil {
+		return nil, err
+	}
+
+	var rows []importRow
+	for i := range input.Categories {
+		rows = append(rows, importRow{row: len(rows) + 1, category: &input.Categories[i]})
+	}
+	for i := range input.Todos {
+		rows = append(rows, importRow{row: len(rows) + 1, todo: &input.Todos[i]})
+	}
+	return rows, nil
+}
+
+func parseNDJSONImport(r io.Reader) ([]importRow, error) {
+	var rows []importRow
+
+	scanner := bufio.NewScanner(r)
+	scanner.Buffer(make([]byte, 0, 64*1024), maxImportSize)
+	line := 0
+	for scanner.Scan() {
+		line++
+		if strings.TrimSpace(scanner.Text()) == "" {
+			continue
+		}
+
+		var record struct {
+			Type string          `json:"type"`
+			Data json.RawMessage `json:"data"`
+		}
+		row := importRow{row: line}
+		row.err = json.Unmarshal(scanner.Bytes(), &record)
+		if row.err == nil {
+			switch record.Type {
+			case "category":
+				row.category = &models.Category{}
+				row.err = json.Unmarshal(record.Data, row.category)
+			case "todo":
+				row.todo = &exportTodo{}
+				row.err = json.Unmarshal(record.Data, row.todo)
+			default:
+				row.err = fmt.Errorf("Unknown record type %q", record.Type)
+			}
+		}
+		rows = append(rows, row)
+	}
+	return rows, scanner.Err()
+}
+
+func parseCSVImport(r io.Reader, loc *time.Location) ([]importRow, error) {
+	reader := csv.NewReader(r)
+	reader.FieldsPerRecord = -1
+
+	header, err := reader.Read()
+	if err != nil {
+		return nil, fmt.Errorf("missing header row: %v", err)
+	}
+	columns := make(map[string]int)
+	for i, name := range header {
+		columns[strings.TrimSpace(name)] = i
+	}
+	if _, ok := columns["type"]; !ok {
+		return nil, fmt.Errorf("missing type column")
+	}
+
+	var rows []importRow
+	line := 1
+	for {
+		record, err := reader.Read()
+		if err == io.EOF {
+			break
+		}
+		line++
+		if err != nil {
+			rows = append(rows, importRow{row: line, err: err})
+			if _, ok := err.(*csv.ParseError); ok {
+				continue
+			}
+			return nil, err
+		}
+
+		field := func(name string) string {
+			i, ok := columns[name]
+			if !ok || i >= len(record) {
+				return ""
+			}
+			return strings.TrimSpace(record[i])
+		}
+
+		row := importRow{row: line}
+		switch field("type") {
+		case "category":
+			row.category = &models.Category{Name: field("name"), Description: field("description")}
+		case "todo":
+			row.todo, row.err = parseCSVTodo(field, loc)
+		default:
+			row.err = fmt.Errorf("Unknown record type %q", field("type"))
+		}
+		rows = append(rows, row)
+	}
+	return rows, nil
+}
+
+func parseCSVTodo(field func(string) string, loc *time.Location) (*exportTodo, error) {
+	todo := &exportTodo{Category: field("category")}
+	todo.Title = field("title")
+	todo.Content = field("content")
+
+	var err error
+	if v := field("priority"); v != "" {
+		todo.Priority, err = strconv.Atoi(v)
+		if err != nil {
+			return nil, fmt.Errorf("Invalid priority %q", v)
+		}
+	}
+
+	if v := field("due_date"); v != "" {
+		todo.DueDate, err = store.ParseDate(v, loc)
+		if err != nil {
+			return nil, fmt.Errorf("Invalid due_date %q", v)
+		}
+	}
+	if v := field("created_at"); v != "" {
+		todo.CreatedAt, err = store.ParseDate(v, loc)
+		if err != nil {
+			return nil, fmt.Errorf("Invalid created_at %q", v)
+		}
+	}
+	if v := field("started_at"); v != "" {
+		startedAt, err := store.ParseDate(v, loc)
+		if err != nil {
+			return nil, fmt.Errorf("Invalid started_at %q", v)
+		}
+		todo.StartedAt = &startedAt
+	}
+	if v := field("completed_at"); v != "" {
+		completedAt, err := store.ParseDate(v, loc)
+		if err != nil {
+			return nil, fmt.Errorf("Invalid completed_at %q", v)
+		}
+		todo.CompletedAt = &completedAt
+	}
+
+	todo.AllDay, err = parseBoolParam(field("all_day"))
+	if err != nil {
+		return nil, fmt.Errorf("Invalid all_day %q", field("all_day"))
+	}
+	todo.Status = field("status")
+	todo.IsDone, err = parseBoolParam(field("is_done"))
+	if err != nil {
+		return nil, fmt.Errorf("Invalid is_done %q", field("is_done"))
+	}
+	todo.Archived, err = parseBoolParam(field("archived"))
+	if err != nil {
+		return nil, fmt.Errorf("Invalid archived %q", field("archived"))
+	}
+	if v := field("tags"); v != "" {
+		todo.Tags = strings.Split(v, ",")
+	}
+	return todo, nil
+}
@@ -0,0 +1,190 @@
+package handlers
+
+import (
+	"encoding/json"
+	"errors"
+	"net/http"
+	"strings"
+	"time"
+
+	"github.com/furkankorkmaz309/todo-api/internal/app"
+	"github.com/furkankorkmaz309/todo-api/internal/live"
+	"github.com/furkankorkmaz309/todo-api/internal/store"
+	"github.com/gorilla/websocket"
+)
+
+const (
+	liveWriteWait  = 10 * time.Second
+	livePongWait   = 60 * time.Second
+	livePingPeriod = livePongWait * 9 / 10
+)
+
+var upgrader = websocket.Upgrader{ReadBufferSize: 1024, WriteBufferSize: 1024}
+
+// LiveTodos upgrades to a WebSocket for shared editing. Clients send
+// {"type":"subscribe","category_id":N} (no category_id for all todos) and
+// get the todo and category events of that category plus "presence"
+// messages listing who is viewing it. {"type":"update","id":N,"version":V,
+// "todo":{...}} is read and validated as PATCH /todos/{id} reads its body,
+// in the time zone of the upgrade request; when V is no longer the current
+// version the reply is a "conflict" with the todo as it is now. ?name= is
+// shown to the other viewers.
+func LiveTodos(app *app.App) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		if app.Live == nil {
+			respondError(w, app.ErrorLog, http.StatusServiceUnavailable, "Live editing is disabled", nil)
+			return
+		}
+
+		name := strings.TrimSpace(r.URL.Query().Get("name"))
+		if name == "" {
+			name = "anonymous"
+		}
+		if len(name) > 30 {
+			respondError(w, app.ErrorLog, http.StatusBadRequest, "Name is too long", nil)
+			return
+		}
+		loc, err := requestLocation(app, r)
+		if err != nil {
+			respondAPIError(w, app.ErrorLog, err)
+			return
+		}
+
+		conn, err := upgrader.Upgrade(w, r, nil)
+		if err != nil {
+			// The upgrader has already replied with an error status.
+			app.ErrorLog.Printf("websocket upgrade: %v", err)
+			return
+		}
+		defer conn.Close()
+
+		client := app.Live.Register(name)
+		defer app.Live.Unregister(client)
+
+		go writeLive(conn, client)
+
+		conn.SetReadLimit(64 << 10)
+		conn.SetReadDeadline(time.Now().Add(livePongWait))
+		conn.SetPongHandler(func(string) error {
+			return conn.SetReadDeadline(time.Now().Add(livePongWait))
+		})
+
+		for {
+			var msg liveRequest
+			err = conn.ReadJSON(&msg)
+			var syntaxErr *json.SyntaxError
+			var typeErr *json.UnmarshalTypeError
+			if errors.As(err, &syntaxErr) || errors.As(err, &typeErr) {
+				app.Live.Reply(client, live.Message{Type: "error", Error: "Invalid JSON message"})
+				continue
+			}
+			if err != nil {
+				// Closed by the client or timed out.
+				return
+			}
+			handleLiveMessage(app, client, loc, msg)
+		}
+	}
+}
+
+// writeLive is the only writer of conn, as gorilla/websocket requires. It
+// stops when the hub closes the client's queue.
+func writeLive(conn *websocket.Conn, client *live.Client) {
+	ticker := time.NewTicker(livePingPeriod)
+	defer func() {
+		ticker.Stop()
+		conn.Close()
+	}()
+
+	for {
+		select {
+		case msg, ok := <-client.Send:
+			conn.SetWriteDeadline(time.Now().Add(liveWriteWait))
+			if !ok {
+				conn.WriteMessage(websocket.CloseMessage, []byte{})
+				return
+			}
+			err := conn.WriteJSON(msg)
+			if err != nil {
+				return
+			}
+		case <-ticker.C:
+			conn.SetWriteDeadline(time.Now().Add(liveWriteWait))
+			err := conn.WriteMessage(websocket.PingMessage, nil)
+			if err != nil {
+				return
+			}
+		}
+	}
+}
+
+// liveRequest is a message as clients send it, with the todo of an update
+// read as PATCH /todos/{id} reads its body.
+type liveRequest struct {
+	live.Message
+	Todo *todoBody `json:"todo"`
+}
+
+func handleLiveMessage(app *app.App, client *live.Client, loc *time.Location, msg liveRequest) {
+	room := live.AllCategories
+	if msg.CategoryID != nil {
+		room = *msg.CategoryID
+	}
+
+	switch msg.Type {
+	case "subscribe":
+		if room != live.AllCategories {
+			err := store.CheckCategory(app.DB, room)
+			if err != nil {
+				replyLiveError(app, client, msg.Ref, err)
+				return
+			}
+		}
+		app.Live.Join(client, room)
+	case "unsubscribe":
+		app.Live.Leave(client, room)
+	case "update":
+		if msg.ID == 0 || msg.Todo == nil {
+			app.Live.Reply(client, live.Message{Type: "error", Ref: msg.Ref, Error: "id and todo are required"})
+			return
+		}
+		if msg.Version == 0 {
+			app.Live.Reply(client, live.Message{Type: "error", Ref: msg.Ref, ID: msg.ID, Error: "version is required"})
+			return
+		}
+
+		newTodo, err := msg.Todo.resolveUpdate(app.DB, msg.ID, loc)
+		if err != nil {
+			replyLiveError(app, client, msg.Ref, err)
+			return
+		}
+		newTodo.Version = msg.Version
+		todo, responseString, err := store.UpdateTodo(app.DB, msg.ID, newTodo)
+		if err != nil {
+			var apiErr *store.Error
+			if errors.As(err, &apiErr) && apiErr.Status == http.StatusConflict {
+				current, selectErr := store.SelectTodo(app.DB, msg.ID)
+				if selectErr == nil {
+					app.Live.Reply(client, live.Message{Type: "conflict", Ref: msg.Ref, ID: msg.ID, Version: current.Version, Todo: &current, Error: apiErr.Msg})
+					return
+				}
+				err = selectErr
+			}
+			replyLiveError(app, client, msg.Ref, err)
+			return
+		}
+		PublishTodoUpdate(app, todo, responseString)
+
+		app.Live.Reply(client, live.Message{Type: "ack", Ref: msg.Ref, ID: todo.ID, Version: todo.Version, Todo: &todo})
+	default:
+		app.Live.Reply(client, live.Message{Type: "error", Ref: msg.Ref, Error: "Unknown message type " + msg.Type})
+	}
+}
+
+func replyLiveError(app *app.App, client *live.Client, ref string, err error) {
+	storeErr := store.AsError(err)
+	if storeErr.Err != nil {
+		app.ErrorLog.Println(storeErr.Err)
+	}
+	app.Live.Reply(client, live.Message{Type: "error", Ref: ref, Error: storeErr.Msg})
+}
@@ -1,13 +1,22 @@
 package handlers
 
 import (
+	"bufio"
+	"bytes"
+	"crypto/sha256"
+	"encoding/hex"
+	"errors"
 	"fmt"
+	"io"
 	"net"
 	"net/http"
 	"runtime/debug"
+	"strings"
 	"time"
 
 	"github.com/furkankorkmaz309/todo-api/internal/app"
+	"github.com/furkankorkmaz309/todo-api/internal/idempotency"
+	"github.com/furkankorkmaz309/todo-api/internal/openapi"
 )
 
 func LogRequest(app *app.App) func(http.Handler) http.Handler {
@@ -72,3 +81,172 @@ func LimitRequest(app *app.App) func(next http.Handler) http.Handler {
 		})
 	}
 }
+
+// Idempotent replays the stored response when a request repeats an
+// Idempotency-Key header seen within the store's TTL.
+func Idempotent(app *app.App) func(next http.Handler) http.Handler {
+	const maxKeyLength = 255
+	const maxBodySize = 1 << 20
+
+	return func(next http.Handler) http.Handler {
+		return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			key := r.Header.Get("Idempotency-Key")
+			if key == "" || app.Idempotency == nil {
+				next.ServeHTTP(w, r)
+				return
+			}
+			if len(key) > maxKeyLength {
+				respondError(w, app.ErrorLog, http.StatusBadRequest, "Idempotency-Key is too long", nil)
+				return
+			}
+
+			body, err := io.ReadAll(http.MaxBytesReader(w, r.Body, maxBodySize))
+			if err != nil {
+				respondError(w, app.ErrorLog, http.StatusBadRequest, "Could not read request body", err)
+				return
+			}
+			r.Body = io.NopCloser(bytes.NewReader(body))
+
+			sum := sha256.Sum256([]byte(r.Method + " " + r.URL.Path + "\n" + string(body)))
+			hash := hex.EncodeToString(sum[:])
+
+			rec, err := app.Idempotency.Reserve(key, hash)
+			if errors.Is(err, idempotency.ErrKeyMismatch) {
+				respondError(w, app.ErrorLog, http.StatusUnprocessableEntity, "Idempotency-Key was already used with a different request", nil)
+				return
+			}
+			if errors.Is(err, idempotency.ErrInProgress) {
+				respondError(w, app.ErrorLog, http.StatusConflict, "A request with this Idempotency-Key is still in progress", nil)
+				return
+			}
+			if err != nil {
+				respondError(w, app.ErrorLog, http.StatusInternalServerError, "Idempotency store error", err)
+				return
+			}
+
+			if rec != nil {
+				w.Header().Set("Content-Type", "application/json")
+				w.Header().Set("Idempotent-Replayed", "true")
+				w.WriteHeader(rec.Status)
+				w.Write(rec.Body)
+				return
+			}
+
+			rw := &recordingWriter{ResponseWriter: w, status: http.StatusOK}
+			defer func() {
+				// Server errors and panics are not stored so the client can
+				// retry them; the panic is passed on to RecoverPanic.
+				p := recover()
+				if p != nil || rw.status >= http.StatusInternalServerError {
+					err = app.Idempotency.Release(key)
+				} else {
+					err = app.Idempotency.Save(key, rw.status, rw.body.Bytes())
+				}
+				if err != nil {
+					app.ErrorLog.Printf("idempotency key %q: %v", key, err)
+				}
+				if p != nil {
+					panic(p)
+				}
+			}()
+
+			next.ServeHTTP(rw, r)
+		})
+	}
+}
+
+// ValidateRequest checks requests against the OpenAPI document before the
+// handlers see them: path, query and header parameters, and JSON bodies,
+// which may not carry fields the document does not list. With
+// app.ValidateResponses set, JSON responses are checked as well and any
+// drift from the document is logged.
+func ValidateRequest(app *app.App, doc *openapi.Document) func(next http.Handler) http.Handler {
+	const maxBodySize = 10 << 20
+
+	return func(next http.Handler) http.Handler {
+		return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			op, pathParams := doc.Find(r.Method, r.URL.Path)
+			if op == nil {
+				// Unknown routes get the router's 404 or 405.
+				next.ServeHTTP(w, r)
+				return
+			}
+
+			problems := doc.ValidateParams(op, pathParams, r)
+
+			if op.RequestBody != nil {
+				media, ok := op.RequestBody.Content["application/json"]
+				if ok && media.Schema != nil {
+					body, err := io.ReadAll(http.MaxBytesReader(w, r.Body, maxBodySize))
+					if err != nil {
+						respondError(w, app.ErrorLog, http.StatusBadRequest, "Could not read request body", err)
+						return
+					}
+					r.Body = io.NopCloser(bytes.NewReader(body))
+
+					if len(bytes.TrimSpace(body)) == 0 {
+						if op.RequestBody.Required {
+							problems = append(problems, "body: is required")
+						}
+					} else {
+						problems = append(problems, doc.ValidateBody(media.Schema, body)...)
+					}
+				}
+			}
+
+			if len(problems) > 0 {
+				respondError(w, app.ErrorLog, http.StatusBadRequest, "Invalid request: "+strings.Join(problems, "; "), nil)
+				return
+			}
+
+			if !app.ValidateResponses || !doc.HasJSONSuccess(op) {
+				next.ServeHTTP(w, r)
+				return
+			}
+
+			rw := &recordingWriter{ResponseWriter: w, status: http.StatusOK}
+			next.ServeHTTP(rw, r)
+
+			for _, problem := range doc.ValidateResponse(op, rw.status, rw.Header().Get("Content-Type"), rw.body.Bytes()) {
+				app.ErrorLog.Printf("[RESPONSE DRIFT] %v %v: %s", r.Method, r.URL.Path, problem)
+			}
+		})
+	}
+}
+
+type recordingWriter struct {
+	http.ResponseWriter
+	status int
+	body   bytes.Buffer
+}
+
+func (rw *recordingWriter) WriteHeader(status int) {
+	rw.status = status
+	rw.ResponseWriter.WriteHeader(status)
+}
+
+func (rw *recordingWriter) Write(b []byte) (int, error) {
+	rw.body.Write(b)
+	return rw.ResponseWriter.Write(b)
+}
+
+// Flush and Hijack pass through, so that event streams and WebSocket
+// upgrades keep working when their responses are recorded.
+func (rw *recordingWriter) Flush() {
+	if f, ok := rw.ResponseWriter.(http.Flusher); ok {
+		f.Flush()
+	}
+}
+
+func (rw *recordingWriter) Hijack() (net.Conn, *bufio.ReadWriter, error) {
+	h, ok := rw.ResponseWriter.(http.Hijacker)
+	if !ok {
+		return nil, nil, fmt.Errorf("%T cannot be hijacked", rw.ResponseWriter)
+	}
+	return h.Hijack()
+}
+
+// Unwrap lets http.ResponseController reach the underlying writer.
+func (rw *recordingWriter) Unwrap() http.ResponseWriter {
+	return rw.ResponseWriter
+}
@@ -0,0 +1,31 @@
+package handlers
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"testing"
+)
+
+func TestRecordingWriterPassesThrough(t *testing.T) {
+	rec := httptest.NewRecorder()
+	rw := &recordingWriter{ResponseWriter: rec, status: http.StatusOK}
+
+	var w http.ResponseWriter = rw
+	f, ok := w.(http.Flusher)
+	if !ok {
+		t.Fatal("recordingWriter is not an http.Flusher")
+	}
+	w.Write([]byte("data: 1\n\n"))
+	f.Flush()
+	if !rec.Flushed {
+		t.Error("Flush did not reach the underlying writer")
+	}
+	if rw.body.String() != "data: 1\n\n" {
+		t.Errorf("recorded %q", rw.body.String())
+	}
+
+	// httptest.ResponseRecorder cannot be hijacked, so neither can its wrapper.
+	if _, _, err := rw.Hijack(); err == nil {
+		t.Error("Hijack of a writer that cannot be hijacked succeeded")
+	}
+}
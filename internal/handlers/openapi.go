@@ -0,0 +1,39 @@
+package handlers
+
+import (
+	"encoding/json"
+	"net/http"
+
+	"github.com/furkankorkmaz309/todo-api/internal/openapi"
+)
+
+// OpenAPI serves the API description. It is served as is, without the
+// response envelope, so that tools can load it directly.
+func OpenAPI(doc *openapi.Document) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("Content-Type", "application/json")
+		json.NewEncoder(w).Encode(doc)
+	}
+}
+
+// SwaggerUI serves a page for browsing and trying out /openapi.json.
+func SwaggerUI(w http.ResponseWriter, r *http.Request) {
+	w.Header().Set("Content-Type", "text/html; charset=utf-8")
+	w.Write([]byte(swaggerPage))
+}
+
+const swaggerPage = `<!DOCTYPE html>
+<html>
+<head>
+<title>todo-api</title>
+<link rel="stylesheet" href="https://unpkg.com/swagger-ui-dist@5/swagger-ui.css">
+</head>
+<body style="margin: 0">
+<div id="swagger-ui"></div>
+<script src="https://unpkg.com/swagger-ui-dist@5/swagger-ui-bundle.js"></script>
+<script>
+SwaggerUIBundle({ url: '/openapi.json', dom_id: '#swagger-ui' });
+</script>
+</body>
+</html>
+`
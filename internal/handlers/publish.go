@@ -0,0 +1,37 @@
+package handlers
+
+import (
+	"strings"
+
+	"github.com/furkankorkmaz309/todo-api/internal/app"
+	"github.com/furkankorkmaz309/todo-api/internal/events"
+	"github.com/furkankorkmaz309/todo-api/internal/models"
+	"github.com/furkankorkmaz309/todo-api/internal/store"
+)
+
+// PublishTodoUpdate announces a todo changed by store.UpdateTodo. changed is
+// its message, which names the fields that were updated.
+func PublishTodoUpdate(app *app.App, todo models.Todo, changed string) {
+	app.Events.Publish(events.TodoUpdated, todo)
+	if todo.IsDone && strings.Contains(changed, "is_done") {
+		app.Events.Publish(events.TodoCompleted, todo)
+	}
+}
+
+// PublishTodos announces eventType for each todo ID, reading the todos
+// again so subscribers get their current state.
+func PublishTodos(app *app.App, eventType string, ids []int) {
+	for _, id := range ids {
+		if eventType == events.TodoDeleted {
+			app.Events.Publish(eventType, events.Deleted{ID: id})
+			continue
+		}
+
+		todo, err := store.SelectTodo(app.DB, id)
+		if err != nil {
+			app.ErrorLog.Printf("publishing %s for todo %d: %v", eventType, id, err)
+			continue
+		}
+		app.Events.Publish(eventType, todo)
+	}
+}
@@ -0,0 +1,156 @@
+package handlers
+
+import (
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"strconv"
+	"time"
+
+	"github.com/furkankorkmaz309/todo-api/internal/app"
+	"github.com/furkankorkmaz309/todo-api/internal/duedate"
+	"github.com/furkankorkmaz309/todo-api/internal/models"
+	"github.com/furkankorkmaz309/todo-api/internal/store"
+	"github.com/go-chi/chi"
+)
+
+// GetReminders lists the latest reminders of every todo, newest first;
+// ?status= narrows it to pending, sent, failed or cancelled ones.
+func GetReminders(app *app.App) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		var reminders []models.Reminder
+		var err error
+		if status := r.URL.Query().Get("status"); status != "" {
+			reminders, err = store.ListReminders(app.DB, `r.status = ?`, status)
+		} else {
+			reminders, err = store.ListReminders(app.DB, "")
+		}
+		if err != nil {
+			respondAPIError(w, app.ErrorLog, err)
+			return
+		}
+		respondJSON(w, http.StatusOK, reminders, "Reminders listed successfully.")
+	}
+}
+
+// GetReminderChannels lists the channels reminders can be sent through,
+// which depend on how the server was started.
+func GetReminderChannels(app *app.App) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		if app.Reminders == nil {
+			respondJSON(w, http.StatusOK, []string{}, "Reminders are disabled.")
+			return
+		}
+		respondJSON(w, http.StatusOK, app.Reminders.Channels(), "Channels listed successfully.")
+	}
+}
+
+func GetTodoReminders(app *app.App) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		id, err := strconv.Atoi(chi.URLParam(r, "id"))
+		if err != nil {
+			err = fmt.Errorf("an error occurred while converting string to integer: %v", err)
+			respondError(w, app.ErrorLog, http.StatusBadRequest, "Invalid ID", err)
+			return
+		}
+
+		_, err = store.SelectTodo(app.DB, id)
+		if err != nil {
+			respondAPIError(w, app.ErrorLog, err)
+			return
+		}
+
+		reminders, err := store.ListReminders(app.DB, `r.todo_id = ?`, id)
+		if err != nil {
+			respondAPIError(w, app.ErrorLog, err)
+			return
+		}
+		respondJSON(w, http.StatusOK, reminders, "Reminders listed successfully.")
+	}
+}
+
+// AddReminder sets a reminder on a todo. remind_at is read like due_date,
+// so "tomorrow 9am" works; offset_minutes instead counts back from the due
+// date, following it when it moves. The channel defaults to log.
+func AddReminder(app *app.App) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		id, err := strconv.Atoi(chi.URLParam(r, "id"))
+		if err != nil {
+			err = fmt.Errorf("an error occurred while converting string to integer: %v", err)
+			respondError(w, app.ErrorLog, http.StatusBadRequest, "Invalid ID", err)
+			return
+		}
+
+		if app.Reminders == nil {
+			respondError(w, app.ErrorLog, http.StatusServiceUnavailable, "Reminders are disabled", nil)
+			return
+		}
+
+		loc, err := requestLocation(app, r)
+		if err != nil {
+			respondAPIError(w, app.ErrorLog, err)
+			return
+		}
+
+		var input struct {
+			RemindAt      string `json:"remind_at"`
+			OffsetMinutes *int   `json:"offset_minutes"`
+			Channel       string `json:"channel"`
+		}
+		err = json.NewDecoder(r.Body).Decode(&input)
+		if err != nil {
+			respondError(w, app.ErrorLog, http.StatusBadRequest, "Invalid JSON body", err)
+			return
+		}
+
+		reminder := models.Reminder{TodoID: id, OffsetMinutes: input.OffsetMinutes, Channel: input.Channel}
+		if input.RemindAt != "" {
+			due, err := duedate.Parse(input.RemindAt, time.Now().In(loc))
+			if err != nil {
+				respondError(w, app.ErrorLog, http.StatusBadRequest, "Invalid remind_at: "+err.Error(), nil)
+				return
+			}
+			reminder.RemindAt = &due.Time
+		}
+		if reminder.Channel == "" {
+			reminder.Channel = "log"
+		}
+		if !app.Reminders.HasChannel(reminder.Channel) {
+			respondError(w, app.ErrorLog, http.StatusBadRequest, fmt.Sprintf("Channel must be one of %v", app.Reminders.Channels()), nil)
+			return
+		}
+
+		err = store.InsertReminder(app.DB, &reminder)
+		if err != nil {
+			respondAPIError(w, app.ErrorLog, err)
+			return
+		}
+		app.Reminders.Notify()
+
+		respondJSON(w, http.StatusCreated, reminder, "Reminder created successfully.")
+	}
+}
+
+func DeleteReminder(app *app.App) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		id, err := strconv.Atoi(chi.URLParam(r, "id"))
+		if err != nil {
+			err = fmt.Errorf("an error occurred while converting string to integer: %v", err)
+			respondError(w, app.ErrorLog, http.StatusBadRequest, "Invalid ID", err)
+			return
+		}
+		reminderID, err := strconv.Atoi(chi.URLParam(r, "reminderID"))
+		if err != nil {
+			err = fmt.Errorf("an error occurred while converting string to integer: %v", err)
+			respondError(w, app.ErrorLog, http.StatusBadRequest, "Invalid reminder ID", err)
+			return
+		}
+
+		err = store.DeleteReminder(app.DB, id, reminderID)
+		if err != nil {
+			respondAPIError(w, app.ErrorLog, err)
+			return
+		}
+		respondSuccess(w, http.StatusOK, fmt.Sprintf("Reminder with ID %d deleted.", reminderID))
+	}
+}
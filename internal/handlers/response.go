@@ -4,6 +4,9 @@ import (
 	"encoding/json"
 	"log"
 	"net/http"
+
+	"github.com/furkankorkmaz309/todo-api/internal/app"
+	"github.com/furkankorkmaz309/todo-api/internal/store"
 )
 
 type APIResponse struct {
@@ -45,3 +48,33 @@ func respondSuccess(w http.ResponseWriter, status int, message string) {
 		Message: message,
 	})
 }
+
+func respondErrorWithData(w http.ResponseWriter, logger *log.Logger, status int, clientMsg string, data interface{}, err error) {
+	if logger != nil && err != nil {
+		logger.Printf("[ERROR %d] %s: %v", status, clientMsg, err)
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(status)
+
+	json.NewEncoder(w).Encode(APIResponse{
+		Success: false,
+		Data:    data,
+		Error:   clientMsg,
+	})
+}
+
+func respondAPIError(w http.ResponseWriter, logger *log.Logger, err error) {
+	storeErr := store.AsError(err)
+	respondError(w, logger, storeErr.Status, storeErr.Msg, storeErr.Err)
+}
+
+// parseTodoFilter reads the list filters from the query string, with dates
+// in the time zone of the request.
+func parseTodoFilter(app *app.App, r *http.Request) (store.Filter, error) {
+	loc, err := requestLocation(app, r)
+	if err != nil {
+		return store.Filter{}, err
+	}
+	return store.ParseFilter(r.URL.Query(), loc)
+}
@@ -0,0 +1,59 @@
+package handlers
+
+import (
+	"encoding/json"
+	"net/http"
+
+	"github.com/furkankorkmaz309/todo-api/internal/app"
+	"github.com/furkankorkmaz309/todo-api/internal/store"
+)
+
+func GetSettings(app *app.App) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		settings, err := store.GetSettings(app.DB)
+		if err != nil {
+			respondAPIError(w, app.ErrorLog, err)
+			return
+		}
+		respondJSON(w, http.StatusOK, settings, "Settings retrieved successfully.")
+	}
+}
+
+// PatchSettings changes the preferences named in the body. A timezone of ""
+// goes back to the server's time zone and empty transitions to the default
+// ones.
+func PatchSettings(app *app.App) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		var input struct {
+			Timezone    *string              `json:"timezone"`
+			Transitions *map[string][]string `json:"transitions"`
+		}
+		err := json.NewDecoder(r.Body).Decode(&input)
+		if err != nil {
+			respondError(w, app.ErrorLog, http.StatusBadRequest, "Invalid JSON body", err)
+			return
+		}
+
+		if input.Timezone != nil {
+			err = store.SetTimezone(app.DB, *input.Timezone)
+			if err != nil {
+				respondAPIError(w, app.ErrorLog, err)
+				return
+			}
+		}
+		if input.Transitions != nil {
+			err = store.SetTransitions(app.DB, *input.Transitions)
+			if err != nil {
+				respondAPIError(w, app.ErrorLog, err)
+				return
+			}
+		}
+
+		settings, err := store.GetSettings(app.DB)
+		if err != nil {
+			respondAPIError(w, app.ErrorLog, err)
+			return
+		}
+		respondJSON(w, http.StatusOK, settings, "Settings updated successfully.")
+	}
+}
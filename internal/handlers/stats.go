@@ -0,0 +1,45 @@
+package handlers
+
+import (
+	"fmt"
+	"net/http"
+	"strconv"
+	"time"
+
+	"github.com/furkankorkmaz309/todo-api/internal/app"
+	"github.com/furkankorkmaz309/todo-api/internal/store"
+)
+
+// GetStats summarizes the todos for dashboards. ?days sets how many days,
+// 30 by default, the per-day series covers; days are those of the request's
+// time zone.
+func GetStats(app *app.App) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		loc, err := requestLocation(app, r)
+		if err != nil {
+			respondAPIError(w, app.ErrorLog, err)
+			return
+		}
+
+		days := 30
+		if v := r.URL.Query().Get("days"); v != "" {
+			days, err = strconv.Atoi(v)
+			if err != nil {
+				err = fmt.Errorf("an error occurred while converting string to integer: %v", err)
+				respondError(w, app.ErrorLog, http.StatusBadRequest, "Invalid days", err)
+				return
+			}
+			if days < 1 || days > 366 {
+				respondError(w, app.ErrorLog, http.StatusBadRequest, "days must be between 1 and 366", nil)
+				return
+			}
+		}
+
+		stats, err := store.ComputeStats(app.DB, time.Now(), loc, days)
+		if err != nil {
+			respondAPIError(w, app.ErrorLog, err)
+			return
+		}
+		respondJSON(w, http.StatusOK, stats, "Statistics computed successfully.")
+	}
+}
@@ -0,0 +1,145 @@
+package handlers
+
+import (
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/furkankorkmaz309/todo-api/internal/app"
+	"github.com/furkankorkmaz309/todo-api/internal/events"
+)
+
+const streamHeartbeat = 15 * time.Second
+
+// StreamEvents sends changes to todos and categories as Server-Sent Events.
+// ?types= takes the same filters as webhooks and ?category_id= narrows the
+// stream to one category. A reconnecting client gets what it missed after
+// Last-Event-ID (or ?last_event_id=, for the first connect); when that is
+// no longer in the event log a "reset" event tells it to reload instead.
+func StreamEvents(app *app.App) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		flusher, ok := w.(http.Flusher)
+		if !ok {
+			respondError(w, app.ErrorLog, http.StatusInternalServerError, "Streaming is not supported", nil)
+			return
+		}
+		if app.Events == nil {
+			respondError(w, app.ErrorLog, http.StatusServiceUnavailable, "Event stream is disabled", nil)
+			return
+		}
+
+		filter := []string{"*"}
+		if types := r.URL.Query().Get("types"); types != "" {
+			filter = strings.Split(types, ",")
+			for _, f := range filter {
+				if !events.ValidFilter(f) {
+					respondError(w, app.ErrorLog, http.StatusBadRequest, fmt.Sprintf("Unknown event %q", f), nil)
+					return
+				}
+			}
+		}
+
+		categoryID := 0
+		if v := r.URL.Query().Get("category_id"); v != "" {
+			id, err := strconv.Atoi(v)
+			if err != nil {
+				err = fmt.Errorf("an error occurred while converting string to integer: %v", err)
+				respondError(w, app.ErrorLog, http.StatusBadRequest, "Invalid category ID", err)
+				return
+			}
+			categoryID = id
+		}
+
+		lastID := app.Events.LastID()
+		resume := r.Header.Get("Last-Event-ID")
+		if resume == "" {
+			resume = r.URL.Query().Get("last_event_id")
+		}
+		if resume != "" {
+			id, err := strconv.ParseInt(resume, 10, 64)
+			if err != nil {
+				respondError(w, app.ErrorLog, http.StatusBadRequest, "Invalid Last-Event-ID", err)
+				return
+			}
+			lastID = id
+		}
+
+		backlog, complete, live, cancel := app.Events.Listen(lastID, 64)
+		defer cancel()
+
+		w.Header().Set("Content-Type", "text/event-stream")
+		w.Header().Set("Cache-Control", "no-cache")
+		w.Header().Set("Connection", "keep-alive")
+		w.Header().Set("X-Accel-Buffering", "no")
+		w.WriteHeader(http.StatusOK)
+
+		fmt.Fprintf(w, "retry: 3000\n\n")
+		if !complete {
+			fmt.Fprintf(w, "event: reset\ndata: {}\n\n")
+		}
+		for _, e := range backlog {
+			if wantEvent(filter, categoryID, e) {
+				err := writeEvent(w, e)
+				if err != nil {
+					return
+				}
+			}
+		}
+		flusher.Flush()
+
+		heartbeat := time.NewTicker(streamHeartbeat)
+		defer heartbeat.Stop()
+
+		for {
+			select {
+			case <-r.Context().Done():
+				return
+			case e, ok := <-live:
+				if !ok {
+					// Dropped for falling behind; the client reconnects
+					// with Last-Event-ID and catches up from the log.
+					return
+				}
+				if !wantEvent(filter, categoryID, e) {
+					continue
+				}
+				err := writeEvent(w, e)
+				if err != nil {
+					return
+				}
+				flusher.Flush()
+			case <-heartbeat.C:
+				_, err := fmt.Fprintf(w, ": ping\n\n")
+				if err != nil {
+					return
+				}
+				flusher.Flush()
+			}
+		}
+	}
+}
+
+// wantEvent applies the stream filters. Deletions only carry an ID, so
+// they pass the category filter and clients drop IDs they do not know.
+func wantEvent(filter []string, categoryID int, e events.Event) bool {
+	if !events.Match(filter, e.Type) {
+		return false
+	}
+	if categoryID == 0 {
+		return true
+	}
+	id, ok := events.CategoryOf(e)
+	return !ok || id == categoryID
+}
+
+func writeEvent(w http.ResponseWriter, e events.Event) error {
+	data, err := json.Marshal(e.Data)
+	if err != nil {
+		return err
+	}
+	_, err = fmt.Fprintf(w, "id: %d\nevent: %s\ndata: %s\n\n", e.ID, e.Type, data)
+	return err
+}
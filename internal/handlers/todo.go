@@ -1,29 +1,30 @@
 package handlers
 
 import (
-	"database/sql"
 	"encoding/json"
 	"fmt"
 	"net/http"
 	"strconv"
-	"strings"
-	"time"
 
 	"github.com/furkankorkmaz309/todo-api/internal/app"
+	"github.com/furkankorkmaz309/todo-api/internal/events"
 	"github.com/furkankorkmaz309/todo-api/internal/models"
+	"github.com/furkankorkmaz309/todo-api/internal/store"
 	"github.com/go-chi/chi"
 )
 
 func GetTodos(app *app.App, archived bool) http.HandlerFunc {
 	return func(w http.ResponseWriter, r *http.Request) {
-		var query string
-		if archived {
-			query = fmt.Sprintf(`SELECT id, title, content, priority, created_at, due_date, done, archived, category_id FROM todo WHERE archived = %v`, 1)
-		} else {
-			query = fmt.Sprintf(`SELECT id, title, content, priority, created_at, due_date, done, archived, category_id FROM todo WHERE archived = %v`, 0)
+		filter, err := parseTodoFilter(app, r)
+		if err != nil {
+			respondError(w, app.ErrorLog, http.StatusBadRequest, "Invalid filter: "+err.Error(), nil)
+			return
 		}
 
-		rows, err := app.DB.Query(query)
+		query := `SELECT ` + store.TodoColumns + ` FROM todo WHERE archived = ?` + filter.And()
+		args := append([]interface{}{archived}, filter.Args...)
+
+		rows, err := app.DB.Query(query, args...)
 		if err != nil {
 			respondError(w, app.ErrorLog, http.StatusInternalServerError, "Database error", err)
 			return
@@ -33,7 +34,7 @@ func GetTodos(app *app.App, archived bool) http.HandlerFunc {
 		var todos []models.Todo
 		for rows.Next() {
 			var todo models.Todo
-			err = rows.Scan(&todo.ID, &todo.Title, &todo.Content, &todo.Priority, &todo.CreatedAt, &todo.DueDate, &todo.IsDone, &todo.Archived, &todo.CategoryID)
+			err = store.ScanTodo(rows, &todo)
 			if err != nil {
 				respondError(w, app.ErrorLog, http.StatusInternalServerError, "Row could not read", err)
 				return
@@ -47,57 +48,31 @@ func GetTodos(app *app.App, archived bool) http.HandlerFunc {
 
 func CreateTodo(app *app.App) http.HandlerFunc {
 	return func(w http.ResponseWriter, r *http.Request) {
-		var todo models.Todo
-		err := json.NewDecoder(r.Body).Decode(&todo)
+		loc, err := requestLocation(app, r)
 		if err != nil {
-			respondError(w, app.ErrorLog, http.StatusBadRequest, "Invalid JSON", err)
-			return
-		}
-
-		if strings.TrimSpace(todo.Title) == "" {
-			respondError(w, app.ErrorLog, http.StatusBadRequest, "Title is blank", fmt.Errorf("blank title"))
-			return
-		}
-		if strings.TrimSpace(todo.Content) == "" {
-			respondError(w, app.ErrorLog, http.StatusBadRequest, "Content is blank", fmt.Errorf("blank content"))
-			return
-		}
-		if todo.Priority < 1 || todo.Priority > 5 {
-			respondError(w, app.ErrorLog, http.StatusBadRequest, "Priority must be between 1-5", nil)
+			respondAPIError(w, app.ErrorLog, err)
 			return
 		}
 
-		todo.CreatedAt = time.Now()
-		if todo.DueDate.Before(time.Now()) {
-			respondError(w, app.ErrorLog, http.StatusBadRequest, "Due date can't be in the past", nil)
-			return
-		}
-		todo.IsDone = false
-
-		row := app.DB.QueryRow(`SELECT id FROM category WHERE id = ?`, todo.CategoryID)
-		var tempID int
-		err = row.Scan(&tempID)
-		if err == sql.ErrNoRows {
-			respondError(w, app.ErrorLog, http.StatusBadRequest, fmt.Sprintf("No category with ID %v", todo.CategoryID), nil)
-			return
-		}
+		var input todoBody
+		err = json.NewDecoder(r.Body).Decode(&input)
 		if err != nil {
-			respondError(w, app.ErrorLog, http.StatusInternalServerError, "Database error", err)
+			respondError(w, app.ErrorLog, http.StatusBadRequest, "Invalid JSON", err)
 			return
 		}
 
-		query := `INSERT INTO todo(title, content, priority, created_at, due_date, done, category_id) VALUES(?, ?, ?, ?, ?, ?, ?)`
-		result, err := app.DB.Exec(query, todo.Title, todo.Content, todo.Priority, todo.CreatedAt, todo.DueDate, todo.IsDone, todo.CategoryID)
+		todo, err := input.resolve(loc)
 		if err != nil {
-			respondError(w, app.ErrorLog, http.StatusInternalServerError, "Insert failed", err)
+			respondAPIError(w, app.ErrorLog, err)
 			return
 		}
-		id, err := result.LastInsertId()
+
+		err = store.InsertTodo(app.DB, &todo)
 		if err != nil {
-			respondError(w, app.ErrorLog, http.StatusInternalServerError, "Failed to retrieve inserted ID", err)
+			respondAPIError(w, app.ErrorLog, err)
 			return
 		}
-		todo.ID = int(id)
+		app.Events.Publish(events.TodoCreated, todo)
 
 		respondJSON(w, http.StatusCreated, todo, "Todo created successfully.")
 	}
@@ -113,12 +88,9 @@ func GetTodo(app *app.App) http.HandlerFunc {
 			return
 		}
 
-		var todo models.Todo
-		query := `SELECT * FROM todo WHERE id = ?`
-		row := app.DB.QueryRow(query, id)
-		err = row.Scan(&todo.ID, &todo.Title, &todo.Content, &todo.Priority, &todo.CreatedAt, &todo.DueDate, &todo.IsDone, &todo.Archived, &todo.CategoryID)
+		todo, err := store.SelectTodo(app.DB, id)
 		if err != nil {
-			respondError(w, app.ErrorLog, http.StatusNotFound, fmt.Sprintf("No todo with ID %v", id), err)
+			respondAPIError(w, app.ErrorLog, err)
 			return
 		}
 
@@ -136,81 +108,33 @@ func PatchTodo(app *app.App) http.HandlerFunc {
 			return
 		}
 
-		var newTodo models.Todo
-		err = json.NewDecoder(r.Body).Decode(&newTodo)
+		loc, err := requestLocation(app, r)
 		if err != nil {
-			respondError(w, app.ErrorLog, http.StatusBadRequest, "Invalid JSON body", err)
+			respondAPIError(w, app.ErrorLog, err)
 			return
 		}
 
-		var oldTodo models.Todo
-		query := `SELECT id, title, content, priority, created_at, due_date, done, category_id FROM todo WHERE id = ?`
-		row := app.DB.QueryRow(query, id)
-		err = row.Scan(&oldTodo.ID, &oldTodo.Title, &oldTodo.Content, &oldTodo.Priority, &oldTodo.CreatedAt, &oldTodo.DueDate, &oldTodo.IsDone, &oldTodo.CategoryID)
-		if err == sql.ErrNoRows {
-			respondError(w, app.ErrorLog, http.StatusNotFound, fmt.Sprintf("No todo with ID %v", id), nil)
-			return
-		}
+		var input todoBody
+		err = json.NewDecoder(r.Body).Decode(&input)
 		if err != nil {
-			respondError(w, app.ErrorLog, http.StatusInternalServerError, "Database error", err)
-			return
-		}
-
-		responseString := "title, content, priority, due_date, is_done, category_id updated!"
-
-		if strings.TrimSpace(newTodo.Title) != "" {
-			oldTodo.Title = newTodo.Title
-		} else {
-			responseString = strings.ReplaceAll(responseString, "title, ", "")
-		}
-		if strings.TrimSpace(newTodo.Content) != "" {
-			oldTodo.Content = newTodo.Content
-		} else {
-			responseString = strings.ReplaceAll(responseString, "content, ", "")
-		}
-		if newTodo.Priority >= 1 && newTodo.Priority <= 5 {
-			oldTodo.Priority = newTodo.Priority
-		} else {
-			responseString = strings.ReplaceAll(responseString, "priority, ", "")
-		}
-		if newTodo.DueDate.After(time.Now()) {
-			oldTodo.DueDate = newTodo.DueDate
-		} else {
-			responseString = strings.ReplaceAll(responseString, "due_date, ", "")
-		}
-		if oldTodo.IsDone == newTodo.IsDone {
-			responseString = strings.ReplaceAll(responseString, "is_done, ", "")
-		}
-		oldTodo.IsDone = newTodo.IsDone
-		if newTodo.CategoryID != 0 {
-			oldTodo.CategoryID = newTodo.CategoryID
-		} else {
-			responseString = strings.ReplaceAll(responseString, "category_id ", "")
-		}
-
-		if responseString == "updated!" {
-			respondError(w, app.ErrorLog, http.StatusBadRequest, "No fields provided for update", nil)
+			respondError(w, app.ErrorLog, http.StatusBadRequest, "Invalid JSON body", err)
 			return
 		}
 
-		queryUpdate := `UPDATE todo SET title = ?, content = ?, priority = ?, due_date = ?, done = ?, category_id = ? WHERE id = ?`
-		result, err := app.DB.Exec(queryUpdate, oldTodo.Title, oldTodo.Content, oldTodo.Priority, oldTodo.DueDate, oldTodo.IsDone, oldTodo.CategoryID, id)
+		newTodo, err := input.resolveUpdate(app.DB, id, loc)
 		if err != nil {
-			respondError(w, app.ErrorLog, http.StatusInternalServerError, "Failed to update todo", err)
+			respondAPIError(w, app.ErrorLog, err)
 			return
 		}
 
-		rowsAffected, err := result.RowsAffected()
+		todo, responseString, err := store.UpdateTodo(app.DB, id, newTodo)
 		if err != nil {
-			respondError(w, app.ErrorLog, http.StatusInternalServerError, "Could not get update result", err)
-			return
-		}
-		if rowsAffected == 0 {
-			respondError(w, app.ErrorLog, http.StatusNotFound, fmt.Sprintf("No todo with ID %v", id), nil)
+			respondAPIError(w, app.ErrorLog, err)
 			return
 		}
+		PublishTodoUpdate(app, todo, responseString)
 
-		respondJSON(w, http.StatusOK, oldTodo, responseString)
+		respondJSON(w, http.StatusOK, todo, responseString)
 	}
 }
 
@@ -224,43 +148,74 @@ func DeleteTodo(app *app.App) http.HandlerFunc {
 			return
 		}
 
-		query := `DELETE FROM todo WHERE id = ?`
-		result, err := app.DB.Exec(query, id)
+		err = store.DeleteTodo(app.DB, id)
 		if err != nil {
-			respondError(w, app.ErrorLog, http.StatusInternalServerError, "Database error", err)
+			respondAPIError(w, app.ErrorLog, err)
 			return
 		}
+		app.Events.Publish(events.TodoDeleted, events.Deleted{ID: id})
+
+		respondSuccess(w, http.StatusOK, fmt.Sprintf("Todo with ID %d deleted.", id))
+	}
+}
 
-		rowsAffected, err := result.RowsAffected()
+func ArchiveFinished(app *app.App) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		filter, err := parseTodoFilter(app, r)
 		if err != nil {
-			respondError(w, app.ErrorLog, http.StatusInternalServerError, "Could not retrieve delete result", err)
+			respondError(w, app.ErrorLog, http.StatusBadRequest, "Invalid filter: "+err.Error(), nil)
 			return
 		}
 
-		if rowsAffected == 0 {
-			respondError(w, app.ErrorLog, http.StatusNotFound, fmt.Sprintf("No todo with ID %v", id), nil)
+		ids, err := ArchiveDone(app, filter.Where, filter.Args)
+		if err != nil {
+			respondError(w, app.ErrorLog, http.StatusInternalServerError, "Database update error", err)
 			return
 		}
 
-		respondSuccess(w, http.StatusOK, fmt.Sprintf("Todo with ID %d deleted.", id))
+		respondJSON(w, http.StatusOK, ids, fmt.Sprintf("Archived %d finished todos.", len(ids)))
 	}
 }
 
-func ArchiveFinished(app *app.App) http.HandlerFunc {
+func ArchiveTodo(app *app.App, archived bool) http.HandlerFunc {
 	return func(w http.ResponseWriter, r *http.Request) {
-		query := `UPDATE todo SET archived = 1 WHERE done = 1 AND archived = 0`
-		result, err := app.DB.Exec(query)
+		idStr := chi.URLParam(r, "id")
+		id, err := strconv.Atoi(idStr)
 		if err != nil {
-			respondError(w, app.ErrorLog, http.StatusInternalServerError, "Database update error", err)
+			err = fmt.Errorf("an error occurred while converting string to integer: %v", err)
+			respondError(w, app.ErrorLog, http.StatusBadRequest, "Invalid ID", err)
 			return
 		}
 
-		rowsAffected, err := result.RowsAffected()
+		err = store.ExecTodo(app.DB, id, `UPDATE todo SET archived = ? WHERE id = ?`, archived)
 		if err != nil {
-			respondError(w, app.ErrorLog, http.StatusInternalServerError, "Could not determine update result", err)
+			respondAPIError(w, app.ErrorLog, err)
 			return
 		}
+		if archived {
+			PublishTodos(app, events.TodoArchived, []int{id})
+		} else {
+			PublishTodos(app, events.TodoUnarchived, []int{id})
+		}
+
+		if archived {
+			respondSuccess(w, http.StatusOK, fmt.Sprintf("Todo with ID %d archived.", id))
+		} else {
+			respondSuccess(w, http.StatusOK, fmt.Sprintf("Todo with ID %d unarchived.", id))
+		}
+	}
+}
 
-		respondSuccess(w, http.StatusOK, fmt.Sprintf("Archived %d finished todos.", rowsAffected))
+// ArchiveDone archives the finished, unarchived todos that also match the
+// where conditions, announces each one and returns their IDs.
+func ArchiveDone(app *app.App, where []string, args []interface{}) ([]int, error) {
+	filter := store.Filter{Where: where, Args: args}
+	query := `UPDATE todo SET archived = 1 WHERE done = 1 AND archived = 0` + filter.And() + ` RETURNING id`
+	ids, err := store.QueryIDs(app.DB, query, filter.Args...)
+	if err != nil {
+		return nil, err
 	}
+
+	PublishTodos(app, events.TodoArchived, ids)
+	return ids, nil
 }
@@ -0,0 +1,264 @@
+package handlers
+
+import (
+	"crypto/rand"
+	"database/sql"
+	"encoding/hex"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"net/url"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/furkankorkmaz309/todo-api/internal/app"
+	"github.com/furkankorkmaz309/todo-api/internal/events"
+	"github.com/furkankorkmaz309/todo-api/internal/models"
+	"github.com/go-chi/chi"
+)
+
+func GetWebhooks(app *app.App) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		rows, err := app.DB.Query(`SELECT id, url, events, active, created_at FROM webhook`)
+		if err != nil {
+			respondError(w, app.ErrorLog, http.StatusInternalServerError, "Database error", err)
+			return
+		}
+		defer rows.Close()
+
+		var webhooks []models.Webhook
+		for rows.Next() {
+			var webhook models.Webhook
+			var filter string
+			err = rows.Scan(&webhook.ID, &webhook.URL, &filter, &webhook.Active, &webhook.CreatedAt)
+			if err != nil {
+				respondError(w, app.ErrorLog, http.StatusInternalServerError, "Row scan error", err)
+				return
+			}
+			webhook.Events = strings.Split(filter, ",")
+			webhooks = append(webhooks, webhook)
+		}
+
+		respondJSON(w, http.StatusOK, webhooks, "Webhooks listed successfully.")
+	}
+}
+
+// AddWebhook subscribes a URL to events. When no secret is given one is
+// generated; either way it is only returned in this response.
+func AddWebhook(app *app.App) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		var input models.Webhook
+		err := json.NewDecoder(r.Body).Decode(&input)
+		if err != nil {
+			respondError(w, app.ErrorLog, http.StatusBadRequest, "Invalid JSON body", err)
+			return
+		}
+
+		u, err := url.Parse(input.URL)
+		if err != nil || (u.Scheme != "http" && u.Scheme != "https") || u.Host == "" {
+			respondError(w, app.ErrorLog, http.StatusBadRequest, "URL must be an absolute http or https URL", err)
+			return
+		}
+
+		if len(input.Events) == 0 {
+			input.Events = []string{"*"}
+		}
+		for _, e := range input.Events {
+			if !events.ValidFilter(e) {
+				respondError(w, app.ErrorLog, http.StatusBadRequest, fmt.Sprintf("Unknown event %q", e), nil)
+				return
+			}
+		}
+
+		if input.Secret == "" {
+			secret := make([]byte, 20)
+			_, err = rand.Read(secret)
+			if err != nil {
+				respondError(w, app.ErrorLog, http.StatusInternalServerError, "Could not generate secret", err)
+				return
+			}
+			input.Secret = hex.EncodeToString(secret)
+		}
+
+		input.Active = true
+		input.CreatedAt = time.Now().UTC()
+
+		query := `INSERT INTO webhook (url, secret, events, active, created_at) VALUES (?, ?, ?, ?, ?)`
+		result, err := app.DB.Exec(query, input.URL, input.Secret, strings.Join(input.Events, ","), input.Active, input.CreatedAt)
+		if err != nil {
+			respondError(w, app.ErrorLog, http.StatusInternalServerError, "Database error", err)
+			return
+		}
+
+		id, err := result.LastInsertId()
+		if err != nil {
+			respondError(w, app.ErrorLog, http.StatusInternalServerError, "Failed to retrieve inserted ID", err)
+			return
+		}
+		input.ID = int(id)
+
+		respondJSON(w, http.StatusCreated, input, "Webhook created successfully.")
+	}
+}
+
+// PatchWebhook can pause or resume a webhook through the active field.
+func PatchWebhook(app *app.App) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		id, err := strconv.Atoi(chi.URLParam(r, "id"))
+		if err != nil {
+			err = fmt.Errorf("an error occurred while converting string to integer: %v", err)
+			respondError(w, app.ErrorLog, http.StatusBadRequest, "Invalid webhook ID", err)
+			return
+		}
+
+		var input struct {
+			Active *bool `json:"active"`
+		}
+		err = json.NewDecoder(r.Body).Decode(&input)
+		if err != nil {
+			respondError(w, app.ErrorLog, http.StatusBadRequest, "Invalid JSON body", err)
+			return
+		}
+		if input.Active == nil {
+			respondError(w, app.ErrorLog, http.StatusBadRequest, "No fields provided for update", nil)
+			return
+		}
+
+		result, err := app.DB.Exec(`UPDATE webhook SET active = ? WHERE id = ?`, *input.Active, id)
+		if err != nil {
+			respondError(w, app.ErrorLog, http.StatusInternalServerError, "Failed to update webhook", err)
+			return
+		}
+
+		rowsAffected, err := result.RowsAffected()
+		if err != nil {
+			respondError(w, app.ErrorLog, http.StatusInternalServerError, "Could not retrieve update result", err)
+			return
+		}
+		if rowsAffected == 0 {
+			respondError(w, app.ErrorLog, http.StatusNotFound, fmt.Sprintf("No webhook with ID %d", id), nil)
+			return
+		}
+
+		respondSuccess(w, http.StatusOK, "active updated!")
+	}
+}
+
+func DeleteWebhook(app *app.App) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		id, err := strconv.Atoi(chi.URLParam(r, "id"))
+		if err != nil {
+			err = fmt.Errorf("an error occurred while converting string to integer: %v", err)
+			respondError(w, app.ErrorLog, http.StatusBadRequest, "Invalid webhook ID", err)
+			return
+		}
+
+		result, err := app.DB.Exec(`DELETE FROM webhook WHERE id = ?`, id)
+		if err != nil {
+			respondError(w, app.ErrorLog, http.StatusInternalServerError, "Database error", err)
+			return
+		}
+
+		rowsAffected, err := result.RowsAffected()
+		if err != nil {
+			respondError(w, app.ErrorLog, http.StatusInternalServerError, "Could not retrieve delete result", err)
+			return
+		}
+		if rowsAffected == 0 {
+			respondError(w, app.ErrorLog, http.StatusNotFound, fmt.Sprintf("No webhook with ID %d", id), nil)
+			return
+		}
+
+		respondSuccess(w, http.StatusOK, fmt.Sprintf("Webhook with ID %d deleted.", id))
+	}
+}
+
+// GetWebhookDeliveries lists the latest deliveries of a webhook, newest
+// first; ?status= narrows it to pending, delivered or failed ones.
+func GetWebhookDeliveries(app *app.App) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		id, err := strconv.Atoi(chi.URLParam(r, "id"))
+		if err != nil {
+			err = fmt.Errorf("an error occurred while converting string to integer: %v", err)
+			respondError(w, app.ErrorLog, http.StatusBadRequest, "Invalid webhook ID", err)
+			return
+		}
+
+		var tempID int
+		err = app.DB.QueryRow(`SELECT id FROM webhook WHERE id = ?`, id).Scan(&tempID)
+		if err == sql.ErrNoRows {
+			respondError(w, app.ErrorLog, http.StatusNotFound, fmt.Sprintf("No webhook with ID %d", id), nil)
+			return
+		}
+		if err != nil {
+			respondError(w, app.ErrorLog, http.StatusInternalServerError, "Database error", err)
+			return
+		}
+
+		query := `SELECT id, webhook_id, event_type, payload, status, attempts, next_attempt_at, COALESCE(response_code, 0), COALESCE(last_error, ''), created_at, delivered_at
+		FROM webhook_delivery WHERE webhook_id = ?`
+		args := []interface{}{id}
+		if status := r.URL.Query().Get("status"); status != "" {
+			query += ` AND status = ?`
+			args = append(args, status)
+		}
+		query += ` ORDER BY id DESC LIMIT 100`
+
+		rows, err := app.DB.Query(query, args...)
+		if err != nil {
+			respondError(w, app.ErrorLog, http.StatusInternalServerError, "Database error", err)
+			return
+		}
+		defer rows.Close()
+
+		var deliveries []models.WebhookDelivery
+		for rows.Next() {
+			var d models.WebhookDelivery
+			var payload []byte
+			err = rows.Scan(&d.ID, &d.WebhookID, &d.EventType, &payload, &d.Status, &d.Attempts, &d.NextAttempt, &d.ResponseCode, &d.LastError, &d.CreatedAt, &d.DeliveredAt)
+			if err != nil {
+				respondError(w, app.ErrorLog, http.StatusInternalServerError, "Row scan error", err)
+				return
+			}
+			d.Payload = payload
+			deliveries = append(deliveries, d)
+		}
+
+		respondJSON(w, http.StatusOK, deliveries, "Deliveries listed successfully.")
+	}
+}
+
+func RedeliverWebhook(app *app.App) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		id, err := strconv.Atoi(chi.URLParam(r, "id"))
+		if err != nil {
+			err = fmt.Errorf("an error occurred while converting string to integer: %v", err)
+			respondError(w, app.ErrorLog, http.StatusBadRequest, "Invalid webhook ID", err)
+			return
+		}
+		deliveryID, err := strconv.Atoi(chi.URLParam(r, "deliveryID"))
+		if err != nil {
+			err = fmt.Errorf("an error occurred while converting string to integer: %v", err)
+			respondError(w, app.ErrorLog, http.StatusBadRequest, "Invalid delivery ID", err)
+			return
+		}
+
+		if app.Webhooks == nil {
+			respondError(w, app.ErrorLog, http.StatusServiceUnavailable, "Webhook delivery is disabled", nil)
+			return
+		}
+
+		newID, err := app.Webhooks.Redeliver(id, deliveryID)
+		if err == sql.ErrNoRows {
+			respondError(w, app.ErrorLog, http.StatusNotFound, fmt.Sprintf("No delivery with ID %d for webhook %d", deliveryID, id), nil)
+			return
+		}
+		if err != nil {
+			respondError(w, app.ErrorLog, http.StatusInternalServerError, "Database error", err)
+			return
+		}
+
+		respondJSON(w, http.StatusAccepted, map[string]int{"delivery_id": newID}, fmt.Sprintf("Delivery %d queued again as %d.", deliveryID, newID))
+	}
+}
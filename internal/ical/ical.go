@@ -0,0 +1,102 @@
+// Package ical writes RFC 5545 calendars.
+package ical
+
+import (
+	"bufio"
+	"io"
+	"strings"
+	"time"
+)
+
+const (
+	dateTimeFormat = "20060102T150405Z"
+	dateFormat     = "20060102"
+	maxLineOctets  = 75
+)
+
+// Writer emits content lines with the escaping, CRLF endings and line
+// folding iCalendar requires. Errors are sticky and reported by Flush.
+type Writer struct {
+	w   *bufio.Writer
+	err error
+}
+
+func NewWriter(w io.Writer) *Writer {
+	return &Writer{w: bufio.NewWriter(w)}
+}
+
+// Begin opens a component such as VCALENDAR, VTODO or VEVENT.
+func (w *Writer) Begin(component string) {
+	w.line("BEGIN:" + component)
+}
+
+func (w *Writer) End(component string) {
+	w.line("END:" + component)
+}
+
+// Raw writes a property whose value is already in iCalendar form.
+func (w *Writer) Raw(name, value string) {
+	w.line(name + ":" + value)
+}
+
+// Text writes a TEXT property, escaping the value.
+func (w *Writer) Text(name, value string) {
+	w.line(name + ":" + EscapeText(value))
+}
+
+// Time writes a DATE-TIME property in UTC.
+func (w *Writer) Time(name string, t time.Time) {
+	w.line(name + ":" + t.UTC().Format(dateTimeFormat))
+}
+
+// Date writes a DATE property: the day of t in t's location.
+func (w *Writer) Date(name string, t time.Time) {
+	w.line(name + ";VALUE=DATE:" + t.Format(dateFormat))
+}
+
+func (w *Writer) Flush() error {
+	if w.err != nil {
+		return w.err
+	}
+	return w.w.Flush()
+}
+
+// line folds s so no physical line is longer than 75 octets, without
+// splitting a UTF-8 sequence.
+func (w *Writer) line(s string) {
+	if w.err != nil {
+		return
+	}
+	limit := maxLineOctets
+	for len(s) > limit {
+		cut := limit
+		for cut > 0 && !isRuneStart(s[cut]) {
+			cut--
+		}
+		_, w.err = w.w.WriteString(s[:cut] + "\r\n ")
+		if w.err != nil {
+			return
+		}
+		s = s[cut:]
+		// Continuation lines start with a space, which counts towards the limit.
+		limit = maxLineOctets - 1
+	}
+	_, w.err = w.w.WriteString(s + "\r\n")
+}
+
+func isRuneStart(b byte) bool {
+	return b&0xC0 != 0x80
+}
+
+var textEscaper = strings.NewReplacer(
+	`\`, `\\`,
+	";", `\;`,
+	",", `\,`,
+	"\r\n", `\n`,
+	"\n", `\n`,
+	"\r", `\n`,
+)
+
+func EscapeText(s string) string {
+	return textEscaper.Replace(s)
+}
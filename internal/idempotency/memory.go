@@ -0,0 +1,77 @@
+package idempotency
+
+import (
+	"sync"
+	"time"
+)
+
+type MemoryStore struct {
+	mu        sync.Mutex
+	ttl       time.Duration
+	records   map[string]*Record
+	lastSweep time.Time
+}
+
+func NewMemoryStore(ttl time.Duration) *MemoryStore {
+	return &MemoryStore{
+		ttl:     ttl,
+		records: make(map[string]*Record),
+	}
+}
+
+func (s *MemoryStore) Reserve(key, hash string) (*Record, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	now := time.Now()
+	if now.Sub(s.lastSweep) > time.Minute {
+		for k, rec := range s.records {
+			if s.expired(rec, now) {
+				delete(s.records, k)
+			}
+		}
+		s.lastSweep = now
+	}
+
+	rec, ok := s.records[key]
+	if ok && !s.expired(rec, now) {
+		if rec.RequestHash != hash {
+			return nil, ErrKeyMismatch
+		}
+		if rec.Status == 0 {
+			return nil, ErrInProgress
+		}
+		found := *rec
+		return &found, nil
+	}
+
+	s.records[key] = &Record{Key: key, RequestHash: hash, CreatedAt: now}
+	return nil, nil
+}
+
+// expired reports whether rec is past the TTL, or an unfinished claim past
+// its lease.
+func (s *MemoryStore) expired(rec *Record, now time.Time) bool {
+	age := now.Sub(rec.CreatedAt)
+	return age > s.ttl || (rec.Status == 0 && age > Lease)
+}
+
+func (s *MemoryStore) Save(key string, status int, body []byte) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	rec, ok := s.records[key]
+	if ok {
+		rec.Status = status
+		rec.Body = body
+	}
+	return nil
+}
+
+func (s *MemoryStore) Release(key string) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	delete(s.records, key)
+	return nil
+}
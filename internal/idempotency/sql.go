@@ -0,0 +1,70 @@
+package idempotency
+
+import (
+	"database/sql"
+	"fmt"
+	"time"
+)
+
+// SQLStore keeps idempotency records in the idempotency_key table.
+type SQLStore struct {
+	db  *sql.DB
+	ttl time.Duration
+}
+
+func NewSQLStore(db *sql.DB, ttl time.Duration) *SQLStore {
+	return &SQLStore{db: db, ttl: ttl}
+}
+
+func (s *SQLStore) Reserve(key, hash string) (*Record, error) {
+	now := time.Now().UTC()
+
+	query := `DELETE FROM idempotency_key WHERE created_at < ? OR (status = 0 AND created_at < ?)`
+	_, err := s.db.Exec(query, now.Add(-s.ttl), now.Add(-Lease))
+	if err != nil {
+		return nil, fmt.Errorf("an error occurred while purging idempotency keys : %v", err)
+	}
+
+	result, err := s.db.Exec(`INSERT INTO idempotency_key (key, request_hash, status, created_at) VALUES (?, ?, 0, ?) ON CONFLICT(key) DO NOTHING`, key, hash, now)
+	if err != nil {
+		return nil, fmt.Errorf("an error occurred while reserving idempotency key : %v", err)
+	}
+	rowsAffected, err := result.RowsAffected()
+	if err != nil {
+		return nil, fmt.Errorf("an error occurred while reserving idempotency key : %v", err)
+	}
+	if rowsAffected == 1 {
+		return nil, nil
+	}
+
+	var rec Record
+	query = `SELECT key, request_hash, status, body, created_at FROM idempotency_key WHERE key = ?`
+	err = s.db.QueryRow(query, key).Scan(&rec.Key, &rec.RequestHash, &rec.Status, &rec.Body, &rec.CreatedAt)
+	if err != nil {
+		return nil, fmt.Errorf("an error occurred while reading idempotency key : %v", err)
+	}
+
+	if rec.RequestHash != hash {
+		return nil, ErrKeyMismatch
+	}
+	if rec.Status == 0 {
+		return nil, ErrInProgress
+	}
+	return &rec, nil
+}
+
+func (s *SQLStore) Save(key string, status int, body []byte) error {
+	_, err := s.db.Exec(`UPDATE idempotency_key SET status = ?, body = ? WHERE key = ?`, status, body, key)
+	if err != nil {
+		return fmt.Errorf("an error occurred while saving idempotency key : %v", err)
+	}
+	return nil
+}
+
+func (s *SQLStore) Release(key string) error {
+	_, err := s.db.Exec(`DELETE FROM idempotency_key WHERE key = ?`, key)
+	if err != nil {
+		return fmt.Errorf("an error occurred while releasing idempotency key : %v", err)
+	}
+	return nil
+}
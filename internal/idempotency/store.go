@@ -0,0 +1,39 @@
+package idempotency
+
+import (
+	"errors"
+	"time"
+)
+
+// ErrKeyMismatch is returned by Reserve when a key is reused for a request
+// with a different body or endpoint.
+var ErrKeyMismatch = errors.New("idempotency key reused with a different request")
+
+// ErrInProgress is returned by Reserve when another request holding the same
+// key has not finished yet.
+var ErrInProgress = errors.New("request with this idempotency key is in progress")
+
+// Lease is how long a key stays claimed by a request that has not finished.
+// A server that died mid-request never releases its keys, so after the
+// lease another request may claim them.
+const Lease = time.Minute
+
+type Record struct {
+	Key         string
+	RequestHash string
+	Status      int
+	Body        []byte
+	CreatedAt   time.Time
+}
+
+// Store keeps the responses of requests sent with an Idempotency-Key header.
+// Keys older than the store's TTL are treated as unseen.
+type Store interface {
+	// Reserve claims key for a request identified by hash. It returns the
+	// stored record if the key already has a completed response, or nil if
+	// the caller now owns the key and must call Save or Release. A claim
+	// older than Lease counts as abandoned.
+	Reserve(key, hash string) (*Record, error)
+	Save(key string, status int, body []byte) error
+	Release(key string) error
+}
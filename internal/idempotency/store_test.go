@@ -0,0 +1,63 @@
+package idempotency
+
+import (
+	"errors"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/furkankorkmaz309/todo-api/internal/db"
+)
+
+func TestAbandonedClaimExpires(t *testing.T) {
+	database, err := db.InitDB(filepath.Join(t.TempDir(), "test.db"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer database.Close()
+
+	memory := NewMemoryStore(time.Hour)
+	stores := map[string]struct {
+		store Store
+		age   func(key string, d time.Duration)
+	}{
+		"memory": {memory, func(key string, d time.Duration) {
+			memory.records[key].CreatedAt = memory.records[key].CreatedAt.Add(-d)
+		}},
+		"sql": {NewSQLStore(database, time.Hour), func(key string, d time.Duration) {
+			_, err := database.Exec(`UPDATE idempotency_key SET created_at = ? WHERE key = ?`, time.Now().UTC().Add(-d), key)
+			if err != nil {
+				t.Fatal(err)
+			}
+		}},
+	}
+
+	for name, s := range stores {
+		t.Run(name, func(t *testing.T) {
+			rec, err := s.store.Reserve("k", "hash")
+			if rec != nil || err != nil {
+				t.Fatalf("first Reserve = %v, %v; want nil, nil", rec, err)
+			}
+			_, err = s.store.Reserve("k", "hash")
+			if !errors.Is(err, ErrInProgress) {
+				t.Fatalf("Reserve of a claimed key = %v; want ErrInProgress", err)
+			}
+
+			s.age("k", Lease+time.Second)
+			rec, err = s.store.Reserve("k", "hash")
+			if rec != nil || err != nil {
+				t.Fatalf("Reserve past the lease = %v, %v; want nil, nil", rec, err)
+			}
+
+			err = s.store.Save("k", 201, []byte(`{}`))
+			if err != nil {
+				t.Fatal(err)
+			}
+			s.age("k", Lease+time.Second)
+			rec, err = s.store.Reserve("k", "hash")
+			if err != nil || rec == nil || rec.Status != 201 {
+				t.Fatalf("Reserve of a saved key past the lease = %v, %v; want the saved record", rec, err)
+			}
+		})
+	}
+}
@@ -0,0 +1,87 @@
+// Package importer parses todo lists exported from other tools into a
+// common Item form. Parsers register themselves by format name.
+package importer
+
+import (
+	"fmt"
+	"io"
+	"sort"
+	"strings"
+	"time"
+)
+
+// Item is a todo as read from a foreign format. Zero values mean the source
+// did not say: Priority 0 is left to the caller's default and a zero
+// DueDate means no due date.
+type Item struct {
+	Line        int
+	Title       string
+	Content     string
+	Priority    int
+	DueDate     time.Time
+	CreatedAt   time.Time
+	Done        bool
+	CompletedAt *time.Time
+	Archived    bool
+	Category    string
+	Tags        []string
+}
+
+type Parser interface {
+	Parse(r io.Reader) ([]Item, error)
+}
+
+type ParserFunc func(r io.Reader) ([]Item, error)
+
+func (f ParserFunc) Parse(r io.Reader) ([]Item, error) {
+	return f(r)
+}
+
+var parsers = make(map[string]Parser)
+
+// Register makes a parser available under name. It panics on duplicates,
+// since that can only be a programming error.
+func Register(name string, p Parser) {
+	if _, ok := parsers[name]; ok {
+		panic(fmt.Sprintf("importer: parser %q registered twice", name))
+	}
+	parsers[name] = p
+}
+
+func Get(name string) (Parser, bool) {
+	p, ok := parsers[name]
+	return p, ok
+}
+
+func Formats() []string {
+	var names []string
+	for name := range parsers {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	return names
+}
+
+// endOfDay turns a date without a time into the last second of that day, so
+// a task due "today" is not already overdue.
+func endOfDay(t time.Time) time.Time {
+	y, m, d := t.Date()
+	return time.Date(y, m, d, 23, 59, 59, 0, time.Local)
+}
+
+func parseDate(v string) (time.Time, error) {
+	v = strings.TrimSpace(v)
+	t, err := time.Parse(time.RFC3339, v)
+	if err == nil {
+		return t, nil
+	}
+	t, err = time.ParseInLocation("2006-01-02T15:04:05", v, time.Local)
+	if err == nil {
+		return t, nil
+	}
+	t, err = time.ParseInLocation("2006-01-02", v, time.Local)
+	if err != nil {
+		return t, err
+	}
+	return endOfDay(t), nil
+}
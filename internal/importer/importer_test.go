@@ -0,0 +1,146 @@
+package importer
+
+import (
+	"reflect"
+	"strings"
+	"testing"
+	"time"
+)
+
+func local(y int, m time.Month, d, h, min, s int) time.Time {
+	return time.Date(y, m, d, h, min, s, 0, time.Local)
+}
+
+func timePtr(t time.Time) *time.Time {
+	return &t
+}
+
+func parseAll(t *testing.T, format, input string) []Item {
+	t.Helper()
+	parser, ok := Get(format)
+	if !ok {
+		t.Fatalf("no parser for %s", format)
+	}
+	items, err := parser.Parse(strings.NewReader(input))
+	if err != nil {
+		t.Fatal(err)
+	}
+	return items
+}
+
+func TestTodoTxt(t *testing.T) {
+	for _, tc := range []struct {
+		line string
+		want Item
+	}{
+		{
+			"(A) 2026-03-01 Call mom +family +phone @home due:2026-03-05",
+			Item{Title: "Call mom", Priority: 5, CreatedAt: local(2026, 3, 1, 0, 0, 0), DueDate: local(2026, 3, 5, 23, 59, 59),
+				Category: "family", Tags: []string{"phone", "home"}},
+		},
+		{
+			"(C) Pay rent due:2026-04-01T09:30:00",
+			Item{Title: "Pay rent", Priority: 3, DueDate: local(2026, 4, 1, 9, 30, 0)},
+		},
+		{"(Z) Someday maybe", Item{Title: "Someday maybe", Priority: 1}},
+		{"(a) lower case is no priority", Item{Title: "(a) lower case is no priority"}},
+		{
+			"x 2026-03-02 2026-03-01 Done already +work pri:B",
+			Item{Title: "Done already", Priority: 4, Done: true, CompletedAt: timePtr(local(2026, 3, 2, 0, 0, 0)),
+				CreatedAt: local(2026, 3, 1, 0, 0, 0), Category: "work"},
+		},
+		{"x Done without a date", Item{Title: "Done without a date", Done: true}},
+		{"Broken due:tomorrow stays in the title", Item{Title: "Broken due:tomorrow stays in the title"}},
+		{"+work @home", Item{Category: "work", Tags: []string{"home"}}},
+	} {
+		items := parseAll(t, "todotxt", tc.line)
+		tc.want.Line = 1
+		tc.want.Content = tc.want.Title
+		if len(items) != 1 || !reflect.DeepEqual(items[0], tc.want) {
+			t.Errorf("%q:\n got %+v\nwant %+v", tc.line, items, tc.want)
+		}
+	}
+
+	items := parseAll(t, "todotxt", "first\n\n  \nsecond\n")
+	if len(items) != 2 || items[0].Line != 1 || items[1].Line != 4 {
+		t.Errorf("items of a file with blank lines = %+v, want lines 1 and 4", items)
+	}
+}
+
+func TestTodoist(t *testing.T) {
+	input := `{
+		"projects": [{"id": "2203306141", "name": "Work"}, {"id": 7, "name": "Home"}],
+		"items": [
+			{"content": "Ship it", "description": "v2", "priority": 4, "project_id": "2203306141", "labels": ["release"],
+			 "added_at": "2026-03-01T10:00:00Z", "due": {"date": "2026-03-05"}},
+			{"content": "Water plants", "priority": 1, "project_id": 7, "checked": true, "completed_at": "2026-03-02T08:00:00Z",
+			 "due": {"date": "2026-03-02", "datetime": "2026-03-02T07:00:00Z"}}
+		],
+		"tasks": [
+			{"content": "No project", "priority": 0, "project_id": "1", "is_completed": true, "created_at": "not a date"}
+		]
+	}`
+	want := []Item{
+		{Line: 1, Title: "Ship it", Content: "v2", Priority: 5, Category: "Work", Tags: []string{"release"},
+			CreatedAt: time.Date(2026, 3, 1, 10, 0, 0, 0, time.UTC), DueDate: local(2026, 3, 5, 23, 59, 59)},
+		{Line: 2, Title: "Water plants", Priority: 2, Category: "Home", Done: true,
+			CompletedAt: timePtr(time.Date(2026, 3, 2, 8, 0, 0, 0, time.UTC)), DueDate: time.Date(2026, 3, 2, 7, 0, 0, 0, time.UTC)},
+		{Line: 3, Title: "No project", Done: true},
+	}
+
+	items := parseAll(t, "todoist", input)
+	if len(items) != len(want) {
+		t.Fatalf("got %d items, want %d: %+v", len(items), len(want), items)
+	}
+	for i := range want {
+		if !reflect.DeepEqual(items[i], want[i]) {
+			t.Errorf("item %d:\n got %+v\nwant %+v", i+1, items[i], want[i])
+		}
+	}
+
+	_, err := parseTodoist(strings.NewReader(`[1, 2]`))
+	if err == nil {
+		t.Error("a JSON array parsed as a Todoist export")
+	}
+}
+
+func TestTrello(t *testing.T) {
+	input := `{
+		"lists": [{"id": "l1", "name": "Doing"}, {"id": "l2", "name": "Old", "closed": true}],
+		"cards": [
+			{"name": "Review PR", "desc": "#42", "due": "2026-03-05T17:00:00.000Z", "idList": "l1",
+			 "labels": [{"name": "urgent", "color": "red"}, {"name": "", "color": "green"}, {"name": "", "color": ""}]},
+			{"name": "Finished", "dueComplete": true, "closed": true, "idList": "l1"},
+			{"name": "On a closed list", "idList": "l2"},
+			{"name": "Nowhere", "idList": "missing", "due": "soon"}
+		]
+	}`
+	want := []Item{
+		{Line: 1, Title: "Review PR", Content: "#42", Category: "Doing", Tags: []string{"urgent", "green"},
+			DueDate: time.Date(2026, 3, 5, 17, 0, 0, 0, time.UTC)},
+		{Line: 2, Title: "Finished", Category: "Doing", Done: true, Archived: true},
+		{Line: 3, Title: "On a closed list", Category: "Old", Archived: true},
+		{Line: 4, Title: "Nowhere"},
+	}
+
+	items := parseAll(t, "trello", input)
+	if len(items) != len(want) {
+		t.Fatalf("got %d items, want %d: %+v", len(items), len(want), items)
+	}
+	for i := range want {
+		if !reflect.DeepEqual(items[i], want[i]) {
+			t.Errorf("item %d:\n got %+v\nwant %+v", i+1, items[i], want[i])
+		}
+	}
+
+	_, err := parseTrello(strings.NewReader(`{"cards": "none"}`))
+	if err == nil {
+		t.Error("cards that are not a list parsed as a Trello export")
+	}
+}
+
+func TestFormats(t *testing.T) {
+	if got, want := Formats(), []string{"todoist", "todotxt", "trello"}; !reflect.DeepEqual(got, want) {
+		t.Errorf("Formats() = %v, want %v", got, want)
+	}
+}
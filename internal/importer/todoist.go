@@ -0,0 +1,92 @@
+package importer
+
+import (
+	"encoding/json"
+	"fmt"
+	"io"
+)
+
+func init() {
+	Register("todoist", ParserFunc(parseTodoist))
+}
+
+type todoistProject struct {
+	ID   json.RawMessage `json:"id"`
+	Name string          `json:"name"`
+}
+
+type todoistTask struct {
+	Content     string          `json:"content"`
+	Description string          `json:"description"`
+	Priority    int             `json:"priority"`
+	ProjectID   json.RawMessage `json:"project_id"`
+	Labels      []string        `json:"labels"`
+	Checked     bool            `json:"checked"`
+	IsCompleted bool            `json:"is_completed"`
+	CompletedAt string          `json:"completed_at"`
+	AddedAt     string          `json:"added_at"`
+	CreatedAt   string          `json:"created_at"`
+	Due         *struct {
+		Date     string `json:"date"`
+		Datetime string `json:"datetime"`
+	} `json:"due"`
+}
+
+// parseTodoist reads a Todoist export: either a sync API dump with
+// "projects" and "items", or a REST style {"projects", "tasks"} object.
+// Todoist priorities run from 1 (normal) to 4 (urgent).
+func parseTodoist(r io.Reader) ([]Item, error) {
+	var export struct {
+		Projects []todoistProject `json:"projects"`
+		Items    []todoistTask    `json:"items"`
+		Tasks    []todoistTask    `json:"tasks"`
+	}
+	err := json.NewDecoder(r).Decode(&export)
+	if err != nil {
+		return nil, fmt.Errorf("invalid Todoist export: %v", err)
+	}
+
+	projects := make(map[string]string)
+	for _, p := range export.Projects {
+		projects[string(p.ID)] = p.Name
+	}
+
+	priorities := map[int]int{1: 2, 2: 3, 3: 4, 4: 5}
+
+	var items []Item
+	for i, task := range append(export.Items, export.Tasks...) {
+		item := Item{
+			Line:     i + 1,
+			Title:    task.Content,
+			Content:  task.Description,
+			Priority: priorities[task.Priority],
+			Category: projects[string(task.ProjectID)],
+			Tags:     task.Labels,
+			Done:     task.Checked || task.IsCompleted,
+		}
+
+		if task.Due != nil {
+			due := task.Due.Datetime
+			if due == "" {
+				due = task.Due.Date
+			}
+			if t, err := parseDate(due); err == nil {
+				item.DueDate = t
+			}
+		}
+
+		created := task.AddedAt
+		if created == "" {
+			created = task.CreatedAt
+		}
+		if t, err := parseDate(created); err == nil {
+			item.CreatedAt = t
+		}
+		if t, err := parseDate(task.CompletedAt); err == nil {
+			item.CompletedAt = &t
+		}
+
+		items = append(items, item)
+	}
+	return items, nil
+}
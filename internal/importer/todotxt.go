@@ -0,0 +1,96 @@
+package importer
+
+import (
+	"bufio"
+	"io"
+	"strings"
+	"time"
+)
+
+func init() {
+	Register("todotxt", ParserFunc(parseTodoTxt))
+}
+
+// parseTodoTxt reads the todo.txt format (http://todotxt.org). Priorities
+// (A) to (E) map to 5 to 1, the first +project becomes the category, other
+// projects and @contexts become tags, and due: sets the due date.
+func parseTodoTxt(r io.Reader) ([]Item, error) {
+	var items []Item
+
+	scanner := bufio.NewScanner(r)
+	line := 0
+	for scanner.Scan() {
+		line++
+		text := strings.TrimSpace(scanner.Text())
+		if text == "" {
+			continue
+		}
+		items = append(items, parseTodoTxtLine(line, text))
+	}
+	return items, scanner.Err()
+}
+
+func parseTodoTxtLine(line int, text string) Item {
+	item := Item{Line: line}
+	fields := strings.Fields(text)
+
+	if len(fields) > 0 && fields[0] == "x" {
+		item.Done = true
+		fields = fields[1:]
+		if len(fields) > 0 {
+			if t, err := time.ParseInLocation("2006-01-02", fields[0], time.Local); err == nil {
+				item.CompletedAt = &t
+				fields = fields[1:]
+			}
+		}
+	}
+
+	if len(fields) > 0 && len(fields[0]) == 3 && fields[0][0] == '(' && fields[0][2] == ')' {
+		letter := fields[0][1]
+		if letter >= 'A' && letter <= 'Z' {
+			item.Priority = 1
+			if letter <= 'E' {
+				item.Priority = 5 - int(letter-'A')
+			}
+			fields = fields[1:]
+		}
+	}
+
+	if len(fields) > 0 {
+		if t, err := time.ParseInLocation("2006-01-02", fields[0], time.Local); err == nil {
+			item.CreatedAt = t
+			fields = fields[1:]
+		}
+	}
+
+	var words []string
+	for _, field := range fields {
+		switch {
+		case len(field) > 1 && field[0] == '+':
+			if item.Category == "" {
+				item.Category = field[1:]
+			} else {
+				item.Tags = append(item.Tags, field[1:])
+			}
+		case len(field) > 1 && field[0] == '@':
+			item.Tags = append(item.Tags, field[1:])
+		case strings.HasPrefix(field, "due:"):
+			if t, err := parseDate(strings.TrimPrefix(field, "due:")); err == nil {
+				item.DueDate = t
+			} else {
+				words = append(words, field)
+			}
+		case strings.HasPrefix(field, "pri:") && len(field) == 5:
+			// Completed tasks keep their priority as pri:X by convention.
+			letter := field[4]
+			if letter >= 'A' && letter <= 'E' {
+				item.Priority = 5 - int(letter-'A')
+			}
+		default:
+			words = append(words, field)
+		}
+	}
+	item.Title = strings.Join(words, " ")
+	item.Content = item.Title
+	return item
+}
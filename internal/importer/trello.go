@@ -0,0 +1,73 @@
+package importer
+
+import (
+	"encoding/json"
+	"fmt"
+	"io"
+)
+
+func init() {
+	Register("trello", ParserFunc(parseTrello))
+}
+
+// parseTrello reads a Trello board export. Each card becomes a todo in the
+// category named after its list, with the card's labels as tags. Closed
+// cards and cards on closed lists are imported as archived.
+func parseTrello(r io.Reader) ([]Item, error) {
+	var board struct {
+		Lists []struct {
+			ID     string `json:"id"`
+			Name   string `json:"name"`
+			Closed bool   `json:"closed"`
+		} `json:"lists"`
+		Cards []struct {
+			Name        string `json:"name"`
+			Desc        string `json:"desc"`
+			Due         string `json:"due"`
+			DueComplete bool   `json:"dueComplete"`
+			Closed      bool   `json:"closed"`
+			IDList      string `json:"idList"`
+			Labels      []struct {
+				Name  string `json:"name"`
+				Color string `json:"color"`
+			} `json:"labels"`
+		} `json:"cards"`
+	}
+	err := json.NewDecoder(r).Decode(&board)
+	if err != nil {
+		return nil, fmt.Errorf("invalid Trello export: %v", err)
+	}
+
+	lists := make(map[string]string)
+	closedLists := make(map[string]bool)
+	for _, list := range board.Lists {
+		lists[list.ID] = list.Name
+		closedLists[list.ID] = list.Closed
+	}
+
+	var items []Item
+	for i, card := range board.Cards {
+		item := Item{
+			Line:     i + 1,
+			Title:    card.Name,
+			Content:  card.Desc,
+			Category: lists[card.IDList],
+			Done:     card.DueComplete,
+			Archived: card.Closed || closedLists[card.IDList],
+		}
+		if t, err := parseDate(card.Due); err == nil {
+			item.DueDate = t
+		}
+		for _, label := range card.Labels {
+			name := label.Name
+			if name == "" {
+				name = label.Color
+			}
+			if name != "" {
+				item.Tags = append(item.Tags, name)
+			}
+		}
+		items = append(items, item)
+	}
+	return items, nil
+}
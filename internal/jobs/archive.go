@@ -0,0 +1,35 @@
+package jobs
+
+import (
+	"time"
+
+	"github.com/furkankorkmaz309/todo-api/internal/app"
+	"github.com/furkankorkmaz309/todo-api/internal/handlers"
+	"github.com/furkankorkmaz309/todo-api/internal/store"
+)
+
+// AutoArchive archives todos that have been done for at least days days,
+// checking once per interval. It blocks, so run it in its own goroutine.
+func AutoArchive(app *app.App, days int, interval time.Duration) {
+	ticker := time.NewTicker(interval)
+	defer ticker.Stop()
+
+	for {
+		archiveDone(app, days)
+		<-ticker.C
+	}
+}
+
+func archiveDone(app *app.App, days int) {
+	cutoff := time.Now().UTC().AddDate(0, 0, -days)
+
+	ids, err := handlers.ArchiveDone(app, []string{store.DoneBeforeCondition}, []interface{}{cutoff})
+	if err != nil {
+		app.ErrorLog.Printf("auto-archive failed: %v", err)
+		return
+	}
+
+	if len(ids) > 0 {
+		app.InfoLog.Printf("Auto-archived %d todos done for %d days", len(ids), days)
+	}
+}
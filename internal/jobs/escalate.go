@@ -0,0 +1,37 @@
+package jobs
+
+import (
+	"time"
+
+	"github.com/furkankorkmaz309/todo-api/internal/app"
+	"github.com/furkankorkmaz309/todo-api/internal/handlers"
+)
+
+// Escalate applies the escalation rules to overdue todos once per
+// interval. It blocks, so run it in its own goroutine.
+func Escalate(app *app.App, interval time.Duration) {
+	ticker := time.NewTicker(interval)
+	defer ticker.Stop()
+
+	for {
+		escalate(app)
+		<-ticker.C
+	}
+}
+
+func escalate(app *app.App) {
+	report, err := handlers.Escalate(app, false)
+	if err != nil {
+		app.ErrorLog.Printf("escalation failed: %v", err)
+		return
+	}
+
+	for _, e := range report {
+		if e.Error != "" {
+			app.ErrorLog.Printf("escalation rule %d on todo %d: %s", e.RuleID, e.TodoID, e.Error)
+		}
+	}
+	if len(report) > 0 {
+		app.InfoLog.Printf("Applied %d escalations", len(report))
+	}
+}
@@ -0,0 +1,161 @@
+// Package live keeps track of the clients connected for real-time editing:
+// which categories each one is watching, who else is there, and which
+// events they should hear about.
+package live
+
+import (
+	"sort"
+	"sync"
+
+	"github.com/furkankorkmaz309/todo-api/internal/events"
+	"github.com/furkankorkmaz309/todo-api/internal/models"
+)
+
+// AllCategories is the room of clients watching every todo.
+const AllCategories = 0
+
+// Message is sent in both directions; Type decides which fields are used.
+// Ref is chosen by the client and echoed on the reply to its message.
+type Message struct {
+	Type       string        `json:"type"`
+	Ref        string        `json:"ref,omitempty"`
+	CategoryID *int          `json:"category_id,omitempty"`
+	ID         int           `json:"id,omitempty"`
+	Version    int           `json:"version,omitempty"`
+	Todo       *models.Todo  `json:"todo,omitempty"`
+	Event      *events.Event `json:"event,omitempty"`
+	Viewers    []string      `json:"viewers,omitempty"`
+	Error      string        `json:"error,omitempty"`
+}
+
+// Client is one connection. Messages for it are queued on Send; a client
+// that lets the queue fill up is disconnected by closing it.
+type Client struct {
+	Name string
+	Send chan Message
+
+	rooms  map[int]bool
+	closed bool
+}
+
+type Hub struct {
+	mu      sync.Mutex
+	clients map[*Client]struct{}
+}
+
+func NewHub() *Hub {
+	return &Hub{clients: make(map[*Client]struct{})}
+}
+
+func (h *Hub) Register(name string) *Client {
+	h.mu.Lock()
+	defer h.mu.Unlock()
+
+	c := &Client{Name: name, Send: make(chan Message, 64), rooms: make(map[int]bool)}
+	h.clients[c] = struct{}{}
+	return c
+}
+
+// Unregister removes the client from all its rooms and closes Send.
+func (h *Hub) Unregister(c *Client) {
+	h.mu.Lock()
+	defer h.mu.Unlock()
+
+	if _, ok := h.clients[c]; !ok {
+		return
+	}
+	delete(h.clients, c)
+	for room := range c.rooms {
+		delete(c.rooms, room)
+		h.announce(room)
+	}
+	h.close(c)
+}
+
+// Join subscribes the client to a category, or to all todos with
+// AllCategories, and tells everyone in it who is now viewing.
+func (h *Hub) Join(c *Client, room int) {
+	h.mu.Lock()
+	defer h.mu.Unlock()
+
+	if c.rooms[room] {
+		h.send(c, h.presence(room))
+		return
+	}
+	c.rooms[room] = true
+	h.announce(room)
+}
+
+func (h *Hub) Leave(c *Client, room int) {
+	h.mu.Lock()
+	defer h.mu.Unlock()
+
+	if !c.rooms[room] {
+		return
+	}
+	delete(c.rooms, room)
+	h.announce(room)
+}
+
+// Reply queues a message for one client.
+func (h *Hub) Reply(c *Client, m Message) {
+	h.mu.Lock()
+	defer h.mu.Unlock()
+	h.send(c, m)
+}
+
+// Publish forwards a todo or category event to the clients watching it.
+// It is meant to be subscribed to the events bus.
+func (h *Hub) Publish(e events.Event) {
+	// Deletions go to every subscribed client.
+	room, ok := events.CategoryOf(e)
+
+	h.mu.Lock()
+	defer h.mu.Unlock()
+
+	m := Message{Type: "event", Event: &e}
+	for c := range h.clients {
+		if c.rooms[AllCategories] || (ok && c.rooms[room]) || (!ok && len(c.rooms) > 0) {
+			h.send(c, m)
+		}
+	}
+}
+
+func (h *Hub) presence(room int) Message {
+	viewers := []string{}
+	for c := range h.clients {
+		if c.rooms[room] {
+			viewers = append(viewers, c.Name)
+		}
+	}
+	sort.Strings(viewers)
+	return Message{Type: "presence", CategoryID: &room, Viewers: viewers}
+}
+
+func (h *Hub) announce(room int) {
+	m := h.presence(room)
+	for c := range h.clients {
+		if c.rooms[room] {
+			h.send(c, m)
+		}
+	}
+}
+
+// send must be called with h.mu held.
+func (h *Hub) send(c *Client, m Message) {
+	if c.closed {
+		return
+	}
+	select {
+	case c.Send <- m:
+	default:
+		h.close(c)
+	}
+}
+
+func (h *Hub) close(c *Client) {
+	if !c.closed {
+		c.closed = true
+		close(c.Send)
+	}
+}
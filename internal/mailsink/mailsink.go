@@ -0,0 +1,119 @@
+// Package mailsink is a minimal SMTP server that accepts every message and
+// hands it to a function instead of delivering it. It stands in for a real
+// mail server while trying out email reminders.
+package mailsink
+
+import (
+	"bufio"
+	"fmt"
+	"net"
+	"strings"
+	"time"
+)
+
+// maxMessage is the largest message accepted, in bytes.
+const maxMessage = 1 << 20
+
+type Message struct {
+	From string
+	To   []string
+	Data string
+}
+
+// Serve accepts SMTP connections on l until it fails, calling handle with
+// every message received.
+func Serve(l net.Listener, handle func(Message)) error {
+	for {
+		conn, err := l.Accept()
+		if err != nil {
+			return err
+		}
+		go session(conn, handle)
+	}
+}
+
+func session(conn net.Conn, handle func(Message)) {
+	defer conn.Close()
+	r := bufio.NewReader(conn)
+	reply := func(format string, args ...interface{}) {
+		conn.SetWriteDeadline(time.Now().Add(time.Minute))
+		fmt.Fprintf(conn, format+"\r\n", args...)
+	}
+
+	var msg Message
+	reply("220 todo-api mailsink ready")
+	for {
+		conn.SetReadDeadline(time.Now().Add(5 * time.Minute))
+		line, err := r.ReadString('\n')
+		if err != nil {
+			return
+		}
+		line = strings.TrimRight(line, "\r\n")
+		verb, arg, _ := strings.Cut(line, " ")
+
+		switch strings.ToUpper(verb) {
+		case "HELO":
+			reply("250 todo-api")
+		case "EHLO":
+			reply("250-todo-api")
+			reply("250 SIZE %d", maxMessage)
+		case "MAIL":
+			msg = Message{From: address(arg)}
+			reply("250 OK")
+		case "RCPT":
+			msg.To = append(msg.To, address(arg))
+			reply("250 OK")
+		case "DATA":
+			if len(msg.To) == 0 {
+				reply("503 RCPT first")
+				continue
+			}
+			reply("354 End data with <CR><LF>.<CR><LF>")
+			data, err := readData(r)
+			if err != nil {
+				reply("552 %v", err)
+				return
+			}
+			msg.Data = data
+			handle(msg)
+			msg = Message{}
+			reply("250 OK")
+		case "RSET":
+			msg = Message{}
+			reply("250 OK")
+		case "NOOP":
+			reply("250 OK")
+		case "QUIT":
+			reply("221 Bye")
+			return
+		default:
+			reply("502 Command not implemented")
+		}
+	}
+}
+
+// address takes the address out of "FROM:<a@b>" or "TO:<a@b>".
+func address(arg string) string {
+	_, addr, _ := strings.Cut(arg, ":")
+	addr, _, _ = strings.Cut(strings.TrimSpace(addr), " ")
+	return strings.Trim(addr, "<>")
+}
+
+// readData reads a message up to the line holding a single dot, undoing
+// the dot stuffing of lines that start with one.
+func readData(r *bufio.Reader) (string, error) {
+	var b strings.Builder
+	for {
+		line, err := r.ReadString('\n')
+		if err != nil {
+			return "", err
+		}
+		if line == ".\r\n" || line == ".\n" {
+			return b.String(), nil
+		}
+		if b.Len()+len(line) > maxMessage {
+			return "", fmt.Errorf("message larger than %d bytes", maxMessage)
+		}
+		b.WriteString(strings.TrimPrefix(line, "."))
+	}
+}
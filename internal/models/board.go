@@ -0,0 +1,24 @@
+package models
+
+import "time"
+
+// Board lays todos out in columns. ColumnBy is status or category: each
+// column holds the unarchived todos with its Status or CategoryID.
+type Board struct {
+	ID        int           `json:"id"`
+	Name      string        `json:"name"`
+	ColumnBy  string        `json:"column_by"`
+	Columns   []BoardColumn `json:"columns"`
+	CreatedAt time.Time     `json:"created_at"`
+}
+
+// BoardColumn lists its todos in the board's manual order, with todos
+// never moved on the board after the others. Todos is only filled in when
+// a single board is read.
+type BoardColumn struct {
+	ID         int    `json:"id"`
+	Name       string `json:"name"`
+	Status     string `json:"status,omitempty"`      // for boards by status
+	CategoryID int    `json:"category_id,omitempty"` // for boards by category
+	Todos      []Todo `json:"todos,omitempty"`
+}
@@ -0,0 +1,14 @@
+package models
+
+import "time"
+
+// CalendarToken grants read access to the calendar feed through a secret
+// URL. Token is only filled in when the token is created.
+type CalendarToken struct {
+	ID         int       `json:"id"`
+	Name       string    `json:"name"`
+	CategoryID int       `json:"category_id,omitempty"`
+	Token      string    `json:"token,omitempty"`
+	URL        string    `json:"url,omitempty"`
+	CreatedAt  time.Time `json:"created_at"`
+}
@@ -0,0 +1,27 @@
+package models
+
+import "time"
+
+// EscalationRule acts on todos that have been overdue for AfterDays days:
+// it raises their priority by one, sends a reminder through Channel, or
+// moves them to CategoryID, depending on Action.
+type EscalationRule struct {
+	ID         int       `json:"id"`
+	Name       string    `json:"name"`
+	AfterDays  int       `json:"after_days"`
+	Action     string    `json:"action"`
+	CategoryID int       `json:"category_id,omitempty"` // for move_category
+	Channel    string    `json:"channel,omitempty"`     // for notify
+	Active     bool      `json:"active"`
+	CreatedAt  time.Time `json:"created_at"`
+}
+
+// Escalation is what a rule did, or would do, to a todo.
+type Escalation struct {
+	RuleID int    `json:"rule_id"`
+	Rule   string `json:"rule"`
+	TodoID int    `json:"todo_id"`
+	Action string `json:"action"`
+	Detail string `json:"detail"`
+	Error  string `json:"error,omitempty"`
+}
@@ -0,0 +1,19 @@
+package models
+
+import "time"
+
+// Reminder notifies about a todo through a channel, either at RemindAt or
+// OffsetMinutes before the todo's due date; exactly one of them is set.
+type Reminder struct {
+	ID            int        `json:"id"`
+	TodoID        int        `json:"todo_id"`
+	RemindAt      *time.Time `json:"remind_at,omitempty"`
+	OffsetMinutes *int       `json:"offset_minutes,omitempty"`
+	FireAt        time.Time  `json:"fire_at"`
+	Channel       string     `json:"channel"`
+	Status        string     `json:"status"`
+	Attempts      int        `json:"attempts"`
+	LastError     string     `json:"last_error,omitempty"`
+	CreatedAt     time.Time  `json:"created_at"`
+	SentAt        *time.Time `json:"sent_at,omitempty"`
+}
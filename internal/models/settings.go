@@ -0,0 +1,9 @@
+package models
+
+// Settings are the preferences of the API's owner. Timezone is an IANA
+// name, empty for the server's own time zone. Transitions lists the
+// statuses a todo may go to from each status.
+type Settings struct {
+	Timezone    string              `json:"timezone"`
+	Transitions map[string][]string `json:"transitions"`
+}
@@ -3,13 +3,20 @@ package models
 import "time"
 
 type Todo struct {
-	ID         int       `json:"id"`
-	Title      string    `json:"title"`
-	Content    string    `json:"content"`
-	Priority   int       `json:"priority"`
-	CreatedAt  time.Time `json:"created_at"`
-	DueDate    time.Time `json:"due_date"`
-	IsDone     bool      `json:"is_done"`
-	Archived   bool      `json:"archived"`
-	CategoryID int       `json:"category_id"`
+	ID          int        `json:"id"`
+	Title       string     `json:"title"`
+	Content     string     `json:"content"`
+	Priority    int        `json:"priority"` // 1 (lowest) to 5 (highest)
+	CreatedAt   time.Time  `json:"created_at"`
+	DueDate     time.Time  `json:"due_date"`
+	AllDay      bool       `json:"all_day"` // due_date names a day and is the end of it
+	Status      string     `json:"status"`
+	IsDone      bool       `json:"is_done"` // status is done; kept for older clients
+	Overdue     bool       `json:"overdue"` // past due_date and neither done nor cancelled; computed, never written
+	Archived    bool       `json:"archived"`
+	CategoryID  int        `json:"category_id"`
+	StartedAt   *time.Time `json:"started_at,omitempty"`
+	CompletedAt *time.Time `json:"completed_at,omitempty"`
+	Tags        []string   `json:"tags,omitempty"`
+	Version     int        `json:"version"`
 }
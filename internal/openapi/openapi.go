@@ -0,0 +1,301 @@
+// Package openapi holds an OpenAPI 3.1 document and builds it from a chi
+// router, so that every route served is also described.
+package openapi
+
+import (
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"reflect"
+	"regexp"
+	"sort"
+	"strings"
+	"time"
+
+	"github.com/go-chi/chi"
+)
+
+const Version = "3.1.0"
+
+type Document struct {
+	OpenAPI    string              `json:"openapi"`
+	Info       Info                `json:"info"`
+	Paths      map[string]PathItem `json:"paths"`
+	Components Components          `json:"components"`
+}
+
+type Info struct {
+	Title       string `json:"title"`
+	Version     string `json:"version"`
+	Description string `json:"description,omitempty"`
+}
+
+// PathItem maps lower-case HTTP methods to their operations.
+type PathItem map[string]*Operation
+
+type Components struct {
+	Schemas         map[string]*Schema         `json:"schemas,omitempty"`
+	Responses       map[string]*Response       `json:"responses,omitempty"`
+	SecuritySchemes map[string]*SecurityScheme `json:"securitySchemes,omitempty"`
+}
+
+type SecurityScheme struct {
+	Type        string `json:"type"`
+	Scheme      string `json:"scheme,omitempty"`
+	Description string `json:"description,omitempty"`
+}
+
+// SecurityRequirement maps security scheme names to the scopes needed.
+type SecurityRequirement map[string][]string
+
+type Operation struct {
+	Summary     string                `json:"summary,omitempty"`
+	Description string                `json:"description,omitempty"`
+	OperationID string                `json:"operationId,omitempty"`
+	Tags        []string              `json:"tags,omitempty"`
+	Parameters  []*Parameter          `json:"parameters,omitempty"`
+	RequestBody *RequestBody          `json:"requestBody,omitempty"`
+	Responses   map[string]*Response  `json:"responses"`
+	Security    []SecurityRequirement `json:"security,omitempty"`
+}
+
+type Parameter struct {
+	Name        string  `json:"name"`
+	In          string  `json:"in"`
+	Description string  `json:"description,omitempty"`
+	Required    bool    `json:"required,omitempty"`
+	Schema      *Schema `json:"schema"`
+}
+
+type RequestBody struct {
+	Description string               `json:"description,omitempty"`
+	Required    bool                 `json:"required,omitempty"`
+	Content     map[string]MediaType `json:"content"`
+}
+
+type MediaType struct {
+	Schema *Schema `json:"schema,omitempty"`
+}
+
+// Response is either a reference to a component response or a response
+// of its own.
+type Response struct {
+	Ref         string               `json:"$ref,omitempty"`
+	Description string               `json:"description,omitempty"`
+	Content     map[string]MediaType `json:"content,omitempty"`
+}
+
+// Schema is the subset of JSON Schema the API needs.
+type Schema struct {
+	Ref                  string             `json:"$ref,omitempty"`
+	Type                 Types              `json:"type,omitempty"`
+	Format               string             `json:"format,omitempty"`
+	Description          string             `json:"description,omitempty"`
+	Enum                 []interface{}      `json:"enum,omitempty"`
+	Default              interface{}        `json:"default,omitempty"`
+	Minimum              *float64           `json:"minimum,omitempty"`
+	Maximum              *float64           `json:"maximum,omitempty"`
+	MinLength            *int               `json:"minLength,omitempty"`
+	MaxLength            *int               `json:"maxLength,omitempty"`
+	MinItems             *int               `json:"minItems,omitempty"`
+	MaxItems             *int               `json:"maxItems,omitempty"`
+	Items                *Schema            `json:"items,omitempty"`
+	Properties           map[string]*Schema `json:"properties,omitempty"`
+	Required             []string           `json:"required,omitempty"`
+	AdditionalProperties *bool              `json:"additionalProperties,omitempty"`
+	AllOf                []*Schema          `json:"allOf,omitempty"`
+	AnyOf                []*Schema          `json:"anyOf,omitempty"`
+	ReadOnly             bool               `json:"readOnly,omitempty"`
+}
+
+// Types is the type keyword, written as a plain string when there is only
+// one type and as an array for nullable ones such as ["string", "null"].
+type Types []string
+
+func (t Types) MarshalJSON() ([]byte, error) {
+	if len(t) == 1 {
+		return json.Marshal(t[0])
+	}
+	return json.Marshal([]string(t))
+}
+
+func (t *Types) UnmarshalJSON(data []byte) error {
+	var one string
+	if json.Unmarshal(data, &one) == nil {
+		*t = Types{one}
+		return nil
+	}
+	return json.Unmarshal(data, (*[]string)(t))
+}
+
+// Has reports whether t allows typ.
+func (t Types) Has(typ string) bool {
+	for _, s := range t {
+		if s == typ {
+			return true
+		}
+	}
+	return false
+}
+
+func Ref(name string) *Schema {
+	return &Schema{Ref: "#/components/schemas/" + name}
+}
+
+func Integer() *Schema { return &Schema{Type: Types{"integer"}} }
+func String() *Schema  { return &Schema{Type: Types{"string"}} }
+func Boolean() *Schema { return &Schema{Type: Types{"boolean"}} }
+
+func DateTime() *Schema {
+	return &Schema{Type: Types{"string"}, Format: "date-time"}
+}
+
+func ArrayOf(items *Schema) *Schema {
+	return &Schema{Type: Types{"array"}, Items: items}
+}
+
+// Object returns a closed object schema: properties not listed are
+// rejected.
+func Object(properties map[string]*Schema, required ...string) *Schema {
+	closed := false
+	return &Schema{Type: Types{"object"}, Properties: properties, Required: required, AdditionalProperties: &closed}
+}
+
+// Range limits a number to [min, max].
+func (s *Schema) Range(min, max float64) *Schema {
+	s.Minimum, s.Maximum = &min, &max
+	return s
+}
+
+// MaxLen limits the length of a string.
+func (s *Schema) MaxLen(n int) *Schema {
+	s.MaxLength = &n
+	return s
+}
+
+func (s *Schema) Describe(description string) *Schema {
+	s.Description = description
+	return s
+}
+
+func (s *Schema) OneOf(values ...interface{}) *Schema {
+	s.Enum = values
+	return s
+}
+
+var timeType = reflect.TypeOf(time.Time{})
+var rawMessageType = reflect.TypeOf(json.RawMessage{})
+
+// SchemaOf describes the JSON encoding of v, a struct, from its json tags.
+// Fields without omitempty are required, since they are always present, and
+// the objects are closed like those of Object.
+func SchemaOf(v interface{}) *Schema {
+	return schemaOf(reflect.TypeOf(v))
+}
+
+func schemaOf(t reflect.Type) *Schema {
+	switch t {
+	case timeType:
+		return DateTime()
+	case rawMessageType:
+		return &Schema{}
+	}
+
+	switch t.Kind() {
+	case reflect.Ptr:
+		s := schemaOf(t.Elem())
+		s.Type = append(s.Type, "null")
+		return s
+	case reflect.Bool:
+		return Boolean()
+	case reflect.Int, reflect.Int8, reflect.Int16, reflect.Int32, reflect.Int64,
+		reflect.Uint, reflect.Uint8, reflect.Uint16, reflect.Uint32, reflect.Uint64:
+		return Integer()
+	case reflect.Float32, reflect.Float64:
+		return &Schema{Type: Types{"number"}}
+	case reflect.String:
+		return String()
+	case reflect.Slice, reflect.Array:
+		return ArrayOf(schemaOf(t.Elem()))
+	case reflect.Map:
+		return &Schema{Type: Types{"object"}}
+	case reflect.Struct:
+		s := Object(make(map[string]*Schema))
+		for i := 0; i < t.NumField(); i++ {
+			f := t.Field(i)
+			if !f.IsExported() {
+				continue
+			}
+			name, opts, _ := strings.Cut(f.Tag.Get("json"), ",")
+			if name == "-" {
+				continue
+			}
+			if name == "" {
+				name = f.Name
+			}
+			s.Properties[name] = schemaOf(f.Type)
+			if !strings.Contains(opts, "omitempty") {
+				s.Required = append(s.Required, name)
+			}
+		}
+		return s
+	}
+	// interface{} and anything else may hold any value.
+	return &Schema{}
+}
+
+var pathParam = regexp.MustCompile(`\{([^}]+)\}`)
+
+// AddRoutes adds the operation of every route in r to the document. ops is
+// keyed by method and path, as in "GET /todos/{id}". Routes missing from
+// ops, or whose path parameters are not declared, are reported as an
+// error, so the document cannot silently fall behind the router.
+func (d *Document) AddRoutes(r chi.Routes, ops map[string]*Operation) error {
+	if d.Paths == nil {
+		d.Paths = make(map[string]PathItem)
+	}
+
+	var problems []string
+	err := chi.Walk(r, func(method, route string, handler http.Handler, middlewares ...func(http.Handler) http.Handler) error {
+		path := route
+		if len(path) > 1 {
+			path = strings.TrimSuffix(path, "/")
+		}
+		key := method + " " + path
+
+		op, ok := ops[key]
+		if !ok {
+			problems = append(problems, key+" has no OpenAPI entry")
+			return nil
+		}
+		for _, m := range pathParam.FindAllStringSubmatch(path, -1) {
+			if !hasParam(op, m[1], "path") {
+				problems = append(problems, fmt.Sprintf("%s does not declare path parameter %q", key, m[1]))
+			}
+		}
+
+		if d.Paths[path] == nil {
+			d.Paths[path] = make(PathItem)
+		}
+		d.Paths[path][strings.ToLower(method)] = op
+		return nil
+	})
+	if err != nil {
+		return err
+	}
+
+	if len(problems) > 0 {
+		sort.Strings(problems)
+		return fmt.Errorf("openapi: %s", strings.Join(problems, "; "))
+	}
+	return nil
+}
+
+func hasParam(op *Operation, name, in string) bool {
+	for _, p := range op.Parameters {
+		if p.Name == name && p.In == in {
+			return true
+		}
+	}
+	return false
+}
@@ -0,0 +1,362 @@
+package openapi
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"math/big"
+	"net/http"
+	"net/url"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+	"unicode/utf8"
+)
+
+// Find returns the operation serving method and path along with the
+// values of its path parameters, or nil when there is none. Literal
+// segments win over parameters, as they do in the router, so
+// /todos/archived is not taken for /todos/{id}.
+func (d *Document) Find(method, path string) (*Operation, map[string]string) {
+	if len(path) > 1 {
+		path = strings.TrimSuffix(path, "/")
+	}
+	segments := strings.Split(path, "/")
+
+	var best *Operation
+	var bestParams map[string]string
+	bestCount := -1
+	for template, item := range d.Paths {
+		op := item[strings.ToLower(method)]
+		if op == nil {
+			continue
+		}
+		params, ok := matchPath(template, segments)
+		if !ok {
+			continue
+		}
+		if best == nil || len(params) < bestCount {
+			best, bestParams, bestCount = op, params, len(params)
+		}
+	}
+	return best, bestParams
+}
+
+func matchPath(template string, segments []string) (map[string]string, bool) {
+	parts := strings.Split(template, "/")
+	if len(parts) != len(segments) {
+		return nil, false
+	}
+
+	params := make(map[string]string)
+	for i, part := range parts {
+		if strings.HasPrefix(part, "{") && strings.HasSuffix(part, "}") {
+			if segments[i] == "" {
+				return nil, false
+			}
+			value, err := url.PathUnescape(segments[i])
+			if err != nil {
+				return nil, false
+			}
+			params[part[1:len(part)-1]] = value
+		} else if part != segments[i] {
+			return nil, false
+		}
+	}
+	return params, true
+}
+
+// ValidateParams checks the path, query and header parameters of r
+// against op. Query parameters op does not declare are rejected.
+func (d *Document) ValidateParams(op *Operation, pathParams map[string]string, r *http.Request) []string {
+	var problems []string
+	query := r.URL.Query()
+
+	for _, p := range op.Parameters {
+		var values []string
+		switch p.In {
+		case "path":
+			values = []string{pathParams[p.Name]}
+		case "query":
+			values = query[p.Name]
+		case "header":
+			values = r.Header.Values(p.Name)
+		}
+
+		where := p.In + " parameter " + p.Name
+		if len(values) == 0 || values[0] == "" {
+			if p.Required {
+				problems = append(problems, where+": is required")
+			}
+			continue
+		}
+		if len(values) > 1 {
+			problems = append(problems, where+": must be given once")
+			continue
+		}
+		problems = append(problems, d.validateString(where, p.Schema, values[0])...)
+	}
+
+	var unknown []string
+	for name := range query {
+		if !hasParam(op, name, "query") {
+			unknown = append(unknown, fmt.Sprintf("query parameter %q is not allowed", name))
+		}
+	}
+	sort.Strings(unknown)
+	return append(problems, unknown...)
+}
+
+// validateString checks a parameter, which arrives as a string, by
+// converting it to the type its schema asks for.
+func (d *Document) validateString(where string, s *Schema, raw string) []string {
+	s = d.resolve(s)
+
+	var value interface{} = raw
+	switch {
+	case s.Type.Has("integer"):
+		if _, err := strconv.ParseInt(raw, 10, 64); err != nil {
+			return []string{where + ": must be an integer"}
+		}
+		value = json.Number(raw)
+	case s.Type.Has("number"):
+		if _, err := strconv.ParseFloat(raw, 64); err != nil {
+			return []string{where + ": must be a number"}
+		}
+		value = json.Number(raw)
+	case s.Type.Has("boolean"):
+		b, err := strconv.ParseBool(raw)
+		if err != nil {
+			return []string{where + ": must be true or false"}
+		}
+		value = b
+	}
+	return d.ValidateValue(where, s, value)
+}
+
+// ValidateBody decodes a JSON body and checks it against schema. Numbers
+// are kept as json.Number so integers can be told from fractions.
+func (d *Document) ValidateBody(schema *Schema, body []byte) []string {
+	dec := json.NewDecoder(bytes.NewReader(body))
+	dec.UseNumber()
+
+	var value interface{}
+	err := dec.Decode(&value)
+	if err != nil {
+		return []string{"body: invalid JSON: " + err.Error()}
+	}
+	if dec.More() {
+		return []string{"body: unexpected data after the JSON value"}
+	}
+	return d.ValidateValue("body", schema, value)
+}
+
+// ValidateValue checks a decoded JSON value against s and returns what is
+// wrong with it, each problem prefixed with where in the value it is.
+func (d *Document) ValidateValue(where string, s *Schema, value interface{}) []string {
+	if s == nil {
+		return nil
+	}
+	if s.Ref != "" {
+		return d.ValidateValue(where, d.resolve(s), value)
+	}
+
+	var problems []string
+	for _, sub := range s.AllOf {
+		problems = append(problems, d.ValidateValue(where, sub, value)...)
+	}
+	if len(s.AnyOf) > 0 {
+		ok := false
+		for _, sub := range s.AnyOf {
+			if len(d.ValidateValue(where, sub, value)) == 0 {
+				ok = true
+				break
+			}
+		}
+		if !ok {
+			problems = append(problems, where+": does not match any of the allowed forms")
+		}
+	}
+
+	if len(s.Type) > 0 && !s.Type.Has(typeOf(value)) && !(typeOf(value) == "integer" && s.Type.Has("number")) {
+		return append(problems, fmt.Sprintf("%s: must be %s", where, strings.Join(s.Type, " or ")))
+	}
+
+	if len(s.Enum) > 0 && !inEnum(s.Enum, value) {
+		problems = append(problems, fmt.Sprintf("%s: must be one of %v", where, s.Enum))
+	}
+
+	switch v := value.(type) {
+	case json.Number:
+		n, _ := new(big.Float).SetString(v.String())
+		if s.Minimum != nil && n.Cmp(big.NewFloat(*s.Minimum)) < 0 {
+			problems = append(problems, fmt.Sprintf("%s: must be at least %v", where, *s.Minimum))
+		}
+		if s.Maximum != nil && n.Cmp(big.NewFloat(*s.Maximum)) > 0 {
+			problems = append(problems, fmt.Sprintf("%s: must be at most %v", where, *s.Maximum))
+		}
+
+	case string:
+		length := utf8.RuneCountInString(v)
+		if s.MinLength != nil && length < *s.MinLength {
+			problems = append(problems, fmt.Sprintf("%s: must be at least %d characters", where, *s.MinLength))
+		}
+		if s.MaxLength != nil && length > *s.MaxLength {
+			problems = append(problems, fmt.Sprintf("%s: must be at most %d characters", where, *s.MaxLength))
+		}
+		if msg := checkFormat(s.Format, v); msg != "" {
+			problems = append(problems, where+": "+msg)
+		}
+
+	case []interface{}:
+		if s.MinItems != nil && len(v) < *s.MinItems {
+			problems = append(problems, fmt.Sprintf("%s: must have at least %d items", where, *s.MinItems))
+		}
+		if s.MaxItems != nil && len(v) > *s.MaxItems {
+			problems = append(problems, fmt.Sprintf("%s: must have at most %d items", where, *s.MaxItems))
+		}
+		for i, item := range v {
+			problems = append(problems, d.ValidateValue(fmt.Sprintf("%s[%d]", where, i), s.Items, item)...)
+		}
+
+	case map[string]interface{}:
+		for _, name := range s.Required {
+			if _, ok := v[name]; !ok {
+				problems = append(problems, fmt.Sprintf("%s.%s: is required", where, name))
+			}
+		}
+
+		names := make([]string, 0, len(v))
+		for name := range v {
+			names = append(names, name)
+		}
+		sort.Strings(names)
+		for _, name := range names {
+			prop, ok := s.Properties[name]
+			if !ok {
+				if s.AdditionalProperties != nil && !*s.AdditionalProperties {
+					problems = append(problems, fmt.Sprintf("%s: unknown field %q", where, name))
+				}
+				continue
+			}
+			problems = append(problems, d.ValidateValue(where+"."+name, prop, v[name])...)
+		}
+	}
+
+	return problems
+}
+
+func (d *Document) resolve(s *Schema) *Schema {
+	for s != nil && s.Ref != "" {
+		name := strings.TrimPrefix(s.Ref, "#/components/schemas/")
+		s = d.Components.Schemas[name]
+	}
+	if s == nil {
+		return &Schema{}
+	}
+	return s
+}
+
+func typeOf(value interface{}) string {
+	switch v := value.(type) {
+	case nil:
+		return "null"
+	case bool:
+		return "boolean"
+	case json.Number:
+		// 1.0 is a valid JSON Schema integer, but encoding/json will not
+		// decode it into an int, so it is not accepted as one here.
+		if _, err := v.Int64(); err == nil {
+			return "integer"
+		}
+		return "number"
+	case string:
+		return "string"
+	case []interface{}:
+		return "array"
+	case map[string]interface{}:
+		return "object"
+	}
+	return "unknown"
+}
+
+func inEnum(enum []interface{}, value interface{}) bool {
+	for _, e := range enum {
+		if fmt.Sprint(e) == fmt.Sprint(value) {
+			return true
+		}
+	}
+	return false
+}
+
+func checkFormat(format, v string) string {
+	switch format {
+	case "date-time":
+		if _, err := time.Parse(time.RFC3339, v); err != nil {
+			return "must be an RFC 3339 date-time"
+		}
+	case "date":
+		if _, err := time.Parse("2006-01-02", v); err != nil {
+			return "must be a YYYY-MM-DD date"
+		}
+	case "uri":
+		u, err := url.Parse(v)
+		if err != nil || u.Scheme == "" || u.Host == "" {
+			return "must be an absolute URI"
+		}
+	}
+	return ""
+}
+
+// ValidateResponse checks a response of op against the document. Only JSON
+// bodies are checked; other content types just need to be listed.
+func (d *Document) ValidateResponse(op *Operation, status int, contentType string, body []byte) []string {
+	resp := d.response(op, status)
+	if resp == nil {
+		return []string{fmt.Sprintf("status %d is not documented", status)}
+	}
+	if len(resp.Content) == 0 {
+		return nil
+	}
+
+	mediaType := strings.TrimSpace(strings.Split(contentType, ";")[0])
+	media, ok := resp.Content[mediaType]
+	if !ok {
+		return []string{fmt.Sprintf("status %d: content type %q is not documented", status, mediaType)}
+	}
+	if media.Schema == nil || mediaType != "application/json" {
+		return nil
+	}
+	return d.ValidateBody(media.Schema, body)
+}
+
+// HasJSONSuccess reports whether a successful response of op has a JSON
+// schema to check, which rules out streams and WebSockets.
+func (d *Document) HasJSONSuccess(op *Operation) bool {
+	for status := range op.Responses {
+		if !strings.HasPrefix(status, "2") {
+			continue
+		}
+		code, _ := strconv.Atoi(status)
+		resp := d.response(op, code)
+		if resp != nil && resp.Content["application/json"].Schema != nil {
+			return true
+		}
+	}
+	return false
+}
+
+func (d *Document) response(op *Operation, status int) *Response {
+	resp, ok := op.Responses[strconv.Itoa(status)]
+	if !ok {
+		resp, ok = op.Responses["default"]
+	}
+	if !ok {
+		return nil
+	}
+	for resp != nil && resp.Ref != "" {
+		resp = d.Components.Responses[strings.TrimPrefix(resp.Ref, "#/components/responses/")]
+	}
+	return resp
+}
@@ -0,0 +1,83 @@
+// Package rank orders items by strings that compare as text, so an item can
+// be placed between two others by giving it a new rank without renumbering
+// the rest.
+package rank
+
+import (
+	"fmt"
+	"strings"
+)
+
+// digits are ordered as bytes, which is how SQLite compares text.
+const digits = "0123456789abcdefghijklmnopqrstuvwxyz"
+
+const base = len(digits)
+
+// Between returns a rank after a and before b. An empty a is before every
+// rank and an empty b after every rank. Ranks it returns never end in "0",
+// which keeps a rank below every other one available.
+func Between(a, b string) (string, error) {
+	if b != "" && a >= b {
+		return "", fmt.Errorf("rank %q is not before %q", a, b)
+	}
+	if !valid(a) || !valid(b) {
+		return "", fmt.Errorf("invalid rank between %q and %q", a, b)
+	}
+	return midpoint(a, b), nil
+}
+
+// midpoint is Between for valid ranks, where a is read as a fraction in
+// base 36 and an empty b as 1.
+func midpoint(a, b string) string {
+	if b != "" {
+		// Past a shared prefix, only the rest needs a midpoint.
+		n := 0
+		for n < len(b) && digitAt(a, n) == b[n] {
+			n++
+		}
+		if n > 0 {
+			rest := ""
+			if n < len(a) {
+				rest = a[n:]
+			}
+			return b[:n] + midpoint(rest, b[n:])
+		}
+	}
+
+	da := strings.IndexByte(digits, digitAt(a, 0))
+	db := base
+	if b != "" {
+		db = strings.IndexByte(digits, b[0])
+	}
+	if db-da > 1 {
+		return string(digits[(da+db)/2])
+	}
+
+	// The first digits are next to each other. A longer b is already above
+	// its first digit alone; otherwise go after a's first digit.
+	if len(b) > 1 {
+		return b[:1]
+	}
+	rest := ""
+	if len(a) > 1 {
+		rest = a[1:]
+	}
+	return string(digits[da]) + midpoint(rest, "")
+}
+
+// digitAt is the ith digit of r, with r padded by zeros.
+func digitAt(r string, i int) byte {
+	if i < len(r) {
+		return r[i]
+	}
+	return digits[0]
+}
+
+func valid(r string) bool {
+	for i := 0; i < len(r); i++ {
+		if strings.IndexByte(digits, r[i]) < 0 {
+			return false
+		}
+	}
+	return !strings.HasSuffix(r, "0")
+}
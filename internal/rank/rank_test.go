@@ -0,0 +1,91 @@
+package rank
+
+import (
+	"strings"
+	"testing"
+)
+
+func TestBetween(t *testing.T) {
+	for _, tc := range []struct {
+		a, b, want string
+	}{
+		{"", "", "i"},
+		{"", "i", "9"},
+		{"i", "", "r"},
+		{"", "1", "0i"},
+		{"y", "", "z"},
+		{"z", "", "zi"},
+		{"a1", "a3", "a2"},
+		{"ab", "ac", "abi"},
+		{"a", "b", "ai"},
+		{"a", "az", "ah"},
+		{"", "01", "00i"},
+		{"1", "2", "1i"},
+		{"1", "11", "10i"},
+		{"1z", "2", "1zi"},
+		{"y", "z1", "z"},
+	} {
+		got, err := Between(tc.a, tc.b)
+		if err != nil {
+			t.Errorf("Between(%q, %q): %v", tc.a, tc.b, err)
+			continue
+		}
+		if got != tc.want {
+			t.Errorf("Between(%q, %q) = %q, want %q", tc.a, tc.b, got, tc.want)
+		}
+	}
+}
+
+func TestBetweenInvalid(t *testing.T) {
+	for _, tc := range []struct {
+		a, b string
+	}{
+		{"b", "a"},
+		{"a", "a"},
+		{"a1", "a"},
+		{"A", ""},
+		{"", "a-"},
+		{"a0", ""},
+		{"", "0"},
+	} {
+		got, err := Between(tc.a, tc.b)
+		if err == nil {
+			t.Errorf("Between(%q, %q) = %q, want an error", tc.a, tc.b, got)
+		}
+	}
+}
+
+// TestBetweenRepeated keeps inserting at the same places, where ranks grow
+// longest, and checks that the order holds.
+func TestBetweenRepeated(t *testing.T) {
+	ranks := []string{}
+	insert := func(i int) {
+		a, b := "", ""
+		if i > 0 {
+			a = ranks[i-1]
+		}
+		if i < len(ranks) {
+			b = ranks[i]
+		}
+		r, err := Between(a, b)
+		if err != nil {
+			t.Fatalf("Between(%q, %q): %v", a, b, err)
+		}
+		if r <= a || (b != "" && r >= b) || strings.HasSuffix(r, "0") {
+			t.Fatalf("Between(%q, %q) = %q", a, b, r)
+		}
+		ranks = append(ranks[:i], append([]string{r}, ranks[i:]...)...)
+	}
+
+	for i := 0; i < 100; i++ {
+		insert(0)
+		insert(len(ranks))
+		insert(len(ranks) / 2)
+		insert(1)
+	}
+	for i := 1; i < len(ranks); i++ {
+		if ranks[i-1] >= ranks[i] {
+			t.Fatalf("ranks %d and %d out of order: %q, %q", i-1, i, ranks[i-1], ranks[i])
+		}
+	}
+}
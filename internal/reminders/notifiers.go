@@ -0,0 +1,153 @@
+package reminders
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"io"
+	"log"
+	"mime"
+	"net/http"
+	"net/smtp"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/furkankorkmaz309/todo-api/internal/models"
+	"github.com/furkankorkmaz309/todo-api/internal/webhooks"
+)
+
+// Notification is a reminder that went off, with its todo. Location is the
+// time zone preference, for showing times to people.
+type Notification struct {
+	Reminder models.Reminder
+	Todo     models.Todo
+	Location *time.Location
+}
+
+// Subject is a one-line summary of n.
+func (n Notification) Subject() string {
+	return "Reminder: " + n.Todo.Title
+}
+
+// Text describes n for people to read.
+func (n Notification) Text() string {
+	due := n.Todo.DueDate.In(n.Location)
+	var b strings.Builder
+	fmt.Fprintf(&b, "%s\n\n", n.Todo.Title)
+	if n.Todo.AllDay {
+		fmt.Fprintf(&b, "Due: %s\n", due.Format("Monday, 2 January 2006"))
+	} else {
+		fmt.Fprintf(&b, "Due: %s\n", due.Format("Monday, 2 January 2006 15:04 MST"))
+	}
+	fmt.Fprintf(&b, "Priority: %d\n", n.Todo.Priority)
+	if n.Todo.Content != "" {
+		fmt.Fprintf(&b, "\n%s\n", n.Todo.Content)
+	}
+	return b.String()
+}
+
+// A Notifier delivers reminders through one channel. An error means the
+// reminder was not delivered and is retried.
+type Notifier interface {
+	Notify(n Notification) error
+}
+
+// LogNotifier writes reminders to a log, which is mostly useful while
+// trying reminders out.
+type LogNotifier struct {
+	Logger *log.Logger
+}
+
+func (l LogNotifier) Notify(n Notification) error {
+	l.Logger.Printf("Reminder %d for todo %d: %s, due %s", n.Reminder.ID, n.Todo.ID, n.Todo.Title, n.Todo.DueDate.In(n.Location).Format(time.RFC3339))
+	return nil
+}
+
+// WebhookNotifier posts reminders to URL as JSON, signed like webhook
+// deliveries so receivers can check them the same way.
+type WebhookNotifier struct {
+	URL    string
+	Secret string
+	Client *http.Client
+}
+
+// webhookBody is the JSON body WebhookNotifier sends, shaped like a webhook
+// delivery of a todo.reminder event.
+type webhookBody struct {
+	Event      string          `json:"event"`
+	OccurredAt time.Time       `json:"occurred_at"`
+	Data       webhookReminder `json:"data"`
+}
+
+type webhookReminder struct {
+	Reminder models.Reminder `json:"reminder"`
+	Todo     models.Todo     `json:"todo"`
+}
+
+func (wn WebhookNotifier) Notify(n Notification) error {
+	body, err := json.Marshal(webhookBody{
+		Event:      "todo.reminder",
+		OccurredAt: time.Now().UTC(),
+		Data:       webhookReminder{Reminder: n.Reminder, Todo: n.Todo},
+	})
+	if err != nil {
+		return err
+	}
+
+	req, err := http.NewRequest(http.MethodPost, wn.URL, bytes.NewReader(body))
+	if err != nil {
+		return err
+	}
+	req.Header.Set("Content-Type", "application/json")
+	req.Header.Set("User-Agent", "todo-api-reminders")
+	req.Header.Set("X-Todo-Event", "todo.reminder")
+	req.Header.Set("X-Todo-Reminder", strconv.Itoa(n.Reminder.ID))
+	req.Header.Set("X-Todo-Signature", "sha256="+webhooks.Sign(wn.Secret, body))
+
+	client := wn.Client
+	if client == nil {
+		client = &http.Client{Timeout: 10 * time.Second}
+	}
+	resp, err := client.Do(req)
+	if err != nil {
+		return err
+	}
+	defer resp.Body.Close()
+	io.Copy(io.Discard, io.LimitReader(resp.Body, 64<<10))
+
+	if resp.StatusCode < 200 || resp.StatusCode > 299 {
+		return fmt.Errorf("receiver responded %s", resp.Status)
+	}
+	return nil
+}
+
+// SMTPNotifier mails reminders through the SMTP server at Addr. Auth may be
+// nil for servers that do not ask for it, such as `todo-api mailsink`.
+type SMTPNotifier struct {
+	Addr string
+	Auth smtp.Auth
+	From string
+	To   []string
+}
+
+func (sn SMTPNotifier) Notify(n Notification) error {
+	var msg bytes.Buffer
+	fmt.Fprintf(&msg, "From: %s\r\n", sn.From)
+	fmt.Fprintf(&msg, "To: %s\r\n", strings.Join(sn.To, ", "))
+	fmt.Fprintf(&msg, "Subject: %s\r\n", mimeHeader(n.Subject()))
+	fmt.Fprintf(&msg, "Date: %s\r\n", time.Now().Format(time.RFC1123Z))
+	fmt.Fprintf(&msg, "MIME-Version: 1.0\r\n")
+	fmt.Fprintf(&msg, "Content-Type: text/plain; charset=utf-8\r\n")
+	fmt.Fprintf(&msg, "\r\n")
+	msg.WriteString(strings.ReplaceAll(n.Text(), "\n", "\r\n"))
+
+	return smtp.SendMail(sn.Addr, sn.Auth, sn.From, sn.To, msg.Bytes())
+}
+
+// mimeHeader encodes s for a header when it is not plain ASCII, and drops
+// line breaks that would start a new header.
+func mimeHeader(s string) string {
+	s = strings.NewReplacer("\r", " ", "\n", " ").Replace(s)
+	return mime.QEncoding.Encode("utf-8", s)
+}
@@ -0,0 +1,189 @@
+// Package reminders sends the reminders of todos when they go off. The
+// schedule is the reminder table itself, so reminders that came due while
+// the server was down are sent once it is back.
+package reminders
+
+import (
+	"database/sql"
+	"fmt"
+	"log"
+	"sort"
+	"time"
+
+	"github.com/furkankorkmaz309/todo-api/internal/models"
+	"github.com/furkankorkmaz309/todo-api/internal/store"
+)
+
+const (
+	StatusPending   = "pending"
+	StatusSent      = "sent"
+	StatusFailed    = "failed"
+	StatusCancelled = "cancelled" // the todo was done, cancelled or archived first
+)
+
+// Statuses lists every reminder status.
+var Statuses = []string{StatusPending, StatusSent, StatusFailed, StatusCancelled}
+
+// fireAt is when a reminder goes off, in the layout timestamps are stored
+// in so that it compares with them as text.
+const fireAt = `COALESCE(r.remind_at, strftime('%Y-%m-%d %H:%M:%f+00:00', t.due_date, '-' || r.offset_minutes || ' minutes'))`
+
+// Scheduler sends due reminders through the notifier of their channel.
+// Failed sends are retried after BaseDelay, doubling each time up to
+// MaxDelay, until MaxAttempts is reached. A reminder can be sent twice if
+// the process stops between sending it and recording that.
+type Scheduler struct {
+	DB       *sql.DB
+	InfoLog  *log.Logger
+	ErrorLog *log.Logger
+
+	MaxAttempts int
+	BaseDelay   time.Duration
+	MaxDelay    time.Duration
+
+	notifiers map[string]Notifier
+	wake      chan struct{}
+}
+
+// NewScheduler returns a scheduler with the log channel registered.
+func NewScheduler(db *sql.DB, infoLog, errorLog *log.Logger) *Scheduler {
+	s := &Scheduler{
+		DB:          db,
+		InfoLog:     infoLog,
+		ErrorLog:    errorLog,
+		MaxAttempts: 5,
+		BaseDelay:   time.Minute,
+		MaxDelay:    time.Hour,
+		notifiers:   make(map[string]Notifier),
+		wake:        make(chan struct{}, 1),
+	}
+	s.Register("log", LogNotifier{Logger: infoLog})
+	return s
+}
+
+// Register makes n the notifier of channel. It panics on duplicates, since
+// that can only be a programming error.
+func (s *Scheduler) Register(channel string, n Notifier) {
+	if _, ok := s.notifiers[channel]; ok {
+		panic(fmt.Sprintf("reminders: channel %q registered twice", channel))
+	}
+	s.notifiers[channel] = n
+}
+
+// Channels returns the registered channels, sorted.
+func (s *Scheduler) Channels() []string {
+	var names []string
+	for name := range s.notifiers {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	return names
+}
+
+func (s *Scheduler) HasChannel(channel string) bool {
+	_, ok := s.notifiers[channel]
+	return ok
+}
+
+// Notify makes Run look for due reminders now, such as after one was added
+// that is due before the next check.
+func (s *Scheduler) Notify() {
+	select {
+	case s.wake <- struct{}{}:
+	default:
+	}
+}
+
+// Run sends due reminders until the process exits, checking every
+// interval and whenever Notify is called.
+func (s *Scheduler) Run(interval time.Duration) {
+	ticker := time.NewTicker(interval)
+	defer ticker.Stop()
+
+	for {
+		s.sendDue()
+		select {
+		case <-ticker.C:
+		case <-s.wake:
+		}
+	}
+}
+
+func (s *Scheduler) sendDue() {
+	now := time.Now().UTC()
+	where := `r.status = ? AND ` + fireAt + ` <= ? AND (r.next_attempt_at IS NULL OR r.next_attempt_at <= ?)`
+	due, err := store.ListReminders(s.DB, where, StatusPending, now, now)
+	if err != nil {
+		s.ErrorLog.Printf("reminder queue: %v", err)
+		return
+	}
+
+	// ListReminders is newest first; send the oldest first.
+	for i := len(due) - 1; i >= 0; i-- {
+		s.send(due[i])
+	}
+}
+
+func (s *Scheduler) send(reminder models.Reminder) {
+	todo, err := store.SelectTodo(s.DB, reminder.TodoID)
+	if err != nil {
+		s.ErrorLog.Printf("reminder %d: %v", reminder.ID, err)
+		return
+	}
+	if store.IsClosed(todo) || todo.Archived {
+		_, err = s.DB.Exec(`UPDATE reminder SET status = ? WHERE id = ?`, StatusCancelled, reminder.ID)
+		if err != nil {
+			s.ErrorLog.Printf("reminder %d: %v", reminder.ID, err)
+		}
+		return
+	}
+
+	loc, err := store.Location(s.DB)
+	if err != nil {
+		s.ErrorLog.Printf("reminder %d: %v", reminder.ID, err)
+		return
+	}
+
+	n := Notification{Reminder: reminder, Todo: todo, Location: loc}
+	notifier, ok := s.notifiers[reminder.Channel]
+	if ok {
+		err = notifier.Notify(n)
+	} else {
+		err = fmt.Errorf("channel %q is not configured", reminder.Channel)
+	}
+	reminder.Attempts++
+	now := time.Now().UTC()
+
+	if err == nil {
+		query := `UPDATE reminder SET status = ?, attempts = ?, last_error = NULL, sent_at = ? WHERE id = ?`
+		_, err = s.DB.Exec(query, StatusSent, reminder.Attempts, now, reminder.ID)
+		if err != nil {
+			s.ErrorLog.Printf("reminder %d: %v", reminder.ID, err)
+		}
+		return
+	}
+
+	status := StatusPending
+	next := now.Add(s.backoff(reminder.Attempts))
+	if reminder.Attempts >= s.MaxAttempts {
+		status = StatusFailed
+		s.ErrorLog.Printf("reminder %d through %s failed after %d attempts: %v", reminder.ID, reminder.Channel, reminder.Attempts, err)
+	}
+
+	query := `UPDATE reminder SET status = ?, attempts = ?, last_error = ?, next_attempt_at = ? WHERE id = ?`
+	_, dbErr := s.DB.Exec(query, status, reminder.Attempts, err.Error(), next, reminder.ID)
+	if dbErr != nil {
+		s.ErrorLog.Printf("reminder %d: %v", reminder.ID, dbErr)
+	}
+}
+
+func (s *Scheduler) backoff(attempts int) time.Duration {
+	delay := s.BaseDelay
+	for i := 1; i < attempts && delay < s.MaxDelay; i++ {
+		delay *= 2
+	}
+	if delay > s.MaxDelay {
+		delay = s.MaxDelay
+	}
+	return delay
+}
@@ -0,0 +1,285 @@
+package reminders
+
+import (
+	"database/sql"
+	"errors"
+	"io"
+	"log"
+	"net"
+	"path/filepath"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/furkankorkmaz309/todo-api/internal/db"
+	"github.com/furkankorkmaz309/todo-api/internal/mailsink"
+	"github.com/furkankorkmaz309/todo-api/internal/models"
+	"github.com/furkankorkmaz309/todo-api/internal/store"
+)
+
+// recorder is a notifier that keeps what it is sent and fails with err.
+type recorder struct {
+	mu   sync.Mutex
+	sent []Notification
+	err  error
+}
+
+func (r *recorder) Notify(n Notification) error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	r.sent = append(r.sent, n)
+	return r.err
+}
+
+func (r *recorder) todoIDs() []int {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	var ids []int
+	for _, n := range r.sent {
+		ids = append(ids, n.Todo.ID)
+	}
+	return ids
+}
+
+// newTestScheduler returns a scheduler on a fresh database with rec
+// registered as the test channel.
+func newTestScheduler(t *testing.T, rec *recorder) *Scheduler {
+	t.Helper()
+	conn, err := db.InitDB(filepath.Join(t.TempDir(), "test.db"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	t.Cleanup(func() { conn.Close() })
+
+	quiet := log.New(io.Discard, "", 0)
+	s := NewScheduler(conn, quiet, quiet)
+	s.Register("test", rec)
+	return s
+}
+
+// addTodo adds a todo due in two hours. Due dates in the past cannot be
+// set through the store, so tests move them with setDue afterwards.
+func addTodo(t *testing.T, conn *sql.DB, title string) models.Todo {
+	t.Helper()
+	category := models.Category{Name: title}
+	err := store.InsertCategory(conn, &category)
+	if err != nil {
+		t.Fatal(err)
+	}
+	todo := models.Todo{Title: title, Content: "c", Priority: 3, DueDate: time.Now().Add(2 * time.Hour), CategoryID: category.ID}
+	err = store.InsertTodo(conn, &todo)
+	if err != nil {
+		t.Fatal(err)
+	}
+	return todo
+}
+
+func setDue(t *testing.T, conn *sql.DB, todoID int, due time.Time) {
+	t.Helper()
+	_, err := conn.Exec(`UPDATE todo SET due_date = ? WHERE id = ?`, due.UTC(), todoID)
+	if err != nil {
+		t.Fatal(err)
+	}
+}
+
+func addReminder(t *testing.T, conn *sql.DB, todoID, offset int, channel string) models.Reminder {
+	t.Helper()
+	reminder := models.Reminder{TodoID: todoID, OffsetMinutes: &offset, Channel: channel}
+	err := store.InsertReminder(conn, &reminder)
+	if err != nil {
+		t.Fatal(err)
+	}
+	return reminder
+}
+
+func getReminder(t *testing.T, conn *sql.DB, id int) models.Reminder {
+	t.Helper()
+	reminders, err := store.ListReminders(conn, `r.id = ?`, id)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(reminders) != 1 {
+		t.Fatalf("reminder %d not found", id)
+	}
+	return reminders[0]
+}
+
+func TestOffsetReminderFires(t *testing.T) {
+	rec := &recorder{}
+	s := newTestScheduler(t, rec)
+
+	todo := addTodo(t, s.DB, "offset")
+	early := addReminder(t, s.DB, todo.ID, 60, "test")
+	late := addReminder(t, s.DB, todo.ID, 10, "test")
+
+	s.sendDue()
+	if len(rec.sent) != 0 {
+		t.Fatalf("sent %d reminders two hours before the due date, want none", len(rec.sent))
+	}
+
+	// Due in 30 minutes: the hour-before reminder has gone off, the
+	// ten-minutes-before one has not.
+	setDue(t, s.DB, todo.ID, time.Now().Add(30*time.Minute))
+	s.sendDue()
+	if len(rec.sent) != 1 || rec.sent[0].Reminder.ID != early.ID {
+		t.Fatalf("sent %v, want only reminder %d", rec.sent, early.ID)
+	}
+
+	got := getReminder(t, s.DB, early.ID)
+	if got.Status != StatusSent || got.Attempts != 1 || got.SentAt == nil {
+		t.Errorf("sent reminder = %+v, want status sent after 1 attempt with sent_at", got)
+	}
+	if got := getReminder(t, s.DB, late.ID); got.Status != StatusPending || got.Attempts != 0 {
+		t.Errorf("reminder not yet due = %+v, want pending with no attempts", got)
+	}
+
+	// Sent reminders are not sent again.
+	s.sendDue()
+	if len(rec.sent) != 1 {
+		t.Errorf("sent %d reminders after a second check, want 1", len(rec.sent))
+	}
+}
+
+func TestRetriesBackOff(t *testing.T) {
+	rec := &recorder{err: errors.New("unreachable")}
+	s := newTestScheduler(t, rec)
+	s.MaxAttempts = 3
+	s.BaseDelay = time.Hour
+	s.MaxDelay = 4 * time.Hour
+
+	todo := addTodo(t, s.DB, "retry")
+	reminder := addReminder(t, s.DB, todo.ID, 60, "test")
+	setDue(t, s.DB, todo.ID, time.Now().Add(30*time.Minute))
+
+	for attempt := 1; attempt <= s.MaxAttempts; attempt++ {
+		before := time.Now().UTC()
+		s.sendDue()
+		if len(rec.sent) != attempt {
+			t.Fatalf("attempt %d: sent %d times, want %d", attempt, len(rec.sent), attempt)
+		}
+
+		got := getReminder(t, s.DB, reminder.ID)
+		wantStatus := StatusPending
+		if attempt == s.MaxAttempts {
+			wantStatus = StatusFailed
+		}
+		if got.Status != wantStatus || got.Attempts != attempt || got.LastError != "unreachable" {
+			t.Errorf("attempt %d: reminder = %+v, want %s with last_error unreachable", attempt, got, wantStatus)
+		}
+
+		var next time.Time
+		err := s.DB.QueryRow(`SELECT next_attempt_at FROM reminder WHERE id = ?`, reminder.ID).Scan(&next)
+		if err != nil {
+			t.Fatal(err)
+		}
+		delay := s.backoff(attempt)
+		if next.Before(before.Add(delay)) || next.After(time.Now().Add(delay)) {
+			t.Errorf("attempt %d: next attempt at %v, want %v after %v", attempt, next, delay, before)
+		}
+
+		// Not retried before next_attempt_at.
+		s.sendDue()
+		if len(rec.sent) != attempt {
+			t.Fatalf("attempt %d: retried before the backoff ran out", attempt)
+		}
+		_, err = s.DB.Exec(`UPDATE reminder SET next_attempt_at = ? WHERE id = ?`, time.Now().Add(-time.Second).UTC(), reminder.ID)
+		if err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	// Failed reminders are given up on.
+	s.sendDue()
+	if len(rec.sent) != s.MaxAttempts {
+		t.Errorf("sent %d times after failing, want %d", len(rec.sent), s.MaxAttempts)
+	}
+}
+
+func TestBackoff(t *testing.T) {
+	s := &Scheduler{BaseDelay: time.Minute, MaxDelay: time.Hour}
+	for _, tc := range []struct {
+		attempts int
+		want     time.Duration
+	}{
+		{1, time.Minute},
+		{2, 2 * time.Minute},
+		{3, 4 * time.Minute},
+		{6, 32 * time.Minute},
+		{7, time.Hour},
+		{50, time.Hour},
+	} {
+		if got := s.backoff(tc.attempts); got != tc.want {
+			t.Errorf("backoff(%d) = %v, want %v", tc.attempts, got, tc.want)
+		}
+	}
+}
+
+func TestClosedTodosCancelReminders(t *testing.T) {
+	rec := &recorder{}
+	s := newTestScheduler(t, rec)
+
+	var reminders []models.Reminder
+	var todos []models.Todo
+	for _, title := range []string{"open", "done", "cancelled", "archived"} {
+		todo := addTodo(t, s.DB, title)
+		todos = append(todos, todo)
+		reminders = append(reminders, addReminder(t, s.DB, todo.ID, 60, "test"))
+		setDue(t, s.DB, todo.ID, time.Now().Add(30*time.Minute))
+	}
+	for i, query := range []string{
+		`UPDATE todo SET status = 'done', done = 1 WHERE id = ?`,
+		`UPDATE todo SET status = 'cancelled' WHERE id = ?`,
+		`UPDATE todo SET archived = 1 WHERE id = ?`,
+	} {
+		_, err := s.DB.Exec(query, todos[i+1].ID)
+		if err != nil {
+			t.Fatal(err)
+		}
+	}
+	s.sendDue()
+
+	if ids := rec.todoIDs(); len(ids) != 1 || ids[0] != todos[0].ID {
+		t.Fatalf("sent reminders of todos %v, want only the open one, %d", ids, todos[0].ID)
+	}
+	want := []string{StatusSent, StatusCancelled, StatusCancelled, StatusCancelled}
+	for i, reminder := range reminders {
+		if got := getReminder(t, s.DB, reminder.ID); got.Status != want[i] || (want[i] == StatusCancelled && got.Attempts != 0) {
+			t.Errorf("reminder %d = %+v, want %s", i, got, want[i])
+		}
+	}
+}
+
+func TestSMTPNotifier(t *testing.T) {
+	l, err := net.Listen("tcp", "127.0.0.1:0")
+	if err != nil {
+		t.Fatal(err)
+	}
+	t.Cleanup(func() { l.Close() })
+	received := make(chan mailsink.Message, 1)
+	go mailsink.Serve(l, func(msg mailsink.Message) { received <- msg })
+
+	s := newTestScheduler(t, &recorder{})
+	s.Register("email", SMTPNotifier{Addr: l.Addr().String(), From: "todo@example.com", To: []string{"me@example.com"}})
+
+	todo := addTodo(t, s.DB, "pay rent")
+	reminder := addReminder(t, s.DB, todo.ID, 60, "email")
+	setDue(t, s.DB, todo.ID, time.Now().Add(30*time.Minute))
+	s.sendDue()
+
+	select {
+	case msg := <-received:
+		if msg.From != "todo@example.com" || len(msg.To) != 1 || msg.To[0] != "me@example.com" {
+			t.Errorf("mail from %q to %v, want from todo@example.com to [me@example.com]", msg.From, msg.To)
+		}
+		if !strings.Contains(msg.Data, "Subject: Reminder: pay rent\r\n") || !strings.Contains(msg.Data, "\r\n\r\npay rent\r\n") {
+			t.Errorf("mail = %q, want the subject and body of the reminder", msg.Data)
+		}
+	case <-time.After(5 * time.Second):
+		t.Fatal("no mail received")
+	}
+
+	if got := getReminder(t, s.DB, reminder.ID); got.Status != StatusSent {
+		t.Errorf("reminder = %+v, want sent", got)
+	}
+}
@@ -0,0 +1,112 @@
+package routes
+
+import (
+	"fmt"
+	"net/http"
+	"testing"
+	"time"
+
+	"github.com/furkankorkmaz309/todo-api/internal/models"
+)
+
+// columnTodos returns the IDs of the todos in the column with the given
+// status, in board order.
+func columnTodos(t *testing.T, board models.Board, status string) []int {
+	t.Helper()
+	for _, column := range board.Columns {
+		if column.Status == status {
+			ids := []int{}
+			for _, todo := range column.Todos {
+				ids = append(ids, todo.ID)
+			}
+			return ids
+		}
+	}
+	t.Fatalf("board %d has no %s column", board.ID, status)
+	return nil
+}
+
+func columnID(t *testing.T, board models.Board, status string) int {
+	t.Helper()
+	for _, column := range board.Columns {
+		if column.Status == status {
+			return column.ID
+		}
+	}
+	t.Fatalf("board %d has no %s column", board.ID, status)
+	return 0
+}
+
+func TestMoveBoardTodo(t *testing.T) {
+	a, _ := newTestApp(t)
+	h := Routes(a)
+	due := time.Now().Add(72 * time.Hour).UTC().Format(time.RFC3339)
+
+	call(t, h, "POST", "/categories", `{"name":"work"}`, http.StatusCreated, nil)
+	for _, title := range []string{"one", "two", "three"} {
+		call(t, h, "POST", "/todos", fmt.Sprintf(`{"title":%q,"content":"c","priority":3,"due_date":%q,"category_id":1}`, title, due), http.StatusCreated, nil)
+	}
+	call(t, h, "POST", "/todos", fmt.Sprintf(`{"title":"dropped","content":"c","priority":3,"due_date":%q,"category_id":1,"status":"cancelled"}`, due), http.StatusCreated, nil)
+
+	var board models.Board
+	call(t, h, "POST", "/boards", `{"name":"flow","column_by":"status"}`, http.StatusCreated, &board)
+	todoColumn, doneColumn := columnID(t, board, "todo"), columnID(t, board, "done")
+	move := func(body string, want int) models.Board {
+		t.Helper()
+		var moved models.Board
+		var data interface{}
+		if want == http.StatusOK {
+			data = &moved
+		}
+		call(t, h, "POST", fmt.Sprintf("/boards/%d/move", board.ID), body, want, data)
+		return moved
+	}
+
+	// Within a column: first, after another todo, and back again.
+	moved := move(fmt.Sprintf(`{"todo_id":3,"column_id":%d}`, todoColumn), http.StatusOK)
+	if got := columnTodos(t, moved, "todo"); fmt.Sprint(got) != "[3 1 2]" {
+		t.Errorf("after moving 3 first, todo column = %v, want [3 1 2]", got)
+	}
+	moved = move(fmt.Sprintf(`{"todo_id":2,"column_id":%d,"after_id":3}`, todoColumn), http.StatusOK)
+	if got := columnTodos(t, moved, "todo"); fmt.Sprint(got) != "[3 2 1]" {
+		t.Errorf("after moving 2 after 3, todo column = %v, want [3 2 1]", got)
+	}
+	moved = move(fmt.Sprintf(`{"todo_id":3,"column_id":%d,"after_id":1}`, todoColumn), http.StatusOK)
+	if got := columnTodos(t, moved, "todo"); fmt.Sprint(got) != "[2 1 3]" {
+		t.Errorf("after moving 3 after 1, todo column = %v, want [2 1 3]", got)
+	}
+
+	// To another column, which changes the status.
+	moved = move(fmt.Sprintf(`{"todo_id":1,"column_id":%d}`, doneColumn), http.StatusOK)
+	if got := columnTodos(t, moved, "todo"); fmt.Sprint(got) != "[2 3]" {
+		t.Errorf("after moving 1 to done, todo column = %v, want [2 3]", got)
+	}
+	if got := columnTodos(t, moved, "done"); fmt.Sprint(got) != "[1]" {
+		t.Errorf("after moving 1 to done, done column = %v, want [1]", got)
+	}
+	var todo models.Todo
+	call(t, h, "GET", "/todos/1", "", http.StatusOK, &todo)
+	if todo.Status != "done" || !todo.IsDone || todo.CompletedAt == nil {
+		t.Errorf("todo moved to done = %+v, want done with completed_at", todo)
+	}
+	moved = move(fmt.Sprintf(`{"todo_id":2,"column_id":%d,"after_id":1}`, doneColumn), http.StatusOK)
+	if got := columnTodos(t, moved, "done"); fmt.Sprint(got) != "[1 2]" {
+		t.Errorf("after moving 2 after 1 in done, done column = %v, want [1 2]", got)
+	}
+
+	// A cancelled todo can only go back to todo, and stays put otherwise.
+	move(fmt.Sprintf(`{"todo_id":4,"column_id":%d}`, doneColumn), http.StatusBadRequest)
+	call(t, h, "GET", "/todos/4", "", http.StatusOK, &todo)
+	if todo.Status != "cancelled" {
+		t.Errorf("todo after a refused move has status %q, want cancelled", todo.Status)
+	}
+	call(t, h, "GET", fmt.Sprintf("/boards/%d", board.ID), "", http.StatusOK, &board)
+	if got := columnTodos(t, board, "cancelled"); fmt.Sprint(got) != "[4]" {
+		t.Errorf("cancelled column after a refused move = %v, want [4]", got)
+	}
+
+	// Unknown columns and todos not in the target column are refused.
+	move(fmt.Sprintf(`{"todo_id":3,"column_id":%d}`, 9999), http.StatusBadRequest)
+	move(fmt.Sprintf(`{"todo_id":3,"column_id":%d,"after_id":1}`, todoColumn), http.StatusBadRequest)
+	move(fmt.Sprintf(`{"todo_id":404,"column_id":%d}`, todoColumn), http.StatusNotFound)
+}
@@ -0,0 +1,80 @@
+package routes
+
+import (
+	"fmt"
+	"net/http"
+	"testing"
+	"time"
+
+	"github.com/furkankorkmaz309/todo-api/internal/models"
+)
+
+// TestEscalationDryRun checks that a dry run reports what a run does
+// without changing anything, and that a run acts once per due date.
+func TestEscalationDryRun(t *testing.T) {
+	a, _ := newTestApp(t)
+	h := Routes(a)
+	due := time.Now().Add(72 * time.Hour).UTC().Format(time.RFC3339)
+
+	call(t, h, "POST", "/categories", `{"name":"work"}`, http.StatusCreated, nil)
+	call(t, h, "POST", "/categories", `{"name":"late"}`, http.StatusCreated, nil)
+	call(t, h, "POST", "/todos", fmt.Sprintf(`{"title":"a","content":"b","priority":2,"due_date":%q,"category_id":1}`, due), http.StatusCreated, nil)
+	call(t, h, "POST", "/escalations/rules", `{"name":"bump","after_days":1,"action":"bump_priority"}`, http.StatusCreated, nil)
+	call(t, h, "POST", "/escalations/rules", `{"name":"move","after_days":2,"action":"move_category","category_id":2}`, http.StatusCreated, nil)
+	call(t, h, "POST", "/escalations/rules", `{"name":"notify","after_days":2,"action":"notify"}`, http.StatusCreated, nil)
+
+	// Past due dates cannot be set through the API.
+	_, err := a.DB.Exec(`UPDATE todo SET due_date = ? WHERE id = 1`, time.Now().Add(-3*24*time.Hour).UTC())
+	if err != nil {
+		t.Fatal(err)
+	}
+	var before models.Todo
+	call(t, h, "GET", "/todos/1", "", http.StatusOK, &before)
+
+	counts := func() (escalations, reminders int) {
+		t.Helper()
+		err := a.DB.QueryRow(`SELECT (SELECT COUNT(*) FROM escalation), (SELECT COUNT(*) FROM reminder)`).Scan(&escalations, &reminders)
+		if err != nil {
+			t.Fatal(err)
+		}
+		return escalations, reminders
+	}
+
+	var dry []models.Escalation
+	for i := 0; i < 2; i++ {
+		call(t, h, "POST", "/escalations/run?dry_run=true", "", http.StatusOK, &dry)
+		if len(dry) != 3 {
+			t.Fatalf("dry run %d reported %+v, want all three rules", i+1, dry)
+		}
+	}
+	if dry[0].Detail != "priority 2 to 3" || dry[1].Detail != "category 1 to 2" || dry[2].Detail != "remind through log" {
+		t.Errorf("dry run = %+v, want the bump, the move and the reminder", dry)
+	}
+
+	var after models.Todo
+	call(t, h, "GET", "/todos/1", "", http.StatusOK, &after)
+	if after.Priority != before.Priority || after.CategoryID != before.CategoryID || after.Version != before.Version {
+		t.Errorf("todo after a dry run = %+v, want unchanged from %+v", after, before)
+	}
+	if escalations, reminders := counts(); escalations != 0 || reminders != 0 {
+		t.Errorf("after a dry run, %d escalations and %d reminders stored, want none", escalations, reminders)
+	}
+
+	var report []models.Escalation
+	call(t, h, "POST", "/escalations/run", "", http.StatusOK, &report)
+	if len(report) != 3 {
+		t.Fatalf("run reported %+v, want all three rules", report)
+	}
+	call(t, h, "GET", "/todos/1", "", http.StatusOK, &after)
+	if after.Priority != 3 || after.CategoryID != 2 {
+		t.Errorf("todo after a run = %+v, want priority 3 in category 2", after)
+	}
+	if escalations, reminders := counts(); escalations != 3 || reminders != 1 {
+		t.Errorf("after a run, %d escalations and %d reminders stored, want 3 and 1", escalations, reminders)
+	}
+
+	call(t, h, "POST", "/escalations/run", "", http.StatusOK, &report)
+	if len(report) != 0 {
+		t.Errorf("second run reported %+v, want nothing at the same due date", report)
+	}
+}
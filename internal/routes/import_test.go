@@ -0,0 +1,170 @@
+package routes
+
+import (
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"reflect"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/furkankorkmaz309/todo-api/internal/handlers"
+	"github.com/furkankorkmaz309/todo-api/internal/models"
+)
+
+// exported is the JSON export, decoded.
+type exported struct {
+	Categories []models.Category `json:"categories"`
+	Todos      []struct {
+		models.Todo
+		Category string `json:"category"`
+	} `json:"todos"`
+}
+
+// export returns the body of GET /export in format.
+func export(t *testing.T, h http.Handler, format string) string {
+	t.Helper()
+	rec := httptest.NewRecorder()
+	h.ServeHTTP(rec, httptest.NewRequest("GET", "/export?format="+format, nil))
+	if rec.Code != http.StatusOK {
+		t.Fatalf("export as %s = %d %s", format, rec.Code, rec.Body)
+	}
+	return rec.Body.String()
+}
+
+// exportedData decodes the JSON export of h, leaving out the version,
+// which starts over on import.
+func exportedData(t *testing.T, h http.Handler) exported {
+	t.Helper()
+	var data exported
+	err := json.Unmarshal([]byte(export(t, h, "json")), &data)
+	if err != nil {
+		t.Fatal(err)
+	}
+	for i := range data.Todos {
+		data.Todos[i].Version = 0
+	}
+	return data
+}
+
+func TestExportImportRoundTrip(t *testing.T) {
+	a, _ := newTestApp(t)
+	h := Routes(a)
+	due := time.Now().Add(72 * time.Hour).UTC().Format(time.RFC3339)
+
+	call(t, h, "POST", "/categories", `{"name":"work","description":"the office, mostly"}`, http.StatusCreated, nil)
+	call(t, h, "POST", "/categories", `{"name":"home"}`, http.StatusCreated, nil)
+	for _, body := range []string{
+		`{"title":"report","content":"line one\nline \"two\", three","priority":4,"due_date":%q,"category_id":1,"tags":["a","b"]}`,
+		`{"title":"groceries","content":"milk","priority":2,"due_date":%q,"category_id":2}`,
+		`{"title":"taxes","content":"file them","priority":5,"due_date":%q,"category_id":1}`,
+		`{"title":"old","content":"archived","priority":1,"due_date":%q,"category_id":2}`,
+		`{"title":"dropped","content":"cancelled","priority":3,"due_date":%q,"category_id":2}`,
+	} {
+		call(t, h, "POST", "/todos", fmt.Sprintf(body, due), http.StatusCreated, nil)
+	}
+	call(t, h, "POST", "/todos", `{"title":"party","content":"all day","priority":3,"due_date":"2030-06-01","category_id":2}`, http.StatusCreated, nil)
+	call(t, h, "PATCH", "/todos/2", `{"status":"in_progress"}`, http.StatusOK, nil)
+	call(t, h, "PATCH", "/todos/3", `{"status":"done"}`, http.StatusOK, nil)
+	call(t, h, "POST", "/todos/4/archive", "", http.StatusOK, nil)
+	call(t, h, "PATCH", "/todos/5", `{"status":"cancelled"}`, http.StatusOK, nil)
+
+	want := exportedData(t, h)
+	if len(want.Categories) != 2 || len(want.Todos) != 6 {
+		t.Fatalf("export = %+v, want 2 categories and 6 todos", want)
+	}
+
+	for _, format := range []string{"csv", "json", "ndjson"} {
+		b, _ := newTestApp(t)
+		hb := Routes(b)
+
+		var report handlers.ImportReport
+		call(t, hb, "POST", "/import?format="+format, export(t, h, format), http.StatusOK, &report)
+		if report.CategoriesCreated != 2 || report.TodosImported != 6 || len(report.Errors) != 0 {
+			t.Errorf("%s: import report = %+v, want 2 categories and 6 todos", format, report)
+		}
+
+		if got := exportedData(t, hb); !reflect.DeepEqual(got, want) {
+			t.Errorf("%s: after the round trip\n got %+v\nwant %+v", format, got, want)
+		}
+	}
+}
+
+func TestImportFormats(t *testing.T) {
+	for _, tc := range []struct {
+		format string
+		input  string
+		todos  []string // titles imported, in order
+		rows   []int    // rows that fail
+	}{
+		{
+			format: "todotxt",
+			input:  "(A) Call mom +family @phone due:2026-03-05\n+family @home\nNo project at all\nx 2026-03-02 Done +chores\n",
+			todos:  []string{"Call mom", "Done"},
+			rows:   []int{2, 3},
+		},
+		{
+			format: "todoist",
+			input: `{"projects":[{"id":"1","name":"Work"}],"items":[
+				{"content":"Ship it","priority":4,"project_id":"1"},
+				{"content":"","priority":1,"project_id":"1"},
+				{"content":"Lost","project_id":"9"}]}`,
+			todos: []string{"Ship it"},
+			rows:  []int{2, 3},
+		},
+		{
+			format: "trello",
+			input: `{"lists":[{"id":"l1","name":"Doing"}],"cards":[
+				{"name":"Review","idList":"l1","labels":[{"name":"urgent"}]},
+				{"name":"","idList":"l1"},
+				{"name":"Closed","idList":"l1","closed":true}]}`,
+			todos: []string{"Review", "Closed"},
+			rows:  []int{2},
+		},
+	} {
+		a, _ := newTestApp(t)
+		h := Routes(a)
+		path := "/import/" + tc.format + "?create_categories=true"
+
+		before := exportedData(t, h)
+		var dry handlers.ImportReport
+		call(t, h, "POST", path+"&dry_run=true", tc.input, http.StatusOK, &dry)
+		if after := exportedData(t, h); !reflect.DeepEqual(after, before) {
+			t.Errorf("%s: a dry run changed the data to %+v", tc.format, after)
+		}
+
+		var report handlers.ImportReport
+		call(t, h, "POST", path, tc.input, http.StatusOK, &report)
+		if !dry.DryRun || report.DryRun || dry.TodosImported != report.TodosImported || dry.CategoriesCreated != report.CategoriesCreated ||
+			!reflect.DeepEqual(dry.Errors, report.Errors) {
+			t.Errorf("%s: dry run reported %+v, the import %+v; want the same", tc.format, dry, report)
+		}
+
+		var rows []int
+		for _, e := range report.Errors {
+			rows = append(rows, e.Row)
+		}
+		if report.TodosImported != len(tc.todos) || !reflect.DeepEqual(rows, tc.rows) {
+			t.Errorf("%s: report = %+v, want %d todos and rows %v failing", tc.format, report, len(tc.todos), tc.rows)
+		}
+
+		var titles []string
+		for _, todo := range exportedData(t, h).Todos {
+			titles = append(titles, todo.Title)
+			if todo.Category == "" || todo.Content == "" || todo.Priority == 0 || todo.DueDate.IsZero() {
+				t.Errorf("%s: imported %+v, want category, content, priority and due date filled in", tc.format, todo)
+			}
+		}
+		if !reflect.DeepEqual(titles, tc.todos) {
+			t.Errorf("%s: imported %v, want %v", tc.format, titles, tc.todos)
+		}
+	}
+
+	a, _ := newTestApp(t)
+	env := call(t, Routes(a), "POST", "/import/taskwarrior", "[]", http.StatusBadRequest, nil)
+	if !strings.Contains(env.Error, "todotxt") {
+		t.Errorf("unknown format error = %q, want the formats listed", env.Error)
+	}
+}
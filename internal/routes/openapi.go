@@ -0,0 +1,782 @@
+package routes
+
+import (
+	"github.com/furkankorkmaz309/todo-api/internal/backup"
+	"github.com/furkankorkmaz309/todo-api/internal/events"
+	"github.com/furkankorkmaz309/todo-api/internal/handlers"
+	"github.com/furkankorkmaz309/todo-api/internal/importer"
+	"github.com/furkankorkmaz309/todo-api/internal/models"
+	"github.com/furkankorkmaz309/todo-api/internal/openapi"
+	"github.com/furkankorkmaz309/todo-api/internal/reminders"
+	"github.com/furkankorkmaz309/todo-api/internal/store"
+	"github.com/furkankorkmaz309/todo-api/internal/webhooks"
+)
+
+// newSpec returns the document header and components. The paths are added
+// from the router by Document.AddRoutes, using operations.
+func newSpec() *openapi.Document {
+	todo := openapi.SchemaOf(models.Todo{})
+	todo.Properties["priority"].Range(1, 5).Describe("1 (lowest) to 5 (highest)")
+	todo.Properties["category_id"].Describe("0 when the todo has no category")
+	todo.Properties["status"] = stringEnum(store.TodoStatuses)
+	todo.Properties["version"].Describe("Incremented on every change; send it back on PATCH to detect conflicting edits")
+
+	envelope := openapi.SchemaOf(handlers.APIResponse{})
+	envelope.Description = "Every JSON response is wrapped in this envelope."
+
+	return &openapi.Document{
+		OpenAPI: openapi.Version,
+		Info: openapi.Info{
+			Title:       "todo-api",
+			Version:     "1.0.0",
+			Description: "Todos and categories, with import/export, calendar feeds, webhooks and live updates.",
+		},
+		Components: openapi.Components{
+			Schemas: map[string]*openapi.Schema{
+				"APIResponse": envelope,
+				"Todo":        todo,
+				"TodoInput": openapi.Object(map[string]*openapi.Schema{
+					"title":       openapi.String(),
+					"content":     openapi.String(),
+					"priority":    openapi.Integer().Range(1, 5),
+					"due_date":    dueDate().Describe("Must be in the future; " + dueDate().Description),
+					"all_day":     allDay(),
+					"status":      stringEnum(store.TodoStatuses).Describe("todo by default"),
+					"category_id": openapi.Integer(),
+					"tags":        openapi.ArrayOf(openapi.String().MaxLen(30)),
+				}, "title", "content", "priority", "due_date"),
+				"TodoPatch": openapi.Object(map[string]*openapi.Schema{
+					"title":       openapi.String(),
+					"content":     openapi.String(),
+					"priority":    openapi.Integer().Range(0, 5).Describe("0 leaves the priority unchanged"),
+					"due_date":    dueDate(),
+					"all_day":     allDay().Describe(allDay().Description + "; only read along with due_date"),
+					"status":      stringEnum(store.TodoStatuses).Describe("Must be allowed by the transitions of GET /settings"),
+					"is_done":     openapi.Boolean().Describe("Without status: true makes the todo done and false reopens a done todo; missing leaves it unchanged"),
+					"category_id": openapi.Integer(),
+					"tags":        openapi.ArrayOf(openapi.String().MaxLen(30)).Describe("Replaces all tags when present"),
+					"version":     openapi.Integer().Describe("Rejects the update with 409 unless it is the current version"),
+				}),
+				"Category": openapi.SchemaOf(models.Category{}),
+				"CategoryInput": openapi.Object(map[string]*openapi.Schema{
+					"name":        openapi.String().MaxLen(30),
+					"description": openapi.String().MaxLen(100),
+				}),
+				"CalendarToken": openapi.SchemaOf(models.CalendarToken{}),
+				"CalendarTokenInput": openapi.Object(map[string]*openapi.Schema{
+					"name":        openapi.String().MaxLen(30),
+					"category_id": openapi.Integer().Describe("Limits the feed to one category"),
+				}, "name"),
+				"Webhook": openapi.SchemaOf(models.Webhook{}),
+				"WebhookInput": openapi.Object(map[string]*openapi.Schema{
+					"url":    (&openapi.Schema{Type: openapi.Types{"string"}, Format: "uri"}),
+					"secret": openapi.String().Describe("Generated when missing"),
+					"events": openapi.ArrayOf(eventFilter()).Describe(`Defaults to ["*"]`),
+				}, "url"),
+				"WebhookPatch": openapi.Object(map[string]*openapi.Schema{
+					"active": openapi.Boolean(),
+				}, "active"),
+				"WebhookDelivery": openapi.SchemaOf(models.WebhookDelivery{}),
+				"BulkRequest": openapi.Object(map[string]*openapi.Schema{
+					"mode": openapi.String().OneOf("atomic", "best_effort").Describe("atomic rolls back every operation when one fails"),
+					"operations": &openapi.Schema{
+						Type:     openapi.Types{"array"},
+						Items:    openapi.Ref("BulkOperation"),
+						MinItems: intPtr(1),
+						MaxItems: intPtr(1000),
+					},
+				}, "operations"),
+				"BulkOperation": openapi.Object(map[string]*openapi.Schema{
+					"op":          openapi.String().OneOf("create", "update", "delete", "complete", "move_category"),
+					"id":          openapi.Integer().Describe("The todo, for every op but create"),
+					"category_id": openapi.Integer().Describe("For move_category"),
+					"todo":        openapi.Ref("TodoPatch").Describe("For create and update"),
+				}, "op"),
+				"BulkResult": openapi.Object(map[string]*openapi.Schema{
+					"index":   openapi.Integer(),
+					"op":      openapi.String(),
+					"id":      openapi.Integer(),
+					"success": openapi.Boolean(),
+					"error":   openapi.String(),
+					"todo":    openapi.Ref("Todo"),
+				}, "index", "op", "success"),
+				"DueDatePreview": openapi.Object(map[string]*openapi.Schema{
+					"text":     openapi.String(),
+					"due_date": openapi.DateTime(),
+					"all_day":  openapi.Boolean(),
+					"timezone": openapi.String(),
+				}, "text", "due_date", "all_day", "timezone"),
+				"Reminder": openapi.SchemaOf(models.Reminder{}),
+				"ReminderInput": openapi.Object(map[string]*openapi.Schema{
+					"remind_at":      dueDate().Describe("When to remind; " + dueDate().Description),
+					"offset_minutes": openapi.Integer().Describe("Minutes before the due date to remind, following it when it moves"),
+					"channel":        openapi.String().Describe("One of GET /reminders/channels; log by default"),
+				}),
+				"EscalationRule": openapi.SchemaOf(models.EscalationRule{}),
+				"EscalationRuleInput": openapi.Object(map[string]*openapi.Schema{
+					"name":        openapi.String().MaxLen(50),
+					"after_days":  openapi.Integer().Describe("Days a todo has been overdue before the rule acts on it"),
+					"action":      stringEnum(store.EscalationActions).Describe("bump_priority raises the priority by one, up to 5"),
+					"category_id": openapi.Integer().Describe("Where move_category moves todos"),
+					"channel":     openapi.String().Describe("How notify reminds, one of GET /reminders/channels; log by default"),
+				}, "name", "after_days", "action"),
+				"EscalationRulePatch": openapi.Object(map[string]*openapi.Schema{
+					"name":        openapi.String().MaxLen(50),
+					"after_days":  openapi.Integer(),
+					"category_id": openapi.Integer(),
+					"channel":     openapi.String(),
+					"active":      openapi.Boolean(),
+				}),
+				"Escalation": openapi.SchemaOf(models.Escalation{}),
+				"Settings":   settings(),
+				"SettingsInput": openapi.Object(map[string]*openapi.Schema{
+					"timezone":    openapi.String().Describe("IANA time zone, such as Europe/Istanbul; empty for the server's"),
+					"transitions": transitions().Describe("Replaces all transitions; empty for the defaults"),
+				}),
+				"Board": board(),
+				"BoardInput": openapi.Object(map[string]*openapi.Schema{
+					"name":      openapi.String().MaxLen(50),
+					"column_by": stringEnum(store.BoardColumnBy),
+					"columns":   openapi.ArrayOf(openapi.Ref("BoardColumnInput")).Describe("One per status, or per category, by default"),
+				}, "name", "column_by"),
+				"BoardPatch": openapi.Object(map[string]*openapi.Schema{
+					"name":    openapi.String().MaxLen(50),
+					"columns": openapi.ArrayOf(openapi.Ref("BoardColumnInput")).Describe("Replaces the columns; columns that hold the same status or category keep their ID"),
+				}),
+				"BoardColumnInput": openapi.Object(map[string]*openapi.Schema{
+					"name":        openapi.String().MaxLen(50).Describe("The status or category name by default"),
+					"status":      stringEnum(store.TodoStatuses).Describe("For boards by status"),
+					"category_id": openapi.Integer().Describe("For boards by category"),
+				}),
+				"BoardMove": openapi.Object(map[string]*openapi.Schema{
+					"todo_id":   openapi.Integer(),
+					"column_id": openapi.Integer(),
+					"after_id":  openapi.Integer().Describe("The todo of the column to go after; 0 or missing for the top"),
+				}, "todo_id", "column_id"),
+				"Stats":        openapi.SchemaOf(store.Stats{}),
+				"ImportReport": openapi.SchemaOf(handlers.ImportReport{}),
+				"Backup":       openapi.SchemaOf(backup.Backup{}),
+				"GraphQLRequest": openapi.Object(map[string]*openapi.Schema{
+					"query":         openapi.String(),
+					"operationName": openapi.String(),
+					"variables":     &openapi.Schema{Type: openapi.Types{"object", "null"}},
+				}, "query"),
+			},
+			Responses: map[string]*openapi.Response{
+				"BadRequest":      errorResponse("The request is invalid"),
+				"Unauthorized":    errorResponse("The token is missing or unknown"),
+				"Forbidden":       errorResponse("The endpoint is disabled"),
+				"NotFound":        errorResponse("The resource does not exist"),
+				"Conflict":        errorResponse("The change conflicts with the current state"),
+				"TooManyRequests": errorResponse("More than 60 requests in a minute"),
+				"InternalError":   errorResponse("The server failed"),
+			},
+			SecuritySchemes: map[string]*openapi.SecurityScheme{
+				"admin": {Type: "http", Scheme: "bearer", Description: "The server's -admin-token"},
+			},
+		},
+	}
+}
+
+// operations describes every route of Routes; AddRoutes refuses a router
+// with a route that is missing here.
+var operations = map[string]*openapi.Operation{
+	"GET /": {
+		Summary:   "Welcome message",
+		Tags:      []string{"meta"},
+		Responses: responses("200", "Welcome", nil),
+	},
+	"GET /openapi.json": {
+		Summary:   "This document",
+		Tags:      []string{"meta"},
+		Responses: raw("200", "The OpenAPI document", "application/json"),
+	},
+	"GET /docs": {
+		Summary:   "Swagger UI for this document",
+		Tags:      []string{"meta"},
+		Responses: raw("200", "HTML page", "text/html"),
+	},
+
+	"GET /todos": {
+		Summary:    "List unarchived todos",
+		Tags:       []string{"todos"},
+		Parameters: filterParams(),
+		Responses:  responses("200", "The todos", listOf("Todo"), "400"),
+	},
+	"POST /todos": {
+		Summary:     "Create a todo",
+		Tags:        []string{"todos"},
+		Parameters:  []*openapi.Parameter{idempotencyKey(), timezone()},
+		RequestBody: jsonBody(openapi.Ref("TodoInput")),
+		Responses:   responses("201", "The created todo", openapi.Ref("Todo"), "400", "404", "409"),
+	},
+	"POST /todos/bulk": {
+		Summary:     "Run many todo operations in one transaction",
+		Tags:        []string{"todos"},
+		Parameters:  []*openapi.Parameter{idempotencyKey(), timezone()},
+		RequestBody: jsonBody(openapi.Ref("BulkRequest")),
+		Responses:   responses("200", "One result per operation", openapi.ArrayOf(openapi.Ref("BulkResult")), "400", "409"),
+	},
+	"PATCH /todos/archivefinished": {
+		Summary:    "Archive the finished todos matching the filters",
+		Tags:       []string{"todos"},
+		Parameters: filterParams(),
+		Responses:  responses("200", "IDs of the archived todos", nullable(openapi.ArrayOf(openapi.Integer())), "400"),
+	},
+	"GET /todos/archived": {
+		Summary:    "List archived todos",
+		Tags:       []string{"todos"},
+		Parameters: filterParams(),
+		Responses:  responses("200", "The todos", listOf("Todo"), "400"),
+	},
+	"GET /due-dates/preview": {
+		Summary:     "Show what a due date phrase resolves to",
+		Description: "Accepts what due_date does in POST /todos and PATCH /todos/{id}.",
+		Tags:        []string{"todos"},
+		Parameters: []*openapi.Parameter{
+			{Name: "text", In: "query", Required: true, Description: "Such as tomorrow 9am", Schema: openapi.String()},
+			timezone(),
+		},
+		Responses: responses("200", "The due date, in the requested time zone", openapi.Ref("DueDatePreview"), "400"),
+	},
+	"GET /settings": {
+		Summary:   "Get the preferences",
+		Tags:      []string{"settings"},
+		Responses: responses("200", "The preferences", openapi.Ref("Settings")),
+	},
+	"PATCH /settings": {
+		Summary:     "Change the preferences",
+		Description: "The API has no user accounts, so the preferences are the whole server's. The time zone is the default of X-Timezone.",
+		Tags:        []string{"settings"},
+		RequestBody: jsonBody(openapi.Ref("SettingsInput")),
+		Responses:   responses("200", "The preferences", openapi.Ref("Settings"), "400"),
+	},
+	"GET /todos/{id}/reminders": {
+		Summary:    "List the reminders of a todo, newest first",
+		Tags:       []string{"reminders"},
+		Parameters: []*openapi.Parameter{idParam("Todo ID")},
+		Responses:  responses("200", "The reminders", listOf("Reminder"), "400", "404"),
+	},
+	"POST /todos/{id}/reminders": {
+		Summary:     "Remind about a todo",
+		Description: "Give exactly one of remind_at and offset_minutes. The reminder must go off in the future.",
+		Tags:        []string{"reminders"},
+		Parameters:  []*openapi.Parameter{idParam("Todo ID"), timezone()},
+		RequestBody: jsonBody(openapi.Ref("ReminderInput")),
+		Responses:   responses("201", "The reminder", openapi.Ref("Reminder"), "400", "404"),
+	},
+	"DELETE /todos/{id}/reminders/{reminderID}": {
+		Summary: "Delete a reminder",
+		Tags:    []string{"reminders"},
+		Parameters: []*openapi.Parameter{
+			idParam("Todo ID"),
+			{Name: "reminderID", In: "path", Required: true, Schema: openapi.Integer()},
+		},
+		Responses: responses("200", "Deleted", nil, "400", "404"),
+	},
+	"GET /escalations/rules": {
+		Summary:   "List the escalation rules, the ones acting soonest first",
+		Tags:      []string{"escalations"},
+		Responses: responses("200", "The rules", listOf("EscalationRule")),
+	},
+	"POST /escalations/rules": {
+		Summary:     "Add an escalation rule for overdue todos",
+		Tags:        []string{"escalations"},
+		RequestBody: jsonBody(openapi.Ref("EscalationRuleInput")),
+		Responses:   responses("201", "The rule", openapi.Ref("EscalationRule"), "400", "404"),
+	},
+	"PATCH /escalations/rules/{id}": {
+		Summary:     "Change, pause or resume an escalation rule",
+		Tags:        []string{"escalations"},
+		Parameters:  []*openapi.Parameter{idParam("Escalation rule ID")},
+		RequestBody: jsonBody(openapi.Ref("EscalationRulePatch")),
+		Responses:   responses("200", "The rule", openapi.Ref("EscalationRule"), "400", "404"),
+	},
+	"DELETE /escalations/rules/{id}": {
+		Summary:    "Delete an escalation rule",
+		Tags:       []string{"escalations"},
+		Parameters: []*openapi.Parameter{idParam("Escalation rule ID")},
+		Responses:  responses("200", "Deleted", nil, "400", "404"),
+	},
+	"POST /escalations/run": {
+		Summary:     "Run the escalation rules now",
+		Description: "The server also runs them every -escalation-interval. Each rule acts on a todo once per due date.",
+		Tags:        []string{"escalations"},
+		Parameters:  []*openapi.Parameter{query("dry_run", "Report what the rules would do without doing it", openapi.Boolean())},
+		Responses:   responses("200", "What the rules did, or would do", openapi.ArrayOf(openapi.Ref("Escalation")), "400"),
+	},
+	"GET /boards": {
+		Summary:   "List the boards and their columns",
+		Tags:      []string{"boards"},
+		Responses: responses("200", "The boards", listOf("Board")),
+	},
+	"POST /boards": {
+		Summary:     "Create a board",
+		Description: "Columns hold the unarchived todos of a status or of a category, as column_by says.",
+		Tags:        []string{"boards"},
+		RequestBody: jsonBody(openapi.Ref("BoardInput")),
+		Responses:   responses("201", "The created board", openapi.Ref("Board"), "400"),
+	},
+	"GET /boards/{id}": {
+		Summary:     "Get a board with the todos of each column",
+		Description: "Todos are in the order they were moved into; todos never moved on the board come last, in ID order.",
+		Tags:        []string{"boards"},
+		Parameters:  []*openapi.Parameter{idParam("Board ID")},
+		Responses:   responses("200", "The board", openapi.Ref("Board"), "400", "404"),
+	},
+	"PATCH /boards/{id}": {
+		Summary:     "Rename a board or replace its columns",
+		Tags:        []string{"boards"},
+		Parameters:  []*openapi.Parameter{idParam("Board ID")},
+		RequestBody: jsonBody(openapi.Ref("BoardPatch")),
+		Responses:   responses("200", "The updated board", openapi.Ref("Board"), "400", "404"),
+	},
+	"DELETE /boards/{id}": {
+		Summary:    "Delete a board",
+		Tags:       []string{"boards"},
+		Parameters: []*openapi.Parameter{idParam("Board ID")},
+		Responses:  responses("200", "Deleted", nil, "400", "404"),
+	},
+	"POST /boards/{id}/move": {
+		Summary:     "Move a todo to a column and a place in it",
+		Description: "Moving to another column sets the todo's status, which must be an allowed transition, or its category. Column and place change together or not at all.",
+		Tags:        []string{"boards"},
+		Parameters:  []*openapi.Parameter{idParam("Board ID")},
+		RequestBody: jsonBody(openapi.Ref("BoardMove")),
+		Responses:   responses("200", "The board after the move", openapi.Ref("Board"), "400", "404", "409"),
+	},
+	"GET /stats": {
+		Summary:     "Summarize the todos",
+		Description: "Counts by status, category and priority, completion rates of the last 7, 30 and 90 days, the average time to complete a todo and a per-day series of todos created and completed.",
+		Tags:        []string{"stats"},
+		Parameters:  []*openapi.Parameter{query("days", "Days the series covers, 30 by default", openapi.Integer().Range(1, 366)), timezone()},
+		Responses:   responses("200", "The statistics", openapi.Ref("Stats"), "400"),
+	},
+	"GET /reminders": {
+		Summary:    "List the latest 100 reminders of all todos",
+		Tags:       []string{"reminders"},
+		Parameters: []*openapi.Parameter{query("status", "", stringEnum(reminders.Statuses))},
+		Responses:  responses("200", "The reminders", listOf("Reminder"), "400"),
+	},
+	"GET /reminders/channels": {
+		Summary:   "List the channels reminders can be sent through",
+		Tags:      []string{"reminders"},
+		Responses: responses("200", "The channels", openapi.ArrayOf(openapi.String())),
+	},
+	"GET /todos/{id}": {
+		Summary:    "Get a todo",
+		Tags:       []string{"todos"},
+		Parameters: []*openapi.Parameter{idParam("Todo ID")},
+		Responses:  responses("200", "The todo", openapi.Ref("Todo"), "400", "404"),
+	},
+	"PATCH /todos/{id}": {
+		Summary:     "Update the given fields of a todo",
+		Tags:        []string{"todos"},
+		Parameters:  []*openapi.Parameter{idParam("Todo ID"), timezone()},
+		RequestBody: jsonBody(openapi.Ref("TodoPatch")),
+		Responses:   responses("200", "The updated todo", openapi.Ref("Todo"), "400", "404", "409"),
+	},
+	"DELETE /todos/{id}": {
+		Summary:    "Delete a todo",
+		Tags:       []string{"todos"},
+		Parameters: []*openapi.Parameter{idParam("Todo ID")},
+		Responses:  responses("200", "Deleted", nil, "400", "404"),
+	},
+	"POST /todos/{id}/archive": {
+		Summary:    "Archive a todo",
+		Tags:       []string{"todos"},
+		Parameters: []*openapi.Parameter{idParam("Todo ID")},
+		Responses:  responses("200", "Archived", nil, "400", "404"),
+	},
+	"POST /todos/{id}/unarchive": {
+		Summary:    "Unarchive a todo",
+		Tags:       []string{"todos"},
+		Parameters: []*openapi.Parameter{idParam("Todo ID")},
+		Responses:  responses("200", "Unarchived", nil, "400", "404"),
+	},
+
+	"GET /categories": {
+		Summary:   "List categories",
+		Tags:      []string{"categories"},
+		Responses: responses("200", "The categories", listOf("Category"), "400"),
+	},
+	"POST /categories": {
+		Summary:     "Create a category",
+		Tags:        []string{"categories"},
+		Parameters:  []*openapi.Parameter{idempotencyKey()},
+		RequestBody: jsonBody(requireName(openapi.Ref("CategoryInput"))),
+		Responses:   responses("201", "The created category", openapi.Ref("Category"), "400", "409"),
+	},
+	"PATCH /categories/{id}": {
+		Summary:     "Update the given fields of a category",
+		Tags:        []string{"categories"},
+		Parameters:  []*openapi.Parameter{idParam("Category ID")},
+		RequestBody: jsonBody(openapi.Ref("CategoryInput")),
+		Responses:   responses("200", "The updated category", openapi.Ref("Category"), "400", "404"),
+	},
+	"DELETE /categories/{id}": {
+		Summary:     "Delete a category",
+		Description: "policy decides what happens to the todos still in the category.",
+		Tags:        []string{"categories"},
+		Parameters: []*openapi.Parameter{
+			idParam("Category ID"),
+			query("policy", "What to do with the category's todos", openapi.String().OneOf("refuse", "reassign", "archive", "delete")),
+			query("target", "The category todos move to with policy=reassign", openapi.Integer()),
+		},
+		Responses: responses("200", "Deleted", nil, "400", "404", "409"),
+	},
+
+	"GET /export": {
+		Summary:    "Export all categories and todos",
+		Tags:       []string{"import/export"},
+		Parameters: []*openapi.Parameter{query("format", "", openapi.String().OneOf("json", "ndjson", "csv"))},
+		Responses:  raw("200", "The export file", "application/json", "application/x-ndjson", "text/csv"),
+	},
+	"POST /import": {
+		Summary:     "Import a file written by /export",
+		Description: "The format comes from ?format= or the Content-Type. Todos keep their status and dates, so due dates may be in the past.",
+		Tags:        []string{"import/export"},
+		Parameters:  append([]*openapi.Parameter{query("format", "", openapi.String().OneOf("json", "ndjson", "csv"))}, importParams()...),
+		RequestBody: rawBody("application/json", "application/x-ndjson", "text/csv"),
+		Responses:   responses("200", "What was imported", openapi.Ref("ImportReport"), "400"),
+	},
+	"POST /import/{format}": {
+		Summary:     "Import a file from /export or another todo app",
+		Tags:        []string{"import/export"},
+		Parameters:  append([]*openapi.Parameter{{Name: "format", In: "path", Required: true, Schema: openapi.String().OneOf(importFormats()...)}}, importParams()...),
+		RequestBody: rawBody("application/octet-stream"),
+		Responses:   responses("200", "What was imported", openapi.Ref("ImportReport"), "400"),
+	},
+
+	"GET /calendar.ics": {
+		Summary: "iCalendar feed of the unarchived todos",
+		Tags:    []string{"calendar"},
+		Parameters: append([]*openapi.Parameter{
+			query("token", "A calendar token; without a valid one the answer is 401", openapi.String()),
+			query("type", "vevent for clients without task support", openapi.String().OneOf("vtodo", "vevent")),
+		}, filterParams()...),
+		Responses: raw("200", "The feed", "text/calendar", "400", "401"),
+	},
+	"GET /calendar/tokens": {
+		Summary:   "List calendar tokens",
+		Tags:      []string{"calendar"},
+		Responses: responses("200", "The tokens, without their secrets", listOf("CalendarToken")),
+	},
+	"POST /calendar/tokens": {
+		Summary:     "Create a calendar token",
+		Tags:        []string{"calendar"},
+		RequestBody: jsonBody(openapi.Ref("CalendarTokenInput")),
+		Responses:   responses("201", "The token and its feed URL, shown only once", openapi.Ref("CalendarToken"), "400", "404"),
+	},
+	"DELETE /calendar/tokens/{id}": {
+		Summary:    "Revoke a calendar token",
+		Tags:       []string{"calendar"},
+		Parameters: []*openapi.Parameter{idParam("Calendar token ID")},
+		Responses:  responses("200", "Revoked", nil, "400", "404"),
+	},
+
+	"GET /webhooks": {
+		Summary:   "List webhooks",
+		Tags:      []string{"webhooks"},
+		Responses: responses("200", "The webhooks", listOf("Webhook")),
+	},
+	"POST /webhooks": {
+		Summary:     "Subscribe a URL to events",
+		Tags:        []string{"webhooks"},
+		RequestBody: jsonBody(openapi.Ref("WebhookInput")),
+		Responses:   responses("201", "The webhook and its secret", openapi.Ref("Webhook"), "400"),
+	},
+	"PATCH /webhooks/{id}": {
+		Summary:     "Pause or resume a webhook",
+		Tags:        []string{"webhooks"},
+		Parameters:  []*openapi.Parameter{idParam("Webhook ID")},
+		RequestBody: jsonBody(openapi.Ref("WebhookPatch")),
+		Responses:   responses("200", "Updated", nil, "400", "404"),
+	},
+	"DELETE /webhooks/{id}": {
+		Summary:    "Delete a webhook",
+		Tags:       []string{"webhooks"},
+		Parameters: []*openapi.Parameter{idParam("Webhook ID")},
+		Responses:  responses("200", "Deleted", nil, "400", "404"),
+	},
+	"GET /webhooks/{id}/deliveries": {
+		Summary: "List the latest 100 deliveries of a webhook",
+		Tags:    []string{"webhooks"},
+		Parameters: []*openapi.Parameter{
+			idParam("Webhook ID"),
+			query("status", "", openapi.String().OneOf(webhooks.StatusPending, webhooks.StatusDelivered, webhooks.StatusFailed)),
+		},
+		Responses: responses("200", "The deliveries", listOf("WebhookDelivery"), "400", "404"),
+	},
+	"POST /webhooks/{id}/deliveries/{deliveryID}/redeliver": {
+		Summary: "Send a delivery again",
+		Tags:    []string{"webhooks"},
+		Parameters: []*openapi.Parameter{
+			idParam("Webhook ID"),
+			{Name: "deliveryID", In: "path", Required: true, Schema: openapi.Integer()},
+		},
+		Responses: responses("202", "Queued again", openapi.Object(map[string]*openapi.Schema{
+			"delivery_id": openapi.Integer().Describe("ID of the new delivery"),
+		}, "delivery_id"), "400", "404"),
+	},
+
+	"GET /admin/backups": {
+		Summary:   "List the database backups, newest first",
+		Tags:      []string{"admin"},
+		Security:  adminOnly,
+		Responses: responses("200", "The backups", listOf("Backup"), "401", "403"),
+	},
+	"POST /admin/backups": {
+		Summary:     "Back the database up now",
+		Description: "Counts towards the retention of the scheduled backups.",
+		Tags:        []string{"admin"},
+		Security:    adminOnly,
+		Responses:   responses("201", "The backup", openapi.Ref("Backup"), "401", "403"),
+	},
+
+	"GET /events": {
+		Summary:     "Server-Sent Events stream of changes",
+		Description: "Reconnecting clients send Last-Event-ID to get the events they missed.",
+		Tags:        []string{"live"},
+		Parameters: []*openapi.Parameter{
+			query("types", "Comma separated event types or todo.*, category.*", openapi.String()),
+			query("category_id", "", openapi.Integer()),
+			query("last_event_id", "Resume point for the first connect", openapi.Integer()),
+			{Name: "Last-Event-ID", In: "header", Schema: openapi.Integer()},
+		},
+		Responses: raw("200", "The event stream", "text/event-stream", "400"),
+	},
+	"GET /live": {
+		Summary:     "WebSocket for live editing",
+		Description: "See handlers.LiveTodos for the message protocol.",
+		Tags:        []string{"live"},
+		Parameters:  []*openapi.Parameter{query("name", "Shown to the other viewers", openapi.String().MaxLen(30))},
+		Responses: map[string]*openapi.Response{
+			"101": {Description: "Switched to the WebSocket protocol"},
+			"400": {Ref: "#/components/responses/BadRequest"},
+		},
+	},
+
+	"POST /graphql": {
+		Summary:     "GraphQL endpoint",
+		Description: "Answers in the GraphQL {data, errors} shape instead of the API envelope.",
+		Tags:        []string{"graphql"},
+		RequestBody: jsonBody(openapi.Ref("GraphQLRequest")),
+		Responses: map[string]*openapi.Response{
+			"200": {Description: "The GraphQL response", Content: map[string]openapi.MediaType{"application/json": {Schema: &openapi.Schema{Type: openapi.Types{"object"}}}}},
+			"400": {Ref: "#/components/responses/BadRequest"},
+		},
+	},
+	"GET /graphiql": {
+		Summary:   "GraphiQL IDE, in development mode only",
+		Tags:      []string{"graphql"},
+		Responses: raw("200", "HTML page", "text/html"),
+	},
+}
+
+var adminOnly = []openapi.SecurityRequirement{{"admin": {}}}
+
+var errorResponses = map[string]string{
+	"400": "BadRequest",
+	"401": "Unauthorized",
+	"403": "Forbidden",
+	"404": "NotFound",
+	"409": "Conflict",
+}
+
+// responses describes a JSON response in the API envelope with data in
+// it, or without data when it is nil, along with the given error statuses.
+func responses(status, description string, data *openapi.Schema, errors ...string) map[string]*openapi.Response {
+	schema := openapi.Ref("APIResponse")
+	if data != nil {
+		schema = &openapi.Schema{AllOf: []*openapi.Schema{openapi.Ref("APIResponse"), {Properties: map[string]*openapi.Schema{"data": data}}}}
+	}
+
+	r := map[string]*openapi.Response{
+		status: {Description: description, Content: map[string]openapi.MediaType{"application/json": {Schema: schema}}},
+	}
+	addErrors(r, errors)
+	return r
+}
+
+// raw describes a response that is not JSON in the envelope. Arguments
+// that are status codes name error responses, the rest content types.
+func raw(status, description string, types ...string) map[string]*openapi.Response {
+	content := make(map[string]openapi.MediaType)
+	var errors []string
+	for _, t := range types {
+		if _, ok := errorResponses[t]; ok {
+			errors = append(errors, t)
+		} else {
+			content[t] = openapi.MediaType{}
+		}
+	}
+
+	r := map[string]*openapi.Response{status: {Description: description, Content: content}}
+	addErrors(r, errors)
+	return r
+}
+
+func addErrors(r map[string]*openapi.Response, errors []string) {
+	for _, code := range errors {
+		r[code] = &openapi.Response{Ref: "#/components/responses/" + errorResponses[code]}
+	}
+	r["429"] = &openapi.Response{Ref: "#/components/responses/TooManyRequests"}
+	r["500"] = &openapi.Response{Ref: "#/components/responses/InternalError"}
+}
+
+func errorResponse(description string) *openapi.Response {
+	return &openapi.Response{
+		Description: description,
+		Content:     map[string]openapi.MediaType{"application/json": {Schema: openapi.Ref("APIResponse")}},
+	}
+}
+
+// listOf is an array of the named schema. Empty lists are sent as null.
+func listOf(name string) *openapi.Schema {
+	return nullable(openapi.ArrayOf(openapi.Ref(name)))
+}
+
+func nullable(s *openapi.Schema) *openapi.Schema {
+	s.Type = append(s.Type, "null")
+	return s
+}
+
+func jsonBody(schema *openapi.Schema) *openapi.RequestBody {
+	return &openapi.RequestBody{Required: true, Content: map[string]openapi.MediaType{"application/json": {Schema: schema}}}
+}
+
+func rawBody(types ...string) *openapi.RequestBody {
+	content := make(map[string]openapi.MediaType)
+	for _, t := range types {
+		content[t] = openapi.MediaType{}
+	}
+	return &openapi.RequestBody{Required: true, Content: content}
+}
+
+// requireName is CategoryInput with the name required, as on creation.
+func requireName(input *openapi.Schema) *openapi.Schema {
+	return &openapi.Schema{AllOf: []*openapi.Schema{input, {Required: []string{"name"}}}}
+}
+
+func idParam(description string) *openapi.Parameter {
+	return &openapi.Parameter{Name: "id", In: "path", Required: true, Description: description, Schema: openapi.Integer()}
+}
+
+func query(name, description string, schema *openapi.Schema) *openapi.Parameter {
+	return &openapi.Parameter{Name: name, In: "query", Description: description, Schema: schema}
+}
+
+func idempotencyKey() *openapi.Parameter {
+	return &openapi.Parameter{
+		Name:        "Idempotency-Key",
+		In:          "header",
+		Description: "Retries with the same key get the first response instead of repeating the request",
+		Schema:      openapi.String(),
+	}
+}
+
+// dueDate is a due date as clients may write it.
+func dueDate() *openapi.Schema {
+	return openapi.String().Describe(`RFC 3339, or a phrase such as "tomorrow 9am", "next friday", "in 3 days" or "end of month" read in the X-Timezone time zone`)
+}
+
+func allDay() *openapi.Schema {
+	return openapi.Boolean().Describe("Due at the end of the day of due_date; set by phrases without a time of day")
+}
+
+func timezone() *openapi.Parameter {
+	return &openapi.Parameter{
+		Name:        "X-Timezone",
+		In:          "header",
+		Description: "IANA time zone dates are read in; the timezone of GET /settings by default",
+		Schema:      openapi.String(),
+	}
+}
+
+func board() *openapi.Schema {
+	s := openapi.SchemaOf(models.Board{})
+	s.Properties["column_by"] = stringEnum(store.BoardColumnBy)
+	column := s.Properties["columns"].Items
+	column.Properties["status"] = stringEnum(store.TodoStatuses)
+	column.Properties["todos"] = openapi.ArrayOf(openapi.Ref("Todo")).Describe("In board order; only in GET /boards/{id} and moves")
+	return s
+}
+
+func settings() *openapi.Schema {
+	s := openapi.SchemaOf(models.Settings{})
+	s.Properties["transitions"] = transitions()
+	return s
+}
+
+// transitions maps each status to the statuses a todo may go to from it.
+func transitions() *openapi.Schema {
+	s := openapi.Object(make(map[string]*openapi.Schema))
+	for _, status := range store.TodoStatuses {
+		s.Properties[status] = openapi.ArrayOf(stringEnum(store.TodoStatuses))
+	}
+	return s
+}
+
+// filterParams are the todo list filters of store.ParseFilter, and the
+// time zone they are read in.
+func filterParams() []*openapi.Parameter {
+	return []*openapi.Parameter{
+		timezone(),
+		query("category_id", "", openapi.Integer()),
+		query("status", "", stringEnum(store.TodoStatuses)),
+		query("is_done", "Whether the status is done", openapi.Boolean()),
+		query("priority", "", openapi.Integer().Range(1, 5)),
+		query("overdue", "Todos past their due date and neither done nor cancelled, or the others", openapi.Boolean()),
+		query("due", "Todos due in this range of days; overdue ones are also neither done nor cancelled", stringEnum(store.DueRanges)),
+		query("due_before", "", dateParam()),
+		query("due_after", "", dateParam()),
+		query("completed_before", "", dateParam()),
+		query("completed_after", "", dateParam()),
+	}
+}
+
+// dateParam is an RFC 3339 time or a day, as store.ParseDate accepts. A day
+// means its midnight in the time zone of the request.
+func dateParam() *openapi.Schema {
+	s := openapi.String()
+	s.AnyOf = []*openapi.Schema{{Format: "date-time"}, {Format: "date"}}
+	return s
+}
+
+func importParams() []*openapi.Parameter {
+	return []*openapi.Parameter{
+		query("dry_run", "Report what would be imported without keeping it", openapi.Boolean()),
+		query("create_categories", "Create categories that do not exist yet", openapi.Boolean()),
+		query("category", "Category of todos that have none", openapi.String()),
+		query("default_due_days", "Due date, in days from now, of todos without one", openapi.Integer()),
+	}
+}
+
+func importFormats() []interface{} {
+	formats := []interface{}{"json", "ndjson", "csv"}
+	for _, f := range importer.Formats() {
+		formats = append(formats, f)
+	}
+	return formats
+}
+
+func eventFilter() *openapi.Schema {
+	values := []interface{}{"*", "todo.*", "category.*"}
+	for _, t := range events.Types {
+		values = append(values, t)
+	}
+	return openapi.String().OneOf(values...)
+}
+
+func stringEnum(values []string) *openapi.Schema {
+	enum := make([]interface{}, len(values))
+	for i, v := range values {
+		enum[i] = v
+	}
+	return openapi.String().OneOf(enum...)
+}
+
+func intPtr(n int) *int {
+	return &n
+}
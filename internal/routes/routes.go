@@ -10,30 +10,102 @@ import (
 
 func Routes(app *app.App) http.Handler {
 	r := chi.NewRouter()
+	spec := newSpec()
 
 	r.Use(
 		handlers.RecoverPanic(app),
 		handlers.LimitRequest(app),
-		handlers.LogRequest(app))
+		handlers.LogRequest(app),
+		handlers.ValidateRequest(app, spec))
 
 	r.Get("/", handlers.WelcomePage)
+	r.Get("/openapi.json", handlers.OpenAPI(spec))
+	r.Get("/docs", handlers.SwaggerUI)
+	r.Get("/export", handlers.Export(app))
+	r.Post("/import", handlers.Import(app))
+	r.Post("/import/{format}", handlers.ImportFrom(app))
+	r.Get("/calendar.ics", handlers.CalendarFeed(app))
+	r.Get("/events", handlers.StreamEvents(app))
+	r.Get("/live", handlers.LiveTodos(app))
+	r.Post("/graphql", handlers.GraphQL(app))
+	if app.Dev {
+		r.Get("/graphiql", handlers.GraphiQL)
+	}
+
+	r.Route("/calendar/tokens", func(r chi.Router) {
+		r.Get("/", handlers.GetCalendarTokens(app))
+		r.Post("/", handlers.CreateCalendarToken(app))
+		r.Delete("/{id}", handlers.DeleteCalendarToken(app))
+	})
 
 	r.Route("/categories", func(r chi.Router) {
 		r.Get("/", handlers.GetCategories(app))
-		r.Post("/", handlers.AddCategory(app))
+		r.With(handlers.Idempotent(app)).Post("/", handlers.AddCategory(app))
 		r.Patch("/{id}", handlers.PatchCategory(app))
 		r.Delete("/{id}", handlers.DeleteCategory(app))
 	})
 
+	r.Route("/webhooks", func(r chi.Router) {
+		r.Get("/", handlers.GetWebhooks(app))
+		r.Post("/", handlers.AddWebhook(app))
+		r.Patch("/{id}", handlers.PatchWebhook(app))
+		r.Delete("/{id}", handlers.DeleteWebhook(app))
+		r.Get("/{id}/deliveries", handlers.GetWebhookDeliveries(app))
+		r.Post("/{id}/deliveries/{deliveryID}/redeliver", handlers.RedeliverWebhook(app))
+	})
+
+	r.Route("/admin", func(r chi.Router) {
+		r.Use(handlers.RequireAdmin(app))
+		r.Get("/backups", handlers.GetBackups(app))
+		r.Post("/backups", handlers.CreateBackup(app))
+	})
+
+	r.Get("/due-dates/preview", handlers.PreviewDueDate(app))
+	r.Get("/settings", handlers.GetSettings(app))
+	r.Get("/stats", handlers.GetStats(app))
+	r.Patch("/settings", handlers.PatchSettings(app))
+	r.Get("/reminders", handlers.GetReminders(app))
+	r.Get("/reminders/channels", handlers.GetReminderChannels(app))
+
+	r.Route("/escalations", func(r chi.Router) {
+		r.Get("/rules", handlers.GetEscalationRules(app))
+		r.Post("/rules", handlers.AddEscalationRule(app))
+		r.Patch("/rules/{id}", handlers.PatchEscalationRule(app))
+		r.Delete("/rules/{id}", handlers.DeleteEscalationRule(app))
+		r.Post("/run", handlers.RunEscalations(app))
+	})
+
+	r.Route("/boards", func(r chi.Router) {
+		r.Get("/", handlers.GetBoards(app))
+		r.Post("/", handlers.AddBoard(app))
+		r.Get("/{id}", handlers.GetBoard(app))
+		r.Patch("/{id}", handlers.PatchBoard(app))
+		r.Delete("/{id}", handlers.DeleteBoard(app))
+		r.Post("/{id}/move", handlers.MoveBoardTodo(app))
+	})
+
 	r.Route("/todos", func(r chi.Router) {
 		r.Get("/", handlers.GetTodos(app, false))
-		r.Post("/", handlers.CreateTodo(app))
+		r.With(handlers.Idempotent(app)).Post("/", handlers.CreateTodo(app))
+		r.With(handlers.Idempotent(app)).Post("/bulk", handlers.BulkTodos(app))
 		r.Patch("/archivefinished", handlers.ArchiveFinished(app))
 		r.Get("/archived", handlers.GetTodos(app, true))
 		r.Get("/{id}", handlers.GetTodo(app))
 		r.Patch("/{id}", handlers.PatchTodo(app))
 		r.Delete("/{id}", handlers.DeleteTodo(app))
+		r.Post("/{id}/archive", handlers.ArchiveTodo(app, true))
+		r.Post("/{id}/unarchive", handlers.ArchiveTodo(app, false))
+		r.Get("/{id}/reminders", handlers.GetTodoReminders(app))
+		r.Post("/{id}/reminders", handlers.AddReminder(app))
+		r.Delete("/{id}/reminders/{reminderID}", handlers.DeleteReminder(app))
 	})
 
+	// A route without documentation is left out of /openapi.json and its
+	// requests go unvalidated; TestRoutesDocumented catches it before then.
+	err := spec.AddRoutes(r, operations)
+	if err != nil {
+		app.ErrorLog.Println(err)
+	}
+
 	return r
 }
@@ -0,0 +1,165 @@
+package routes
+
+import (
+	"bytes"
+	"encoding/json"
+	"io"
+	"log"
+	"net/http"
+	"net/http/httptest"
+	"path/filepath"
+	"strings"
+	"sync"
+	"testing"
+
+	"github.com/furkankorkmaz309/todo-api/internal/app"
+	"github.com/furkankorkmaz309/todo-api/internal/db"
+	"github.com/furkankorkmaz309/todo-api/internal/events"
+	"github.com/furkankorkmaz309/todo-api/internal/idempotency"
+	"github.com/furkankorkmaz309/todo-api/internal/live"
+	"github.com/furkankorkmaz309/todo-api/internal/webhooks"
+	"github.com/go-chi/chi"
+)
+
+// syncBuffer collects log output written from handler goroutines.
+type syncBuffer struct {
+	mu  sync.Mutex
+	buf bytes.Buffer
+}
+
+func (b *syncBuffer) Write(p []byte) (int, error) {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	return b.buf.Write(p)
+}
+
+func (b *syncBuffer) String() string {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	return b.buf.String()
+}
+
+// newTestApp returns an app on a fresh database, set up the way the server
+// sets it up, along with what it logs as errors.
+func newTestApp(t *testing.T) (*app.App, *syncBuffer) {
+	t.Helper()
+	conn, err := db.InitDB(filepath.Join(t.TempDir(), "test.db"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	t.Cleanup(func() { conn.Close() })
+
+	errors := &syncBuffer{}
+	a := &app.App{
+		InfoLog:  log.New(io.Discard, "", 0),
+		ErrorLog: log.New(errors, "", 0),
+		DB:       conn,
+		Dev:      true,
+		Events:   events.NewBus(100),
+		Live:     live.NewHub(),
+	}
+	a.Idempotency = idempotency.NewSQLStore(conn, idempotency.Lease)
+	a.Webhooks = webhooks.NewDispatcher(conn, a.InfoLog, a.ErrorLog)
+	a.Events.Subscribe(a.Webhooks.Enqueue)
+	a.Events.Subscribe(a.Live.Publish)
+	return a, errors
+}
+
+type envelope struct {
+	Success bool            `json:"success"`
+	Data    json.RawMessage `json:"data"`
+	Message string          `json:"message"`
+	Error   string          `json:"error"`
+}
+
+// call sends a request to h and decodes the response envelope into data,
+// when given, failing the test unless the status is want.
+func call(t *testing.T, h http.Handler, method, path, body string, want int, data interface{}) envelope {
+	t.Helper()
+	var reader io.Reader
+	if body != "" {
+		reader = strings.NewReader(body)
+	}
+	req := httptest.NewRequest(method, path, reader)
+	if body != "" {
+		req.Header.Set("Content-Type", "application/json")
+	}
+	rec := httptest.NewRecorder()
+	h.ServeHTTP(rec, req)
+
+	var env envelope
+	err := json.Unmarshal(rec.Body.Bytes(), &env)
+	if err != nil {
+		t.Fatalf("%s %s: response is not JSON: %s", method, path, rec.Body)
+	}
+	if rec.Code != want {
+		t.Fatalf("%s %s = %d %s, want %d", method, path, rec.Code, rec.Body, want)
+	}
+	if data != nil {
+		err = json.Unmarshal(env.Data, data)
+		if err != nil {
+			t.Fatalf("%s %s: decoding data: %v", method, path, err)
+		}
+	}
+	return env
+}
+
+func TestRoutesDocumented(t *testing.T) {
+	a, _ := newTestApp(t)
+	router, ok := Routes(a).(chi.Routes)
+	if !ok {
+		t.Fatal("Routes does not return a chi router")
+	}
+
+	seen := make(map[string]bool)
+	err := chi.Walk(router, func(method, route string, handler http.Handler, middlewares ...func(http.Handler) http.Handler) error {
+		path := route
+		if len(path) > 1 {
+			path = strings.TrimSuffix(path, "/")
+		}
+		key := method + " " + path
+		seen[key] = true
+		if operations[key] == nil {
+			t.Errorf("%s has no entry in operations", key)
+		}
+		return nil
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	for key := range operations {
+		if !seen[key] {
+			t.Errorf("operations documents %s, which is not a route", key)
+		}
+	}
+}
+
+func TestOpenAPIDocument(t *testing.T) {
+	a, errors := newTestApp(t)
+	h := Routes(a)
+
+	req := httptest.NewRequest(http.MethodGet, "/openapi.json", nil)
+	rec := httptest.NewRecorder()
+	h.ServeHTTP(rec, req)
+	if rec.Code != http.StatusOK {
+		t.Fatalf("GET /openapi.json = %d", rec.Code)
+	}
+
+	var doc struct {
+		OpenAPI string                                `json:"openapi"`
+		Paths   map[string]map[string]json.RawMessage `json:"paths"`
+	}
+	err := json.Unmarshal(rec.Body.Bytes(), &doc)
+	if err != nil {
+		t.Fatalf("/openapi.json does not parse: %v", err)
+	}
+	if !strings.HasPrefix(doc.OpenAPI, "3.1") {
+		t.Errorf("openapi = %q, want 3.1", doc.OpenAPI)
+	}
+	if doc.Paths["/todos/{id}"]["patch"] == nil {
+		t.Error("the document has no PATCH /todos/{id}")
+	}
+	if errors.String() != "" {
+		t.Errorf("building the router logged: %s", errors)
+	}
+}
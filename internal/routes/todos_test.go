@@ -0,0 +1,184 @@
+package routes
+
+import (
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/furkankorkmaz309/todo-api/internal/live"
+	"github.com/furkankorkmaz309/todo-api/internal/models"
+	"github.com/gorilla/websocket"
+)
+
+// TestPatchKeepsDone checks that an update without status or is_done
+// leaves a done todo done, over PATCH and over bulk updates.
+func TestPatchKeepsDone(t *testing.T) {
+	a, _ := newTestApp(t)
+	h := Routes(a)
+	due := time.Now().Add(72 * time.Hour).UTC().Format(time.RFC3339)
+
+	call(t, h, "POST", "/categories", `{"name":"work"}`, http.StatusCreated, nil)
+	call(t, h, "POST", "/todos", fmt.Sprintf(`{"title":"a","content":"b","priority":2,"due_date":%q,"category_id":1}`, due), http.StatusCreated, nil)
+
+	var done models.Todo
+	call(t, h, "PATCH", "/todos/1", `{"status":"done"}`, http.StatusOK, &done)
+	if !done.IsDone || done.CompletedAt == nil {
+		t.Fatalf("after status done: %+v, want done with completed_at", done)
+	}
+
+	for _, tc := range []struct {
+		name, method, path, body string
+	}{
+		{"PATCH", "PATCH", "/todos/1", `{"title":"renamed"}`},
+		{"bulk update", "POST", "/todos/bulk", `{"operations":[{"op":"update","id":1,"todo":{"priority":4}}]}`},
+	} {
+		call(t, h, tc.method, tc.path, tc.body, http.StatusOK, nil)
+
+		var todo models.Todo
+		call(t, h, "GET", "/todos/1", "", http.StatusOK, &todo)
+		if !todo.IsDone || todo.Status != "done" || todo.CompletedAt == nil || !todo.CompletedAt.Equal(*done.CompletedAt) {
+			t.Errorf("%s: todo = %+v, want still done since %v", tc.name, todo, done.CompletedAt)
+		}
+	}
+
+	var reopened models.Todo
+	call(t, h, "PATCH", "/todos/1", `{"is_done":false}`, http.StatusOK, &reopened)
+	if reopened.IsDone || reopened.Status == "done" || reopened.CompletedAt != nil {
+		t.Errorf("after is_done false: %+v, want reopened", reopened)
+	}
+}
+
+// TestLiveUpdateKeepsDone is TestPatchKeepsDone for updates sent over the
+// /live WebSocket.
+func TestLiveUpdateKeepsDone(t *testing.T) {
+	a, _ := newTestApp(t)
+	h := Routes(a)
+	srv := httptest.NewServer(h)
+	defer srv.Close()
+	due := time.Now().Add(72 * time.Hour).UTC().Format(time.RFC3339)
+
+	call(t, h, "POST", "/categories", `{"name":"work"}`, http.StatusCreated, nil)
+	call(t, h, "POST", "/todos", fmt.Sprintf(`{"title":"a","content":"b","priority":2,"due_date":%q,"category_id":1}`, due), http.StatusCreated, nil)
+	var done models.Todo
+	call(t, h, "PATCH", "/todos/1", `{"status":"done"}`, http.StatusOK, &done)
+
+	conn, _, err := websocket.DefaultDialer.Dial("ws"+strings.TrimPrefix(srv.URL, "http")+"/live", nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer conn.Close()
+	send := func(msg string) live.Message {
+		t.Helper()
+		err := conn.WriteMessage(websocket.TextMessage, []byte(msg))
+		if err != nil {
+			t.Fatal(err)
+		}
+		conn.SetReadDeadline(time.Now().Add(5 * time.Second))
+		var reply live.Message
+		err = conn.ReadJSON(&reply)
+		if err != nil {
+			t.Fatal(err)
+		}
+		return reply
+	}
+
+	reply := send(fmt.Sprintf(`{"type":"update","ref":"1","id":1,"version":%d,"todo":{"title":"renamed"}}`, done.Version))
+	if reply.Type != "ack" || reply.Todo == nil {
+		t.Fatalf("reply to an update = %+v, want an ack", reply)
+	}
+	if !reply.Todo.IsDone || reply.Todo.Status != "done" || reply.Todo.CompletedAt == nil {
+		t.Errorf("todo after a live rename = %+v, want still done", *reply.Todo)
+	}
+
+	// Due dates are read as PATCH reads them.
+	reply = send(fmt.Sprintf(`{"type":"update","ref":"2","id":1,"version":%d,"todo":{"due_date":"in 3 days"}}`, reply.Version))
+	if reply.Type != "ack" || reply.Todo == nil || !reply.Todo.IsDone {
+		t.Fatalf("reply to a due date phrase = %+v, want an ack with the todo still done", reply)
+	}
+	reply = send(fmt.Sprintf(`{"type":"update","ref":"3","id":1,"version":%d,"todo":{"due_date":"someday"}}`, reply.Version))
+	if reply.Type != "error" {
+		t.Errorf("reply to an invalid due date = %+v, want an error", reply)
+	}
+}
+
+// TestBulkFailure checks that a failed operation undoes the whole batch in
+// atomic mode and only itself in best_effort mode.
+func TestBulkFailure(t *testing.T) {
+	body := `{"mode":%q,"operations":[
+		{"op":"create","todo":{"title":"new","content":"c","priority":3,"due_date":"in 3 days","category_id":1}},
+		{"op":"update","id":1,"todo":{"title":"renamed"}},
+		{"op":"delete","id":2},
+		{"op":"complete","id":99},
+		{"op":"update","id":2,"todo":{"title":"deleted already"}},
+		{"op":"move_category","id":1,"category_id":2}
+	]}`
+
+	for _, tc := range []struct {
+		mode    string
+		status  int
+		results []bool // success of each operation run
+		titles  []string
+	}{
+		{"atomic", http.StatusBadRequest, []bool{true, true, true, false}, []string{"a", "b"}},
+		{"best_effort", http.StatusOK, []bool{true, true, true, false, false, true}, []string{"renamed", "new"}},
+	} {
+		a, _ := newTestApp(t)
+		h := Routes(a)
+		due := time.Now().Add(72 * time.Hour).UTC().Format(time.RFC3339)
+		call(t, h, "POST", "/categories", `{"name":"work"}`, http.StatusCreated, nil)
+		call(t, h, "POST", "/categories", `{"name":"home"}`, http.StatusCreated, nil)
+		for _, title := range []string{"a", "b"} {
+			call(t, h, "POST", "/todos", fmt.Sprintf(`{"title":%q,"content":"c","priority":2,"due_date":%q,"category_id":1}`, title, due), http.StatusCreated, nil)
+		}
+		lastEvent := a.Events.LastID()
+
+		var results []struct {
+			Index   int    `json:"index"`
+			Success bool   `json:"success"`
+			Error   string `json:"error"`
+		}
+		call(t, h, "POST", "/todos/bulk", fmt.Sprintf(body, tc.mode), tc.status, &results)
+		var success []bool
+		for _, result := range results {
+			success = append(success, result.Success)
+			if !result.Success && result.Error == "" {
+				t.Errorf("%s: operation %d failed without an error", tc.mode, result.Index)
+			}
+		}
+		if fmt.Sprint(success) != fmt.Sprint(tc.results) {
+			t.Errorf("%s: operations succeeded %v, want %v", tc.mode, success, tc.results)
+		}
+
+		var todos []models.Todo
+		call(t, h, "GET", "/todos", "", http.StatusOK, &todos)
+		var titles []string
+		for _, todo := range todos {
+			titles = append(titles, todo.Title)
+		}
+		if strings.Join(titles, ",") != strings.Join(tc.titles, ",") {
+			t.Errorf("%s: todos after the batch %v, want %v", tc.mode, titles, tc.titles)
+		}
+
+		var first models.Todo
+		call(t, h, "GET", "/todos/1", "", http.StatusOK, &first)
+		switch tc.mode {
+		case "atomic":
+			if first.Priority != 2 || first.CategoryID != 1 || first.Version != 1 {
+				t.Errorf("atomic: todo 1 = %+v, want unchanged", first)
+			}
+			if got := a.Events.LastID(); got != lastEvent {
+				t.Errorf("atomic: events published up to %d after a failed batch, want none after %d", got, lastEvent)
+			}
+		case "best_effort":
+			if first.Priority != 2 || first.CategoryID != 2 {
+				t.Errorf("best_effort: todo 1 = %+v, want priority 2 in category 2", first)
+			}
+			if got := a.Events.LastID(); got != lastEvent+4 {
+				t.Errorf("best_effort: %d events published, want one per successful operation, 4", got-lastEvent)
+			}
+		}
+	}
+}
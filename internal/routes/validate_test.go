@@ -0,0 +1,67 @@
+package routes
+
+import (
+	"fmt"
+	"net/http"
+	"strings"
+	"testing"
+	"time"
+)
+
+// TestResponsesMatchDocument runs requests across the API with response
+// validation on and fails on any drift it logs.
+func TestResponsesMatchDocument(t *testing.T) {
+	a, errors := newTestApp(t)
+	a.ValidateResponses = true
+	h := Routes(a)
+	due := time.Now().Add(72 * time.Hour).UTC().Format(time.RFC3339)
+
+	call(t, h, "POST", "/categories", `{"name":"work","description":"paid"}`, http.StatusCreated, nil)
+	call(t, h, "GET", "/categories", "", http.StatusOK, nil)
+	call(t, h, "PATCH", "/categories/1", `{"description":"still paid"}`, http.StatusOK, nil)
+
+	call(t, h, "POST", "/webhooks", `{"url":"http://127.0.0.1:1/hook","events":["todo.*"]}`, http.StatusCreated, nil)
+
+	call(t, h, "POST", "/todos", fmt.Sprintf(`{"title":"a","content":"b","priority":2,"due_date":%q,"category_id":1,"tags":["x"]}`, due), http.StatusCreated, nil)
+	call(t, h, "POST", "/todos", fmt.Sprintf(`{"title":"c","content":"d","priority":4,"due_date":%q,"category_id":1}`, due), http.StatusCreated, nil)
+	call(t, h, "GET", "/todos", "", http.StatusOK, nil)
+	call(t, h, "GET", "/todos?status=todo&priority=2", "", http.StatusOK, nil)
+	call(t, h, "GET", "/todos/1", "", http.StatusOK, nil)
+	call(t, h, "PATCH", "/todos/1", `{"status":"in_progress"}`, http.StatusOK, nil)
+	call(t, h, "PATCH", "/todos/2", `{"is_done":true}`, http.StatusOK, nil)
+	call(t, h, "GET", "/todos/404", "", http.StatusNotFound, nil)
+	call(t, h, "PATCH", "/todos/archivefinished", "", http.StatusOK, nil)
+	call(t, h, "GET", "/todos/archived", "", http.StatusOK, nil)
+	call(t, h, "POST", "/todos/2/unarchive", "", http.StatusOK, nil)
+	call(t, h, "POST", "/todos/bulk", `{"operations":[{"op":"complete","id":1},{"op":"update","id":2,"todo":{"priority":5}}]}`, http.StatusOK, nil)
+
+	call(t, h, "GET", "/stats", "", http.StatusOK, nil)
+	call(t, h, "GET", "/settings", "", http.StatusOK, nil)
+	call(t, h, "GET", "/due-dates/preview?text=tomorrow", "", http.StatusOK, nil)
+
+	var board struct {
+		ID      int `json:"id"`
+		Columns []struct {
+			ID int `json:"id"`
+		} `json:"columns"`
+	}
+	call(t, h, "POST", "/boards", `{"name":"flow","column_by":"status"}`, http.StatusCreated, &board)
+	call(t, h, "GET", "/boards", "", http.StatusOK, nil)
+	call(t, h, "POST", fmt.Sprintf("/boards/%d/move", board.ID), fmt.Sprintf(`{"todo_id":2,"column_id":%d}`, board.Columns[0].ID), http.StatusOK, nil)
+	call(t, h, "GET", fmt.Sprintf("/boards/%d", board.ID), "", http.StatusOK, nil)
+
+	var deliveries []struct {
+		ID int `json:"id"`
+	}
+	call(t, h, "GET", "/webhooks/1/deliveries", "", http.StatusOK, &deliveries)
+	if len(deliveries) == 0 {
+		t.Fatal("no webhook deliveries were queued")
+	}
+	call(t, h, "POST", fmt.Sprintf("/webhooks/1/deliveries/%d/redeliver", deliveries[0].ID), "", http.StatusAccepted, nil)
+
+	for _, line := range strings.Split(errors.String(), "\n") {
+		if strings.Contains(line, "[RESPONSE DRIFT]") {
+			t.Error(line)
+		}
+	}
+}
@@ -0,0 +1,93 @@
+package rpc
+
+import (
+	"context"
+	"net/http"
+
+	"github.com/furkankorkmaz309/todo-api/internal/app"
+	"github.com/furkankorkmaz309/todo-api/internal/events"
+	"github.com/furkankorkmaz309/todo-api/internal/handlers"
+	"github.com/furkankorkmaz309/todo-api/internal/models"
+	"github.com/furkankorkmaz309/todo-api/internal/rpc/todopb"
+	"github.com/furkankorkmaz309/todo-api/internal/store"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+)
+
+type categoryServer struct {
+	todopb.UnimplementedCategoryServiceServer
+	app *app.App
+}
+
+func (s *categoryServer) ListCategories(ctx context.Context, req *todopb.ListCategoriesRequest) (*todopb.ListCategoriesResponse, error) {
+	rows, err := s.app.DB.QueryContext(ctx, `SELECT id, name, description FROM category`)
+	if err != nil {
+		return nil, statusError(s.app, store.NewError(http.StatusInternalServerError, "Database error", err))
+	}
+	defer rows.Close()
+
+	resp := &todopb.ListCategoriesResponse{}
+	for rows.Next() {
+		var category models.Category
+		err := rows.Scan(&category.ID, &category.Name, &category.Description)
+		if err != nil {
+			return nil, statusError(s.app, store.NewError(http.StatusInternalServerError, "Row scan error", err))
+		}
+		resp.Categories = append(resp.Categories, toProtoCategory(category))
+	}
+	return resp, nil
+}
+
+func (s *categoryServer) CreateCategory(ctx context.Context, req *todopb.CreateCategoryRequest) (*todopb.Category, error) {
+	category := models.Category{Name: req.Name, Description: req.Description}
+	err := store.InsertCategory(s.app.DB, &category)
+	if err != nil {
+		return nil, statusError(s.app, err)
+	}
+	s.app.Events.Publish(events.CategoryCreated, category)
+
+	return toProtoCategory(category), nil
+}
+
+func (s *categoryServer) UpdateCategory(ctx context.Context, req *todopb.UpdateCategoryRequest) (*todopb.Category, error) {
+	category, _, err := store.UpdateCategory(s.app.DB, int(req.Id), models.Category{Name: req.Name, Description: req.Description})
+	if err != nil {
+		return nil, statusError(s.app, err)
+	}
+	s.app.Events.Publish(events.CategoryUpdated, category)
+
+	return toProtoCategory(category), nil
+}
+
+func (s *categoryServer) DeleteCategory(ctx context.Context, req *todopb.DeleteCategoryRequest) (*todopb.DeleteCategoryResponse, error) {
+	policy := req.Policy
+	if policy == "" {
+		policy = "refuse"
+	}
+
+	switch policy {
+	case "refuse", "archive", "delete":
+	case "reassign":
+		if req.TargetId <= 0 {
+			return nil, invalidID("target category", req.TargetId)
+		}
+		if req.TargetId == req.Id {
+			return nil, status.Error(codes.InvalidArgument, "Target category must differ from the deleted one")
+		}
+	default:
+		return nil, status.Error(codes.InvalidArgument, "Policy must be one of refuse, reassign, archive, delete")
+	}
+
+	todoIDs, todoEvent, _, err := store.DeleteCategory(s.app.DB, int(req.Id), policy, int(req.TargetId))
+	if err != nil {
+		return nil, statusError(s.app, err)
+	}
+	handlers.PublishTodos(s.app, todoEvent, todoIDs)
+	s.app.Events.Publish(events.CategoryDeleted, events.Deleted{ID: int(req.Id)})
+
+	resp := &todopb.DeleteCategoryResponse{}
+	for _, id := range todoIDs {
+		resp.TodoIds = append(resp.TodoIds, int64(id))
+	}
+	return resp, nil
+}
@@ -0,0 +1,172 @@
+package rpc
+
+import (
+	"context"
+	"io"
+	"log"
+	"net"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/furkankorkmaz309/todo-api/internal/app"
+	"github.com/furkankorkmaz309/todo-api/internal/db"
+	"github.com/furkankorkmaz309/todo-api/internal/events"
+	"github.com/furkankorkmaz309/todo-api/internal/rpc/todopb"
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/credentials/insecure"
+	"google.golang.org/grpc/status"
+	"google.golang.org/grpc/test/bufconn"
+	"google.golang.org/protobuf/proto"
+	"google.golang.org/protobuf/types/known/timestamppb"
+)
+
+// newTestClients serves both services on a fresh database over an
+// in-memory connection.
+func newTestClients(t *testing.T) (*app.App, todopb.TodoServiceClient, todopb.CategoryServiceClient) {
+	t.Helper()
+	conn, err := db.InitDB(filepath.Join(t.TempDir(), "test.db"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	t.Cleanup(func() { conn.Close() })
+
+	quiet := log.New(io.Discard, "", 0)
+	a := &app.App{InfoLog: quiet, ErrorLog: quiet, DB: conn, Events: events.NewBus(100)}
+
+	lis := bufconn.Listen(1 << 20)
+	srv := NewServer(a)
+	go srv.Serve(lis)
+	t.Cleanup(srv.Stop)
+
+	cc, err := grpc.NewClient("passthrough:///bufnet",
+		grpc.WithContextDialer(func(ctx context.Context, _ string) (net.Conn, error) { return lis.DialContext(ctx) }),
+		grpc.WithTransportCredentials(insecure.NewCredentials()))
+	if err != nil {
+		t.Fatal(err)
+	}
+	t.Cleanup(func() { cc.Close() })
+	return a, todopb.NewTodoServiceClient(cc), todopb.NewCategoryServiceClient(cc)
+}
+
+func listIDs(t *testing.T, c todopb.TodoServiceClient, filter *todopb.TodoFilter) []int64 {
+	t.Helper()
+	resp, err := c.ListTodos(context.Background(), &todopb.ListTodosRequest{Filter: filter})
+	if err != nil {
+		t.Fatal(err)
+	}
+	var ids []int64
+	for _, todo := range resp.Todos {
+		ids = append(ids, todo.Id)
+	}
+	return ids
+}
+
+func TestTodoStatus(t *testing.T) {
+	ctx := context.Background()
+	a, todos, categories := newTestClients(t)
+
+	category, err := categories.CreateCategory(ctx, &todopb.CreateCategoryRequest{Name: "work"})
+	if err != nil {
+		t.Fatal(err)
+	}
+	due := timestamppb.New(time.Now().Add(72 * time.Hour))
+	create := func(title, status string) *todopb.Todo {
+		t.Helper()
+		todo, err := todos.CreateTodo(ctx, &todopb.CreateTodoRequest{Title: title, Content: "c", Priority: 3, DueDate: due, CategoryId: category.Id, Status: status})
+		if err != nil {
+			t.Fatal(err)
+		}
+		return todo
+	}
+
+	open := create("open", "")
+	started := create("started", "in_progress")
+	if open.Status != "todo" || open.StartedAt != nil {
+		t.Errorf("todo created without a status = %v, want status todo", open)
+	}
+	if started.Status != "in_progress" || started.StartedAt == nil || started.IsDone {
+		t.Errorf("todo created in progress = %v, want in_progress with started_at", started)
+	}
+
+	done, err := todos.UpdateTodo(ctx, &todopb.UpdateTodoRequest{Id: started.Id, Status: proto.String("done")})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if done.Status != "done" || !done.IsDone || done.CompletedAt == nil {
+		t.Errorf("todo updated to done = %v, want done with completed_at", done)
+	}
+	renamed, err := todos.UpdateTodo(ctx, &todopb.UpdateTodoRequest{Id: started.Id, Title: proto.String("renamed")})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if renamed.Status != "done" || !renamed.IsDone {
+		t.Errorf("done todo after a rename = %v, want still done", renamed)
+	}
+
+	cancelled, err := todos.UpdateTodo(ctx, &todopb.UpdateTodoRequest{Id: open.Id, Status: proto.String("cancelled")})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if cancelled.Status != "cancelled" || cancelled.IsDone {
+		t.Errorf("cancelled todo = %v, want status cancelled and not done", cancelled)
+	}
+	_, err = todos.UpdateTodo(ctx, &todopb.UpdateTodoRequest{Id: open.Id, Status: proto.String("done")})
+	if status.Code(err) != codes.InvalidArgument {
+		t.Errorf("cancelled to done = %v, want InvalidArgument", err)
+	}
+	_, err = todos.UpdateTodo(ctx, &todopb.UpdateTodoRequest{Id: open.Id, Status: proto.String("started")})
+	if status.Code(err) != codes.InvalidArgument {
+		t.Errorf("unknown status = %v, want InvalidArgument", err)
+	}
+
+	// Past due dates cannot be set through the API.
+	late := create("late", "")
+	_, err = a.DB.Exec(`UPDATE todo SET due_date = ? WHERE id IN (?, ?)`, time.Now().Add(-time.Hour).UTC(), late.Id, open.Id)
+	if err != nil {
+		t.Fatal(err)
+	}
+	got, err := todos.GetTodo(ctx, &todopb.GetTodoRequest{Id: late.Id})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !got.Overdue || got.AllDay {
+		t.Errorf("todo past its due date = %v, want overdue", got)
+	}
+
+	for _, tc := range []struct {
+		name   string
+		filter *todopb.TodoFilter
+		want   []int64
+	}{
+		{"status", &todopb.TodoFilter{Status: proto.String("cancelled")}, []int64{open.Id}},
+		{"done", &todopb.TodoFilter{Status: proto.String("done")}, []int64{started.Id}},
+		{"overdue", &todopb.TodoFilter{Overdue: proto.Bool(true)}, []int64{late.Id}},
+		{"not overdue", &todopb.TodoFilter{Overdue: proto.Bool(false)}, []int64{open.Id, started.Id}},
+	} {
+		if got := listIDs(t, todos, tc.filter); !equalIDs(got, tc.want) {
+			t.Errorf("%s: ListTodos = %v, want %v", tc.name, got, tc.want)
+		}
+	}
+	_, err = todos.ListTodos(ctx, &todopb.ListTodosRequest{Filter: &todopb.TodoFilter{Status: proto.String("started")}})
+	if status.Code(err) != codes.InvalidArgument {
+		t.Errorf("filter by an unknown status = %v, want InvalidArgument", err)
+	}
+}
+
+func equalIDs(a, b []int64) bool {
+	seen := make(map[int64]int)
+	for _, id := range a {
+		seen[id]++
+	}
+	for _, id := range b {
+		seen[id]--
+	}
+	for _, n := range seen {
+		if n != 0 {
+			return false
+		}
+	}
+	return true
+}
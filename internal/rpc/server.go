@@ -0,0 +1,153 @@
+// Package rpc serves the todo and category operations over gRPC, using the
+// same store and validation as the REST handlers.
+package rpc
+
+//go:generate protoc --go_out=. --go_opt=paths=source_relative --go-grpc_out=. --go-grpc_opt=paths=source_relative todopb/todo.proto
+
+import (
+	"context"
+	"fmt"
+	"net"
+	"net/http"
+	"runtime/debug"
+	"time"
+
+	"github.com/furkankorkmaz309/todo-api/internal/app"
+	"github.com/furkankorkmaz309/todo-api/internal/models"
+	"github.com/furkankorkmaz309/todo-api/internal/rpc/todopb"
+	"github.com/furkankorkmaz309/todo-api/internal/store"
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/peer"
+	"google.golang.org/grpc/reflection"
+	"google.golang.org/grpc/status"
+	"google.golang.org/protobuf/types/known/timestamppb"
+)
+
+// NewServer returns a gRPC server with both services and server reflection
+// registered.
+func NewServer(app *app.App) *grpc.Server {
+	srv := grpc.NewServer(
+		grpc.ChainUnaryInterceptor(recoverUnary(app), logUnary(app)),
+		grpc.ChainStreamInterceptor(recoverStream(app), logStream(app)))
+
+	todopb.RegisterTodoServiceServer(srv, &todoServer{app: app})
+	todopb.RegisterCategoryServiceServer(srv, &categoryServer{app: app})
+	reflection.Register(srv)
+	return srv
+}
+
+// statusError maps a store error onto a gRPC status, logging the cause of
+// internal failures the way respondError does.
+func statusError(app *app.App, err error) error {
+	storeErr := store.AsError(err)
+
+	code := codes.Internal
+	switch storeErr.Status {
+	case http.StatusBadRequest:
+		code = codes.InvalidArgument
+	case http.StatusNotFound:
+		code = codes.NotFound
+	case http.StatusConflict:
+		code = codes.Aborted
+	}
+
+	if code == codes.Internal && storeErr.Err != nil {
+		app.ErrorLog.Printf("[ERROR grpc] %s: %v", storeErr.Msg, storeErr.Err)
+	}
+	return status.Error(code, storeErr.Msg)
+}
+
+func toProtoTodo(t models.Todo) *todopb.Todo {
+	todo := &todopb.Todo{
+		Id:         int64(t.ID),
+		Title:      t.Title,
+		Content:    t.Content,
+		Priority:   int32(t.Priority),
+		CreatedAt:  timestamppb.New(t.CreatedAt),
+		DueDate:    timestamppb.New(t.DueDate),
+		AllDay:     t.AllDay,
+		Status:     t.Status,
+		IsDone:     t.IsDone,
+		Overdue:    t.Overdue,
+		Archived:   t.Archived,
+		CategoryId: int64(t.CategoryID),
+		Tags:       t.Tags,
+		Version:    int64(t.Version),
+	}
+	if t.StartedAt != nil {
+		todo.StartedAt = timestamppb.New(*t.StartedAt)
+	}
+	if t.CompletedAt != nil {
+		todo.CompletedAt = timestamppb.New(*t.CompletedAt)
+	}
+	return todo
+}
+
+func toProtoCategory(c models.Category) *todopb.Category {
+	return &todopb.Category{Id: int64(c.ID), Name: c.Name, Description: c.Description}
+}
+
+func logUnary(app *app.App) grpc.UnaryServerInterceptor {
+	return func(ctx context.Context, req interface{}, info *grpc.UnaryServerInfo, handler grpc.UnaryHandler) (interface{}, error) {
+		start := time.Now()
+		resp, err := handler(ctx, req)
+		logCall(app, ctx, info.FullMethod, start, err)
+		return resp, err
+	}
+}
+
+func logStream(app *app.App) grpc.StreamServerInterceptor {
+	return func(srv interface{}, ss grpc.ServerStream, info *grpc.StreamServerInfo, handler grpc.StreamHandler) error {
+		start := time.Now()
+		err := handler(srv, ss)
+		logCall(app, ss.Context(), info.FullMethod, start, err)
+		return err
+	}
+}
+
+func logCall(app *app.App, ctx context.Context, method string, start time.Time, err error) {
+	ms := float64(time.Since(start).Microseconds()) / 1000
+
+	addr := "unknown"
+	if p, ok := peer.FromContext(ctx); ok {
+		addr = p.Addr.String()
+		if host, _, splitErr := net.SplitHostPort(addr); splitErr == nil {
+			addr = host
+		}
+	}
+
+	app.InfoLog.Printf("GRPC - %v %v %.2fms from %v", method, status.Code(err), ms, addr)
+}
+
+func recoverUnary(app *app.App) grpc.UnaryServerInterceptor {
+	return func(ctx context.Context, req interface{}, info *grpc.UnaryServerInfo, handler grpc.UnaryHandler) (resp interface{}, err error) {
+		defer func() {
+			if p := recover(); p != nil {
+				err = panicError(app, info.FullMethod, p)
+			}
+		}()
+		return handler(ctx, req)
+	}
+}
+
+func recoverStream(app *app.App) grpc.StreamServerInterceptor {
+	return func(srv interface{}, ss grpc.ServerStream, info *grpc.StreamServerInfo, handler grpc.StreamHandler) (err error) {
+		defer func() {
+			if p := recover(); p != nil {
+				err = panicError(app, info.FullMethod, p)
+			}
+		}()
+		return handler(srv, ss)
+	}
+}
+
+func panicError(app *app.App, method string, p interface{}) error {
+	err := fmt.Errorf(" Panic : %v\nMethod : %v\nStack Trace : %v", p, method, debug.Stack())
+	app.ErrorLog.Println(err)
+	return status.Error(codes.Internal, "internal error")
+}
+
+func invalidID(what string, id int64) error {
+	return status.Error(codes.InvalidArgument, fmt.Sprintf("Invalid %s ID %d", what, id))
+}
@@ -0,0 +1,277 @@
+package rpc
+
+import (
+	"context"
+	"net/url"
+	"strconv"
+	"time"
+
+	"github.com/furkankorkmaz309/todo-api/internal/app"
+	"github.com/furkankorkmaz309/todo-api/internal/events"
+	"github.com/furkankorkmaz309/todo-api/internal/handlers"
+	"github.com/furkankorkmaz309/todo-api/internal/models"
+	"github.com/furkankorkmaz309/todo-api/internal/rpc/todopb"
+	"github.com/furkankorkmaz309/todo-api/internal/store"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+	"google.golang.org/protobuf/types/known/timestamppb"
+)
+
+type todoServer struct {
+	todopb.UnimplementedTodoServiceServer
+	app *app.App
+}
+
+// parseFilter maps the request filter onto the REST query parameters so
+// both share one set of rules.
+func parseFilter(f *todopb.TodoFilter) (store.Filter, error) {
+	q := url.Values{}
+	if f != nil {
+		if f.CategoryId != nil {
+			q.Set("category_id", strconv.FormatInt(f.GetCategoryId(), 10))
+		}
+		if f.Status != nil {
+			q.Set("status", f.GetStatus())
+		}
+		if f.IsDone != nil {
+			q.Set("is_done", strconv.FormatBool(f.GetIsDone()))
+		}
+		if f.Overdue != nil {
+			q.Set("overdue", strconv.FormatBool(f.GetOverdue()))
+		}
+		if f.Priority != nil {
+			q.Set("priority", strconv.Itoa(int(f.GetPriority())))
+		}
+		for param, ts := range map[string]*timestamppb.Timestamp{
+			"due_before":       f.DueBefore,
+			"due_after":        f.DueAfter,
+			"completed_before": f.CompletedBefore,
+			"completed_after":  f.CompletedAfter,
+		} {
+			if ts != nil {
+				q.Set(param, ts.AsTime().Format(time.RFC3339Nano))
+			}
+		}
+	}
+
+	// Timestamps carry their own zone, so the location is never consulted.
+	filter, err := store.ParseFilter(q, time.UTC)
+	if err != nil {
+		return filter, status.Error(codes.InvalidArgument, "Invalid filter: "+err.Error())
+	}
+	return filter, nil
+}
+
+func (s *todoServer) ListTodos(ctx context.Context, req *todopb.ListTodosRequest) (*todopb.ListTodosResponse, error) {
+	filter, err := parseFilter(req.Filter)
+	if err != nil {
+		return nil, err
+	}
+
+	todos, err := store.ListTodos(s.app.DB, req.Archived, filter)
+	if err != nil {
+		return nil, statusError(s.app, err)
+	}
+
+	resp := &todopb.ListTodosResponse{}
+	for _, t := range todos {
+		resp.Todos = append(resp.Todos, toProtoTodo(t))
+	}
+	return resp, nil
+}
+
+func (s *todoServer) GetTodo(ctx context.Context, req *todopb.GetTodoRequest) (*todopb.Todo, error) {
+	todo, err := store.SelectTodo(s.app.DB, int(req.Id))
+	if err != nil {
+		return nil, statusError(s.app, err)
+	}
+	return toProtoTodo(todo), nil
+}
+
+func (s *todoServer) CreateTodo(ctx context.Context, req *todopb.CreateTodoRequest) (*todopb.Todo, error) {
+	todo := models.Todo{
+		Title:      req.Title,
+		Content:    req.Content,
+		Priority:   int(req.Priority),
+		CategoryID: int(req.CategoryId),
+		Status:     req.Status,
+		Tags:       req.Tags,
+	}
+	if req.DueDate != nil {
+		todo.DueDate = req.DueDate.AsTime()
+	}
+
+	err := store.InsertTodo(s.app.DB, &todo)
+	if err != nil {
+		return nil, statusError(s.app, err)
+	}
+	s.app.Events.Publish(events.TodoCreated, todo)
+
+	return toProtoTodo(todo), nil
+}
+
+func (s *todoServer) UpdateTodo(ctx context.Context, req *todopb.UpdateTodoRequest) (*todopb.Todo, error) {
+	newTodo := models.Todo{
+		Title:      req.GetTitle(),
+		Content:    req.GetContent(),
+		Priority:   int(req.GetPriority()),
+		CategoryID: int(req.GetCategoryId()),
+		Version:    int(req.Version),
+	}
+	if req.DueDate != nil {
+		newTodo.DueDate = req.DueDate.AsTime()
+	}
+	if req.Tags != nil {
+		newTodo.Tags = append([]string{}, req.Tags.Names...)
+	}
+	if req.Status != nil {
+		newTodo.Status = req.GetStatus()
+	}
+
+	// A missing is_done leaves it unchanged, as over REST.
+	if req.IsDone != nil {
+		newTodo.IsDone = req.GetIsDone()
+	} else if req.Status == nil {
+		current, err := store.SelectTodo(s.app.DB, int(req.Id))
+		if err != nil {
+			return nil, statusError(s.app, err)
+		}
+		newTodo.IsDone = current.IsDone
+	}
+
+	todo, changed, err := store.UpdateTodo(s.app.DB, int(req.Id), newTodo)
+	if err != nil {
+		return nil, statusError(s.app, err)
+	}
+	handlers.PublishTodoUpdate(s.app, todo, changed)
+
+	return toProtoTodo(todo), nil
+}
+
+func (s *todoServer) DeleteTodo(ctx context.Context, req *todopb.DeleteTodoRequest) (*todopb.DeleteTodoResponse, error) {
+	err := store.DeleteTodo(s.app.DB, int(req.Id))
+	if err != nil {
+		return nil, statusError(s.app, err)
+	}
+	s.app.Events.Publish(events.TodoDeleted, events.Deleted{ID: int(req.Id)})
+
+	return &todopb.DeleteTodoResponse{}, nil
+}
+
+func (s *todoServer) ArchiveTodo(ctx context.Context, req *todopb.ArchiveTodoRequest) (*todopb.Todo, error) {
+	err := store.ExecTodo(s.app.DB, int(req.Id), `UPDATE todo SET archived = ? WHERE id = ?`, req.Archived)
+	if err != nil {
+		return nil, statusError(s.app, err)
+	}
+
+	todo, err := store.SelectTodo(s.app.DB, int(req.Id))
+	if err != nil {
+		return nil, statusError(s.app, err)
+	}
+	if req.Archived {
+		s.app.Events.Publish(events.TodoArchived, todo)
+	} else {
+		s.app.Events.Publish(events.TodoUnarchived, todo)
+	}
+
+	return toProtoTodo(todo), nil
+}
+
+func (s *todoServer) ArchiveFinished(ctx context.Context, req *todopb.ArchiveFinishedRequest) (*todopb.ArchiveFinishedResponse, error) {
+	filter, err := parseFilter(req.Filter)
+	if err != nil {
+		return nil, err
+	}
+
+	ids, err := handlers.ArchiveDone(s.app, filter.Where, filter.Args)
+	if err != nil {
+		return nil, statusError(s.app, err)
+	}
+
+	resp := &todopb.ArchiveFinishedResponse{}
+	for _, id := range ids {
+		resp.Ids = append(resp.Ids, int64(id))
+	}
+	return resp, nil
+}
+
+// WatchTodos follows the event bus like GET /events does, including the
+// resumption from last_event_id.
+func (s *todoServer) WatchTodos(req *todopb.WatchTodosRequest, stream todopb.TodoService_WatchTodosServer) error {
+	if s.app.Events == nil {
+		return status.Error(codes.Unavailable, "Event stream is disabled")
+	}
+
+	filter := req.Types
+	if len(filter) == 0 {
+		filter = []string{"*"}
+	}
+	for _, f := range filter {
+		if !events.ValidFilter(f) {
+			return status.Errorf(codes.InvalidArgument, "Unknown event %q", f)
+		}
+	}
+
+	lastID := s.app.Events.LastID()
+	if req.LastEventId != nil {
+		lastID = req.GetLastEventId()
+	}
+
+	backlog, complete, live, cancel := s.app.Events.Listen(lastID, 64)
+	defer cancel()
+
+	if !complete {
+		err := stream.Send(&todopb.Event{Type: "reset", Time: timestamppb.Now(), Data: &todopb.Event_Reset_{Reset_: true}})
+		if err != nil {
+			return err
+		}
+	}
+
+	send := func(e events.Event) error {
+		if !events.Match(filter, e.Type) {
+			return nil
+		}
+		if req.CategoryId != 0 {
+			id, ok := events.CategoryOf(e)
+			if ok && id != int(req.CategoryId) {
+				return nil
+			}
+		}
+		return stream.Send(toProtoEvent(e))
+	}
+
+	for _, e := range backlog {
+		err := send(e)
+		if err != nil {
+			return err
+		}
+	}
+
+	for {
+		select {
+		case <-stream.Context().Done():
+			return nil
+		case e, ok := <-live:
+			if !ok {
+				return status.Error(codes.Unavailable, "Fell behind the event stream, resume with last_event_id")
+			}
+			err := send(e)
+			if err != nil {
+				return err
+			}
+		}
+	}
+}
+
+func toProtoEvent(e events.Event) *todopb.Event {
+	event := &todopb.Event{Id: e.ID, Type: e.Type, Time: timestamppb.New(e.Time)}
+	switch data := e.Data.(type) {
+	case models.Todo:
+		event.Data = &todopb.Event_Todo{Todo: toProtoTodo(data)}
+	case models.Category:
+		event.Data = &todopb.Event_Category{Category: toProtoCategory(data)}
+	case events.Deleted:
+		event.Data = &todopb.Event_DeletedId{DeletedId: int64(data.ID)}
+	}
+	return event
+}
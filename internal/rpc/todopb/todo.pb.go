@@ -0,0 +1,1752 @@
+// Code generated by protoc-gen-go. DO NOT EDIT.
+// versions:
+// 	protoc-gen-go v1.36.11
+// 	protoc        (unknown)
+// source: todopb/todo.proto
+
+package todopb
+
+import (
+	protoreflect "google.golang.org/protobuf/reflect/protoreflect"
+	protoimpl "google.golang.org/protobuf/runtime/protoimpl"
+	timestamppb "google.golang.org/protobuf/types/known/timestamppb"
+	reflect "reflect"
+	sync "sync"
+	unsafe "unsafe"
+)
+
+const (
+	// Verify that this generated code is sufficiently up-to-date.
+	_ = protoimpl.EnforceVersion(20 - protoimpl.MinVersion)
+	// Verify that runtime/protoimpl is sufficiently up-to-date.
+	_ = protoimpl.EnforceVersion(protoimpl.MaxVersion - 20)
+)
+
+type Todo struct {
+	state   protoimpl.MessageState `protogen:"open.v1"`
+	Id      int64                  `protobuf:"varint,1,opt,name=id,proto3" json:"id,omitempty"`
+	Title   string                 `protobuf:"bytes,2,opt,name=title,proto3" json:"title,omitempty"`
+	Content string                 `protobuf:"bytes,3,opt,name=content,proto3" json:"content,omitempty"`
+	// 1 (lowest) to 5 (highest)
+	Priority  int32                  `protobuf:"varint,4,opt,name=priority,proto3" json:"priority,omitempty"`
+	CreatedAt *timestamppb.Timestamp `protobuf:"bytes,5,opt,name=created_at,json=createdAt,proto3" json:"created_at,omitempty"`
+	DueDate   *timestamppb.Timestamp `protobuf:"bytes,6,opt,name=due_date,json=dueDate,proto3" json:"due_date,omitempty"`
+	// true when status is "done".
+	IsDone   bool `protobuf:"varint,7,opt,name=is_done,json=isDone,proto3" json:"is_done,omitempty"`
+	Archived bool `protobuf:"varint,8,opt,name=archived,proto3" json:"archived,omitempty"`
+	// 0 when the todo has no category.
+	CategoryId  int64                  `protobuf:"varint,9,opt,name=category_id,json=categoryId,proto3" json:"category_id,omitempty"`
+	CompletedAt *timestamppb.Timestamp `protobuf:"bytes,10,opt,name=completed_at,json=completedAt,proto3" json:"completed_at,omitempty"`
+	Tags        []string               `protobuf:"bytes,11,rep,name=tags,proto3" json:"tags,omitempty"`
+	Version     int64                  `protobuf:"varint,12,opt,name=version,proto3" json:"version,omitempty"`
+	// todo, in_progress, blocked, done or cancelled
+	Status    string                 `protobuf:"bytes,13,opt,name=status,proto3" json:"status,omitempty"`
+	StartedAt *timestamppb.Timestamp `protobuf:"bytes,14,opt,name=started_at,json=startedAt,proto3" json:"started_at,omitempty"`
+	// due_date names a day and is the end of it.
+	AllDay bool `protobuf:"varint,15,opt,name=all_day,json=allDay,proto3" json:"all_day,omitempty"`
+	// Past due_date and neither done nor cancelled.
+	Overdue       bool `protobuf:"varint,16,opt,name=overdue,proto3" json:"overdue,omitempty"`
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *Todo) Reset() {
+	*x = Todo{}
+	mi := &file_todopb_todo_proto_msgTypes[0]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *Todo) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*Todo) ProtoMessage() {}
+
+func (x *Todo) ProtoReflect() protoreflect.Message {
+	mi := &file_todopb_todo_proto_msgTypes[0]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use Todo.ProtoReflect.Descriptor instead.
+func (*Todo) Descriptor() ([]byte, []int) {
+	return file_todopb_todo_proto_rawDescGZIP(), []int{0}
+}
+
+func (x *Todo) GetId() int64 {
+	if x != nil {
+		return x.Id
+	}
+	return 0
+}
+
+func (x *Todo) GetTitle() string {
+	if x != nil {
+		return x.Title
+	}
+	return ""
+}
+
+func (x *Todo) GetContent() string {
+	if x != nil {
+		return x.Content
+	}
+	return ""
+}
+
+func (x *Todo) GetPriority() int32 {
+	if x != nil {
+		return x.Priority
+	}
+	return 0
+}
+
+func (x *Todo) GetCreatedAt() *timestamppb.Timestamp {
+	if x != nil {
+		return x.CreatedAt
+	}
+	return nil
+}
+
+func (x *Todo) GetDueDate() *timestamppb.Timestamp {
+	if x != nil {
+		return x.DueDate
+	}
+	return nil
+}
+
+func (x *Todo) GetIsDone() bool {
+	if x != nil {
+		return x.IsDone
+	}
+	return false
+}
+
+func (x *Todo) GetArchived() bool {
+	if x != nil {
+		return x.Archived
+	}
+	return false
+}
+
+func (x *Todo) GetCategoryId() int64 {
+	if x != nil {
+		return x.CategoryId
+	}
+	return 0
+}
+
+func (x *Todo) GetCompletedAt() *timestamppb.Timestamp {
+	if x != nil {
+		return x.CompletedAt
+	}
+	return nil
+}
+
+func (x *Todo) GetTags() []string {
+	if x != nil {
+		return x.Tags
+	}
+	return nil
+}
+
+func (x *Todo) GetVersion() int64 {
+	if x != nil {
+		return x.Version
+	}
+	return 0
+}
+
+func (x *Todo) GetStatus() string {
+	if x != nil {
+		return x.Status
+	}
+	return ""
+}
+
+func (x *Todo) GetStartedAt() *timestamppb.Timestamp {
+	if x != nil {
+		return x.StartedAt
+	}
+	return nil
+}
+
+func (x *Todo) GetAllDay() bool {
+	if x != nil {
+		return x.AllDay
+	}
+	return false
+}
+
+func (x *Todo) GetOverdue() bool {
+	if x != nil {
+		return x.Overdue
+	}
+	return false
+}
+
+type Category struct {
+	state         protoimpl.MessageState `protogen:"open.v1"`
+	Id            int64                  `protobuf:"varint,1,opt,name=id,proto3" json:"id,omitempty"`
+	Name          string                 `protobuf:"bytes,2,opt,name=name,proto3" json:"name,omitempty"`
+	Description   string                 `protobuf:"bytes,3,opt,name=description,proto3" json:"description,omitempty"`
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *Category) Reset() {
+	*x = Category{}
+	mi := &file_todopb_todo_proto_msgTypes[1]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *Category) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*Category) ProtoMessage() {}
+
+func (x *Category) ProtoReflect() protoreflect.Message {
+	mi := &file_todopb_todo_proto_msgTypes[1]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use Category.ProtoReflect.Descriptor instead.
+func (*Category) Descriptor() ([]byte, []int) {
+	return file_todopb_todo_proto_rawDescGZIP(), []int{1}
+}
+
+func (x *Category) GetId() int64 {
+	if x != nil {
+		return x.Id
+	}
+	return 0
+}
+
+func (x *Category) GetName() string {
+	if x != nil {
+		return x.Name
+	}
+	return ""
+}
+
+func (x *Category) GetDescription() string {
+	if x != nil {
+		return x.Description
+	}
+	return ""
+}
+
+type TodoFilter struct {
+	state           protoimpl.MessageState `protogen:"open.v1"`
+	CategoryId      *int64                 `protobuf:"varint,1,opt,name=category_id,json=categoryId,proto3,oneof" json:"category_id,omitempty"`
+	IsDone          *bool                  `protobuf:"varint,2,opt,name=is_done,json=isDone,proto3,oneof" json:"is_done,omitempty"`
+	Priority        *int32                 `protobuf:"varint,3,opt,name=priority,proto3,oneof" json:"priority,omitempty"`
+	DueBefore       *timestamppb.Timestamp `protobuf:"bytes,4,opt,name=due_before,json=dueBefore,proto3" json:"due_before,omitempty"`
+	DueAfter        *timestamppb.Timestamp `protobuf:"bytes,5,opt,name=due_after,json=dueAfter,proto3" json:"due_after,omitempty"`
+	CompletedBefore *timestamppb.Timestamp `protobuf:"bytes,6,opt,name=completed_before,json=completedBefore,proto3" json:"completed_before,omitempty"`
+	CompletedAfter  *timestamppb.Timestamp `protobuf:"bytes,7,opt,name=completed_after,json=completedAfter,proto3" json:"completed_after,omitempty"`
+	Status          *string                `protobuf:"bytes,8,opt,name=status,proto3,oneof" json:"status,omitempty"`
+	Overdue         *bool                  `protobuf:"varint,9,opt,name=overdue,proto3,oneof" json:"overdue,omitempty"`
+	unknownFields   protoimpl.UnknownFields
+	sizeCache       protoimpl.SizeCache
+}
+
+func (x *TodoFilter) Reset() {
+	*x = TodoFilter{}
+	mi := &file_todopb_todo_proto_msgTypes[2]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *TodoFilter) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*TodoFilter) ProtoMessage() {}
+
+func (x *TodoFilter) ProtoReflect() protoreflect.Message {
+	mi := &file_todopb_todo_proto_msgTypes[2]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use TodoFilter.ProtoReflect.Descriptor instead.
+func (*TodoFilter) Descriptor() ([]byte, []int) {
+	return file_todopb_todo_proto_rawDescGZIP(), []int{2}
+}
+
+func (x *TodoFilter) GetCategoryId() int64 {
+	if x != nil && x.CategoryId != nil {
+		return *x.CategoryId
+	}
+	return 0
+}
+
+func (x *TodoFilter) GetIsDone() bool {
+	if x != nil && x.IsDone != nil {
+		return *x.IsDone
+	}
+	return false
+}
+
+func (x *TodoFilter) GetPriority() int32 {
+	if x != nil && x.Priority != nil {
+		return *x.Priority
+	}
+	return 0
+}
+
+func (x *TodoFilter) GetDueBefore() *timestamppb.Timestamp {
+	if x != nil {
+		return x.DueBefore
+	}
+	return nil
+}
+
+func (x *TodoFilter) GetDueAfter() *timestamppb.Timestamp {
+	if x != nil {
+		return x.DueAfter
+	}
+	return nil
+}
+
+func (x *TodoFilter) GetCompletedBefore() *timestamppb.Timestamp {
+	if x != nil {
+		return x.CompletedBefore
+	}
+	return nil
+}
+
+func (x *TodoFilter) GetCompletedAfter() *timestamppb.Timestamp {
+	if x != nil {
+		return x.CompletedAfter
+	}
+	return nil
+}
+
+func (x *TodoFilter) GetStatus() string {
+	if x != nil && x.Status != nil {
+		return *x.Status
+	}
+	return ""
+}
+
+func (x *TodoFilter) GetOverdue() bool {
+	if x != nil && x.Overdue != nil {
+		return *x.Overdue
+	}
+	return false
+}
+
+type ListTodosRequest struct {
+	state         protoimpl.MessageState `protogen:"open.v1"`
+	Archived      bool                   `protobuf:"varint,1,opt,name=archived,proto3" json:"archived,omitempty"`
+	Filter        *TodoFilter            `protobuf:"bytes,2,opt,name=filter,proto3" json:"filter,omitempty"`
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *ListTodosRequest) Reset() {
+	*x = ListTodosRequest{}
+	mi := &file_todopb_todo_proto_msgTypes[3]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *ListTodosRequest) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*ListTodosRequest) ProtoMessage() {}
+
+func (x *ListTodosRequest) ProtoReflect() protoreflect.Message {
+	mi := &file_todopb_todo_proto_msgTypes[3]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use ListTodosRequest.ProtoReflect.Descriptor instead.
+func (*ListTodosRequest) Descriptor() ([]byte, []int) {
+	return file_todopb_todo_proto_rawDescGZIP(), []int{3}
+}
+
+func (x *ListTodosRequest) GetArchived() bool {
+	if x != nil {
+		return x.Archived
+	}
+	return false
+}
+
+func (x *ListTodosRequest) GetFilter() *TodoFilter {
+	if x != nil {
+		return x.Filter
+	}
+	return nil
+}
+
+type ListTodosResponse struct {
+	state         protoimpl.MessageState `protogen:"open.v1"`
+	Todos         []*Todo                `protobuf:"bytes,1,rep,name=todos,proto3" json:"todos,omitempty"`
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *ListTodosResponse) Reset() {
+	*x = ListTodosResponse{}
+	mi := &file_todopb_todo_proto_msgTypes[4]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *ListTodosResponse) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*ListTodosResponse) ProtoMessage() {}
+
+func (x *ListTodosResponse) ProtoReflect() protoreflect.Message {
+	mi := &file_todopb_todo_proto_msgTypes[4]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use ListTodosResponse.ProtoReflect.Descriptor instead.
+func (*ListTodosResponse) Descriptor() ([]byte, []int) {
+	return file_todopb_todo_proto_rawDescGZIP(), []int{4}
+}
+
+func (x *ListTodosResponse) GetTodos() []*Todo {
+	if x != nil {
+		return x.Todos
+	}
+	return nil
+}
+
+type GetTodoRequest struct {
+	state         protoimpl.MessageState `protogen:"open.v1"`
+	Id            int64                  `protobuf:"varint,1,opt,name=id,proto3" json:"id,omitempty"`
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *GetTodoRequest) Reset() {
+	*x = GetTodoRequest{}
+	mi := &file_todopb_todo_proto_msgTypes[5]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *GetTodoRequest) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*GetTodoRequest) ProtoMessage() {}
+
+func (x *GetTodoRequest) ProtoReflect() protoreflect.Message {
+	mi := &file_todopb_todo_proto_msgTypes[5]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use GetTodoRequest.ProtoReflect.Descriptor instead.
+func (*GetTodoRequest) Descriptor() ([]byte, []int) {
+	return file_todopb_todo_proto_rawDescGZIP(), []int{5}
+}
+
+func (x *GetTodoRequest) GetId() int64 {
+	if x != nil {
+		return x.Id
+	}
+	return 0
+}
+
+type CreateTodoRequest struct {
+	state      protoimpl.MessageState `protogen:"open.v1"`
+	Title      string                 `protobuf:"bytes,1,opt,name=title,proto3" json:"title,omitempty"`
+	Content    string                 `protobuf:"bytes,2,opt,name=content,proto3" json:"content,omitempty"`
+	Priority   int32                  `protobuf:"varint,3,opt,name=priority,proto3" json:"priority,omitempty"`
+	DueDate    *timestamppb.Timestamp `protobuf:"bytes,4,opt,name=due_date,json=dueDate,proto3" json:"due_date,omitempty"`
+	CategoryId int64                  `protobuf:"varint,5,opt,name=category_id,json=categoryId,proto3" json:"category_id,omitempty"`
+	Tags       []string               `protobuf:"bytes,6,rep,name=tags,proto3" json:"tags,omitempty"`
+	// Empty for "todo".
+	Status        string `protobuf:"bytes,7,opt,name=status,proto3" json:"status,omitempty"`
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *CreateTodoRequest) Reset() {
+	*x = CreateTodoRequest{}
+	mi := &file_todopb_todo_proto_msgTypes[6]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *CreateTodoRequest) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*CreateTodoRequest) ProtoMessage() {}
+
+func (x *CreateTodoRequest) ProtoReflect() protoreflect.Message {
+	mi := &file_todopb_todo_proto_msgTypes[6]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use CreateTodoRequest.ProtoReflect.Descriptor instead.
+func (*CreateTodoRequest) Descriptor() ([]byte, []int) {
+	return file_todopb_todo_proto_rawDescGZIP(), []int{6}
+}
+
+func (x *CreateTodoRequest) GetTitle() string {
+	if x != nil {
+		return x.Title
+	}
+	return ""
+}
+
+func (x *CreateTodoRequest) GetContent() string {
+	if x != nil {
+		return x.Content
+	}
+	return ""
+}
+
+func (x *CreateTodoRequest) GetPriority() int32 {
+	if x != nil {
+		return x.Priority
+	}
+	return 0
+}
+
+func (x *CreateTodoRequest) GetDueDate() *timestamppb.Timestamp {
+	if x != nil {
+		return x.DueDate
+	}
+	return nil
+}
+
+func (x *CreateTodoRequest) GetCategoryId() int64 {
+	if x != nil {
+		return x.CategoryId
+	}
+	return 0
+}
+
+func (x *CreateTodoRequest) GetTags() []string {
+	if x != nil {
+		return x.Tags
+	}
+	return nil
+}
+
+func (x *CreateTodoRequest) GetStatus() string {
+	if x != nil {
+		return x.Status
+	}
+	return ""
+}
+
+type Tags struct {
+	state         protoimpl.MessageState `protogen:"open.v1"`
+	Names         []string               `protobuf:"bytes,1,rep,name=names,proto3" json:"names,omitempty"`
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *Tags) Reset() {
+	*x = Tags{}
+	mi := &file_todopb_todo_proto_msgTypes[7]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *Tags) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*Tags) ProtoMessage() {}
+
+func (x *Tags) ProtoReflect() protoreflect.Message {
+	mi := &file_todopb_todo_proto_msgTypes[7]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use Tags.ProtoReflect.Descriptor instead.
+func (*Tags) Descriptor() ([]byte, []int) {
+	return file_todopb_todo_proto_rawDescGZIP(), []int{7}
+}
+
+func (x *Tags) GetNames() []string {
+	if x != nil {
+		return x.Names
+	}
+	return nil
+}
+
+// Fields left unset keep their value. A status change must be allowed by
+// the transitions setting; without a status, is_done marks the todo done or
+// reopens it. A non-zero version must match the stored one, or the call
+// fails with ABORTED.
+type UpdateTodoRequest struct {
+	state         protoimpl.MessageState `protogen:"open.v1"`
+	Id            int64                  `protobuf:"varint,1,opt,name=id,proto3" json:"id,omitempty"`
+	Title         *string                `protobuf:"bytes,2,opt,name=title,proto3,oneof" json:"title,omitempty"`
+	Content       *string                `protobuf:"bytes,3,opt,name=content,proto3,oneof" json:"content,omitempty"`
+	Priority      *int32                 `protobuf:"varint,4,opt,name=priority,proto3,oneof" json:"priority,omitempty"`
+	DueDate       *timestamppb.Timestamp `protobuf:"bytes,5,opt,name=due_date,json=dueDate,proto3" json:"due_date,omitempty"`
+	IsDone        *bool                  `protobuf:"varint,6,opt,name=is_done,json=isDone,proto3,oneof" json:"is_done,omitempty"`
+	CategoryId    *int64                 `protobuf:"varint,7,opt,name=category_id,json=categoryId,proto3,oneof" json:"category_id,omitempty"`
+	Tags          *Tags                  `protobuf:"bytes,8,opt,name=tags,proto3" json:"tags,omitempty"`
+	Version       int64                  `protobuf:"varint,9,opt,name=version,proto3" json:"version,omitempty"`
+	Status        *string                `protobuf:"bytes,10,opt,name=status,proto3,oneof" json:"status,omitempty"`
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *UpdateTodoRequest) Reset() {
+	*x = UpdateTodoRequest{}
+	mi := &file_todopb_todo_proto_msgTypes[8]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *UpdateTodoRequest) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*UpdateTodoRequest) ProtoMessage() {}
+
+func (x *UpdateTodoRequest) ProtoReflect() protoreflect.Message {
+	mi := &file_todopb_todo_proto_msgTypes[8]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use UpdateTodoRequest.ProtoReflect.Descriptor instead.
+func (*UpdateTodoRequest) Descriptor() ([]byte, []int) {
+	return file_todopb_todo_proto_rawDescGZIP(), []int{8}
+}
+
+func (x *UpdateTodoRequest) GetId() int64 {
+	if x != nil {
+		return x.Id
+	}
+	return 0
+}
+
+func (x *UpdateTodoRequest) GetTitle() string {
+	if x != nil && x.Title != nil {
+		return *x.Title
+	}
+	return ""
+}
+
+func (x *UpdateTodoRequest) GetContent() string {
+	if x != nil && x.Content != nil {
+		return *x.Content
+	}
+	return ""
+}
+
+func (x *UpdateTodoRequest) GetPriority() int32 {
+	if x != nil && x.Priority != nil {
+		return *x.Priority
+	}
+	return 0
+}
+
+func (x *UpdateTodoRequest) GetDueDate() *timestamppb.Timestamp {
+	if x != nil {
+		return x.DueDate
+	}
+	return nil
+}
+
+func (x *UpdateTodoRequest) GetIsDone() bool {
+	if x != nil && x.IsDone != nil {
+		return *x.IsDone
+	}
+	return false
+}
+
+func (x *UpdateTodoRequest) GetCategoryId() int64 {
+	if x != nil && x.CategoryId != nil {
+		return *x.CategoryId
+	}
+	return 0
+}
+
+func (x *UpdateTodoRequest) GetTags() *Tags {
+	if x != nil {
+		return x.Tags
+	}
+	return nil
+}
+
+func (x *UpdateTodoRequest) GetVersion() int64 {
+	if x != nil {
+		return x.Version
+	}
+	return 0
+}
+
+func (x *UpdateTodoRequest) GetStatus() string {
+	if x != nil && x.Status != nil {
+		return *x.Status
+	}
+	return ""
+}
+
+type DeleteTodoRequest struct {
+	state         protoimpl.MessageState `protogen:"open.v1"`
+	Id            int64                  `protobuf:"varint,1,opt,name=id,proto3" json:"id,omitempty"`
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *DeleteTodoRequest) Reset() {
+	*x = DeleteTodoRequest{}
+	mi := &file_todopb_todo_proto_msgTypes[9]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *DeleteTodoRequest) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*DeleteTodoRequest) ProtoMessage() {}
+
+func (x *DeleteTodoRequest) ProtoReflect() protoreflect.Message {
+	mi := &file_todopb_todo_proto_msgTypes[9]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use DeleteTodoRequest.ProtoReflect.Descriptor instead.
+func (*DeleteTodoRequest) Descriptor() ([]byte, []int) {
+	return file_todopb_todo_proto_rawDescGZIP(), []int{9}
+}
+
+func (x *DeleteTodoRequest) GetId() int64 {
+	if x != nil {
+		return x.Id
+	}
+	return 0
+}
+
+type DeleteTodoResponse struct {
+	state         protoimpl.MessageState `protogen:"open.v1"`
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *DeleteTodoResponse) Reset() {
+	*x = DeleteTodoResponse{}
+	mi := &file_todopb_todo_proto_msgTypes[10]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *DeleteTodoResponse) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*DeleteTodoResponse) ProtoMessage() {}
+
+func (x *DeleteTodoResponse) ProtoReflect() protoreflect.Message {
+	mi := &file_todopb_todo_proto_msgTypes[10]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use DeleteTodoResponse.ProtoReflect.Descriptor instead.
+func (*DeleteTodoResponse) Descriptor() ([]byte, []int) {
+	return file_todopb_todo_proto_rawDescGZIP(), []int{10}
+}
+
+type ArchiveTodoRequest struct {
+	state protoimpl.MessageState `protogen:"open.v1"`
+	Id    int64                  `protobuf:"varint,1,opt,name=id,proto3" json:"id,omitempty"`
+	// false unarchives the todo.
+	Archived      bool `protobuf:"varint,2,opt,name=archived,proto3" json:"archived,omitempty"`
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *ArchiveTodoRequest) Reset() {
+	*x = ArchiveTodoRequest{}
+	mi := &file_todopb_todo_proto_msgTypes[11]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *ArchiveTodoRequest) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*ArchiveTodoRequest) ProtoMessage() {}
+
+func (x *ArchiveTodoRequest) ProtoReflect() protoreflect.Message {
+	mi := &file_todopb_todo_proto_msgTypes[11]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use ArchiveTodoRequest.ProtoReflect.Descriptor instead.
+func (*ArchiveTodoRequest) Descriptor() ([]byte, []int) {
+	return file_todopb_todo_proto_rawDescGZIP(), []int{11}
+}
+
+func (x *ArchiveTodoRequest) GetId() int64 {
+	if x != nil {
+		return x.Id
+	}
+	return 0
+}
+
+func (x *ArchiveTodoRequest) GetArchived() bool {
+	if x != nil {
+		return x.Archived
+	}
+	return false
+}
+
+type ArchiveFinishedRequest struct {
+	state         protoimpl.MessageState `protogen:"open.v1"`
+	Filter        *TodoFilter            `protobuf:"bytes,1,opt,name=filter,proto3" json:"filter,omitempty"`
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *ArchiveFinishedRequest) Reset() {
+	*x = ArchiveFinishedRequest{}
+	mi := &file_todopb_todo_proto_msgTypes[12]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *ArchiveFinishedRequest) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*ArchiveFinishedRequest) ProtoMessage() {}
+
+func (x *ArchiveFinishedRequest) ProtoReflect() protoreflect.Message {
+	mi := &file_todopb_todo_proto_msgTypes[12]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use ArchiveFinishedRequest.ProtoReflect.Descriptor instead.
+func (*ArchiveFinishedRequest) Descriptor() ([]byte, []int) {
+	return file_todopb_todo_proto_rawDescGZIP(), []int{12}
+}
+
+func (x *ArchiveFinishedRequest) GetFilter() *TodoFilter {
+	if x != nil {
+		return x.Filter
+	}
+	return nil
+}
+
+type ArchiveFinishedResponse struct {
+	state         protoimpl.MessageState `protogen:"open.v1"`
+	Ids           []int64                `protobuf:"varint,1,rep,packed,name=ids,proto3" json:"ids,omitempty"`
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *ArchiveFinishedResponse) Reset() {
+	*x = ArchiveFinishedResponse{}
+	mi := &file_todopb_todo_proto_msgTypes[13]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *ArchiveFinishedResponse) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*ArchiveFinishedResponse) ProtoMessage() {}
+
+func (x *ArchiveFinishedResponse) ProtoReflect() protoreflect.Message {
+	mi := &file_todopb_todo_proto_msgTypes[13]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use ArchiveFinishedResponse.ProtoReflect.Descriptor instead.
+func (*ArchiveFinishedResponse) Descriptor() ([]byte, []int) {
+	return file_todopb_todo_proto_rawDescGZIP(), []int{13}
+}
+
+func (x *ArchiveFinishedResponse) GetIds() []int64 {
+	if x != nil {
+		return x.Ids
+	}
+	return nil
+}
+
+type WatchTodosRequest struct {
+	state protoimpl.MessageState `protogen:"open.v1"`
+	// Event types such as "todo.created", "todo.*" or "*"; empty means all.
+	Types      []string `protobuf:"bytes,1,rep,name=types,proto3" json:"types,omitempty"`
+	CategoryId int64    `protobuf:"varint,2,opt,name=category_id,json=categoryId,proto3" json:"category_id,omitempty"`
+	// Resume after this event ID instead of starting with new events.
+	LastEventId   *int64 `protobuf:"varint,3,opt,name=last_event_id,json=lastEventId,proto3,oneof" json:"last_event_id,omitempty"`
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *WatchTodosRequest) Reset() {
+	*x = WatchTodosRequest{}
+	mi := &file_todopb_todo_proto_msgTypes[14]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *WatchTodosRequest) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*WatchTodosRequest) ProtoMessage() {}
+
+func (x *WatchTodosRequest) ProtoReflect() protoreflect.Message {
+	mi := &file_todopb_todo_proto_msgTypes[14]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use WatchTodosRequest.ProtoReflect.Descriptor instead.
+func (*WatchTodosRequest) Descriptor() ([]byte, []int) {
+	return file_todopb_todo_proto_rawDescGZIP(), []int{14}
+}
+
+func (x *WatchTodosRequest) GetTypes() []string {
+	if x != nil {
+		return x.Types
+	}
+	return nil
+}
+
+func (x *WatchTodosRequest) GetCategoryId() int64 {
+	if x != nil {
+		return x.CategoryId
+	}
+	return 0
+}
+
+func (x *WatchTodosRequest) GetLastEventId() int64 {
+	if x != nil && x.LastEventId != nil {
+		return *x.LastEventId
+	}
+	return 0
+}
+
+type Event struct {
+	state protoimpl.MessageState `protogen:"open.v1"`
+	Id    int64                  `protobuf:"varint,1,opt,name=id,proto3" json:"id,omitempty"`
+	Type  string                 `protobuf:"bytes,2,opt,name=type,proto3" json:"type,omitempty"`
+	Time  *timestamppb.Timestamp `protobuf:"bytes,3,opt,name=time,proto3" json:"time,omitempty"`
+	// Types that are valid to be assigned to Data:
+	//
+	//	*Event_Todo
+	//	*Event_Category
+	//	*Event_DeletedId
+	//	*Event_Reset_
+	Data          isEvent_Data `protobuf_oneof:"data"`
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *Event) Reset() {
+	*x = Event{}
+	mi := &file_todopb_todo_proto_msgTypes[15]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *Event) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*Event) ProtoMessage() {}
+
+func (x *Event) ProtoReflect() protoreflect.Message {
+	mi := &file_todopb_todo_proto_msgTypes[15]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use Event.ProtoReflect.Descriptor instead.
+func (*Event) Descriptor() ([]byte, []int) {
+	return file_todopb_todo_proto_rawDescGZIP(), []int{15}
+}
+
+func (x *Event) GetId() int64 {
+	if x != nil {
+		return x.Id
+	}
+	return 0
+}
+
+func (x *Event) GetType() string {
+	if x != nil {
+		return x.Type
+	}
+	return ""
+}
+
+func (x *Event) GetTime() *timestamppb.Timestamp {
+	if x != nil {
+		return x.Time
+	}
+	return nil
+}
+
+func (x *Event) GetData() isEvent_Data {
+	if x != nil {
+		return x.Data
+	}
+	return nil
+}
+
+func (x *Event) GetTodo() *Todo {
+	if x != nil {
+		if x, ok := x.Data.(*Event_Todo); ok {
+			return x.Todo
+		}
+	}
+	return nil
+}
+
+func (x *Event) GetCategory() *Category {
+	if x != nil {
+		if x, ok := x.Data.(*Event_Category); ok {
+			return x.Category
+		}
+	}
+	return nil
+}
+
+func (x *Event) GetDeletedId() int64 {
+	if x != nil {
+		if x, ok := x.Data.(*Event_DeletedId); ok {
+			return x.DeletedId
+		}
+	}
+	return 0
+}
+
+func (x *Event) GetReset_() bool {
+	if x != nil {
+		if x, ok := x.Data.(*Event_Reset_); ok {
+			return x.Reset_
+		}
+	}
+	return false
+}
+
+type isEvent_Data interface {
+	isEvent_Data()
+}
+
+type Event_Todo struct {
+	Todo *Todo `protobuf:"bytes,4,opt,name=todo,proto3,oneof"`
+}
+
+type Event_Category struct {
+	Category *Category `protobuf:"bytes,5,opt,name=category,proto3,oneof"`
+}
+
+type Event_DeletedId struct {
+	// ID of the deleted todo or category.
+	DeletedId int64 `protobuf:"varint,6,opt,name=deleted_id,json=deletedId,proto3,oneof"`
+}
+
+type Event_Reset_ struct {
+	// Sent first when events after last_event_id are no longer known;
+	// the client should reload instead.
+	Reset_ bool `protobuf:"varint,7,opt,name=reset,proto3,oneof"`
+}
+
+func (*Event_Todo) isEvent_Data() {}
+
+func (*Event_Category) isEvent_Data() {}
+
+func (*Event_DeletedId) isEvent_Data() {}
+
+func (*Event_Reset_) isEvent_Data() {}
+
+type ListCategoriesRequest struct {
+	state         protoimpl.MessageState `protogen:"open.v1"`
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *ListCategoriesRequest) Reset() {
+	*x = ListCategoriesRequest{}
+	mi := &file_todopb_todo_proto_msgTypes[16]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *ListCategoriesRequest) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*ListCategoriesRequest) ProtoMessage() {}
+
+func (x *ListCategoriesRequest) ProtoReflect() protoreflect.Message {
+	mi := &file_todopb_todo_proto_msgTypes[16]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use ListCategoriesRequest.ProtoReflect.Descriptor instead.
+func (*ListCategoriesRequest) Descriptor() ([]byte, []int) {
+	return file_todopb_todo_proto_rawDescGZIP(), []int{16}
+}
+
+type ListCategoriesResponse struct {
+	state         protoimpl.MessageState `protogen:"open.v1"`
+	Categories    []*Category            `protobuf:"bytes,1,rep,name=categories,proto3" json:"categories,omitempty"`
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *ListCategoriesResponse) Reset() {
+	*x = ListCategoriesResponse{}
+	mi := &file_todopb_todo_proto_msgTypes[17]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *ListCategoriesResponse) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*ListCategoriesResponse) ProtoMessage() {}
+
+func (x *ListCategoriesResponse) ProtoReflect() protoreflect.Message {
+	mi := &file_todopb_todo_proto_msgTypes[17]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use ListCategoriesResponse.ProtoReflect.Descriptor instead.
+func (*ListCategoriesResponse) Descriptor() ([]byte, []int) {
+	return file_todopb_todo_proto_rawDescGZIP(), []int{17}
+}
+
+func (x *ListCategoriesResponse) GetCategories() []*Category {
+	if x != nil {
+		return x.Categories
+	}
+	return nil
+}
+
+type CreateCategoryRequest struct {
+	state         protoimpl.MessageState `protogen:"open.v1"`
+	Name          string                 `protobuf:"bytes,1,opt,name=name,proto3" json:"name,omitempty"`
+	Description   string                 `protobuf:"bytes,2,opt,name=description,proto3" json:"description,omitempty"`
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *CreateCategoryRequest) Reset() {
+	*x = CreateCategoryRequest{}
+	mi := &file_todopb_todo_proto_msgTypes[18]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *CreateCategoryRequest) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*CreateCategoryRequest) ProtoMessage() {}
+
+func (x *CreateCategoryRequest) ProtoReflect() protoreflect.Message {
+	mi := &file_todopb_todo_proto_msgTypes[18]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use CreateCategoryRequest.ProtoReflect.Descriptor instead.
+func (*CreateCategoryRequest) Descriptor() ([]byte, []int) {
+	return file_todopb_todo_proto_rawDescGZIP(), []int{18}
+}
+
+func (x *CreateCategoryRequest) GetName() string {
+	if x != nil {
+		return x.Name
+	}
+	return ""
+}
+
+func (x *CreateCategoryRequest) GetDescription() string {
+	if x != nil {
+		return x.Description
+	}
+	return ""
+}
+
+type UpdateCategoryRequest struct {
+	state         protoimpl.MessageState `protogen:"open.v1"`
+	Id            int64                  `protobuf:"varint,1,opt,name=id,proto3" json:"id,omitempty"`
+	Name          string                 `protobuf:"bytes,2,opt,name=name,proto3" json:"name,omitempty"`
+	Description   string                 `protobuf:"bytes,3,opt,name=description,proto3" json:"description,omitempty"`
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *UpdateCategoryRequest) Reset() {
+	*x = UpdateCategoryRequest{}
+	mi := &file_todopb_todo_proto_msgTypes[19]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *UpdateCategoryRequest) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*UpdateCategoryRequest) ProtoMessage() {}
+
+func (x *UpdateCategoryRequest) ProtoReflect() protoreflect.Message {
+	mi := &file_todopb_todo_proto_msgTypes[19]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use UpdateCategoryRequest.ProtoReflect.Descriptor instead.
+func (*UpdateCategoryRequest) Descriptor() ([]byte, []int) {
+	return file_todopb_todo_proto_rawDescGZIP(), []int{19}
+}
+
+func (x *UpdateCategoryRequest) GetId() int64 {
+	if x != nil {
+		return x.Id
+	}
+	return 0
+}
+
+func (x *UpdateCategoryRequest) GetName() string {
+	if x != nil {
+		return x.Name
+	}
+	return ""
+}
+
+func (x *UpdateCategoryRequest) GetDescription() string {
+	if x != nil {
+		return x.Description
+	}
+	return ""
+}
+
+type DeleteCategoryRequest struct {
+	state protoimpl.MessageState `protogen:"open.v1"`
+	Id    int64                  `protobuf:"varint,1,opt,name=id,proto3" json:"id,omitempty"`
+	// refuse (default), reassign (to target_id), archive or delete
+	Policy        string `protobuf:"bytes,2,opt,name=policy,proto3" json:"policy,omitempty"`
+	TargetId      int64  `protobuf:"varint,3,opt,name=target_id,json=targetId,proto3" json:"target_id,omitempty"`
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *DeleteCategoryRequest) Reset() {
+	*x = DeleteCategoryRequest{}
+	mi := &file_todopb_todo_proto_msgTypes[20]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *DeleteCategoryRequest) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*DeleteCategoryRequest) ProtoMessage() {}
+
+func (x *DeleteCategoryRequest) ProtoReflect() protoreflect.Message {
+	mi := &file_todopb_todo_proto_msgTypes[20]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use DeleteCategoryRequest.ProtoReflect.Descriptor instead.
+func (*DeleteCategoryRequest) Descriptor() ([]byte, []int) {
+	return file_todopb_todo_proto_rawDescGZIP(), []int{20}
+}
+
+func (x *DeleteCategoryRequest) GetId() int64 {
+	if x != nil {
+		return x.Id
+	}
+	return 0
+}
+
+func (x *DeleteCategoryRequest) GetPolicy() string {
+	if x != nil {
+		return x.Policy
+	}
+	return ""
+}
+
+func (x *DeleteCategoryRequest) GetTargetId() int64 {
+	if x != nil {
+		return x.TargetId
+	}
+	return 0
+}
+
+type DeleteCategoryResponse struct {
+	state         protoimpl.MessageState `protogen:"open.v1"`
+	TodoIds       []int64                `protobuf:"varint,1,rep,packed,name=todo_ids,json=todoIds,proto3" json:"todo_ids,omitempty"`
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *DeleteCategoryResponse) Reset() {
+	*x = DeleteCategoryResponse{}
+	mi := &file_todopb_todo_proto_msgTypes[21]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *DeleteCategoryResponse) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*DeleteCategoryResponse) ProtoMessage() {}
+
+func (x *DeleteCategoryResponse) ProtoReflect() protoreflect.Message {
+	mi := &file_todopb_todo_proto_msgTypes[21]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use DeleteCategoryResponse.ProtoReflect.Descriptor instead.
+func (*DeleteCategoryResponse) Descriptor() ([]byte, []int) {
+	return file_todopb_todo_proto_rawDescGZIP(), []int{21}
+}
+
+func (x *DeleteCategoryResponse) GetTodoIds() []int64 {
+	if x != nil {
+		return x.TodoIds
+	}
+	return nil
+}
+
+var File_todopb_todo_proto protoreflect.FileDescriptor
+
+const file_todopb_todo_proto_rawDesc = "" +
+	"\n" +
+	"\x11todopb/todo.proto\x12\atodo.v1\x1a\x1fgoogle/protobuf/timestamp.proto\"\x9d\x04\n" +
+	"\x04Todo\x12\x0e\n" +
+	"\x02id\x18\x01 \x01(\x03R\x02id\x12\x14\n" +
+	"\x05title\x18\x02 \x01(\tR\x05title\x12\x18\n" +
+	"\acontent\x18\x03 \x01(\tR\acontent\x12\x1a\n" +
+	"\bpriority\x18\x04 \x01(\x05R\bpriority\x129\n" +
+	"\n" +
+	"created_at\x18\x05 \x01(\v2\x1a.google.protobuf.TimestampR\tcreatedAt\x125\n" +
+	"\bdue_date\x18\x06 \x01(\v2\x1a.google.protobuf.TimestampR\adueDate\x12\x17\n" +
+	"\ais_done\x18\a \x01(\bR\x06isDone\x12\x1a\n" +
+	"\barchived\x18\b \x01(\bR\barchived\x12\x1f\n" +
+	"\vcategory_id\x18\t \x01(\x03R\n" +
+	"categoryId\x12=\n" +
+	"\fcompleted_at\x18\n" +
+	" \x01(\v2\x1a.google.protobuf.TimestampR\vcompletedAt\x12\x12\n" +
+	"\x04tags\x18\v \x03(\tR\x04tags\x12\x18\n" +
+	"\aversion\x18\f \x01(\x03R\aversion\x12\x16\n" +
+	"\x06status\x18\r \x01(\tR\x06status\x129\n" +
+	"\n" +
+	"started_at\x18\x0e \x01(\v2\x1a.google.protobuf.TimestampR\tstartedAt\x12\x17\n" +
+	"\aall_day\x18\x0f \x01(\bR\x06allDay\x12\x18\n" +
+	"\aoverdue\x18\x10 \x01(\bR\aoverdue\"P\n" +
+	"\bCategory\x12\x0e\n" +
+	"\x02id\x18\x01 \x01(\x03R\x02id\x12\x12\n" +
+	"\x04name\x18\x02 \x01(\tR\x04name\x12 \n" +
+	"\vdescription\x18\x03 \x01(\tR\vdescription\"\xed\x03\n" +
+	"\n" +
+	"TodoFilter\x12$\n" +
+	"\vcategory_id\x18\x01 \x01(\x03H\x00R\n" +
+	"categoryId\x88\x01\x01\x12\x1c\n" +
+	"\ais_done\x18\x02 \x01(\bH\x01R\x06isDone\x88\x01\x01\x12\x1f\n" +
+	"\bpriority\x18\x03 \x01(\x05H\x02R\bpriority\x88\x01\x01\x129\n" +
+	"\n" +
+	"due_before\x18\x04 \x01(\v2\x1a.google.protobuf.TimestampR\tdueBefore\x127\n" +
+	"\tdue_after\x18\x05 \x01(\v2\x1a.google.protobuf.TimestampR\bdueAfter\x12E\n" +
+	"\x10completed_before\x18\x06 \x01(\v2\x1a.google.protobuf.TimestampR\x0fcompletedBefore\x12C\n" +
+	"\x0fcompleted_after\x18\a \x01(\v2\x1a.google.protobuf.TimestampR\x0ecompletedAfter\x12\x1b\n" +
+	"\x06status\x18\b \x01(\tH\x03R\x06status\x88\x01\x01\x12\x1d\n" +
+	"\aoverdue\x18\t \x01(\bH\x04R\aoverdue\x88\x01\x01B\x0e\n" +
+	"\f_category_idB\n" +
+	"\n" +
+	"\b_is_doneB\v\n" +
+	"\t_priorityB\t\n" +
+	"\a_statusB\n" +
+	"\n" +
+	"\b_overdue\"[\n" +
+	"\x10ListTodosRequest\x12\x1a\n" +
+	"\barchived\x18\x01 \x01(\bR\barchived\x12+\n" +
+	"\x06filter\x18\x02 \x01(\v2\x13.todo.v1.TodoFilterR\x06filter\"8\n" +
+	"\x11ListTodosResponse\x12#\n" +
+	"\x05todos\x18\x01 \x03(\v2\r.todo.v1.TodoR\x05todos\" \n" +
+	"\x0eGetTodoRequest\x12\x0e\n" +
+	"\x02id\x18\x01 \x01(\x03R\x02id\"\xe3\x01\n" +
+	"\x11CreateTodoRequest\x12\x14\n" +
+	"\x05title\x18\x01 \x01(\tR\x05title\x12\x18\n" +
+	"\acontent\x18\x02 \x01(\tR\acontent\x12\x1a\n" +
+	"\bpriority\x18\x03 \x01(\x05R\bpriority\x125\n" +
+	"\bdue_date\x18\x04 \x01(\v2\x1a.google.protobuf.TimestampR\adueDate\x12\x1f\n" +
+	"\vcategory_id\x18\x05 \x01(\x03R\n" +
+	"categoryId\x12\x12\n" +
+	"\x04tags\x18\x06 \x03(\tR\x04tags\x12\x16\n" +
+	"\x06status\x18\a \x01(\tR\x06status\"\x1c\n" +
+	"\x04Tags\x12\x14\n" +
+	"\x05names\x18\x01 \x03(\tR\x05names\"\x9d\x03\n" +
+	"\x11UpdateTodoRequest\x12\x0e\n" +
+	"\x02id\x18\x01 \x01(\x03R\x02id\x12\x19\n" +
+	"\x05title\x18\x02 \x01(\tH\x00R\x05title\x88\x01\x01\x12\x1d\n" +
+	"\acontent\x18\x03 \x01(\tH\x01R\acontent\x88\x01\x01\x12\x1f\n" +
+	"\bpriority\x18\x04 \x01(\x05H\x02R\bpriority\x88\x01\x01\x125\n" +
+	"\bdue_date\x18\x05 \x01(\v2\x1a.google.protobuf.TimestampR\adueDate\x12\x1c\n" +
+	"\ais_done\x18\x06 \x01(\bH\x03R\x06isDone\x88\x01\x01\x12$\n" +
+	"\vcategory_id\x18\a \x01(\x03H\x04R\n" +
+	"categoryId\x88\x01\x01\x12!\n" +
+	"\x04tags\x18\b \x01(\v2\r.todo.v1.TagsR\x04tags\x12\x18\n" +
+	"\aversion\x18\t \x01(\x03R\aversion\x12\x1b\n" +
+	"\x06status\x18\n" +
+	" \x01(\tH\x05R\x06status\x88\x01\x01B\b\n" +
+	"\x06_titleB\n" +
+	"\n" +
+	"\b_contentB\v\n" +
+	"\t_priorityB\n" +
+	"\n" +
+	"\b_is_doneB\x0e\n" +
+	"\f_category_idB\t\n" +
+	"\a_status\"#\n" +
+	"\x11DeleteTodoRequest\x12\x0e\n" +
+	"\x02id\x18\x01 \x01(\x03R\x02id\"\x14\n" +
+	"\x12DeleteTodoResponse\"@\n" +
+	"\x12ArchiveTodoRequest\x12\x0e\n" +
+	"\x02id\x18\x01 \x01(\x03R\x02id\x12\x1a\n" +
+	"\barchived\x18\x02 \x01(\bR\barchived\"E\n" +
+	"\x16ArchiveFinishedRequest\x12+\n" +
+	"\x06filter\x18\x01 \x01(\v2\x13.todo.v1.TodoFilterR\x06filter\"+\n" +
+	"\x17ArchiveFinishedResponse\x12\x10\n" +
+	"\x03ids\x18\x01 \x03(\x03R\x03ids\"\x85\x01\n" +
+	"\x11WatchTodosRequest\x12\x14\n" +
+	"\x05types\x18\x01 \x03(\tR\x05types\x12\x1f\n" +
+	"\vcategory_id\x18\x02 \x01(\x03R\n" +
+	"categoryId\x12'\n" +
+	"\rlast_event_id\x18\x03 \x01(\x03H\x00R\vlastEventId\x88\x01\x01B\x10\n" +
+	"\x0e_last_event_id\"\xf2\x01\n" +
+	"\x05Event\x12\x0e\n" +
+	"\x02id\x18\x01 \x01(\x03R\x02id\x12\x12\n" +
+	"\x04type\x18\x02 \x01(\tR\x04type\x12.\n" +
+	"\x04time\x18\x03 \x01(\v2\x1a.google.protobuf.TimestampR\x04time\x12#\n" +
+	"\x04todo\x18\x04 \x01(\v2\r.todo.v1.TodoH\x00R\x04todo\x12/\n" +
+	"\bcategory\x18\x05 \x01(\v2\x11.todo.v1.CategoryH\x00R\bcategory\x12\x1f\n" +
+	"\n" +
+	"deleted_id\x18\x06 \x01(\x03H\x00R\tdeletedId\x12\x16\n" +
+	"\x05reset\x18\a \x01(\bH\x00R\x05resetB\x06\n" +
+	"\x04data\"\x17\n" +
+	"\x15ListCategoriesRequest\"K\n" +
+	"\x16ListCategoriesResponse\x121\n" +
+	"\n" +
+	"categories\x18\x01 \x03(\v2\x11.todo.v1.CategoryR\n" +
+	"categories\"M\n" +
+	"\x15CreateCategoryRequest\x12\x12\n" +
+	"\x04name\x18\x01 \x01(\tR\x04name\x12 \n" +
+	"\vdescription\x18\x02 \x01(\tR\vdescription\"]\n" +
+	"\x15UpdateCategoryRequest\x12\x0e\n" +
+	"\x02id\x18\x01 \x01(\x03R\x02id\x12\x12\n" +
+	"\x04name\x18\x02 \x01(\tR\x04name\x12 \n" +
+	"\vdescription\x18\x03 \x01(\tR\vdescription\"\\\n" +
+	"\x15DeleteCategoryRequest\x12\x0e\n" +
+	"\x02id\x18\x01 \x01(\x03R\x02id\x12\x16\n" +
+	"\x06policy\x18\x02 \x01(\tR\x06policy\x12\x1b\n" +
+	"\ttarget_id\x18\x03 \x01(\x03R\btargetId\"3\n" +
+	"\x16DeleteCategoryResponse\x12\x19\n" +
+	"\btodo_ids\x18\x01 \x03(\x03R\atodoIds2\x8a\x04\n" +
+	"\vTodoService\x12B\n" +
+	"\tListTodos\x12\x19.todo.v1.ListTodosRequest\x1a\x1a.todo.v1.ListTodosResponse\x121\n" +
+	"\aGetTodo\x12\x17.todo.v1.GetTodoRequest\x1a\r.todo.v1.Todo\x127\n" +
+	"\n" +
+	"CreateTodo\x12\x1a.todo.v1.CreateTodoRequest\x1a\r.todo.v1.Todo\x127\n" +
+	"\n" +
+	"UpdateTodo\x12\x1a.todo.v1.UpdateTodoRequest\x1a\r.todo.v1.Todo\x12E\n" +
+	"\n" +
+	"DeleteTodo\x12\x1a.todo.v1.DeleteTodoRequest\x1a\x1b.todo.v1.DeleteTodoResponse\x129\n" +
+	"\vArchiveTodo\x12\x1b.todo.v1.ArchiveTodoRequest\x1a\r.todo.v1.Todo\x12T\n" +
+	"\x0fArchiveFinished\x12\x1f.todo.v1.ArchiveFinishedRequest\x1a .todo.v1.ArchiveFinishedResponse\x12:\n" +
+	"\n" +
+	"WatchTodos\x12\x1a.todo.v1.WatchTodosRequest\x1a\x0e.todo.v1.Event0\x012\xc1\x02\n" +
+	"\x0fCategoryService\x12Q\n" +
+	"\x0eListCategories\x12\x1e.todo.v1.ListCategoriesRequest\x1a\x1f.todo.v1.ListCategoriesResponse\x12C\n" +
+	"\x0eCreateCategory\x12\x1e.todo.v1.CreateCategoryRequest\x1a\x11.todo.v1.Category\x12C\n" +
+	"\x0eUpdateCategory\x12\x1e.todo.v1.UpdateCategoryRequest\x1a\x11.todo.v1.Category\x12Q\n" +
+	"\x0eDeleteCategory\x12\x1e.todo.v1.DeleteCategoryRequest\x1a\x1f.todo.v1.DeleteCategoryResponseB:Z8github.com/furkankorkmaz309/todo-api/internal/rpc/todopbb\x06proto3"
+
+var (
+	file_todopb_todo_proto_rawDescOnce sync.Once
+	file_todopb_todo_proto_rawDescData []byte
+)
+
+func file_todopb_todo_proto_rawDescGZIP() []byte {
+	file_todopb_todo_proto_rawDescOnce.Do(func() {
+		file_todopb_todo_proto_rawDescData = protoimpl.X.CompressGZIP(unsafe.Slice(unsafe.StringData(file_todopb_todo_proto_rawDesc), len(file_todopb_todo_proto_rawDesc)))
+	})
+	return file_todopb_todo_proto_rawDescData
+}
+
+var file_todopb_todo_proto_msgTypes = make([]protoimpl.MessageInfo, 22)
+var file_todopb_todo_proto_goTypes = []any{
+	(*Todo)(nil),                    // 0: todo.v1.Todo
+	(*Category)(nil),                // 1: todo.v1.Category
+	(*TodoFilter)(nil),              // 2: todo.v1.TodoFilter
+	(*ListTodosRequest)(nil),        // 3: todo.v1.ListTodosRequest
+	(*ListTodosResponse)(nil),       // 4: todo.v1.ListTodosResponse
+	(*GetTodoRequest)(nil),          // 5: todo.v1.GetTodoRequest
+	(*CreateTodoRequest)(nil),       // 6: todo.v1.CreateTodoRequest
+	(*Tags)(nil),                    // 7: todo.v1.Tags
+	(*UpdateTodoRequest)(nil),       // 8: todo.v1.UpdateTodoRequest
+	(*DeleteTodoRequest)(nil),       // 9: todo.v1.DeleteTodoRequest
+	(*DeleteTodoResponse)(nil),      // 10: todo.v1.DeleteTodoResponse
+	(*ArchiveTodoRequest)(nil),      // 11: todo.v1.ArchiveTodoRequest
+	(*ArchiveFinishedRequest)(nil),  // 12: todo.v1.ArchiveFinishedRequest
+	(*ArchiveFinishedResponse)(nil), // 13: todo.v1.ArchiveFinishedResponse
+	(*WatchTodosRequest)(nil),       // 14: todo.v1.WatchTodosRequest
+	(*Event)(nil),                   // 15: todo.v1.Event
+	(*ListCategoriesRequest)(nil),   // 16: todo.v1.ListCategoriesRequest
+	(*ListCategoriesResponse)(nil),  // 17: todo.v1.ListCategoriesResponse
+	(*CreateCategoryRequest)(nil),   // 18: todo.v1.CreateCategoryRequest
+	(*UpdateCategoryRequest)(nil),   // 19: todo.v1.UpdateCategoryRequest
+	(*DeleteCategoryRequest)(nil),   // 20: todo.v1.DeleteCategoryRequest
+	(*DeleteCategoryResponse)(nil),  // 21: todo.v1.DeleteCategoryResponse
+	(*timestamppb.Timestamp)(nil),   // 22: google.protobuf.Timestamp
+}
+var file_todopb_todo_proto_depIdxs = []int32{
+	22, // 0: todo.v1.Todo.created_at:type_name -> google.protobuf.Timestamp
+	22, // 1: todo.v1.Todo.due_date:type_name -> google.protobuf.Timestamp
+	22, // 2: todo.v1.Todo.completed_at:type_name -> google.protobuf.Timestamp
+	22, // 3: todo.v1.Todo.started_at:type_name -> google.protobuf.Timestamp
+	22, // 4: todo.v1.TodoFilter.due_before:type_name -> google.protobuf.Timestamp
+	22, // 5: todo.v1.TodoFilter.due_after:type_name -> google.protobuf.Timestamp
+	22, // 6: todo.v1.TodoFilter.completed_before:type_name -> google.protobuf.Timestamp
+	22, // 7: todo.v1.TodoFilter.completed_after:type_name -> google.protobuf.Timestamp
+	2,  // 8: todo.v1.ListTodosRequest.filter:type_name -> todo.v1.TodoFilter
+	0,  // 9: todo.v1.ListTodosResponse.todos:type_name -> todo.v1.Todo
+	22, // 10: todo.v1.CreateTodoRequest.due_date:type_name -> google.protobuf.Timestamp
+	22, // 11: todo.v1.UpdateTodoRequest.due_date:type_name -> google.protobuf.Timestamp
+	7,  // 12: todo.v1.UpdateTodoRequest.tags:type_name -> todo.v1.Tags
+	2,  // 13: todo.v1.ArchiveFinishedRequest.filter:type_name -> todo.v1.TodoFilter
+	22, // 14: todo.v1.Event.time:type_name -> google.protobuf.Timestamp
+	0,  // 15: todo.v1.Event.todo:type_name -> todo.v1.Todo
+	1,  // 16: todo.v1.Event.category:type_name -> todo.v1.Category
+	1,  // 17: todo.v1.ListCategoriesResponse.categories:type_name -> todo.v1.Category
+	3,  // 18: todo.v1.TodoService.ListTodos:input_type -> todo.v1.ListTodosRequest
+	5,  // 19: todo.v1.TodoService.GetTodo:input_type -> todo.v1.GetTodoRequest
+	6,  // 20: todo.v1.TodoService.CreateTodo:input_type -> todo.v1.CreateTodoRequest
+	8,  // 21: todo.v1.TodoService.UpdateTodo:input_type -> todo.v1.UpdateTodoRequest
+	9,  // 22: todo.v1.TodoService.DeleteTodo:input_type -> todo.v1.DeleteTodoRequest
+	11, // 23: todo.v1.TodoService.ArchiveTodo:input_type -> todo.v1.ArchiveTodoRequest
+	12, // 24: todo.v1.TodoService.ArchiveFinished:input_type -> todo.v1.ArchiveFinishedRequest
+	14, // 25: todo.v1.TodoService.WatchTodos:input_type -> todo.v1.WatchTodosRequest
+	16, // 26: todo.v1.CategoryService.ListCategories:input_type -> todo.v1.ListCategoriesRequest
+	18, // 27: todo.v1.CategoryService.CreateCategory:input_type -> todo.v1.CreateCategoryRequest
+	19, // 28: todo.v1.CategoryService.UpdateCategory:input_type -> todo.v1.UpdateCategoryRequest
+	20, // 29: todo.v1.CategoryService.DeleteCategory:input_type -> todo.v1.DeleteCategoryRequest
+	4,  // 30: todo.v1.TodoService.ListTodos:output_type -> todo.v1.ListTodosResponse
+	0,  // 31: todo.v1.TodoService.GetTodo:output_type -> todo.v1.Todo
+	0,  // 32: todo.v1.TodoService.CreateTodo:output_type -> todo.v1.Todo
+	0,  // 33: todo.v1.TodoService.UpdateTodo:output_type -> todo.v1.Todo
+	10, // 34: todo.v1.TodoService.DeleteTodo:output_type -> todo.v1.DeleteTodoResponse
+	0,  // 35: todo.v1.TodoService.ArchiveTodo:output_type -> todo.v1.Todo
+	13, // 36: todo.v1.TodoService.ArchiveFinished:output_type -> todo.v1.ArchiveFinishedResponse
+	15, // 37: todo.v1.TodoService.WatchTodos:output_type -> todo.v1.Event
+	17, // 38: todo.v1.CategoryService.ListCategories:output_type -> todo.v1.ListCategoriesResponse
+	1,  // 39: todo.v1.CategoryService.CreateCategory:output_type -> todo.v1.Category
+	1,  // 40: todo.v1.CategoryService.UpdateCategory:output_type -> todo.v1.Category
+	21, // 41: todo.v1.CategoryService.DeleteCategory:output_type -> todo.v1.DeleteCategoryResponse
+	30, // [30:42] is the sub-list for method output_type
+	18, // [18:30] is the sub-list for method input_type
+	18, // [18:18] is the sub-list for extension type_name
+	18, // [18:18] is the sub-list for extension extendee
+	0,  // [0:18] is the sub-list for field type_name
+}
+
+func init() { file_todopb_todo_proto_init() }
+func file_todopb_todo_proto_init() {
+	if File_todopb_todo_proto != nil {
+		return
+	}
+	file_todopb_todo_proto_msgTypes[2].OneofWrappers = []any{}
+	file_todopb_todo_proto_msgTypes[8].OneofWrappers = []any{}
+	file_todopb_todo_proto_msgTypes[14].OneofWrappers = []any{}
+	file_todopb_todo_proto_msgTypes[15].OneofWrappers = []any{
+		(*Event_Todo)(nil),
+		(*Event_Category)(nil),
+		(*Event_DeletedId)(nil),
+		(*Event_Reset_)(nil),
+	}
+	type x struct{}
+	out := protoimpl.TypeBuilder{
+		File: protoimpl.DescBuilder{
+			GoPackagePath: reflect.TypeOf(x{}).PkgPath(),
+			RawDescriptor: unsafe.Slice(unsafe.StringData(file_todopb_todo_proto_rawDesc), len(file_todopb_todo_proto_rawDesc)),
+			NumEnums:      0,
+			NumMessages:   22,
+			NumExtensions: 0,
+			NumServices:   2,
+		},
+		GoTypes:           file_todopb_todo_proto_goTypes,
+		DependencyIndexes: file_todopb_todo_proto_depIdxs,
+		MessageInfos:      file_todopb_todo_proto_msgTypes,
+	}.Build()
+	File_todopb_todo_proto = out.File
+	file_todopb_todo_proto_goTypes = nil
+	file_todopb_todo_proto_depIdxs = nil
+}
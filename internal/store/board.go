@@ -0,0 +1,435 @@
+package store
+
+import (
+	"database/sql"
+	"fmt"
+	"net/http"
+	"strings"
+	"time"
+
+	"github.com/furkankorkmaz309/todo-api/internal/models"
+	"github.com/furkankorkmaz309/todo-api/internal/rank"
+)
+
+const (
+	BoardByStatus   = "status"
+	BoardByCategory = "category"
+)
+
+// BoardColumnBy lists what the columns of a board can stand for.
+var BoardColumnBy = []string{BoardByStatus, BoardByCategory}
+
+// ListBoards returns the boards with their columns, but not their todos.
+func ListBoards(q Querier) ([]models.Board, error) {
+	rows, err := q.Query(`SELECT id, name, column_by, created_at FROM board ORDER BY id`)
+	if err != nil {
+		return nil, NewError(http.StatusInternalServerError, "Database error", err)
+	}
+
+	var boards []models.Board
+	for rows.Next() {
+		var board models.Board
+		err = rows.Scan(&board.ID, &board.Name, &board.ColumnBy, &board.CreatedAt)
+		if err != nil {
+			rows.Close()
+			return nil, NewError(http.StatusInternalServerError, "Row scan error", err)
+		}
+		boards = append(boards, board)
+	}
+	rows.Close()
+
+	for i := range boards {
+		boards[i].Columns, err = boardColumns(q, boards[i].ID)
+		if err != nil {
+			return nil, err
+		}
+	}
+	return boards, nil
+}
+
+// SelectBoard returns a board with its columns, but not their todos.
+func SelectBoard(q Querier, id int) (models.Board, error) {
+	var board models.Board
+	err := q.QueryRow(`SELECT id, name, column_by, created_at FROM board WHERE id = ?`, id).Scan(&board.ID, &board.Name, &board.ColumnBy, &board.CreatedAt)
+	if err == sql.ErrNoRows {
+		return board, NewError(http.StatusNotFound, fmt.Sprintf("No board with ID %d", id), nil)
+	}
+	if err != nil {
+		return board, NewError(http.StatusInternalServerError, "Database error", err)
+	}
+
+	board.Columns, err = boardColumns(q, id)
+	return board, err
+}
+
+func boardColumns(q Querier, boardID int) ([]models.BoardColumn, error) {
+	query := `SELECT id, name, COALESCE(status, ''), COALESCE(category_id, 0) FROM board_column WHERE board_id = ? ORDER BY position`
+	rows, err := q.Query(query, boardID)
+	if err != nil {
+		return nil, NewError(http.StatusInternalServerError, "Database error", err)
+	}
+	defer rows.Close()
+
+	columns := []models.BoardColumn{}
+	for rows.Next() {
+		var column models.BoardColumn
+		err = rows.Scan(&column.ID, &column.Name, &column.Status, &column.CategoryID)
+		if err != nil {
+			return nil, NewError(http.StatusInternalServerError, "Row scan error", err)
+		}
+		columns = append(columns, column)
+	}
+	return columns, rows.Err()
+}
+
+// ValidateBoard checks board and fills in what it leaves out: without
+// columns it gets one per status, or per category, and a column without a
+// name is named after its status or category.
+func ValidateBoard(q Querier, board *models.Board) error {
+	board.Name = strings.TrimSpace(board.Name)
+	if board.Name == "" || len(board.Name) > 50 {
+		return NewError(http.StatusBadRequest, "Name must be 1 to 50 characters", nil)
+	}
+
+	categories := make(map[int]string)
+	rows, err := q.Query(`SELECT id, name FROM category ORDER BY id`)
+	if err != nil {
+		return NewError(http.StatusInternalServerError, "Database error", err)
+	}
+	var categoryIDs []int
+	for rows.Next() {
+		var id int
+		var name string
+		err = rows.Scan(&id, &name)
+		if err != nil {
+			rows.Close()
+			return NewError(http.StatusInternalServerError, "Row scan error", err)
+		}
+		categories[id] = name
+		categoryIDs = append(categoryIDs, id)
+	}
+	rows.Close()
+
+	if len(board.Columns) == 0 {
+		switch board.ColumnBy {
+		case BoardByStatus:
+			for _, status := range TodoStatuses {
+				board.Columns = append(board.Columns, models.BoardColumn{Status: status})
+			}
+		case BoardByCategory:
+			for _, id := range categoryIDs {
+				board.Columns = append(board.Columns, models.BoardColumn{CategoryID: id})
+			}
+		}
+	}
+
+	seen := make(map[string]bool)
+	for i := range board.Columns {
+		column := &board.Columns[i]
+		column.Todos = nil
+		column.Name = strings.TrimSpace(column.Name)
+
+		var key string
+		switch board.ColumnBy {
+		case BoardByStatus:
+			if !IsStatus(column.Status) {
+				return NewError(http.StatusBadRequest, "Column status must be one of "+strings.Join(TodoStatuses, ", "), nil)
+			}
+			column.CategoryID = 0
+			key = column.Status
+			if column.Name == "" {
+				column.Name = column.Status
+			}
+		case BoardByCategory:
+			name, ok := categories[column.CategoryID]
+			if !ok {
+				return NewError(http.StatusBadRequest, fmt.Sprintf("No category with ID %v", column.CategoryID), nil)
+			}
+			column.Status = ""
+			key = fmt.Sprint(column.CategoryID)
+			if column.Name == "" {
+				column.Name = name
+			}
+		default:
+			return NewError(http.StatusBadRequest, "column_by must be one of "+strings.Join(BoardColumnBy, ", "), nil)
+		}
+
+		if seen[key] {
+			return NewError(http.StatusBadRequest, fmt.Sprintf("Two columns hold %s %s", board.ColumnBy, key), nil)
+		}
+		seen[key] = true
+		if len(column.Name) > 50 {
+			return NewError(http.StatusBadRequest, "Column names must be at most 50 characters", nil)
+		}
+	}
+	return nil
+}
+
+func InsertBoard(q Querier, board *models.Board) error {
+	err := ValidateBoard(q, board)
+	if err != nil {
+		return err
+	}
+
+	board.CreatedAt = time.Now().UTC()
+	result, err := q.Exec(`INSERT INTO board (name, column_by, created_at) VALUES (?, ?, ?)`, board.Name, board.ColumnBy, board.CreatedAt)
+	if err != nil {
+		return NewError(http.StatusInternalServerError, "Insert failed", err)
+	}
+	id, err := result.LastInsertId()
+	if err != nil {
+		return NewError(http.StatusInternalServerError, "Failed to retrieve inserted ID", err)
+	}
+	board.ID = int(id)
+	return saveColumns(q, board)
+}
+
+// UpdateBoard saves board, which must have been read and changed by the
+// caller, over the stored one. Its columns replace the stored ones; columns
+// that hold the same status or category keep their ID.
+func UpdateBoard(q Querier, board *models.Board) error {
+	err := ValidateBoard(q, board)
+	if err != nil {
+		return err
+	}
+
+	_, err = q.Exec(`UPDATE board SET name = ? WHERE id = ?`, board.Name, board.ID)
+	if err != nil {
+		return NewError(http.StatusInternalServerError, "Failed to update board", err)
+	}
+	return saveColumns(q, board)
+}
+
+// saveColumns makes the stored columns of board match board.Columns and
+// sets their IDs.
+func saveColumns(q Querier, board *models.Board) error {
+	stored, err := boardColumns(q, board.ID)
+	if err != nil {
+		return err
+	}
+	key := func(c models.BoardColumn) string { return c.Status + "/" + fmt.Sprint(c.CategoryID) }
+	ids := make(map[string]int)
+	for _, c := range stored {
+		ids[key(c)] = c.ID
+	}
+
+	kept := make(map[int]bool)
+	for i := range board.Columns {
+		column := &board.Columns[i]
+		column.ID = ids[key(*column)]
+		if column.ID != 0 {
+			kept[column.ID] = true
+			_, err = q.Exec(`UPDATE board_column SET name = ?, position = ? WHERE id = ?`, column.Name, i, column.ID)
+		} else {
+			var result sql.Result
+			query := `INSERT INTO board_column (board_id, name, status, category_id, position) VALUES (?, ?, NULLIF(?, ''), NULLIF(?, 0), ?)`
+			result, err = q.Exec(query, board.ID, column.Name, column.Status, column.CategoryID, i)
+			if err == nil {
+				var id int64
+				id, err = result.LastInsertId()
+				column.ID = int(id)
+			}
+		}
+		if err != nil {
+			return NewError(http.StatusInternalServerError, "Failed to save board columns", err)
+		}
+	}
+
+	for _, c := range stored {
+		if kept[c.ID] {
+			continue
+		}
+		_, err = q.Exec(`DELETE FROM board_column WHERE id = ?`, c.ID)
+		if err != nil {
+			return NewError(http.StatusInternalServerError, "Failed to save board columns", err)
+		}
+	}
+	return nil
+}
+
+func DeleteBoard(q Querier, id int) error {
+	result, err := q.Exec(`DELETE FROM board WHERE id = ?`, id)
+	if err != nil {
+		return NewError(http.StatusInternalServerError, "Database error", err)
+	}
+	rowsAffected, err := result.RowsAffected()
+	if err != nil {
+		return NewError(http.StatusInternalServerError, "Could not retrieve delete result", err)
+	}
+	if rowsAffected == 0 {
+		return NewError(http.StatusNotFound, fmt.Sprintf("No board with ID %d", id), nil)
+	}
+	return nil
+}
+
+// columnCondition matches the todos of column.
+func columnCondition(column models.BoardColumn) (string, interface{}) {
+	if column.Status != "" {
+		return "status = ?", column.Status
+	}
+	return "category_id = ?", column.CategoryID
+}
+
+// columnQuery selects the unarchived todos of column in board order, with
+// their rank, NULL for todos never moved on the board.
+const columnQuery = `SELECT ` + TodoColumns + `, p.rank FROM todo
+	LEFT JOIN board_position p ON p.board_id = ? AND p.todo_id = todo.id
+	WHERE archived = 0 AND `
+
+const columnOrder = ` ORDER BY p.rank IS NULL, p.rank, todo.id`
+
+// BoardTodos fills in the todos of every column of board.
+func BoardTodos(q Querier, board *models.Board) error {
+	for i := range board.Columns {
+		cond, arg := columnCondition(board.Columns[i])
+		rows, err := q.Query(columnQuery+cond+columnOrder, board.ID, arg)
+		if err != nil {
+			return NewError(http.StatusInternalServerError, "Database error", err)
+		}
+
+		board.Columns[i].Todos = []models.Todo{}
+		for rows.Next() {
+			var todo models.Todo
+			var rank sql.NullString
+			err = ScanTodo(rows, &todo, &rank)
+			if err != nil {
+				rows.Close()
+				return NewError(http.StatusInternalServerError, "Row scan error", err)
+			}
+			board.Columns[i].Todos = append(board.Columns[i].Todos, todo)
+		}
+		rows.Close()
+	}
+	return nil
+}
+
+// MoveTodo puts a todo in the column of board with the given ID, right
+// after the todo afterID, or first when afterID is 0. Moving to another
+// column changes the todo's status, which must be an allowed transition,
+// or its category; the fields that changed are returned, named as in the
+// message of UpdateTodo. Only the moved todo gets a new rank, apart from
+// todos never moved on the board that it is placed after, which get theirs
+// the first time.
+func MoveTodo(q Querier, board models.Board, todoID, columnID, afterID int) (string, error) {
+	var column *models.BoardColumn
+	for i := range board.Columns {
+		if board.Columns[i].ID == columnID {
+			column = &board.Columns[i]
+		}
+	}
+	if column == nil {
+		return "", NewError(http.StatusBadRequest, fmt.Sprintf("Board %d has no column with ID %d", board.ID, columnID), nil)
+	}
+
+	todo, err := SelectTodo(q, todoID)
+	if err != nil {
+		return "", err
+	}
+	if todo.Archived {
+		return "", NewError(http.StatusBadRequest, fmt.Sprintf("Todo %d is archived", todoID), nil)
+	}
+
+	changed := ""
+	switch {
+	case column.Status != "" && todo.Status != column.Status:
+		_, err = SetTodoStatus(q, todoID, column.Status)
+		changed = "status"
+		if todo.IsDone != (column.Status == StatusDone) {
+			changed = "status, is_done"
+		}
+	case column.CategoryID != 0 && todo.CategoryID != column.CategoryID:
+		err = ExecTodo(q, todoID, `UPDATE todo SET category_id = ? WHERE id = ?`, column.CategoryID)
+		changed = "category_id"
+	}
+	if err != nil {
+		return "", err
+	}
+
+	// The column as it is without the moved todo.
+	cond, arg := columnCondition(*column)
+	rows, err := q.Query(`SELECT todo.id, p.rank FROM todo
+	LEFT JOIN board_position p ON p.board_id = ? AND p.todo_id = todo.id
+	WHERE archived = 0 AND todo.id != ? AND `+cond+columnOrder, board.ID, todoID, arg)
+	if err != nil {
+		return "", NewError(http.StatusInternalServerError, "Database error", err)
+	}
+	type entry struct {
+		id   int
+		rank sql.NullString
+	}
+	var entries []entry
+	for rows.Next() {
+		var e entry
+		err = rows.Scan(&e.id, &e.rank)
+		if err != nil {
+			rows.Close()
+			return "", NewError(http.StatusInternalServerError, "Row scan error", err)
+		}
+		entries = append(entries, e)
+	}
+	rows.Close()
+
+	at := 0
+	if afterID != 0 {
+		at = -1
+		for i, e := range entries {
+			if e.id == afterID {
+				at = i + 1
+			}
+		}
+		if at < 0 {
+			return "", NewError(http.StatusBadRequest, fmt.Sprintf("Todo %d is not in column %d", afterID, columnID), nil)
+		}
+	}
+
+	// Todos never moved on the board come last, so the ones the todo goes
+	// after are ranked now, in their current order.
+	prev := ""
+	for i := 0; i < at; i++ {
+		if entries[i].rank.Valid {
+			prev = entries[i].rank.String
+			continue
+		}
+		prev, err = setRank(q, board.ID, entries[i].id, prev, "")
+		if err != nil {
+			return "", err
+		}
+	}
+	next := ""
+	if at < len(entries) && entries[at].rank.Valid {
+		next = entries[at].rank.String
+	}
+
+	_, err = setRank(q, board.ID, todoID, prev, next)
+	return changed, err
+}
+
+// setRank gives the todo a rank on the board between a and b that no other
+// todo of the board has, and returns it.
+func setRank(q Querier, boardID, todoID int, a, b string) (string, error) {
+	for {
+		r, err := rank.Between(a, b)
+		if err != nil {
+			return "", NewError(http.StatusInternalServerError, "Failed to rank todo", err)
+		}
+
+		var taken int
+		err = q.QueryRow(`SELECT COUNT(*) FROM board_position WHERE board_id = ? AND rank = ? AND todo_id != ?`, boardID, r, todoID).Scan(&taken)
+		if err != nil {
+			return "", NewError(http.StatusInternalServerError, "Database error", err)
+		}
+		if taken > 0 {
+			// A todo in another column has it; look further up.
+			a = r
+			continue
+		}
+
+		query := `INSERT INTO board_position (board_id, todo_id, rank) VALUES (?, ?, ?)
+		ON CONFLICT (board_id, todo_id) DO UPDATE SET rank = excluded.rank`
+		_, err = q.Exec(query, boardID, todoID, r)
+		if err != nil {
+			return "", NewError(http.StatusInternalServerError, "Failed to move todo", err)
+		}
+		return r, nil
+	}
+}
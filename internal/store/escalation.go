@@ -0,0 +1,183 @@
+package store
+
+import (
+	"database/sql"
+	"fmt"
+	"net/http"
+	"strings"
+	"time"
+
+	"github.com/furkankorkmaz309/todo-api/internal/models"
+)
+
+const (
+	ActionBumpPriority = "bump_priority"
+	ActionNotify       = "notify"
+	ActionMoveCategory = "move_category"
+)
+
+// EscalationActions lists what an escalation rule can do.
+var EscalationActions = []string{ActionBumpPriority, ActionNotify, ActionMoveCategory}
+
+const escalationRuleColumns = `id, name, after_days, action, COALESCE(category_id, 0), COALESCE(channel, ''), active, created_at`
+
+func scanEscalationRule(row RowScanner, rule *models.EscalationRule) error {
+	return row.Scan(&rule.ID, &rule.Name, &rule.AfterDays, &rule.Action, &rule.CategoryID, &rule.Channel, &rule.Active, &rule.CreatedAt)
+}
+
+// ListEscalationRules returns the rules, the ones acting soonest first.
+// With activeOnly, paused rules are left out.
+func ListEscalationRules(q Querier, activeOnly bool) ([]models.EscalationRule, error) {
+	query := `SELECT ` + escalationRuleColumns + ` FROM escalation_rule`
+	if activeOnly {
+		query += ` WHERE active = 1`
+	}
+	query += ` ORDER BY after_days, id`
+
+	rows, err := q.Query(query)
+	if err != nil {
+		return nil, NewError(http.StatusInternalServerError, "Database error", err)
+	}
+	defer rows.Close()
+
+	var rules []models.EscalationRule
+	for rows.Next() {
+		var rule models.EscalationRule
+		err = scanEscalationRule(rows, &rule)
+		if err != nil {
+			return nil, NewError(http.StatusInternalServerError, "Row scan error", err)
+		}
+		rules = append(rules, rule)
+	}
+	return rules, rows.Err()
+}
+
+func SelectEscalationRule(q Querier, id int) (models.EscalationRule, error) {
+	var rule models.EscalationRule
+	err := scanEscalationRule(q.QueryRow(`SELECT `+escalationRuleColumns+` FROM escalation_rule WHERE id = ?`, id), &rule)
+	if err == sql.ErrNoRows {
+		return rule, NewError(http.StatusNotFound, fmt.Sprintf("No escalation rule with ID %d", id), nil)
+	}
+	if err != nil {
+		return rule, NewError(http.StatusInternalServerError, "Database error", err)
+	}
+	return rule, nil
+}
+
+// ValidateEscalationRule checks rule and clears the fields its action does
+// not use. The channel of notify rules is checked by the caller, who knows
+// which channels are configured.
+func ValidateEscalationRule(q Querier, rule *models.EscalationRule) error {
+	rule.Name = strings.TrimSpace(rule.Name)
+	if rule.Name == "" || len(rule.Name) > 50 {
+		return NewError(http.StatusBadRequest, "Name must be 1 to 50 characters", nil)
+	}
+	if rule.AfterDays < 0 {
+		return NewError(http.StatusBadRequest, "after_days cannot be negative", nil)
+	}
+
+	switch rule.Action {
+	case ActionBumpPriority:
+		rule.CategoryID, rule.Channel = 0, ""
+	case ActionNotify:
+		rule.CategoryID = 0
+	case ActionMoveCategory:
+		rule.Channel = ""
+		if rule.CategoryID == 0 {
+			return NewError(http.StatusBadRequest, "move_category needs a category_id", nil)
+		}
+		return CheckCategory(q, rule.CategoryID)
+	default:
+		return NewError(http.StatusBadRequest, "Action must be one of "+strings.Join(EscalationActions, ", "), nil)
+	}
+	return nil
+}
+
+func InsertEscalationRule(q Querier, rule *models.EscalationRule) error {
+	err := ValidateEscalationRule(q, rule)
+	if err != nil {
+		return err
+	}
+
+	rule.Active = true
+	rule.CreatedAt = time.Now().UTC()
+	query := `INSERT INTO escalation_rule (name, after_days, action, category_id, channel, active, created_at) VALUES (?, ?, ?, NULLIF(?, 0), NULLIF(?, ''), ?, ?)`
+	result, err := q.Exec(query, rule.Name, rule.AfterDays, rule.Action, rule.CategoryID, rule.Channel, rule.Active, rule.CreatedAt)
+	if err != nil {
+		return NewError(http.StatusInternalServerError, "Insert failed", err)
+	}
+	id, err := result.LastInsertId()
+	if err != nil {
+		return NewError(http.StatusInternalServerError, "Failed to retrieve inserted ID", err)
+	}
+	rule.ID = int(id)
+	return nil
+}
+
+// UpdateEscalationRule saves rule, which must have been read and changed
+// by the caller, over the stored one.
+func UpdateEscalationRule(q Querier, rule *models.EscalationRule) error {
+	err := ValidateEscalationRule(q, rule)
+	if err != nil {
+		return err
+	}
+
+	query := `UPDATE escalation_rule SET name = ?, after_days = ?, category_id = NULLIF(?, 0), channel = NULLIF(?, ''), active = ? WHERE id = ?`
+	_, err = q.Exec(query, rule.Name, rule.AfterDays, rule.CategoryID, rule.Channel, rule.Active, rule.ID)
+	if err != nil {
+		return NewError(http.StatusInternalServerError, "Failed to update escalation rule", err)
+	}
+	return nil
+}
+
+func DeleteEscalationRule(q Querier, id int) error {
+	result, err := q.Exec(`DELETE FROM escalation_rule WHERE id = ?`, id)
+	if err != nil {
+		return NewError(http.StatusInternalServerError, "Database error", err)
+	}
+	rowsAffected, err := result.RowsAffected()
+	if err != nil {
+		return NewError(http.StatusInternalServerError, "Could not retrieve delete result", err)
+	}
+	if rowsAffected == 0 {
+		return NewError(http.StatusNotFound, fmt.Sprintf("No escalation rule with ID %d", id), nil)
+	}
+	return nil
+}
+
+// EscalationCandidates returns the unarchived todos that have been overdue
+// for the days of rule at now and that it has not acted on since their due
+// date was set.
+func EscalationCandidates(q Querier, rule models.EscalationRule, now time.Time) ([]models.Todo, error) {
+	query := `SELECT ` + TodoColumns + ` FROM todo WHERE archived = 0 AND ` + OverdueCondition + `
+	AND NOT EXISTS (SELECT 1 FROM escalation e WHERE e.rule_id = ? AND e.todo_id = todo.id AND e.due_date = todo.due_date)
+	ORDER BY id`
+	cutoff := now.UTC().Add(-time.Duration(rule.AfterDays) * 24 * time.Hour)
+	rows, err := q.Query(query, cutoff, rule.ID)
+	if err != nil {
+		return nil, NewError(http.StatusInternalServerError, "Database error", err)
+	}
+	defer rows.Close()
+
+	var todos []models.Todo
+	for rows.Next() {
+		var todo models.Todo
+		err = ScanTodo(rows, &todo)
+		if err != nil {
+			return nil, NewError(http.StatusInternalServerError, "Row scan error", err)
+		}
+		todos = append(todos, todo)
+	}
+	return todos, rows.Err()
+}
+
+// RecordEscalation remembers that rule acted on the todo at its current
+// due date, so it does not act again until the due date moves.
+func RecordEscalation(q Querier, ruleID, todoID int) error {
+	query := `INSERT INTO escalation (rule_id, todo_id, due_date, applied_at) SELECT ?, id, due_date, ? FROM todo WHERE id = ?`
+	_, err := q.Exec(query, ruleID, time.Now().UTC(), todoID)
+	if err != nil {
+		return NewError(http.StatusInternalServerError, "Database error", err)
+	}
+	return nil
+}
@@ -0,0 +1,85 @@
+package store
+
+import (
+	"testing"
+	"time"
+
+	"github.com/furkankorkmaz309/todo-api/internal/models"
+)
+
+func TestEscalationOncePerDueDate(t *testing.T) {
+	conn := openTestDB(t)
+	todo := insertTestTodo(t, conn, models.Todo{})
+	now := time.Now()
+	setDue := func(due time.Time) {
+		t.Helper()
+		_, err := conn.Exec(`UPDATE todo SET due_date = ? WHERE id = ?`, due.UTC(), todo.ID)
+		if err != nil {
+			t.Fatal(err)
+		}
+	}
+	candidates := func(rule models.EscalationRule) []int {
+		t.Helper()
+		todos, err := EscalationCandidates(conn, rule, now)
+		if err != nil {
+			t.Fatal(err)
+		}
+		var ids []int
+		for _, todo := range todos {
+			ids = append(ids, todo.ID)
+		}
+		return ids
+	}
+
+	rule := models.EscalationRule{Name: "bump", AfterDays: 2, Action: ActionBumpPriority}
+	err := InsertEscalationRule(conn, &rule)
+	if err != nil {
+		t.Fatal(err)
+	}
+	later := models.EscalationRule{Name: "later", AfterDays: 5, Action: ActionNotify, Channel: "log"}
+	err = InsertEscalationRule(conn, &later)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if ids := candidates(rule); len(ids) != 0 {
+		t.Fatalf("candidates before the due date = %v, want none", ids)
+	}
+
+	setDue(now.Add(-3 * 24 * time.Hour))
+	if ids := candidates(rule); len(ids) != 1 || ids[0] != todo.ID {
+		t.Fatalf("candidates three days overdue = %v, want [%d]", ids, todo.ID)
+	}
+	if ids := candidates(later); len(ids) != 0 {
+		t.Errorf("candidates of a five day rule three days overdue = %v, want none", ids)
+	}
+
+	err = RecordEscalation(conn, rule.ID, todo.ID)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if ids := candidates(rule); len(ids) != 0 {
+		t.Errorf("candidates after the rule acted = %v, want none", ids)
+	}
+
+	// Moving the due date, even to one that is overdue as long, lets the
+	// rule act again.
+	setDue(now.Add(-4 * 24 * time.Hour))
+	if ids := candidates(rule); len(ids) != 1 || ids[0] != todo.ID {
+		t.Errorf("candidates after the due date moved = %v, want [%d]", ids, todo.ID)
+	}
+
+	for _, query := range []string{
+		`UPDATE todo SET archived = 1 WHERE id = ?`,
+		`UPDATE todo SET archived = 0, status = 'done', done = 1 WHERE id = ?`,
+		`UPDATE todo SET status = 'cancelled', done = 0 WHERE id = ?`,
+	} {
+		_, err = conn.Exec(query, todo.ID)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if ids := candidates(rule); len(ids) != 0 {
+			t.Errorf("after %q, candidates = %v, want none", query, ids)
+		}
+	}
+}
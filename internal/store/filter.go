@@ -0,0 +1,149 @@
+package store
+
+import (
+	"fmt"
+	"net/url"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// Filter is a set of SQL conditions on the todo table with their
+// arguments.
+type Filter struct {
+	Where []string
+	Args  []interface{}
+}
+
+// ParseFilter turns the list query parameters (category_id, status,
+// is_done, priority, overdue, due, due_before, due_after, completed_before,
+// completed_after) into a Filter. Days, in dates and in due, are those of loc.
+func ParseFilter(q url.Values, loc *time.Location) (Filter, error) {
+	var f Filter
+
+	if v := q.Get("category_id"); v != "" {
+		id, err := strconv.Atoi(v)
+		if err != nil {
+			return f, fmt.Errorf("invalid category_id")
+		}
+		f.Add("category_id = ?", id)
+	}
+
+	if v := q.Get("status"); v != "" {
+		if !IsStatus(v) {
+			return f, fmt.Errorf("status must be one of %s", strings.Join(TodoStatuses, ", "))
+		}
+		f.Add("status = ?", v)
+	}
+
+	if v := q.Get("is_done"); v != "" {
+		done, err := strconv.ParseBool(v)
+		if err != nil {
+			return f, fmt.Errorf("invalid is_done")
+		}
+		f.Add("done = ?", done)
+	}
+
+	if v := q.Get("priority"); v != "" {
+		priority, err := strconv.Atoi(v)
+		if err != nil || priority < 1 || priority > 5 {
+			return f, fmt.Errorf("priority must be between 1-5")
+		}
+		f.Add("priority = ?", priority)
+	}
+
+	if v := q.Get("overdue"); v != "" {
+		overdue, err := strconv.ParseBool(v)
+		if err != nil {
+			return f, fmt.Errorf("invalid overdue")
+		}
+		if overdue {
+			f.Add(OverdueCondition, time.Now().UTC())
+		} else {
+			f.Add("NOT "+OverdueCondition, time.Now().UTC())
+		}
+	}
+
+	if v := q.Get("due"); v != "" {
+		if v == "overdue" {
+			f.Add(OverdueCondition, time.Now().UTC())
+		} else {
+			from, to, ok := DueRange(v, time.Now().In(loc))
+			if !ok {
+				return f, fmt.Errorf("due must be one of %s", strings.Join(DueRanges, ", "))
+			}
+			f.Add("due_date >= ?", from.UTC())
+			f.Add("due_date < ?", to.UTC())
+		}
+	}
+
+	dateFilters := []struct {
+		param string
+		cond  string
+	}{
+		{"due_before", "due_date < ?"},
+		{"due_after", "due_date > ?"},
+		{"completed_before", "completed_at < ?"},
+		{"completed_after", "completed_at > ?"},
+	}
+	for _, df := range dateFilters {
+		v := q.Get(df.param)
+		if v == "" {
+			continue
+		}
+		t, err := ParseDate(v, loc)
+		if err != nil {
+			return f, fmt.Errorf("invalid %s", df.param)
+		}
+		f.Add(df.cond, t.UTC())
+	}
+
+	return f, nil
+}
+
+func (f *Filter) Add(cond string, arg interface{}) {
+	f.Where = append(f.Where, cond)
+	f.Args = append(f.Args, arg)
+}
+
+// And returns the conditions joined for use after an existing WHERE clause.
+func (f Filter) And() string {
+	if len(f.Where) == 0 {
+		return ""
+	}
+	return " AND " + strings.Join(f.Where, " AND ")
+}
+
+// ParseDate accepts RFC 3339 or YYYY-MM-DD, which is midnight in loc.
+func ParseDate(v string, loc *time.Location) (time.Time, error) {
+	t, err := time.Parse(time.RFC3339, v)
+	if err == nil {
+		return t, nil
+	}
+	return time.ParseInLocation("2006-01-02", v, loc)
+}
+
+// DueRanges are the values the due filter accepts.
+var DueRanges = []string{"overdue", "today", "tomorrow", "this_week", "next_week", "this_month"}
+
+// DueRange returns the span [from, to) the due filter name covers, in
+// now's location. Weeks start on Monday.
+func DueRange(name string, now time.Time) (time.Time, time.Time, bool) {
+	today := time.Date(now.Year(), now.Month(), now.Day(), 0, 0, 0, 0, now.Location())
+	monday := today.AddDate(0, 0, -(int(today.Weekday())+6)%7)
+
+	switch name {
+	case "today":
+		return today, today.AddDate(0, 0, 1), true
+	case "tomorrow":
+		return today.AddDate(0, 0, 1), today.AddDate(0, 0, 2), true
+	case "this_week":
+		return monday, monday.AddDate(0, 0, 7), true
+	case "next_week":
+		return monday.AddDate(0, 0, 7), monday.AddDate(0, 0, 14), true
+	case "this_month":
+		first := time.Date(today.Year(), today.Month(), 1, 0, 0, 0, 0, today.Location())
+		return first, first.AddDate(0, 1, 0), true
+	}
+	return time.Time{}, time.Time{}, false
+}
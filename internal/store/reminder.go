@@ -0,0 +1,134 @@
+package store
+
+import (
+	"fmt"
+	"net/http"
+	"time"
+
+	"github.com/furkankorkmaz309/todo-api/internal/models"
+)
+
+// ReminderColumns are read by ScanReminder. The todo's due date comes along
+// so FireAt can follow it.
+const ReminderColumns = `r.id, r.todo_id, r.remind_at, r.offset_minutes, t.due_date, r.channel, r.status, r.attempts,
+	COALESCE(r.last_error, ''), r.created_at, r.sent_at`
+
+// ReminderFrom joins reminders to their todos for ReminderColumns.
+const ReminderFrom = ` FROM reminder r JOIN todo t ON t.id = r.todo_id`
+
+func ScanReminder(row RowScanner, reminder *models.Reminder) error {
+	var dueDate time.Time
+	err := row.Scan(&reminder.ID, &reminder.TodoID, &reminder.RemindAt, &reminder.OffsetMinutes, &dueDate, &reminder.Channel,
+		&reminder.Status, &reminder.Attempts, &reminder.LastError, &reminder.CreatedAt, &reminder.SentAt)
+	if err != nil {
+		return err
+	}
+	reminder.FireAt = FireAt(*reminder, dueDate)
+	return nil
+}
+
+// FireAt is when reminder goes off for a todo due at dueDate.
+func FireAt(reminder models.Reminder, dueDate time.Time) time.Time {
+	if reminder.RemindAt != nil {
+		return *reminder.RemindAt
+	}
+	return dueDate.Add(-time.Duration(*reminder.OffsetMinutes) * time.Minute)
+}
+
+// ListReminders returns the reminders matching where, newest first.
+func ListReminders(q Querier, where string, args ...interface{}) ([]models.Reminder, error) {
+	query := `SELECT ` + ReminderColumns + ReminderFrom
+	if where != "" {
+		query += ` WHERE ` + where
+	}
+	query += ` ORDER BY r.id DESC LIMIT 100`
+
+	rows, err := q.Query(query, args...)
+	if err != nil {
+		return nil, NewError(http.StatusInternalServerError, "Database error", err)
+	}
+	defer rows.Close()
+
+	var reminders []models.Reminder
+	for rows.Next() {
+		var reminder models.Reminder
+		err = ScanReminder(rows, &reminder)
+		if err != nil {
+			return nil, NewError(http.StatusInternalServerError, "Row scan error", err)
+		}
+		reminders = append(reminders, reminder)
+	}
+	return reminders, rows.Err()
+}
+
+// InsertReminder adds a pending reminder to the todo it names, which must
+// exist and be due after the reminder goes off.
+func InsertReminder(q Querier, reminder *models.Reminder) error {
+	todo, err := SelectTodo(q, reminder.TodoID)
+	if err != nil {
+		return err
+	}
+
+	if (reminder.RemindAt == nil) == (reminder.OffsetMinutes == nil) {
+		return NewError(http.StatusBadRequest, "Exactly one of remind_at and offset_minutes is required", nil)
+	}
+	if reminder.OffsetMinutes != nil && *reminder.OffsetMinutes < 0 {
+		return NewError(http.StatusBadRequest, "offset_minutes cannot be negative", nil)
+	}
+	if reminder.RemindAt != nil {
+		utc := reminder.RemindAt.UTC()
+		reminder.RemindAt = &utc
+	}
+
+	reminder.FireAt = FireAt(*reminder, todo.DueDate)
+	if !reminder.FireAt.After(time.Now()) {
+		return NewError(http.StatusBadRequest, fmt.Sprintf("The reminder would go off in the past, at %s", reminder.FireAt.Format(time.RFC3339)), nil)
+	}
+
+	reminder.Status = "pending"
+	reminder.Attempts = 0
+	reminder.CreatedAt = time.Now().UTC()
+
+	query := `INSERT INTO reminder (todo_id, remind_at, offset_minutes, channel, status, created_at) VALUES (?, ?, ?, ?, ?, ?)`
+	result, err := q.Exec(query, reminder.TodoID, reminder.RemindAt, reminder.OffsetMinutes, reminder.Channel, reminder.Status, reminder.CreatedAt)
+	if err != nil {
+		return NewError(http.StatusInternalServerError, "Insert failed", err)
+	}
+	id, err := result.LastInsertId()
+	if err != nil {
+		return NewError(http.StatusInternalServerError, "Failed to retrieve inserted ID", err)
+	}
+	reminder.ID = int(id)
+	return nil
+}
+
+func DeleteReminder(q Querier, todoID, id int) error {
+	result, err := q.Exec(`DELETE FROM reminder WHERE id = ? AND todo_id = ?`, id, todoID)
+	if err != nil {
+		return NewError(http.StatusInternalServerError, "Database error", err)
+	}
+	rowsAffected, err := result.RowsAffected()
+	if err != nil {
+		return NewError(http.StatusInternalServerError, "Could not retrieve delete result", err)
+	}
+	if rowsAffected == 0 {
+		return NewError(http.StatusNotFound, fmt.Sprintf("No reminder with ID %d for todo %d", id, todoID), nil)
+	}
+	return nil
+}
+
+// QueueReminder adds a reminder through channel that goes off at once, for
+// notifications the server decides on itself.
+func QueueReminder(q Querier, todoID int, channel string) (int, error) {
+	now := time.Now().UTC()
+	query := `INSERT INTO reminder (todo_id, remind_at, channel, status, created_at) VALUES (?, ?, ?, 'pending', ?)`
+	result, err := q.Exec(query, todoID, now, channel, now)
+	if err != nil {
+		return 0, NewError(http.StatusInternalServerError, "Insert failed", err)
+	}
+	id, err := result.LastInsertId()
+	if err != nil {
+		return 0, NewError(http.StatusInternalServerError, "Failed to retrieve inserted ID", err)
+	}
+	return int(id), nil
+}
@@ -0,0 +1,58 @@
+package store
+
+import (
+	"database/sql"
+	"net/http"
+	"time"
+
+	"github.com/furkankorkmaz309/todo-api/internal/models"
+)
+
+// GetSettings returns the preferences. The API has no user accounts, so
+// they belong to the whole server.
+func GetSettings(q Querier) (models.Settings, error) {
+	var settings models.Settings
+	err := q.QueryRow(`SELECT value FROM setting WHERE key = 'timezone'`).Scan(&settings.Timezone)
+	if err != nil && err != sql.ErrNoRows {
+		return settings, NewError(http.StatusInternalServerError, "Database error", err)
+	}
+	settings.Transitions, err = Transitions(q)
+	return settings, err
+}
+
+// SetTimezone stores the time zone preference; an empty name removes it.
+func SetTimezone(q Querier, name string) error {
+	if name != "" {
+		_, err := time.LoadLocation(name)
+		if err != nil || name == "Local" {
+			return NewError(http.StatusBadRequest, "Unknown time zone "+name, err)
+		}
+	}
+
+	_, err := q.Exec(`DELETE FROM setting WHERE key = 'timezone'`)
+	if err == nil && name != "" {
+		_, err = q.Exec(`INSERT INTO setting (key, value) VALUES ('timezone', ?)`, name)
+	}
+	if err != nil {
+		return NewError(http.StatusInternalServerError, "Failed to save settings", err)
+	}
+	return nil
+}
+
+// Location is the time zone dates are read in unless a request names its
+// own: the preference, or the server's time zone when there is none.
+func Location(q Querier) (*time.Location, error) {
+	settings, err := GetSettings(q)
+	if err != nil {
+		return nil, err
+	}
+	if settings.Timezone == "" {
+		return time.Local, nil
+	}
+
+	loc, err := time.LoadLocation(settings.Timezone)
+	if err != nil {
+		return nil, NewError(http.StatusInternalServerError, "Stored time zone is invalid", err)
+	}
+	return loc, nil
+}
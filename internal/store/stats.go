@@ -0,0 +1,205 @@
+package store
+
+import (
+	"fmt"
+	"net/http"
+	"time"
+)
+
+type Stats struct {
+	Totals     StatusCounts    `json:"totals"`
+	Categories []CategoryStats `json:"categories"`
+	Priorities []PriorityStats `json:"priorities"`
+	Windows    []WindowStats   `json:"windows"`
+
+	// AverageCompletionSeconds is the mean time from created_at to
+	// completed_at of done todos, 0 when there are none. Todos done before
+	// completion times were recorded are left out of it, of the completed
+	// counts of the windows and of the series.
+	AverageCompletionSeconds int64 `json:"average_completion_seconds"`
+
+	Days     []DayStats `json:"days"`
+	Timezone string     `json:"timezone"`
+}
+
+// StatusCounts counts todos, archived ones included. Open todos are neither
+// done nor cancelled; overdue ones are open as well.
+type StatusCounts struct {
+	Total      int `json:"total"`
+	Open       int `json:"open"`
+	Todo       int `json:"todo"`
+	InProgress int `json:"in_progress"`
+	Blocked    int `json:"blocked"`
+	Done       int `json:"done"`
+	Cancelled  int `json:"cancelled"`
+	Overdue    int `json:"overdue"`
+	Archived   int `json:"archived"`
+}
+
+type CategoryStats struct {
+	CategoryID int          `json:"category_id"` // 0 for todos without one
+	Name       string       `json:"name"`
+	Counts     StatusCounts `json:"counts"`
+}
+
+type PriorityStats struct {
+	Priority int          `json:"priority"`
+	Counts   StatusCounts `json:"counts"`
+}
+
+// WindowStats covers the last Days days. CompletionRate is the share of the
+// todos created in them that are done.
+type WindowStats struct {
+	Days           int     `json:"days"`
+	Created        int     `json:"created"`
+	Completed      int     `json:"completed"`
+	CompletionRate float64 `json:"completion_rate"`
+}
+
+type DayStats struct {
+	Date      string `json:"date"`
+	Created   int    `json:"created"`
+	Completed int    `json:"completed"`
+}
+
+// StatsWindows are the periods completion rates are given for.
+var StatsWindows = []int{7, 30, 90}
+
+// statusColumns aggregates StatusCounts; it takes the current time.
+const statusColumns = `COUNT(*), COALESCE(SUM(NOT ` + ClosedCondition + `), 0),
+	COALESCE(SUM(status = 'todo'), 0), COALESCE(SUM(status = 'in_progress'), 0), COALESCE(SUM(status = 'blocked'), 0),
+	COALESCE(SUM(status = 'done'), 0), COALESCE(SUM(status = 'cancelled'), 0),
+	COALESCE(SUM(` + OverdueCondition + `), 0), COALESCE(SUM(archived = 1), 0)`
+
+func scanStatus(row RowScanner, counts *StatusCounts, before ...interface{}) error {
+	dest := append(before, &counts.Total, &counts.Open, &counts.Todo, &counts.InProgress, &counts.Blocked,
+		&counts.Done, &counts.Cancelled, &counts.Overdue, &counts.Archived)
+	return row.Scan(dest...)
+}
+
+// ComputeStats aggregates the todos at now. The series holds one entry per
+// day of loc, the last days of them up to today, oldest first.
+func ComputeStats(q Querier, now time.Time, loc *time.Location, days int) (Stats, error) {
+	stats := Stats{Categories: []CategoryStats{}, Priorities: []PriorityStats{}, Timezone: loc.String()}
+	utcNow := now.UTC()
+
+	err := scanStatus(q.QueryRow(`SELECT `+statusColumns+` FROM todo`, utcNow), &stats.Totals)
+	if err != nil {
+		return stats, NewError(http.StatusInternalServerError, "Database error", err)
+	}
+
+	query := `SELECT COALESCE(t.category_id, 0), COALESCE(c.name, ''), ` + statusColumns + `
+	FROM todo t LEFT JOIN category c ON c.id = t.category_id GROUP BY 1, 2 ORDER BY 1`
+	rows, err := q.Query(query, utcNow)
+	if err != nil {
+		return stats, NewError(http.StatusInternalServerError, "Database error", err)
+	}
+	for rows.Next() {
+		var c CategoryStats
+		err = scanStatus(rows, &c.Counts, &c.CategoryID, &c.Name)
+		if err != nil {
+			rows.Close()
+			return stats, NewError(http.StatusInternalServerError, "Row scan error", err)
+		}
+		stats.Categories = append(stats.Categories, c)
+	}
+	rows.Close()
+
+	rows, err = q.Query(`SELECT priority, `+statusColumns+` FROM todo GROUP BY priority ORDER BY priority`, utcNow)
+	if err != nil {
+		return stats, NewError(http.StatusInternalServerError, "Database error", err)
+	}
+	for rows.Next() {
+		var p PriorityStats
+		err = scanStatus(rows, &p.Counts, &p.Priority)
+		if err != nil {
+			rows.Close()
+			return stats, NewError(http.StatusInternalServerError, "Row scan error", err)
+		}
+		stats.Priorities = append(stats.Priorities, p)
+	}
+	rows.Close()
+
+	for _, n := range StatsWindows {
+		w := WindowStats{Days: n}
+		since := utcNow.AddDate(0, 0, -n)
+		var createdDone int
+		query := `SELECT COALESCE(SUM(created_at >= ?), 0), COALESCE(SUM(created_at >= ? AND done = 1), 0), COALESCE(SUM(completed_at IS NOT NULL AND completed_at >= ?), 0) FROM todo`
+		err = q.QueryRow(query, since, since, since).Scan(&w.Created, &createdDone, &w.Completed)
+		if err != nil {
+			return stats, NewError(http.StatusInternalServerError, "Database error", err)
+		}
+		if w.Created > 0 {
+			w.CompletionRate = float64(createdDone) / float64(w.Created)
+		}
+		stats.Windows = append(stats.Windows, w)
+	}
+
+	var avg float64
+	query = `SELECT COALESCE(AVG((julianday(completed_at) - julianday(created_at)) * 86400), 0) FROM todo WHERE done = 1 AND completed_at IS NOT NULL`
+	err = q.QueryRow(query).Scan(&avg)
+	if err != nil {
+		return stats, NewError(http.StatusInternalServerError, "Database error", err)
+	}
+	stats.AverageCompletionSeconds = int64(avg)
+
+	stats.Days, err = daySeries(q, now.In(loc), days)
+	if err != nil {
+		return stats, err
+	}
+	return stats, nil
+}
+
+// daySeries counts the todos created and completed on each of the last
+// days days of now's location. SQLite only knows UTC, so it counts per
+// hour, shifted to start on the hours of the location, and the hours are
+// added up into days here.
+func daySeries(q Querier, now time.Time, days int) ([]DayStats, error) {
+	loc := now.Location()
+	today := time.Date(now.Year(), now.Month(), now.Day(), 0, 0, 0, 0, loc)
+	start := today.AddDate(0, 0, -(days - 1))
+
+	series := make([]DayStats, days)
+	index := make(map[string]int, days)
+	for i := range series {
+		series[i].Date = start.AddDate(0, 0, i).Format("2006-01-02")
+		index[series[i].Date] = i
+	}
+
+	_, offset := now.Zone()
+	shift := time.Duration(offset%3600) * time.Second
+	modifier := fmt.Sprintf("%+d minutes", int(shift.Minutes()))
+
+	for _, column := range []string{"created_at", "completed_at"} {
+		query := `SELECT strftime('%Y-%m-%d %H:00:00', ` + column + `, ?) AS hour, COUNT(*) FROM todo WHERE ` + column + ` IS NOT NULL AND ` + column + ` >= ? GROUP BY hour`
+		rows, err := q.Query(query, modifier, start.UTC())
+		if err != nil {
+			return nil, NewError(http.StatusInternalServerError, "Database error", err)
+		}
+
+		for rows.Next() {
+			var bucket string
+			var count int
+			err = rows.Scan(&bucket, &count)
+			if err != nil {
+				rows.Close()
+				return nil, NewError(http.StatusInternalServerError, "Row scan error", err)
+			}
+			t, err := time.Parse("2006-01-02 15:04:05", bucket)
+			if err != nil {
+				continue
+			}
+			i, ok := index[t.Add(-shift).In(loc).Format("2006-01-02")]
+			if !ok {
+				continue
+			}
+			if column == "created_at" {
+				series[i].Created += count
+			} else {
+				series[i].Completed += count
+			}
+		}
+		rows.Close()
+	}
+	return series, nil
+}
@@ -0,0 +1,138 @@
+package store
+
+import (
+	"database/sql"
+	"reflect"
+	"testing"
+	"time"
+
+	"github.com/furkankorkmaz309/todo-api/internal/models"
+)
+
+// statsTodo is a todo as ComputeStats sees it, with its times in UTC.
+type statsTodo struct {
+	created   string
+	completed string // empty while not done
+	due       string
+	status    string
+	archived  bool
+}
+
+func utc(t *testing.T, v string) time.Time {
+	t.Helper()
+	ts, err := time.Parse("2006-01-02 15:04", v)
+	if err != nil {
+		t.Fatal(err)
+	}
+	return ts
+}
+
+// insertStatsTodos adds todos with the given times, which cannot be set
+// through InsertTodo.
+func insertStatsTodos(t *testing.T, conn *sql.DB, todos []statsTodo) {
+	t.Helper()
+	for _, st := range todos {
+		todo := insertTestTodo(t, conn, models.Todo{})
+		if st.due == "" {
+			st.due = "2030-01-01 00:00"
+		}
+		if st.status == "" {
+			st.status = StatusTodo
+		}
+		var completed interface{}
+		if st.completed != "" {
+			completed = utc(t, st.completed)
+		}
+		query := `UPDATE todo SET created_at = ?, completed_at = ?, due_date = ?, status = ?, done = ?, archived = ? WHERE id = ?`
+		_, err := conn.Exec(query, utc(t, st.created), completed, utc(t, st.due), st.status, st.status == StatusDone, st.archived, todo.ID)
+		if err != nil {
+			t.Fatal(err)
+		}
+	}
+}
+
+// TestStatsInLocation checks the stats of a location half an hour off
+// the hours of UTC, where days start at 18:30 UTC.
+func TestStatsInLocation(t *testing.T) {
+	conn := openTestDB(t)
+	ist := time.FixedZone("IST", 5*3600+30*60)
+	now := utc(t, "2026-03-10 20:00") // 01:30 on the 11th in IST
+
+	insertStatsTodos(t, conn, []statsTodo{
+		{created: "2026-03-10 19:00"},
+		{created: "2026-03-10 18:29", completed: "2026-03-10 18:45", due: "2026-03-10 00:00", status: StatusDone},
+		{created: "2026-03-02 21:00", completed: "2026-03-09 12:00", status: StatusDone},
+		{created: "2026-01-01 00:00", due: "2026-02-01 00:00", status: StatusCancelled},
+		{created: "2026-03-05 10:00", due: "2026-03-09 00:00", status: StatusInProgress, archived: true},
+	})
+
+	stats, err := ComputeStats(conn, now, ist, 3)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	wantTotals := StatusCounts{Total: 5, Open: 2, Todo: 1, InProgress: 1, Done: 2, Cancelled: 1, Overdue: 1, Archived: 1}
+	if stats.Totals != wantTotals {
+		t.Errorf("totals = %+v, want %+v", stats.Totals, wantTotals)
+	}
+
+	wantWindows := []WindowStats{
+		{Days: 7, Created: 3, Completed: 2, CompletionRate: 1.0 / 3},
+		{Days: 30, Created: 4, Completed: 2, CompletionRate: 0.5},
+		{Days: 90, Created: 5, Completed: 2, CompletionRate: 0.4},
+	}
+	if !reflect.DeepEqual(stats.Windows, wantWindows) {
+		t.Errorf("windows = %+v, want %+v", stats.Windows, wantWindows)
+	}
+
+	// 16 minutes and 6 days 15 hours; julianday can round a second off.
+	if want := int64((960 + 572400) / 2); stats.AverageCompletionSeconds < want-1 || stats.AverageCompletionSeconds > want {
+		t.Errorf("average completion = %ds, want %ds", stats.AverageCompletionSeconds, want)
+	}
+
+	// In UTC all of these would fall on the 10th.
+	wantDays := []DayStats{
+		{Date: "2026-03-09", Created: 0, Completed: 1},
+		{Date: "2026-03-10", Created: 1, Completed: 0},
+		{Date: "2026-03-11", Created: 1, Completed: 1},
+	}
+	if !reflect.DeepEqual(stats.Days, wantDays) {
+		t.Errorf("days = %+v, want %+v", stats.Days, wantDays)
+	}
+	if stats.Timezone != "IST" {
+		t.Errorf("timezone = %q, want IST", stats.Timezone)
+	}
+}
+
+// TestDaySeriesAcrossDST checks that days keep to local midnight when the
+// offset changes within the series.
+func TestDaySeriesAcrossDST(t *testing.T) {
+	loc, err := time.LoadLocation("America/New_York")
+	if err != nil {
+		t.Skip(err)
+	}
+	conn := openTestDB(t)
+
+	// Clocks went forward at 07:00 UTC on 8 March 2026, from -05:00 to -04:00.
+	insertStatsTodos(t, conn, []statsTodo{
+		{created: "2026-03-07 04:30"}, // 6 March, before the series
+		{created: "2026-03-07 05:30"}, // 7 March 00:30 EST
+		{created: "2026-03-08 06:30"}, // 8 March 01:30 EST
+		{created: "2026-03-08 07:30"}, // 8 March 03:30 EDT
+		{created: "2026-03-09 03:30"}, // 8 March 23:30 EDT
+		{created: "2026-03-09 04:30", completed: "2026-03-09 15:00", status: StatusDone}, // 9 March 00:30 EDT
+	})
+
+	days, err := daySeries(conn, utc(t, "2026-03-09 16:00").In(loc), 3)
+	if err != nil {
+		t.Fatal(err)
+	}
+	want := []DayStats{
+		{Date: "2026-03-07", Created: 1},
+		{Date: "2026-03-08", Created: 3},
+		{Date: "2026-03-09", Created: 1, Completed: 1},
+	}
+	if !reflect.DeepEqual(days, want) {
+		t.Errorf("days = %+v, want %+v", days, want)
+	}
+}
@@ -0,0 +1,182 @@
+package store
+
+import (
+	"database/sql"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"sort"
+	"strings"
+	"time"
+
+	"github.com/furkankorkmaz309/todo-api/internal/models"
+)
+
+const (
+	StatusTodo       = "todo"
+	StatusInProgress = "in_progress"
+	StatusBlocked    = "blocked"
+	StatusDone       = "done"
+	StatusCancelled  = "cancelled"
+)
+
+// TodoStatuses lists the statuses of a todo in workflow order.
+var TodoStatuses = []string{StatusTodo, StatusInProgress, StatusBlocked, StatusDone, StatusCancelled}
+
+// DefaultTransitions are the status changes allowed until the transitions
+// setting says otherwise: anything goes while a todo is open, a done todo
+// can be reopened and a cancelled one restored.
+var DefaultTransitions = map[string][]string{
+	StatusTodo:       {StatusInProgress, StatusBlocked, StatusDone, StatusCancelled},
+	StatusInProgress: {StatusTodo, StatusBlocked, StatusDone, StatusCancelled},
+	StatusBlocked:    {StatusTodo, StatusInProgress, StatusDone, StatusCancelled},
+	StatusDone:       {StatusTodo, StatusInProgress},
+	StatusCancelled:  {StatusTodo},
+}
+
+// ClosedCondition matches the todos nothing is left to do for.
+const ClosedCondition = `status IN ('done', 'cancelled')`
+
+// IsStatus reports whether status is one of TodoStatuses.
+func IsStatus(status string) bool {
+	for _, s := range TodoStatuses {
+		if s == status {
+			return true
+		}
+	}
+	return false
+}
+
+// IsClosed reports whether the todo is done or cancelled.
+func IsClosed(todo models.Todo) bool {
+	return todo.Status == StatusDone || todo.Status == StatusCancelled
+}
+
+// SetStatus moves todo to status at now and keeps its timestamps in step:
+// started_at is set when work first starts and cleared if the todo goes
+// back to todo; completed_at is set when it is done and cleared when it is
+// reopened.
+func SetStatus(todo *models.Todo, status string, now time.Time) {
+	now = now.UTC()
+	switch status {
+	case StatusTodo:
+		todo.StartedAt = nil
+	case StatusInProgress:
+		if todo.StartedAt == nil {
+			todo.StartedAt = &now
+		}
+	}
+	if status == StatusDone {
+		if todo.Status != StatusDone || todo.CompletedAt == nil {
+			todo.CompletedAt = &now
+		}
+	} else {
+		todo.CompletedAt = nil
+	}
+	todo.Status = status
+	todo.IsDone = status == StatusDone
+}
+
+// Transitions returns the allowed status changes, keyed by the status they
+// start from.
+func Transitions(q Querier) (map[string][]string, error) {
+	var value string
+	err := q.QueryRow(`SELECT value FROM setting WHERE key = 'transitions'`).Scan(&value)
+	if err == sql.ErrNoRows {
+		return DefaultTransitions, nil
+	}
+	if err != nil {
+		return nil, NewError(http.StatusInternalServerError, "Database error", err)
+	}
+
+	var transitions map[string][]string
+	err = json.Unmarshal([]byte(value), &transitions)
+	if err != nil {
+		return nil, NewError(http.StatusInternalServerError, "Stored transitions are invalid", err)
+	}
+	return transitions, nil
+}
+
+// SetTransitions stores the allowed status changes; an empty map goes back
+// to DefaultTransitions. Statuses missing from it cannot be left.
+func SetTransitions(q Querier, transitions map[string][]string) error {
+	for from, to := range transitions {
+		if !IsStatus(from) {
+			return NewError(http.StatusBadRequest, fmt.Sprintf("Unknown status %q; statuses are %s", from, strings.Join(TodoStatuses, ", ")), nil)
+		}
+		for _, status := range to {
+			if !IsStatus(status) {
+				return NewError(http.StatusBadRequest, fmt.Sprintf("Unknown status %q; statuses are %s", status, strings.Join(TodoStatuses, ", ")), nil)
+			}
+		}
+		sort.Strings(to)
+	}
+
+	_, err := q.Exec(`DELETE FROM setting WHERE key = 'transitions'`)
+	if err == nil && len(transitions) > 0 {
+		var value []byte
+		value, err = json.Marshal(transitions)
+		if err == nil {
+			_, err = q.Exec(`INSERT INTO setting (key, value) VALUES ('transitions', ?)`, string(value))
+		}
+	}
+	if err != nil {
+		return NewError(http.StatusInternalServerError, "Failed to save settings", err)
+	}
+	return nil
+}
+
+// CheckTransition returns an error unless a todo may go from one status to
+// the other. Staying in the same status is always allowed.
+func CheckTransition(q Querier, from, to string) error {
+	if !IsStatus(to) {
+		return NewError(http.StatusBadRequest, "Status must be one of "+strings.Join(TodoStatuses, ", "), nil)
+	}
+	if from == to {
+		return nil
+	}
+
+	transitions, err := Transitions(q)
+	if err != nil {
+		return err
+	}
+	for _, status := range transitions[from] {
+		if status == to {
+			return nil
+		}
+	}
+	if len(transitions[from]) == 0 {
+		return NewError(http.StatusBadRequest, fmt.Sprintf("A todo cannot leave %s", from), nil)
+	}
+	return NewError(http.StatusBadRequest, fmt.Sprintf("A todo cannot go from %s to %s, only to %s", from, to, strings.Join(transitions[from], ", ")), nil)
+}
+
+// SetTodoStatus moves the todo with the given ID to status, if the
+// transitions allow it, and returns it as it is now.
+func SetTodoStatus(q Querier, id int, status string) (models.Todo, error) {
+	todo, err := SelectTodo(q, id)
+	if err != nil {
+		return todo, err
+	}
+	err = CheckTransition(q, todo.Status, status)
+	if err != nil || todo.Status == status {
+		return todo, err
+	}
+
+	SetStatus(&todo, status, time.Now())
+	query := `UPDATE todo SET status = ?, done = ?, started_at = ?, completed_at = ? WHERE id = ? AND version = ?`
+	result, err := q.Exec(query, todo.Status, todo.IsDone, todo.StartedAt, todo.CompletedAt, id, todo.Version)
+	if err != nil {
+		return todo, NewError(http.StatusInternalServerError, "Failed to update todo", err)
+	}
+	rowsAffected, err := result.RowsAffected()
+	if err != nil {
+		return todo, NewError(http.StatusInternalServerError, "Could not get update result", err)
+	}
+	if rowsAffected == 0 {
+		return todo, conflictError(id)
+	}
+	todo.Version++
+	todo.Overdue = isOverdue(todo, time.Now())
+	return todo, nil
+}
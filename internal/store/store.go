@@ -0,0 +1,511 @@
+// Package store holds the todo and category operations shared by the REST,
+// GraphQL and gRPC servers, along with their validation rules.
+package store
+
+import (
+	"database/sql"
+	"errors"
+	"fmt"
+	"net/http"
+	"sort"
+	"strings"
+	"time"
+
+	"github.com/furkankorkmaz309/todo-api/internal/events"
+	"github.com/furkankorkmaz309/todo-api/internal/models"
+)
+
+// Querier is satisfied by both *sql.DB and *sql.Tx so the todo operations
+// below can run on their own or as part of a larger transaction.
+type Querier interface {
+	Exec(query string, args ...interface{}) (sql.Result, error)
+	Query(query string, args ...interface{}) (*sql.Rows, error)
+	QueryRow(query string, args ...interface{}) *sql.Row
+}
+
+// Error carries the HTTP status and client message an operation failed
+// with; Err is the underlying cause and is only logged.
+type Error struct {
+	Status int
+	Msg    string
+	Err    error
+}
+
+func (e *Error) Error() string {
+	return e.Msg
+}
+
+func NewError(status int, msg string, err error) *Error {
+	return &Error{Status: status, Msg: msg, Err: err}
+}
+
+// AsError returns err as an *Error, treating anything else as a database
+// failure.
+func AsError(err error) *Error {
+	var storeErr *Error
+	if errors.As(err, &storeErr) {
+		return storeErr
+	}
+	return NewError(http.StatusInternalServerError, "Database error", err)
+}
+
+func CheckCategory(q Querier, id int) error {
+	var tempID int
+	err := q.QueryRow(`SELECT id FROM category WHERE id = ?`, id).Scan(&tempID)
+	if err == sql.ErrNoRows {
+		return NewError(http.StatusBadRequest, fmt.Sprintf("No category with ID %v", id), nil)
+	}
+	if err != nil {
+		return NewError(http.StatusInternalServerError, "Database error", err)
+	}
+	return nil
+}
+
+func InsertCategory(q Querier, category *models.Category) error {
+	if strings.TrimSpace(category.Name) == "" {
+		return NewError(http.StatusBadRequest, "Name field is blank", fmt.Errorf("blank name"))
+	}
+	if len(category.Name) > 30 {
+		return NewError(http.StatusBadRequest, "Name field is too long", nil)
+	}
+	if len(category.Description) > 100 {
+		return NewError(http.StatusBadRequest, "Description field is too long", nil)
+	}
+
+	query := `INSERT INTO category (name, description) VALUES (?,?)`
+	result, err := q.Exec(query, category.Name, category.Description)
+	if err != nil {
+		return NewError(http.StatusInternalServerError, "Database error", err)
+	}
+
+	id, err := result.LastInsertId()
+	if err != nil {
+		return NewError(http.StatusInternalServerError, "Failed to retrieve inserted ID", err)
+	}
+	category.ID = int(id)
+	return nil
+}
+
+// UpdateCategory applies the non-blank fields of newCategory and returns
+// the result along with a message naming the fields that changed.
+func UpdateCategory(q Querier, id int, newCategory models.Category) (models.Category, string, error) {
+	var oldCategory models.Category
+	err := q.QueryRow(`SELECT * FROM category WHERE id = ?`, id).Scan(&oldCategory.ID, &oldCategory.Name, &oldCategory.Description)
+	if err != nil {
+		return oldCategory, "", NewError(http.StatusNotFound, "Category not found", err)
+	}
+
+	responseString := "name, description updated!"
+
+	if len(newCategory.Name) > 30 {
+		return oldCategory, "", NewError(http.StatusBadRequest, "Name is too long", nil)
+	}
+	if len(newCategory.Description) > 100 {
+		return oldCategory, "", NewError(http.StatusBadRequest, "Description is too long", nil)
+	}
+	if strings.TrimSpace(newCategory.Name) == "" {
+		newCategory.Name = oldCategory.Name
+		responseString = strings.ReplaceAll(responseString, "name, ", "")
+	}
+	if strings.TrimSpace(newCategory.Description) == "" {
+		newCategory.Description = oldCategory.Description
+		responseString = strings.ReplaceAll(responseString, "description ", "")
+	}
+
+	if responseString == "updated!" {
+		return oldCategory, "", NewError(http.StatusBadRequest, "No fields provided for update", nil)
+	}
+
+	queryUpdate := `UPDATE category SET name = ?, description = ? WHERE id = ?`
+	result, err := q.Exec(queryUpdate, newCategory.Name, newCategory.Description, id)
+	if err != nil {
+		return oldCategory, "", NewError(http.StatusInternalServerError, "Failed to update category", err)
+	}
+
+	rowsAffected, err := result.RowsAffected()
+	if err != nil {
+		return oldCategory, "", NewError(http.StatusInternalServerError, "Could not retrieve update result", err)
+	}
+	if rowsAffected == 0 {
+		return oldCategory, "", NewError(http.StatusNotFound, fmt.Sprintf("No category with ID %d", id), nil)
+	}
+
+	newCategory.ID = id
+	return newCategory, responseString, nil
+}
+
+// DeleteCategory removes a category, handling its todos according to
+// policy (refuse, reassign to targetID, archive or delete). It returns the
+// affected todo IDs and the event to announce for them once committed.
+func DeleteCategory(db *sql.DB, id int, policy string, targetID int) ([]int, string, string, error) {
+	tx, err := db.Begin()
+	if err != nil {
+		return nil, "", "", NewError(http.StatusInternalServerError, "Database error", err)
+	}
+	defer tx.Rollback()
+
+	var tempID int
+	err = tx.QueryRow(`SELECT id FROM category WHERE id = ?`, id).Scan(&tempID)
+	if err == sql.ErrNoRows {
+		return nil, "", "", NewError(http.StatusNotFound, fmt.Sprintf("No category with ID %d", id), nil)
+	}
+	if err != nil {
+		return nil, "", "", NewError(http.StatusInternalServerError, "Database error", err)
+	}
+
+	todoIDs, err := QueryIDs(tx, `SELECT id FROM todo WHERE category_id = ?`, id)
+	if err != nil {
+		return nil, "", "", NewError(http.StatusInternalServerError, "Database error", err)
+	}
+	todoCount := len(todoIDs)
+	todoEvent := events.TodoUpdated
+
+	responseString := fmt.Sprintf("Category with ID %d deleted.", id)
+
+	if todoCount > 0 {
+		switch policy {
+		case "refuse":
+			return nil, "", "", NewError(http.StatusConflict, fmt.Sprintf("Category with ID %d still has %d todos", id, todoCount), nil)
+		case "reassign":
+			err = tx.QueryRow(`SELECT id FROM category WHERE id = ?`, targetID).Scan(&tempID)
+			if err == sql.ErrNoRows {
+				return nil, "", "", NewError(http.StatusBadRequest, fmt.Sprintf("No category with ID %d", targetID), nil)
+			}
+			if err != nil {
+				return nil, "", "", NewError(http.StatusInternalServerError, "Database error", err)
+			}
+			_, err = tx.Exec(`UPDATE todo SET category_id = ? WHERE category_id = ?`, targetID, id)
+			responseString = fmt.Sprintf("Category with ID %d deleted, %d todos moved to category %d.", id, todoCount, targetID)
+		case "archive":
+			_, err = tx.Exec(`UPDATE todo SET archived = 1, category_id = NULL WHERE category_id = ?`, id)
+			responseString = fmt.Sprintf("Category with ID %d deleted, %d todos archived.", id, todoCount)
+			todoEvent = events.TodoArchived
+		case "delete":
+			_, err = tx.Exec(`DELETE FROM todo WHERE category_id = ?`, id)
+			responseString = fmt.Sprintf("Category with ID %d deleted along with %d todos.", id, todoCount)
+			todoEvent = events.TodoDeleted
+		}
+		if err != nil {
+			return nil, "", "", NewError(http.StatusInternalServerError, "Failed to update todos of category", err)
+		}
+	}
+
+	_, err = tx.Exec(`DELETE FROM category WHERE id = ?`, id)
+	if err != nil {
+		return nil, "", "", NewError(http.StatusInternalServerError, "Database error", err)
+	}
+
+	err = tx.Commit()
+	if err != nil {
+		return nil, "", "", NewError(http.StatusInternalServerError, "Database error", err)
+	}
+	return todoIDs, todoEvent, responseString, nil
+}
+
+// ValidateTodo checks a todo about to be created, whose due date can't be
+// in the past.
+func ValidateTodo(q Querier, todo *models.Todo) error {
+	return validateTodo(q, todo, true)
+}
+
+// ValidateImportedTodo checks a todo restored from an export or brought over
+// from another app, which may well be overdue or done already, so its due
+// date may be in the past.
+func ValidateImportedTodo(q Querier, todo *models.Todo) error {
+	return validateTodo(q, todo, false)
+}
+
+func validateTodo(q Querier, todo *models.Todo, futureDue bool) error {
+	if strings.TrimSpace(todo.Title) == "" {
+		return NewError(http.StatusBadRequest, "Title is blank", fmt.Errorf("blank title"))
+	}
+	if strings.TrimSpace(todo.Content) == "" {
+		return NewError(http.StatusBadRequest, "Content is blank", fmt.Errorf("blank content"))
+	}
+	if todo.Priority < 1 || todo.Priority > 5 {
+		return NewError(http.StatusBadRequest, "Priority must be between 1-5", nil)
+	}
+	if futureDue && todo.DueDate.Before(time.Now()) {
+		return NewError(http.StatusBadRequest, "Due date can't be in the past", nil)
+	}
+	err := NormalizeTags(todo)
+	if err != nil {
+		return err
+	}
+	return CheckCategory(q, todo.CategoryID)
+}
+
+// NormalizeTags trims and de-duplicates the todo's tags. Tags are stored
+// comma separated in query results, so commas are not allowed in them.
+func NormalizeTags(todo *models.Todo) error {
+	if todo.Tags == nil {
+		return nil
+	}
+
+	seen := make(map[string]bool)
+	tags := []string{}
+	for _, tag := range todo.Tags {
+		tag = strings.TrimSpace(tag)
+		if tag == "" {
+			return NewError(http.StatusBadRequest, "Tag is blank", nil)
+		}
+		if len(tag) > 30 {
+			return NewError(http.StatusBadRequest, "Tag is too long", nil)
+		}
+		if strings.Contains(tag, ",") {
+			return NewError(http.StatusBadRequest, "Tag can't contain a comma", nil)
+		}
+		if !seen[tag] {
+			seen[tag] = true
+			tags = append(tags, tag)
+		}
+	}
+	sort.Strings(tags)
+	todo.Tags = tags
+	return nil
+}
+
+// SetTags replaces the tags of the todo with the given ID. The version
+// trigger only watches the todo table, so SetTags bumps the version itself,
+// with a conflict unless the todo is still at version. A version of 0 is
+// for a todo whose row the caller has just inserted or updated, which
+// counts as the change already.
+func SetTags(q Querier, id, version int, tags []string) error {
+	if version != 0 {
+		result, err := q.Exec(`UPDATE todo SET version = version + 1 WHERE id = ? AND version = ?`, id, version)
+		if err != nil {
+			return NewError(http.StatusInternalServerError, "Failed to update tags", err)
+		}
+		rowsAffected, err := result.RowsAffected()
+		if err != nil {
+			return NewError(http.StatusInternalServerError, "Could not get update result", err)
+		}
+		if rowsAffected == 0 {
+			return conflictError(id)
+		}
+	}
+
+	_, err := q.Exec(`DELETE FROM todo_tag WHERE todo_id = ?`, id)
+	if err != nil {
+		return NewError(http.StatusInternalServerError, "Failed to update tags", err)
+	}
+	for _, tag := range tags {
+		_, err = q.Exec(`INSERT INTO todo_tag (todo_id, name) VALUES (?, ?)`, id, tag)
+		if err != nil {
+			return NewError(http.StatusInternalServerError, "Failed to update tags", err)
+		}
+	}
+	return nil
+}
+
+func InsertTodo(q Querier, todo *models.Todo) error {
+	err := ValidateTodo(q, todo)
+	if err != nil {
+		return err
+	}
+
+	status := todo.Status
+	if status == "" {
+		status = StatusTodo
+	} else if !IsStatus(status) {
+		return NewError(http.StatusBadRequest, "Status must be one of "+strings.Join(TodoStatuses, ", "), nil)
+	}
+
+	todo.CreatedAt = time.Now().UTC()
+	todo.DueDate = todo.DueDate.UTC()
+	todo.Archived = false
+	todo.Status, todo.StartedAt, todo.CompletedAt = "", nil, nil
+	SetStatus(todo, status, todo.CreatedAt)
+	todo.Version = 1
+
+	query := `INSERT INTO todo(title, content, priority, created_at, due_date, all_day, status, done, category_id, started_at, completed_at) VALUES(?, ?, ?, ?, ?, ?, ?, ?, ?, ?, ?)`
+	result, err := q.Exec(query, todo.Title, todo.Content, todo.Priority, todo.CreatedAt, todo.DueDate, todo.AllDay, todo.Status, todo.IsDone, todo.CategoryID, todo.StartedAt, todo.CompletedAt)
+	if err != nil {
+		return NewError(http.StatusInternalServerError, "Insert failed", err)
+	}
+	id, err := result.LastInsertId()
+	if err != nil {
+		return NewError(http.StatusInternalServerError, "Failed to retrieve inserted ID", err)
+	}
+	todo.ID = int(id)
+	return SetTags(q, todo.ID, 0, todo.Tags)
+}
+
+func SelectTodo(q Querier, id int) (models.Todo, error) {
+	var todo models.Todo
+	query := `SELECT ` + TodoColumns + ` FROM todo WHERE id = ?`
+	err := ScanTodo(q.QueryRow(query, id), &todo)
+	if err == sql.ErrNoRows {
+		return todo, NewError(http.StatusNotFound, fmt.Sprintf("No todo with ID %v", id), nil)
+	}
+	if err != nil {
+		return todo, NewError(http.StatusInternalServerError, "Database error", err)
+	}
+	return todo, nil
+}
+
+// UpdateTodo applies the non-zero fields of newTodo to the todo with the
+// given ID and returns the result along with a message naming the fields
+// that changed. A non-zero newTodo.Version must match the stored one.
+//
+// A status change must be an allowed transition. Without a status, IsDone
+// is applied as before statuses existed: true marks the todo done and false
+// reopens it if it was done.
+func UpdateTodo(q Querier, id int, newTodo models.Todo) (models.Todo, string, error) {
+	oldTodo, err := SelectTodo(q, id)
+	if err != nil {
+		return oldTodo, "", err
+	}
+	if newTodo.Version != 0 && newTodo.Version != oldTodo.Version {
+		return oldTodo, "", conflictError(id)
+	}
+
+	responseString := "title, content, priority, due_date, status, is_done, category_id updated!"
+
+	if strings.TrimSpace(newTodo.Title) != "" {
+		oldTodo.Title = newTodo.Title
+	} else {
+		responseString = strings.ReplaceAll(responseString, "title, ", "")
+	}
+	if strings.TrimSpace(newTodo.Content) != "" {
+		oldTodo.Content = newTodo.Content
+	} else {
+		responseString = strings.ReplaceAll(responseString, "content, ", "")
+	}
+	if newTodo.Priority >= 1 && newTodo.Priority <= 5 {
+		oldTodo.Priority = newTodo.Priority
+	} else {
+		responseString = strings.ReplaceAll(responseString, "priority, ", "")
+	}
+	if newTodo.DueDate.After(time.Now()) {
+		oldTodo.DueDate = newTodo.DueDate.UTC()
+		oldTodo.AllDay = newTodo.AllDay
+	} else {
+		responseString = strings.ReplaceAll(responseString, "due_date, ", "")
+	}
+	status := newTodo.Status
+	if status == "" {
+		status = oldTodo.Status
+		if newTodo.IsDone {
+			status = StatusDone
+		} else if oldTodo.IsDone {
+			status = StatusTodo
+		}
+	} else if newTodo.IsDone && status != StatusDone {
+		return oldTodo, "", NewError(http.StatusBadRequest, "is_done contradicts status "+status, nil)
+	}
+	err = CheckTransition(q, oldTodo.Status, status)
+	if err != nil {
+		return oldTodo, "", err
+	}
+	wasDone := oldTodo.IsDone
+	if status == oldTodo.Status {
+		responseString = strings.ReplaceAll(responseString, "status, ", "")
+	} else {
+		SetStatus(&oldTodo, status, time.Now())
+	}
+	if oldTodo.IsDone == wasDone {
+		responseString = strings.ReplaceAll(responseString, "is_done, ", "")
+	}
+	if newTodo.CategoryID != 0 {
+		err = CheckCategory(q, newTodo.CategoryID)
+		if err != nil {
+			return oldTodo, "", err
+		}
+		oldTodo.CategoryID = newTodo.CategoryID
+	} else {
+		responseString = strings.ReplaceAll(responseString, "category_id ", "")
+	}
+
+	if newTodo.Tags != nil {
+		err = NormalizeTags(&newTodo)
+		if err != nil {
+			return oldTodo, "", err
+		}
+		oldTodo.Tags = newTodo.Tags
+		fields := strings.TrimSuffix(strings.TrimSuffix(responseString, "updated!"), " ")
+		if fields == "" {
+			responseString = "tags updated!"
+		} else {
+			responseString = strings.TrimSuffix(fields, ",") + ", tags updated!"
+		}
+	}
+
+	if responseString == "updated!" {
+		return oldTodo, "", NewError(http.StatusBadRequest, "No fields provided for update", nil)
+	}
+
+	if responseString == "tags updated!" {
+		err = SetTags(q, id, oldTodo.Version, oldTodo.Tags)
+		if err != nil {
+			return oldTodo, "", err
+		}
+		oldTodo.Version++
+		oldTodo.Overdue = isOverdue(oldTodo, time.Now())
+		return oldTodo, responseString, nil
+	}
+
+	queryUpdate := `UPDATE todo SET title = ?, content = ?, priority = ?, due_date = ?, all_day = ?, status = ?, done = ?, category_id = NULLIF(?, 0), started_at = ?, completed_at = ? WHERE id = ? AND version = ?`
+	result, err := q.Exec(queryUpdate, oldTodo.Title, oldTodo.Content, oldTodo.Priority, oldTodo.DueDate, oldTodo.AllDay, oldTodo.Status, oldTodo.IsDone, oldTodo.CategoryID, oldTodo.StartedAt, oldTodo.CompletedAt, id, oldTodo.Version)
+	if err != nil {
+		return oldTodo, "", NewError(http.StatusInternalServerError, "Failed to update todo", err)
+	}
+
+	rowsAffected, err := result.RowsAffected()
+	if err != nil {
+		return oldTodo, "", NewError(http.StatusInternalServerError, "Could not get update result", err)
+	}
+	if rowsAffected == 0 {
+		// The todo was read above, so it changed in between.
+		return oldTodo, "", conflictError(id)
+	}
+	oldTodo.Version++
+	oldTodo.Overdue = isOverdue(oldTodo, time.Now())
+
+	if newTodo.Tags != nil {
+		err = SetTags(q, id, 0, oldTodo.Tags)
+		if err != nil {
+			return oldTodo, "", err
+		}
+	}
+
+	return oldTodo, responseString, nil
+}
+
+func conflictError(id int) error {
+	return NewError(http.StatusConflict, fmt.Sprintf("Todo with ID %v was changed by someone else, reload it and try again", id), nil)
+}
+
+func DeleteTodo(q Querier, id int) error {
+	result, err := q.Exec(`DELETE FROM todo WHERE id = ?`, id)
+	if err != nil {
+		return NewError(http.StatusInternalServerError, "Database error", err)
+	}
+
+	rowsAffected, err := result.RowsAffected()
+	if err != nil {
+		return NewError(http.StatusInternalServerError, "Could not retrieve delete result", err)
+	}
+	if rowsAffected == 0 {
+		return NewError(http.StatusNotFound, fmt.Sprintf("No todo with ID %v", id), nil)
+	}
+	return nil
+}
+
+// ExecTodo runs a single-row UPDATE on the todo with the given ID and
+// reports 404 when no such todo exists.
+func ExecTodo(q Querier, id int, query string, args ...interface{}) error {
+	result, err := q.Exec(query, append(args, id)...)
+	if err != nil {
+		return NewError(http.StatusInternalServerError, "Database update error", err)
+	}
+
+	rowsAffected, err := result.RowsAffected()
+	if err != nil {
+		return NewError(http.StatusInternalServerError, "Could not determine update result", err)
+	}
+	if rowsAffected == 0 {
+		return NewError(http.StatusNotFound, fmt.Sprintf("No todo with ID %v", id), nil)
+	}
+	return nil
+}
@@ -0,0 +1,100 @@
+package store
+
+import (
+	"database/sql"
+	"net/http"
+	"path/filepath"
+	"reflect"
+	"testing"
+	"time"
+
+	"github.com/furkankorkmaz309/todo-api/internal/db"
+	"github.com/furkankorkmaz309/todo-api/internal/models"
+)
+
+func openTestDB(t *testing.T) *sql.DB {
+	t.Helper()
+	conn, err := db.InitDB(filepath.Join(t.TempDir(), "test.db"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	t.Cleanup(func() { conn.Close() })
+	return conn
+}
+
+// insertTestTodo adds a category and a todo in it.
+func insertTestTodo(t *testing.T, q Querier, todo models.Todo) models.Todo {
+	t.Helper()
+	category := models.Category{Name: "work"}
+	err := InsertCategory(q, &category)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if todo.Title == "" {
+		todo.Title = "write tests"
+	}
+	todo.Content = "for the store"
+	todo.Priority = 3
+	todo.DueDate = time.Now().Add(48 * time.Hour)
+	todo.CategoryID = category.ID
+	err = InsertTodo(q, &todo)
+	if err != nil {
+		t.Fatal(err)
+	}
+	return todo
+}
+
+func wantStatus(t *testing.T, err error, status int) {
+	t.Helper()
+	if err == nil {
+		t.Fatalf("got no error, want %d", status)
+	}
+	if got := AsError(err).Status; got != status {
+		t.Fatalf("got %d (%v), want %d", got, err, status)
+	}
+}
+
+func TestTagEditsBumpVersion(t *testing.T) {
+	conn := openTestDB(t)
+	todo := insertTestTodo(t, conn, models.Todo{Tags: []string{"a"}})
+	if todo.Version != 1 {
+		t.Fatalf("new todo at version %d, want 1", todo.Version)
+	}
+
+	// Two editors hold version 1; the first one's tag change must make
+	// the second one's conflict.
+	updated, msg, err := UpdateTodo(conn, todo.ID, models.Todo{Tags: []string{"b"}, Version: 1})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if msg != "tags updated!" || updated.Version != 2 || !reflect.DeepEqual(updated.Tags, []string{"b"}) {
+		t.Fatalf("tag update = %q, version %d, tags %v; want tags [b] at version 2", msg, updated.Version, updated.Tags)
+	}
+	_, _, err = UpdateTodo(conn, todo.ID, models.Todo{Tags: []string{"c"}, Version: 1})
+	wantStatus(t, err, http.StatusConflict)
+
+	stored, err := SelectTodo(conn, todo.ID)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if stored.Version != 2 || !reflect.DeepEqual(stored.Tags, []string{"b"}) {
+		t.Fatalf("stored todo at version %d with tags %v, want version 2 with [b]", stored.Version, stored.Tags)
+	}
+
+	err = SetTags(conn, todo.ID, 1, []string{"d"})
+	wantStatus(t, err, http.StatusConflict)
+
+	// A change to other fields along with the tags bumps the version once.
+	updated, _, err = UpdateTodo(conn, todo.ID, models.Todo{Title: "renamed", Tags: []string{"e"}, Version: 2})
+	if err != nil {
+		t.Fatal(err)
+	}
+	stored, err = SelectTodo(conn, todo.ID)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if updated.Version != 3 || stored.Version != 3 {
+		t.Fatalf("after a title and tag change, version %d returned and %d stored, want 3", updated.Version, stored.Version)
+	}
+}
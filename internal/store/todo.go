@@ -0,0 +1,93 @@
+package store
+
+import (
+	"database/sql"
+	"sort"
+	"strings"
+	"time"
+
+	"github.com/furkankorkmaz309/todo-api/internal/models"
+)
+
+// TodoColumns selects a whole todo, to be read back with ScanTodo.
+const TodoColumns = `id, title, content, priority, created_at, due_date, all_day, status, done, archived, COALESCE(category_id, 0), started_at, completed_at,
+	(SELECT group_concat(name, ',') FROM todo_tag WHERE todo_tag.todo_id = todo.id), version`
+
+// DoneBeforeCondition matches todos completed at or before its argument.
+// Todos done before completion times were recorded have no completed_at;
+// they count from created_at, the earliest they can have been done.
+const DoneBeforeCondition = `COALESCE(completed_at, created_at) <= ?`
+
+type RowScanner interface {
+	Scan(dest ...interface{}) error
+}
+
+// ScanTodo reads a row selected with TodoColumns; extra receives any columns
+// selected after them.
+func ScanTodo(row RowScanner, todo *models.Todo, extra ...interface{}) error {
+	var tags sql.NullString
+	dest := []interface{}{&todo.ID, &todo.Title, &todo.Content, &todo.Priority, &todo.CreatedAt, &todo.DueDate, &todo.AllDay, &todo.Status, &todo.IsDone, &todo.Archived, &todo.CategoryID, &todo.StartedAt, &todo.CompletedAt, &tags, &todo.Version}
+	err := row.Scan(append(dest, extra...)...)
+	if err != nil {
+		return err
+	}
+
+	todo.Tags = nil
+	if tags.Valid && tags.String != "" {
+		todo.Tags = strings.Split(tags.String, ",")
+		sort.Strings(todo.Tags)
+	}
+	todo.Overdue = isOverdue(*todo, time.Now())
+	return nil
+}
+
+// OverdueCondition matches the todos isOverdue is true for, given the
+// current time in UTC.
+const OverdueCondition = `(NOT ` + ClosedCondition + ` AND due_date < ?)`
+
+func isOverdue(todo models.Todo, now time.Time) bool {
+	return !IsClosed(todo) && todo.DueDate.Before(now)
+}
+
+// ListTodos returns the archived or unarchived todos matching f, in ID
+// order.
+func ListTodos(q Querier, archived bool, f Filter) ([]models.Todo, error) {
+	query := `SELECT ` + TodoColumns + ` FROM todo WHERE archived = ?` + f.And() + ` ORDER BY id`
+	rows, err := q.Query(query, append([]interface{}{archived}, f.Args...)...)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	var todos []models.Todo
+	for rows.Next() {
+		var todo models.Todo
+		err = ScanTodo(rows, &todo)
+		if err != nil {
+			return nil, err
+		}
+		todos = append(todos, todo)
+	}
+	return todos, rows.Err()
+}
+
+// QueryIDs collects the single integer column returned by query, such as
+// the ids from an UPDATE ... RETURNING id.
+func QueryIDs(q Querier, query string, args ...interface{}) ([]int, error) {
+	rows, err := q.Query(query, args...)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	var ids []int
+	for rows.Next() {
+		var id int
+		err = rows.Scan(&id)
+		if err != nil {
+			return nil, err
+		}
+		ids = append(ids, id)
+	}
+	return ids, rows.Err()
+}
@@ -0,0 +1,269 @@
+// Package webhooks delivers events to subscribed URLs. Deliveries are
+// queued in the webhook_delivery table, so pending ones survive restarts.
+package webhooks
+
+import (
+	"bytes"
+	"crypto/hmac"
+	"crypto/sha256"
+	"database/sql"
+	"encoding/hex"
+	"encoding/json"
+	"fmt"
+	"io"
+	"log"
+	"net/http"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/furkankorkmaz309/todo-api/internal/events"
+)
+
+const (
+	StatusPending   = "pending"
+	StatusDelivered = "delivered"
+	StatusFailed    = "failed"
+)
+
+// Dispatcher queues events for matching webhooks and sends them. Failed
+// attempts are retried after BaseDelay, doubling each time up to MaxDelay,
+// until MaxAttempts is reached.
+type Dispatcher struct {
+	DB       *sql.DB
+	Client   *http.Client
+	InfoLog  *log.Logger
+	ErrorLog *log.Logger
+
+	MaxAttempts int
+	BaseDelay   time.Duration
+	MaxDelay    time.Duration
+
+	wake chan struct{}
+}
+
+func NewDispatcher(db *sql.DB, infoLog, errorLog *log.Logger) *Dispatcher {
+	return &Dispatcher{
+		DB:          db,
+		Client:      &http.Client{Timeout: 10 * time.Second},
+		InfoLog:     infoLog,
+		ErrorLog:    errorLog,
+		MaxAttempts: 8,
+		BaseDelay:   30 * time.Second,
+		MaxDelay:    time.Hour,
+		wake:        make(chan struct{}, 1),
+	}
+}
+
+// payload is the JSON body sent to receivers.
+type payload struct {
+	Event      string      `json:"event"`
+	OccurredAt time.Time   `json:"occurred_at"`
+	Data       interface{} `json:"data"`
+}
+
+// Enqueue records a delivery of e for every active webhook that wants it.
+// It is meant to be subscribed to the events bus.
+func (d *Dispatcher) Enqueue(e events.Event) {
+	body, err := json.Marshal(payload{Event: e.Type, OccurredAt: e.Time, Data: e.Data})
+	if err != nil {
+		d.ErrorLog.Printf("webhook payload for %s: %v", e.Type, err)
+		return
+	}
+
+	rows, err := d.DB.Query(`SELECT id, events FROM webhook WHERE active = 1`)
+	if err != nil {
+		d.ErrorLog.Printf("webhook lookup for %s: %v", e.Type, err)
+		return
+	}
+
+	var ids []int
+	for rows.Next() {
+		var id int
+		var filter string
+		err = rows.Scan(&id, &filter)
+		if err != nil {
+			d.ErrorLog.Printf("webhook lookup for %s: %v", e.Type, err)
+			continue
+		}
+		if events.Match(strings.Split(filter, ","), e.Type) {
+			ids = append(ids, id)
+		}
+	}
+	rows.Close()
+
+	for _, id := range ids {
+		_, err = d.insertDelivery(id, e.Type, body)
+		if err != nil {
+			d.ErrorLog.Printf("webhook %d: %v", id, err)
+		}
+	}
+	if len(ids) > 0 {
+		d.notify()
+	}
+}
+
+func (d *Dispatcher) insertDelivery(webhookID int, eventType string, body []byte) (int, error) {
+	now := time.Now().UTC()
+	query := `INSERT INTO webhook_delivery (webhook_id, event_type, payload, status, next_attempt_at, created_at) VALUES (?, ?, ?, ?, ?, ?)`
+	result, err := d.DB.Exec(query, webhookID, eventType, body, StatusPending, now, now)
+	if err != nil {
+		return 0, fmt.Errorf("an error occurred while queueing delivery : %v", err)
+	}
+	id, err := result.LastInsertId()
+	if err != nil {
+		return 0, fmt.Errorf("an error occurred while queueing delivery : %v", err)
+	}
+	return int(id), nil
+}
+
+// Redeliver queues a fresh copy of an earlier delivery and returns its ID.
+// The original stays in the log untouched.
+func (d *Dispatcher) Redeliver(webhookID, deliveryID int) (int, error) {
+	var eventType string
+	var body []byte
+	query := `SELECT event_type, payload FROM webhook_delivery WHERE id = ? AND webhook_id = ?`
+	err := d.DB.QueryRow(query, deliveryID, webhookID).Scan(&eventType, &body)
+	if err != nil {
+		return 0, err
+	}
+
+	id, err := d.insertDelivery(webhookID, eventType, body)
+	if err != nil {
+		return 0, err
+	}
+	d.notify()
+	return id, nil
+}
+
+func (d *Dispatcher) notify() {
+	select {
+	case d.wake <- struct{}{}:
+	default:
+	}
+}
+
+// Run sends due deliveries until the process exits, checking every
+// interval and whenever new deliveries are queued.
+func (d *Dispatcher) Run(interval time.Duration) {
+	ticker := time.NewTicker(interval)
+	defer ticker.Stop()
+
+	for {
+		d.deliverDue()
+		select {
+		case <-ticker.C:
+		case <-d.wake:
+		}
+	}
+}
+
+type delivery struct {
+	id        int
+	webhookID int
+	eventType string
+	body      []byte
+	attempts  int
+	url       string
+	secret    string
+}
+
+func (d *Dispatcher) deliverDue() {
+	query := `SELECT d.id, d.webhook_id, d.event_type, d.payload, d.attempts, w.url, w.secret
+	FROM webhook_delivery d JOIN webhook w ON w.id = d.webhook_id
+	WHERE d.status = ? AND d.next_attempt_at <= ? ORDER BY d.id LIMIT 100`
+	rows, err := d.DB.Query(query, StatusPending, time.Now().UTC())
+	if err != nil {
+		d.ErrorLog.Printf("webhook queue: %v", err)
+		return
+	}
+
+	var due []delivery
+	for rows.Next() {
+		var dl delivery
+		err = rows.Scan(&dl.id, &dl.webhookID, &dl.eventType, &dl.body, &dl.attempts, &dl.url, &dl.secret)
+		if err != nil {
+			d.ErrorLog.Printf("webhook queue: %v", err)
+			continue
+		}
+		due = append(due, dl)
+	}
+	rows.Close()
+
+	for _, dl := range due {
+		d.attempt(dl)
+	}
+}
+
+func (d *Dispatcher) attempt(dl delivery) {
+	code, err := d.send(dl)
+	dl.attempts++
+	now := time.Now().UTC()
+
+	if err == nil {
+		query := `UPDATE webhook_delivery SET status = ?, attempts = ?, response_code = ?, last_error = NULL, delivered_at = ? WHERE id = ?`
+		_, err = d.DB.Exec(query, StatusDelivered, dl.attempts, code, now, dl.id)
+		if err != nil {
+			d.ErrorLog.Printf("webhook delivery %d: %v", dl.id, err)
+		}
+		return
+	}
+
+	status := StatusPending
+	next := now.Add(d.backoff(dl.attempts))
+	if dl.attempts >= d.MaxAttempts {
+		status = StatusFailed
+		d.ErrorLog.Printf("webhook delivery %d to %s failed after %d attempts: %v", dl.id, dl.url, dl.attempts, err)
+	}
+
+	query := `UPDATE webhook_delivery SET status = ?, attempts = ?, response_code = NULLIF(?, 0), last_error = ?, next_attempt_at = ? WHERE id = ?`
+	_, dbErr := d.DB.Exec(query, status, dl.attempts, code, err.Error(), next, dl.id)
+	if dbErr != nil {
+		d.ErrorLog.Printf("webhook delivery %d: %v", dl.id, dbErr)
+	}
+}
+
+func (d *Dispatcher) backoff(attempts int) time.Duration {
+	delay := d.BaseDelay
+	for i := 1; i < attempts && delay < d.MaxDelay; i++ {
+		delay *= 2
+	}
+	if delay > d.MaxDelay {
+		delay = d.MaxDelay
+	}
+	return delay
+}
+
+// send posts the delivery and returns the response code. Anything other
+// than a 2xx response counts as a failure.
+func (d *Dispatcher) send(dl delivery) (int, error) {
+	req, err := http.NewRequest(http.MethodPost, dl.url, bytes.NewReader(dl.body))
+	if err != nil {
+		return 0, err
+	}
+	req.Header.Set("Content-Type", "application/json")
+	req.Header.Set("User-Agent", "todo-api-webhooks")
+	req.Header.Set("X-Todo-Event", dl.eventType)
+	req.Header.Set("X-Todo-Delivery", strconv.Itoa(dl.id))
+	req.Header.Set("X-Todo-Signature", "sha256="+Sign(dl.secret, dl.body))
+
+	resp, err := d.Client.Do(req)
+	if err != nil {
+		return 0, err
+	}
+	defer resp.Body.Close()
+	io.Copy(io.Discard, io.LimitReader(resp.Body, 64<<10))
+
+	if resp.StatusCode < 200 || resp.StatusCode > 299 {
+		return resp.StatusCode, fmt.Errorf("receiver responded %s", resp.Status)
+	}
+	return resp.StatusCode, nil
+}
+
+// Sign returns the hex HMAC-SHA256 of body keyed with secret, as sent in
+// the X-Todo-Signature header after "sha256=".
+func Sign(secret string, body []byte) string {
+	mac := hmac.New(sha256.New, []byte(secret))
+	mac.Write(body)
+	return hex.EncodeToString(mac.Sum(nil))
+}
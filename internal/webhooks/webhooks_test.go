@@ -0,0 +1,202 @@
+package webhooks
+
+import (
+	"crypto/hmac"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"io"
+	"log"
+	"net/http"
+	"net/http/httptest"
+	"path/filepath"
+	"strconv"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/furkankorkmaz309/todo-api/internal/db"
+	"github.com/furkankorkmaz309/todo-api/internal/events"
+	"github.com/furkankorkmaz309/todo-api/internal/models"
+)
+
+// receiver records the requests it gets and answers them with the next of
+// its codes, repeating the last one.
+type receiver struct {
+	mu       sync.Mutex
+	codes    []int
+	requests []*http.Request
+	bodies   [][]byte
+}
+
+func (rc *receiver) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	body, _ := io.ReadAll(r.Body)
+
+	rc.mu.Lock()
+	defer rc.mu.Unlock()
+	rc.requests = append(rc.requests, r)
+	rc.bodies = append(rc.bodies, body)
+	code := rc.codes[0]
+	if len(rc.codes) > 1 {
+		rc.codes = rc.codes[1:]
+	}
+	w.WriteHeader(code)
+}
+
+func (rc *receiver) count() int {
+	rc.mu.Lock()
+	defer rc.mu.Unlock()
+	return len(rc.requests)
+}
+
+func newDispatcher(t *testing.T, rc *receiver, secret string) *Dispatcher {
+	t.Helper()
+	conn, err := db.InitDB(filepath.Join(t.TempDir(), "test.db"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	t.Cleanup(func() { conn.Close() })
+
+	srv := httptest.NewServer(rc)
+	t.Cleanup(srv.Close)
+
+	_, err = conn.Exec(`INSERT INTO webhook (url, secret, events, active, created_at) VALUES (?, ?, ?, 1, ?)`, srv.URL, secret, "todo.*", time.Now().UTC())
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	quiet := log.New(io.Discard, "", 0)
+	d := NewDispatcher(conn, quiet, quiet)
+	d.BaseDelay = 20 * time.Millisecond
+	d.MaxDelay = 80 * time.Millisecond
+	return d
+}
+
+type deliveryRow struct {
+	status       string
+	attempts     int
+	responseCode int
+	lastError    string
+	nextAttempt  time.Time
+}
+
+func readDelivery(t *testing.T, d *Dispatcher) deliveryRow {
+	t.Helper()
+	var row deliveryRow
+	query := `SELECT status, attempts, COALESCE(response_code, 0), COALESCE(last_error, ''), next_attempt_at FROM webhook_delivery`
+	err := d.DB.QueryRow(query).Scan(&row.status, &row.attempts, &row.responseCode, &row.lastError, &row.nextAttempt)
+	if err != nil {
+		t.Fatal(err)
+	}
+	return row
+}
+
+func TestDeliverySigned(t *testing.T) {
+	rc := &receiver{codes: []int{http.StatusNoContent}}
+	d := newDispatcher(t, rc, "s3cret")
+
+	d.Enqueue(events.Event{ID: 1, Type: events.TodoCreated, Time: time.Now(), Data: models.Todo{ID: 7, Title: "a"}})
+	d.Enqueue(events.Event{ID: 2, Type: events.CategoryCreated, Time: time.Now(), Data: models.Category{ID: 1}})
+	d.deliverDue()
+
+	if rc.count() != 1 {
+		t.Fatalf("receiver got %d requests, want 1 for the todo event only", rc.count())
+	}
+	req, body := rc.requests[0], rc.bodies[0]
+
+	mac := hmac.New(sha256.New, []byte("s3cret"))
+	mac.Write(body)
+	want := "sha256=" + hex.EncodeToString(mac.Sum(nil))
+	if got := req.Header.Get("X-Todo-Signature"); got != want {
+		t.Errorf("X-Todo-Signature = %q, want %q", got, want)
+	}
+	if got := req.Header.Get("X-Todo-Event"); got != events.TodoCreated {
+		t.Errorf("X-Todo-Event = %q, want %q", got, events.TodoCreated)
+	}
+	if _, err := strconv.Atoi(req.Header.Get("X-Todo-Delivery")); err != nil {
+		t.Errorf("X-Todo-Delivery = %q, want a delivery ID", req.Header.Get("X-Todo-Delivery"))
+	}
+
+	var p struct {
+		Event string      `json:"event"`
+		Data  models.Todo `json:"data"`
+	}
+	err := json.Unmarshal(body, &p)
+	if err != nil || p.Event != events.TodoCreated || p.Data.ID != 7 {
+		t.Errorf("body = %s, want the todo.created payload of todo 7", body)
+	}
+
+	row := readDelivery(t, d)
+	if row.status != StatusDelivered || row.attempts != 1 || row.responseCode != http.StatusNoContent {
+		t.Errorf("delivery = %+v, want delivered after 1 attempt with 204", row)
+	}
+}
+
+func TestDeliveryRetried(t *testing.T) {
+	rc := &receiver{codes: []int{http.StatusInternalServerError, http.StatusBadGateway, http.StatusOK}}
+	d := newDispatcher(t, rc, "s3cret")
+
+	d.Enqueue(events.Event{ID: 1, Type: events.TodoUpdated, Time: time.Now(), Data: models.Todo{ID: 7}})
+	before := time.Now().UTC()
+	d.deliverDue()
+
+	row := readDelivery(t, d)
+	if row.status != StatusPending || row.attempts != 1 || row.responseCode != http.StatusInternalServerError || row.lastError == "" {
+		t.Fatalf("delivery after a 500 = %+v, want pending after 1 attempt with the error", row)
+	}
+	if row.nextAttempt.Before(before.Add(d.BaseDelay)) {
+		t.Errorf("next attempt at %v, want at least BaseDelay after %v", row.nextAttempt, before)
+	}
+
+	// Not due yet, so nothing is sent.
+	d.deliverDue()
+	if rc.count() != 1 {
+		t.Fatalf("receiver got %d requests before the retry was due, want 1", rc.count())
+	}
+
+	for i := 0; i < 50 && rc.count() < 3; i++ {
+		time.Sleep(10 * time.Millisecond)
+		d.deliverDue()
+	}
+	row = readDelivery(t, d)
+	if row.status != StatusDelivered || row.attempts != 3 || row.responseCode != http.StatusOK {
+		t.Fatalf("delivery = %+v, want delivered on the third attempt", row)
+	}
+	for i := 1; i < 3; i++ {
+		if string(rc.bodies[i]) != string(rc.bodies[0]) || rc.requests[i].Header.Get("X-Todo-Signature") != rc.requests[0].Header.Get("X-Todo-Signature") {
+			t.Errorf("attempt %d sent a different body or signature", i+1)
+		}
+	}
+}
+
+func TestDeliveryGivesUp(t *testing.T) {
+	rc := &receiver{codes: []int{http.StatusInternalServerError}}
+	d := newDispatcher(t, rc, "s3cret")
+	d.MaxAttempts = 2
+
+	d.Enqueue(events.Event{ID: 1, Type: events.TodoDeleted, Time: time.Now(), Data: events.Deleted{ID: 7}})
+	for i := 0; i < 50 && rc.count() < 2; i++ {
+		d.deliverDue()
+		time.Sleep(10 * time.Millisecond)
+	}
+
+	row := readDelivery(t, d)
+	if row.status != StatusFailed || row.attempts != 2 {
+		t.Fatalf("delivery = %+v, want failed after 2 attempts", row)
+	}
+	time.Sleep(2 * d.MaxDelay)
+	d.deliverDue()
+	if rc.count() != 2 {
+		t.Errorf("receiver got %d requests, want no more after the delivery failed", rc.count())
+	}
+}
+
+func TestBackoff(t *testing.T) {
+	d := &Dispatcher{BaseDelay: 30 * time.Second, MaxDelay: 5 * time.Minute}
+	want := []time.Duration{30 * time.Second, time.Minute, 2 * time.Minute, 4 * time.Minute, 5 * time.Minute, 5 * time.Minute}
+	for i, w := range want {
+		if got := d.backoff(i + 1); got != w {
+			t.Errorf("backoff(%d) = %v, want %v", i+1, got, w)
+		}
+	}
+}
@@ -0,0 +1,56 @@
+package client
+
+import (
+	"context"
+	"net/http"
+	"net/url"
+	"strconv"
+)
+
+// CategoryInput is the body of CreateCategory and UpdateCategory; on
+// update, empty fields are left unchanged.
+type CategoryInput struct {
+	Name        string `json:"name,omitempty"`
+	Description string `json:"description,omitempty"`
+}
+
+// Policies for the todos of a deleted category.
+const (
+	PolicyRefuse   = "refuse"
+	PolicyReassign = "reassign"
+	PolicyArchive  = "archive"
+	PolicyDelete   = "delete"
+)
+
+func (c *Client) ListCategories(ctx context.Context) ([]Category, error) {
+	var categories []Category
+	_, err := c.do(ctx, request{method: http.MethodGet, path: "/categories", retry: true}, &categories)
+	return categories, err
+}
+
+func (c *Client) CreateCategory(ctx context.Context, category CategoryInput) (Category, error) {
+	var created Category
+	_, err := c.do(ctx, request{method: http.MethodPost, path: "/categories", body: category, idempotent: true}, &created)
+	return created, err
+}
+
+func (c *Client) UpdateCategory(ctx context.Context, id int, category CategoryInput) (Category, error) {
+	var updated Category
+	_, err := c.do(ctx, request{method: http.MethodPatch, path: idPath("/categories", id, ""), body: category}, &updated)
+	return updated, err
+}
+
+// DeleteCategory deletes a category, handling its todos by policy. With
+// PolicyReassign they move to targetID, which is ignored otherwise.
+func (c *Client) DeleteCategory(ctx context.Context, id int, policy string, targetID int) error {
+	q := url.Values{}
+	if policy != "" {
+		q.Set("policy", policy)
+	}
+	if policy == PolicyReassign {
+		q.Set("target", strconv.Itoa(targetID))
+	}
+
+	_, err := c.do(ctx, request{method: http.MethodDelete, path: idPath("/categories", id, ""), query: q, retry: true}, nil)
+	return err
+}
@@ -0,0 +1,275 @@
+// Package client is a Go client for the todo-api REST API. It decodes the
+// API's response envelope into the models and its failures into *Error.
+//
+//	c := client.New("http://localhost:8080")
+//	todos, err := c.ListTodos(ctx, &client.ListOptions{Priority: 5})
+package client
+
+import (
+	"bytes"
+	"context"
+	"crypto/rand"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"math"
+	mathrand "math/rand"
+	"net/http"
+	"net/url"
+	"strconv"
+	"strings"
+	"time"
+)
+
+type Client struct {
+	baseURL    string
+	httpClient *http.Client
+	apiKey     string
+	userAgent  string
+
+	maxRetries int
+	minBackoff time.Duration
+	maxBackoff time.Duration
+}
+
+type Option func(*Client)
+
+// WithHTTPClient replaces http.DefaultClient, for timeouts or transports.
+func WithHTTPClient(hc *http.Client) Option {
+	return func(c *Client) { c.httpClient = hc }
+}
+
+// WithAPIKey sends key as a bearer token on every request.
+func WithAPIKey(key string) Option {
+	return func(c *Client) { c.apiKey = key }
+}
+
+func WithUserAgent(ua string) Option {
+	return func(c *Client) { c.userAgent = ua }
+}
+
+// WithRetries sets how many times a failed request is retried and the
+// backoff between attempts, which doubles from min up to max. Only
+// requests that are safe to repeat are retried: reads, deletes, archiving
+// and creations, which carry an Idempotency-Key. The default is 3 retries
+// from 200ms to 5s; 0 disables them.
+func WithRetries(n int, min, max time.Duration) Option {
+	return func(c *Client) {
+		c.maxRetries, c.minBackoff, c.maxBackoff = n, min, max
+	}
+}
+
+func New(baseURL string, opts ...Option) *Client {
+	c := &Client{
+		baseURL:    strings.TrimSuffix(baseURL, "/"),
+		httpClient: http.DefaultClient,
+		userAgent:  "todo-api-go-client",
+		maxRetries: 3,
+		minBackoff: 200 * time.Millisecond,
+		maxBackoff: 5 * time.Second,
+	}
+	for _, opt := range opts {
+		opt(c)
+	}
+	return c
+}
+
+var (
+	ErrBadRequest      = errors.New("bad request")
+	ErrUnauthorized    = errors.New("unauthorized")
+	ErrNotFound        = errors.New("not found")
+	ErrConflict        = errors.New("conflict")
+	ErrTooManyRequests = errors.New("too many requests")
+)
+
+// Error is a failure reported by the API. It matches the Err* values with
+// errors.Is by status, so callers can write errors.Is(err, ErrNotFound).
+type Error struct {
+	StatusCode int
+	Message    string
+	// Data is set by the few endpoints that explain a failure, such as
+	// the per-operation results of a failed bulk request.
+	Data json.RawMessage
+}
+
+func (e *Error) Error() string {
+	return fmt.Sprintf("todo-api: %d %s", e.StatusCode, e.Message)
+}
+
+func (e *Error) Is(target error) bool {
+	switch e.StatusCode {
+	case http.StatusBadRequest:
+		return target == ErrBadRequest
+	case http.StatusUnauthorized:
+		return target == ErrUnauthorized
+	case http.StatusNotFound:
+		return target == ErrNotFound
+	case http.StatusConflict:
+		return target == ErrConflict
+	case http.StatusTooManyRequests:
+		return target == ErrTooManyRequests
+	}
+	return false
+}
+
+type envelope struct {
+	Success bool            `json:"success"`
+	Data    json.RawMessage `json:"data"`
+	Message string          `json:"message"`
+	Error   string          `json:"error"`
+}
+
+// request describes one API call. retry marks calls that may be repeated
+// without changing their outcome.
+type request struct {
+	method string
+	path   string
+	query  url.Values
+	body   interface{}
+	retry  bool
+	// idempotent sends an Idempotency-Key, shared by all attempts, so the
+	// server runs the call at most once.
+	idempotent bool
+}
+
+// do performs req and decodes the data of the response into out, unless
+// out is nil. It returns the envelope's message.
+func (c *Client) do(ctx context.Context, req request, out interface{}) (string, error) {
+	var body []byte
+	if req.body != nil {
+		var err error
+		body, err = json.Marshal(req.body)
+		if err != nil {
+			return "", err
+		}
+	}
+
+	u := c.baseURL + req.path
+	if len(req.query) > 0 {
+		u += "?" + req.query.Encode()
+	}
+
+	var key string
+	if req.idempotent {
+		key = newIdempotencyKey()
+	}
+
+	attempts := 1
+	if req.retry || req.idempotent {
+		attempts += c.maxRetries
+	}
+
+	var lastErr error
+	for attempt := 0; attempt < attempts; attempt++ {
+		if attempt > 0 {
+			err := c.sleep(ctx, attempt)
+			if err != nil {
+				return "", err
+			}
+		}
+
+		msg, err := c.send(ctx, req.method, u, body, key, out)
+		if err == nil {
+			return msg, nil
+		}
+		lastErr = err
+		if !retryable(ctx, err) {
+			break
+		}
+	}
+	return "", lastErr
+}
+
+func (c *Client) send(ctx context.Context, method, u string, body []byte, key string, out interface{}) (string, error) {
+	var reader io.Reader
+	if body != nil {
+		reader = bytes.NewReader(body)
+	}
+	httpReq, err := http.NewRequestWithContext(ctx, method, u, reader)
+	if err != nil {
+		return "", err
+	}
+	httpReq.Header.Set("Accept", "application/json")
+	httpReq.Header.Set("User-Agent", c.userAgent)
+	if body != nil {
+		httpReq.Header.Set("Content-Type", "application/json")
+	}
+	if key != "" {
+		httpReq.Header.Set("Idempotency-Key", key)
+	}
+	if c.apiKey != "" {
+		httpReq.Header.Set("Authorization", "Bearer "+c.apiKey)
+	}
+
+	resp, err := c.httpClient.Do(httpReq)
+	if err != nil {
+		return "", err
+	}
+	defer resp.Body.Close()
+
+	var env envelope
+	err = json.NewDecoder(resp.Body).Decode(&env)
+	if err != nil {
+		if resp.StatusCode >= 300 {
+			return "", &Error{StatusCode: resp.StatusCode, Message: http.StatusText(resp.StatusCode)}
+		}
+		return "", fmt.Errorf("todo-api: decoding response: %w", err)
+	}
+
+	if resp.StatusCode >= 300 || !env.Success {
+		return "", &Error{StatusCode: resp.StatusCode, Message: env.Error, Data: env.Data}
+	}
+
+	if out != nil && len(env.Data) > 0 {
+		err = json.Unmarshal(env.Data, out)
+		if err != nil {
+			return "", fmt.Errorf("todo-api: decoding data: %w", err)
+		}
+	}
+	return env.Message, nil
+}
+
+// retryable reports whether err may go away on its own: rate limiting,
+// server errors and network failures, but not a cancelled context.
+func retryable(ctx context.Context, err error) bool {
+	if ctx.Err() != nil {
+		return false
+	}
+	var apiErr *Error
+	if errors.As(err, &apiErr) {
+		return apiErr.StatusCode == http.StatusTooManyRequests || apiErr.StatusCode >= 500
+	}
+	var netErr interface{ Timeout() bool }
+	var urlErr *url.Error
+	return errors.As(err, &urlErr) || errors.As(err, &netErr)
+}
+
+func (c *Client) sleep(ctx context.Context, attempt int) error {
+	backoff := time.Duration(float64(c.minBackoff) * math.Pow(2, float64(attempt-1)))
+	if backoff > c.maxBackoff {
+		backoff = c.maxBackoff
+	}
+	// Jitter keeps clients that failed together from retrying together.
+	backoff = backoff/2 + time.Duration(mathrand.Int63n(int64(backoff/2)+1))
+
+	t := time.NewTimer(backoff)
+	defer t.Stop()
+	select {
+	case <-ctx.Done():
+		return ctx.Err()
+	case <-t.C:
+		return nil
+	}
+}
+
+func newIdempotencyKey() string {
+	b := make([]byte, 16)
+	rand.Read(b)
+	return hex.EncodeToString(b)
+}
+
+func idPath(prefix string, id int, suffix string) string {
+	return prefix + "/" + strconv.Itoa(id) + suffix
+}
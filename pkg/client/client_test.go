@@ -0,0 +1,338 @@
+package client
+
+import (
+	"context"
+	"errors"
+	"io"
+	"log"
+	"net/http"
+	"net/http/httptest"
+	"path/filepath"
+	"sort"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/furkankorkmaz309/todo-api/internal/app"
+	"github.com/furkankorkmaz309/todo-api/internal/db"
+	"github.com/furkankorkmaz309/todo-api/internal/events"
+	"github.com/furkankorkmaz309/todo-api/internal/idempotency"
+	"github.com/furkankorkmaz309/todo-api/internal/routes"
+	"github.com/furkankorkmaz309/todo-api/internal/webhooks"
+)
+
+// newTestServer serves the API on a fresh database. wrap, when given, sits
+// in front of the router.
+func newTestServer(t *testing.T, wrap func(http.Handler) http.Handler) (*httptest.Server, *app.App) {
+	t.Helper()
+	conn, err := db.InitDB(filepath.Join(t.TempDir(), "test.db"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	t.Cleanup(func() { conn.Close() })
+
+	quiet := log.New(io.Discard, "", 0)
+	a := &app.App{
+		InfoLog:  quiet,
+		ErrorLog: quiet,
+		DB:       conn,
+		Events:   events.NewBus(100),
+	}
+	a.Idempotency = idempotency.NewSQLStore(conn, idempotency.Lease)
+	a.Webhooks = webhooks.NewDispatcher(conn, quiet, quiet)
+	a.Events.Subscribe(a.Webhooks.Enqueue)
+
+	h := routes.Routes(a)
+	if wrap != nil {
+		h = wrap(h)
+	}
+	srv := httptest.NewServer(h)
+	t.Cleanup(srv.Close)
+	return srv, a
+}
+
+func newTestClient(t *testing.T, wrap func(http.Handler) http.Handler) *Client {
+	t.Helper()
+	srv, _ := newTestServer(t, wrap)
+	return New(srv.URL, WithRetries(2, time.Millisecond, 5*time.Millisecond))
+}
+
+func ids(todos []Todo) []int {
+	var ids []int
+	for _, todo := range todos {
+		ids = append(ids, todo.ID)
+	}
+	sort.Ints(ids)
+	return ids
+}
+
+func sameIDs(a, b []int) bool {
+	if len(a) != len(b) {
+		return false
+	}
+	for i := range a {
+		if a[i] != b[i] {
+			return false
+		}
+	}
+	return true
+}
+
+func TestTodos(t *testing.T) {
+	ctx := context.Background()
+	c := newTestClient(t, nil)
+
+	work, err := c.CreateCategory(ctx, CategoryInput{Name: "work", Description: "paid"})
+	if err != nil {
+		t.Fatal(err)
+	}
+	home, err := c.CreateCategory(ctx, CategoryInput{Name: "home"})
+	if err != nil {
+		t.Fatal(err)
+	}
+	work, err = c.UpdateCategory(ctx, work.ID, CategoryInput{Description: "still paid"})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if work.Name != "work" || work.Description != "still paid" {
+		t.Errorf("updated category = %+v, want work with the new description", work)
+	}
+	categories, err := c.ListCategories(ctx)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(categories) != 2 {
+		t.Errorf("ListCategories returned %d categories, want 2", len(categories))
+	}
+
+	due := time.Now().Add(72 * time.Hour).UTC().Truncate(time.Second)
+	report, err := c.CreateTodo(ctx, NewTodo{Title: "report", Content: "q3", Priority: 5, DueDate: due, CategoryID: work.ID, Tags: []string{"q3"}})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if report.ID == 0 || report.Title != "report" || report.CategoryID != work.ID || !report.DueDate.Equal(due) {
+		t.Errorf("created todo = %+v", report)
+	}
+	review, err := c.CreateTodo(ctx, NewTodo{Title: "review", Content: "pr", Priority: 2, DueDate: due.Add(48 * time.Hour), CategoryID: work.ID})
+	if err != nil {
+		t.Fatal(err)
+	}
+	dishes, err := c.CreateTodo(ctx, NewTodo{Title: "dishes", Content: "all", Priority: 2, DueDate: due, CategoryID: home.ID})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	done, err := c.PatchTodo(ctx, dishes.ID, TodoPatch{Status: "done"})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !done.IsDone || done.Status != "done" || done.Version != dishes.Version+1 {
+		t.Errorf("patched todo = %+v, want done at version %d", done, dishes.Version+1)
+	}
+	_, err = c.PatchTodo(ctx, report.ID, TodoPatch{Title: "stale", Version: report.Version + 5})
+	if !errors.Is(err, ErrConflict) {
+		t.Errorf("PatchTodo with a stale version = %v, want ErrConflict", err)
+	}
+
+	yes, no := true, false
+	for _, tc := range []struct {
+		name string
+		opts *ListOptions
+		want []int
+	}{
+		{"all", nil, []int{report.ID, review.ID, dishes.ID}},
+		{"category", &ListOptions{CategoryID: work.ID}, []int{report.ID, review.ID}},
+		{"priority", &ListOptions{Priority: 2}, []int{review.ID, dishes.ID}},
+		{"status", &ListOptions{Status: "done"}, []int{dishes.ID}},
+		{"is_done", &ListOptions{IsDone: &no}, []int{report.ID, review.ID}},
+		{"due_before", &ListOptions{DueBefore: due.Add(time.Hour)}, []int{report.ID, dishes.ID}},
+		{"combined", &ListOptions{CategoryID: work.ID, DueAfter: due.Add(time.Hour)}, []int{review.ID}},
+	} {
+		todos, err := c.ListTodos(ctx, tc.opts)
+		if err != nil {
+			t.Fatalf("%s: %v", tc.name, err)
+		}
+		if got := ids(todos); !sameIDs(got, tc.want) {
+			t.Errorf("%s: ListTodos = %v, want %v", tc.name, got, tc.want)
+		}
+	}
+
+	archived, err := c.ArchiveFinished(ctx, &ListOptions{IsDone: &yes})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !sameIDs(archived, []int{dishes.ID}) {
+		t.Errorf("ArchiveFinished = %v, want [%d]", archived, dishes.ID)
+	}
+	todos, err := c.ListTodos(ctx, &ListOptions{Archived: true})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if got := ids(todos); !sameIDs(got, []int{dishes.ID}) {
+		t.Errorf("archived todos = %v, want [%d]", got, dishes.ID)
+	}
+}
+
+func TestErrors(t *testing.T) {
+	ctx := context.Background()
+	c := newTestClient(t, nil)
+
+	_, err := c.GetTodo(ctx, 404)
+	var apiErr *Error
+	if !errors.As(err, &apiErr) || apiErr.StatusCode != http.StatusNotFound || apiErr.Message == "" {
+		t.Fatalf("GetTodo of a missing todo = %v, want an *Error with status 404", err)
+	}
+	if !errors.Is(err, ErrNotFound) || errors.Is(err, ErrBadRequest) {
+		t.Errorf("errors.Is(%v) matches the wrong sentinel", err)
+	}
+
+	_, err = c.CreateTodo(ctx, NewTodo{Title: "", Priority: 9, DueDate: time.Now().Add(time.Hour)})
+	if !errors.Is(err, ErrBadRequest) {
+		t.Errorf("CreateTodo of an invalid todo = %v, want ErrBadRequest", err)
+	}
+}
+
+// TestRetryReusesIdempotencyKey loses the response to the first attempt at
+// a creation after the server ran it; the retry must replay it rather than
+// create the todo again.
+func TestRetryReusesIdempotencyKey(t *testing.T) {
+	ctx := context.Background()
+
+	var mu sync.Mutex
+	var keys []string
+	c := newTestClient(t, func(next http.Handler) http.Handler {
+		return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			if r.Method != http.MethodPost || r.URL.Path != "/todos" {
+				next.ServeHTTP(w, r)
+				return
+			}
+			mu.Lock()
+			keys = append(keys, r.Header.Get("Idempotency-Key"))
+			first := len(keys) == 1
+			mu.Unlock()
+			if first {
+				next.ServeHTTP(httptest.NewRecorder(), r)
+				http.Error(w, "connection lost", http.StatusBadGateway)
+				return
+			}
+			next.ServeHTTP(w, r)
+		})
+	})
+
+	category, err := c.CreateCategory(ctx, CategoryInput{Name: "work"})
+	if err != nil {
+		t.Fatal(err)
+	}
+	todo, err := c.CreateTodo(ctx, NewTodo{Title: "once", Content: "only", Priority: 3, DueDate: time.Now().Add(time.Hour), CategoryID: category.ID})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if len(keys) != 2 || keys[0] == "" || keys[0] != keys[1] {
+		t.Fatalf("Idempotency-Keys sent = %q, want the same key on both attempts", keys)
+	}
+	todos, err := c.ListTodos(ctx, nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if got := ids(todos); !sameIDs(got, []int{todo.ID}) {
+		t.Errorf("todos after a retried creation = %v, want only [%d]", got, todo.ID)
+	}
+}
+
+func TestOverdue(t *testing.T) {
+	ctx := context.Background()
+	srv, a := newTestServer(t, nil)
+	c := New(srv.URL)
+
+	category, err := c.CreateCategory(ctx, CategoryInput{Name: "work"})
+	if err != nil {
+		t.Fatal(err)
+	}
+	var late []int
+	for _, status := range []string{"todo", "in_progress", "done", "cancelled"} {
+		todo, err := c.CreateTodo(ctx, NewTodo{Title: status, Content: "c", Priority: 3, DueDate: time.Now().Add(time.Hour), CategoryID: category.ID, Status: status})
+		if err != nil {
+			t.Fatal(err)
+		}
+		late = append(late, todo.ID)
+	}
+	upcoming, err := c.CreateTodo(ctx, NewTodo{Title: "upcoming", Content: "c", Priority: 3, DueDate: time.Now().Add(time.Hour), CategoryID: category.ID})
+	if err != nil {
+		t.Fatal(err)
+	}
+	// Past due dates cannot be set through the API.
+	_, err = a.DB.Exec(`UPDATE todo SET due_date = ? WHERE id != ?`, time.Now().Add(-time.Hour).UTC(), upcoming.ID)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	todos, err := c.ListTodos(ctx, &ListOptions{Overdue: true})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if got := ids(todos); !sameIDs(got, late[:2]) {
+		t.Errorf("overdue todos = %v, want the open ones past their due date, %v", got, late[:2])
+	}
+	for _, todo := range todos {
+		if !todo.Overdue {
+			t.Errorf("todo %d is listed as overdue but not marked so", todo.ID)
+		}
+	}
+}
+
+// TestDuePhrase checks that phrases are left to the server, which reads
+// them in its time zone preference.
+func TestDuePhrase(t *testing.T) {
+	ctx := context.Background()
+	srv, _ := newTestServer(t, nil)
+	c := New(srv.URL)
+
+	loc, err := time.LoadLocation("Asia/Kolkata")
+	if err != nil {
+		t.Skip(err)
+	}
+	req, err := http.NewRequest(http.MethodPatch, srv.URL+"/settings", strings.NewReader(`{"timezone":"Asia/Kolkata"}`))
+	if err != nil {
+		t.Fatal(err)
+	}
+	req.Header.Set("Content-Type", "application/json")
+	resp, err := http.DefaultClient.Do(req)
+	if err != nil {
+		t.Fatal(err)
+	}
+	resp.Body.Close()
+	if resp.StatusCode != http.StatusOK {
+		t.Fatalf("PATCH /settings = %d", resp.StatusCode)
+	}
+
+	category, err := c.CreateCategory(ctx, CategoryInput{Name: "work"})
+	if err != nil {
+		t.Fatal(err)
+	}
+	todo, err := c.CreateTodo(ctx, NewTodo{Title: "t", Content: "c", Priority: 3, Due: "tomorrow 9am", CategoryID: category.ID, Tags: []string{"a"}})
+	if err != nil {
+		t.Fatal(err)
+	}
+	tomorrow := time.Now().In(loc).AddDate(0, 0, 1)
+	want := time.Date(tomorrow.Year(), tomorrow.Month(), tomorrow.Day(), 9, 0, 0, 0, loc)
+	if !todo.DueDate.Equal(want) || todo.AllDay {
+		t.Errorf("due date of %q = %v, want %v", "tomorrow 9am", todo.DueDate, want)
+	}
+
+	todo, err = c.PatchTodo(ctx, todo.ID, TodoPatch{Due: "in 3 days", Tags: []string{}})
+	if err != nil {
+		t.Fatal(err)
+	}
+	later := time.Now().In(loc).AddDate(0, 0, 3)
+	want = time.Date(later.Year(), later.Month(), later.Day(), 23, 59, 0, 0, loc)
+	if !todo.DueDate.Equal(want) || !todo.AllDay || len(todo.Tags) != 0 {
+		t.Errorf("after patching the due date to %q and removing the tags: due %v, all day %v, tags %v; want %v, all day, no tags", "in 3 days", todo.DueDate, todo.AllDay, todo.Tags, want)
+	}
+
+	_, err = c.PatchTodo(ctx, todo.ID, TodoPatch{Due: "someday"})
+	if !errors.Is(err, ErrBadRequest) {
+		t.Errorf("PatchTodo with an unreadable due date = %v, want ErrBadRequest", err)
+	}
+}
@@ -0,0 +1,179 @@
+package client
+
+import (
+	"context"
+	"net/http"
+	"net/url"
+	"strconv"
+	"time"
+
+	"github.com/furkankorkmaz309/todo-api/internal/models"
+)
+
+// Todo and Category are the API's models, aliased so that code outside
+// this module can name them.
+type (
+	Todo     = models.Todo
+	Category = models.Category
+)
+
+// ListOptions filters the todo list. Zero values do not filter.
+type ListOptions struct {
+	Archived   bool
+	CategoryID int
+	Status     string
+	IsDone     *bool
+	Priority   int
+	// Overdue keeps the todos past their due date that are neither done
+	// nor cancelled.
+	Overdue bool
+
+	DueBefore       time.Time
+	DueAfter        time.Time
+	CompletedBefore time.Time
+	CompletedAfter  time.Time
+}
+
+func (o *ListOptions) values() url.Values {
+	q := url.Values{}
+	if o == nil {
+		return q
+	}
+	if o.CategoryID != 0 {
+		q.Set("category_id", strconv.Itoa(o.CategoryID))
+	}
+	if o.Status != "" {
+		q.Set("status", o.Status)
+	}
+	if o.IsDone != nil {
+		q.Set("is_done", strconv.FormatBool(*o.IsDone))
+	}
+	if o.Priority != 0 {
+		q.Set("priority", strconv.Itoa(o.Priority))
+	}
+	if o.Overdue {
+		q.Set("overdue", "true")
+	}
+	for param, t := range map[string]time.Time{
+		"due_before":       o.DueBefore,
+		"due_after":        o.DueAfter,
+		"completed_before": o.CompletedBefore,
+		"completed_after":  o.CompletedAfter,
+	} {
+		if !t.IsZero() {
+			q.Set(param, t.Format(time.RFC3339))
+		}
+	}
+	return q
+}
+
+// NewTodo is the body of CreateTodo.
+type NewTodo struct {
+	Title    string    `json:"title"`
+	Content  string    `json:"content"`
+	Priority int       `json:"priority"`
+	DueDate  time.Time `json:"due_date"`
+	// Due is a due date for the server to read, such as "tomorrow 9am",
+	// in its time zone preference. When set it takes the place of DueDate.
+	Due        string   `json:"-"`
+	CategoryID int      `json:"category_id,omitempty"`
+	Status     string   `json:"status,omitempty"`
+	Tags       []string `json:"tags,omitempty"`
+}
+
+// TodoPatch is the body of PatchTodo. Empty fields are left unchanged.
+// IsDone, when set without Status, marks the todo done or reopens it; along
+// with Status it may only be true for Status "done". A non-zero Version
+// makes the update fail with ErrConflict if the todo changed since it was
+// read.
+type TodoPatch struct {
+	Title    string     `json:"title,omitempty"`
+	Content  string     `json:"content,omitempty"`
+	Priority int        `json:"priority,omitempty"`
+	DueDate  *time.Time `json:"due_date,omitempty"`
+	// Due is read as in NewTodo and takes the place of DueDate when set.
+	Due        string `json:"-"`
+	Status     string `json:"status,omitempty"`
+	IsDone     *bool  `json:"is_done,omitempty"`
+	CategoryID int    `json:"category_id,omitempty"`
+	// Tags replaces all tags when not nil; an empty slice removes them.
+	Tags    []string `json:"tags,omitempty"`
+	Version int      `json:"version,omitempty"`
+}
+
+func (c *Client) ListTodos(ctx context.Context, opts *ListOptions) ([]Todo, error) {
+	path := "/todos"
+	if opts != nil && opts.Archived {
+		path = "/todos/archived"
+	}
+
+	var todos []Todo
+	_, err := c.do(ctx, request{method: http.MethodGet, path: path, query: opts.values(), retry: true}, &todos)
+	return todos, err
+}
+
+func (c *Client) GetTodo(ctx context.Context, id int) (Todo, error) {
+	var todo Todo
+	_, err := c.do(ctx, request{method: http.MethodGet, path: idPath("/todos", id, ""), retry: true}, &todo)
+	return todo, err
+}
+
+func (c *Client) CreateTodo(ctx context.Context, todo NewTodo) (Todo, error) {
+	var body interface{} = todo
+	if todo.Due != "" {
+		body = struct {
+			NewTodo
+			DueDate string `json:"due_date"`
+		}{todo, todo.Due}
+	}
+
+	var created Todo
+	_, err := c.do(ctx, request{method: http.MethodPost, path: "/todos", body: body, idempotent: true}, &created)
+	return created, err
+}
+
+func (c *Client) PatchTodo(ctx context.Context, id int, patch TodoPatch) (Todo, error) {
+	// The fields of patch are shadowed where they cannot be sent as they
+	// are: omitempty would drop an empty slice meant to remove all tags,
+	// and Due goes in place of DueDate.
+	body := struct {
+		TodoPatch
+		Tags    *[]string   `json:"tags,omitempty"`
+		DueDate interface{} `json:"due_date,omitempty"`
+	}{TodoPatch: patch}
+	if patch.Tags != nil {
+		body.Tags = &patch.Tags
+	}
+	if patch.Due != "" {
+		body.DueDate = patch.Due
+	} else if patch.DueDate != nil {
+		body.DueDate = patch.DueDate
+	}
+
+	var todo Todo
+	_, err := c.do(ctx, request{method: http.MethodPatch, path: idPath("/todos", id, ""), body: body}, &todo)
+	return todo, err
+}
+
+func (c *Client) DeleteTodo(ctx context.Context, id int) error {
+	_, err := c.do(ctx, request{method: http.MethodDelete, path: idPath("/todos", id, ""), retry: true}, nil)
+	return err
+}
+
+func (c *Client) ArchiveTodo(ctx context.Context, id int) error {
+	_, err := c.do(ctx, request{method: http.MethodPost, path: idPath("/todos", id, "/archive"), retry: true}, nil)
+	return err
+}
+
+func (c *Client) UnarchiveTodo(ctx context.Context, id int) error {
+	_, err := c.do(ctx, request{method: http.MethodPost, path: idPath("/todos", id, "/unarchive"), retry: true}, nil)
+	return err
+}
+
+// ArchiveFinished archives the finished todos matching opts and returns
+// their IDs. opts.Archived is ignored.
+func (c *Client) ArchiveFinished(ctx context.Context, opts *ListOptions) ([]int, error) {
+	var ids []int
+	_, err := c.do(ctx, request{method: http.MethodPatch, path: "/todos/archivefinished", query: opts.values(), retry: true}, &ids)
+	return ids, err
+}